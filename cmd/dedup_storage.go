@@ -0,0 +1,92 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	dedup_model "code.gitea.io/gitea/models/dedup"
+	"code.gitea.io/gitea/models/migrations"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+
+	"github.com/urfave/cli/v2"
+)
+
+// CmdDedupStorage represents the available dedup storage sub-command.
+var CmdDedupStorage = &cli.Command{
+	Name:        "dedup-storage",
+	Usage:       "Deduplicate the stored files",
+	Description: "Moves the files stored before [dedup] was enabled into the deduplicated storage, so identical contents are stored once",
+	Action:      runDedupStorage,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "type",
+			Aliases: []string{"t"},
+			Value:   "",
+			Usage:   "Type of stored files to deduplicate, all deduplicated stores if empty.  Allowed types: 'lfs', 'attachments', 'packages'",
+		},
+	},
+}
+
+func runDedupStorage(ctx *cli.Context) error {
+	stdCtx, cancel := installSignals()
+	defer cancel()
+
+	if err := initDB(stdCtx); err != nil {
+		return err
+	}
+
+	if !setting.Dedup.Enabled {
+		return errors.New("deduplication isn't enabled, set [dedup] ENABLED = true first")
+	}
+
+	if err := db.InitEngineWithMigration(context.Background(), migrations.Migrate); err != nil {
+		log.Fatal("Failed to initialize ORM engine: %v", err)
+		return err
+	}
+
+	if err := storage.Init(); err != nil {
+		return err
+	}
+
+	stores := map[string]storage.ObjectStorage{
+		setting.DedupStoreLFS:         storage.LFS,
+		setting.DedupStoreAttachments: storage.Attachments,
+		setting.DedupStorePackages:    storage.Packages,
+	}
+
+	tp := strings.ToLower(ctx.String("type"))
+	for _, name := range setting.Dedup.Stores {
+		if tp != "" && tp != name {
+			continue
+		}
+		dedupStorage, ok := storage.Unwrap(stores[name]).(*storage.DedupStorage)
+		if !ok {
+			log.Info("%s isn't enabled (skipped)", name)
+			continue
+		}
+
+		result, err := dedupStorage.MigrateLegacyObjects(stdCtx, log.Trace)
+		if err != nil {
+			return fmt.Errorf("deduplicate %s: %w", name, err)
+		}
+		log.Info("%d %s files (%s) have been moved to the deduplicated storage, %d (%s) of them were duplicates",
+			result.Objects, name, base.FileSize(result.Size), result.Deduplicated, base.FileSize(result.SavedSize))
+	}
+
+	stats, err := dedup_model.GetStats(stdCtx)
+	if err != nil {
+		return err
+	}
+	log.Info("The deduplicated storage holds %d contents (%s) referenced by %d files (%s)",
+		stats.Blobs, base.FileSize(stats.StoredSize), stats.References, base.FileSize(stats.ReferencedSize))
+	return nil
+}
@@ -139,6 +139,7 @@ func NewMainApp(version, versionExtra string) *cli.App {
 		CmdManager,
 		CmdEmbedded,
 		CmdMigrateStorage,
+		CmdDedupStorage,
 		CmdDumpRepository,
 		CmdRestoreRepository,
 		CmdActions,
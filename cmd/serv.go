@@ -22,6 +22,7 @@ import (
 	"code.gitea.io/gitea/models/perm"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/lfstransfer"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/pprof"
 	"code.gitea.io/gitea/modules/private"
@@ -37,6 +38,7 @@ import (
 
 const (
 	lfsAuthenticateVerb = "git-lfs-authenticate"
+	lfsTransferVerb     = "git-lfs-transfer"
 )
 
 // CmdServ represents the available serv sub-command.
@@ -89,10 +91,29 @@ var (
 		"git-upload-archive": perm.AccessModeRead,
 		"git-receive-pack":   perm.AccessModeWrite,
 		lfsAuthenticateVerb:  perm.AccessModeNone,
+		lfsTransferVerb:      perm.AccessModeNone,
 	}
 	alphaDashDotPattern = regexp.MustCompile(`[^\w-\.]`)
 )
 
+// newLFSToken returns a signed token which authorizes the LFS operation of the user on the repository
+func newLFSToken(repoID, userID int64, op string) (string, error) {
+	now := time.Now()
+	claims := lfs.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(setting.LFS.HTTPAuthExpiry)),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+		RepoID: repoID,
+		Op:     op,
+		UserID: userID,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	// Sign and get the complete encoded token as a string using the secret
+	return token.SignedString(setting.LFS.JWTSecretBytes)
+}
+
 // fail prints message to stdout, it's mainly used for git serv and git hook commands.
 // The output will be passed to git client and shown to user.
 func fail(ctx context.Context, userMessage, logMsgFmt string, args ...any) error {
@@ -206,9 +227,9 @@ func runServ(c *cli.Context) error {
 	}
 
 	var lfsVerb string
-	if verb == lfsAuthenticateVerb {
+	if verb == lfsAuthenticateVerb || verb == lfsTransferVerb {
 		if !setting.LFS.StartServer {
-			return fail(ctx, "Unknown git command", "LFS authentication request over SSH denied, LFS support is disabled")
+			return fail(ctx, "Unknown git command", "LFS request over SSH denied, LFS support is disabled")
 		}
 
 		if len(words) > 2 {
@@ -254,7 +275,7 @@ func runServ(c *cli.Context) error {
 		return fail(ctx, "Unknown git command", "Unknown git command %s", verb)
 	}
 
-	if verb == lfsAuthenticateVerb {
+	if verb == lfsAuthenticateVerb || verb == lfsTransferVerb {
 		if lfsVerb == "upload" {
 			requestedMode = perm.AccessModeWrite
 		} else if lfsVerb == "download" {
@@ -273,20 +294,7 @@ func runServ(c *cli.Context) error {
 	if verb == lfsAuthenticateVerb {
 		url := fmt.Sprintf("%s%s/%s.git/info/lfs", setting.AppURL, url.PathEscape(results.OwnerName), url.PathEscape(results.RepoName))
 
-		now := time.Now()
-		claims := lfs.Claims{
-			RegisteredClaims: jwt.RegisteredClaims{
-				ExpiresAt: jwt.NewNumericDate(now.Add(setting.LFS.HTTPAuthExpiry)),
-				NotBefore: jwt.NewNumericDate(now),
-			},
-			RepoID: results.RepoID,
-			Op:     lfsVerb,
-			UserID: results.UserID,
-		}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-		// Sign and get the complete encoded token as a string using the secret
-		tokenString, err := token.SignedString(setting.LFS.JWTSecretBytes)
+		tokenString, err := newLFSToken(results.RepoID, results.UserID, lfsVerb)
 		if err != nil {
 			return fail(ctx, "Failed to sign JWT Token", "Failed to sign JWT token: %v", err)
 		}
@@ -305,6 +313,20 @@ func runServ(c *cli.Context) error {
 		return nil
 	}
 
+	// LFS transfer over the SSH connection, the objects are passed to the LFS server of the instance
+	if verb == lfsTransferVerb {
+		tokenString, err := newLFSToken(results.RepoID, results.UserID, lfsVerb)
+		if err != nil {
+			return fail(ctx, "Failed to sign JWT Token", "Failed to sign JWT token: %v", err)
+		}
+		baseURL := fmt.Sprintf("%s%s/%s.git/info/lfs", setting.LocalURL, url.PathEscape(results.OwnerName), url.PathEscape(results.RepoName))
+		backend := lfstransfer.NewHTTPBackend(baseURL, "Bearer "+tokenString)
+		if err := lfstransfer.Run(ctx, backend, lfsVerb, os.Stdin, os.Stdout); err != nil {
+			return fail(ctx, "LFS transfer failed", "LFS transfer failed: %v", err)
+		}
+		return nil
+	}
+
 	var gitcmd *exec.Cmd
 	gitBinPath := filepath.Dir(git.GitExecutable) // e.g. /usr/bin
 	gitBinVerb := filepath.Join(gitBinPath, verb) // e.g. /usr/bin/git-upload-pack
@@ -341,6 +363,9 @@ func runServ(c *cli.Context) error {
 		repo_module.EnvKeyID+"="+fmt.Sprintf("%d", results.KeyID),
 		repo_module.EnvAppURL+"="+setting.AppURL,
 	)
+	if verb == "git-upload-pack" {
+		gitcmd.Env = append(gitcmd.Env, git.PartialCloneEnvs(results.IsPartialCloneEnabled)...)
+	}
 	// to avoid breaking, here only use the minimal environment variables for the "gitea serv" command.
 	// it could be re-considered whether to use the same git.CommonGitCmdEnvs() as "git" command later.
 	gitcmd.Env = append(gitcmd.Env, git.CommonCmdServEnvs()...)
@@ -0,0 +1,230 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// notDoneStatuses are the statuses of the runs and jobs which occupy or wait for a concurrency group
+var notDoneStatuses = []Status{StatusWaiting, StatusRunning, StatusBlocked}
+
+// IsRunPending returns whether none of the jobs of a run has been released yet,
+// a pending run waits for approval or for the runs of its concurrency group
+func IsRunPending(jobs []*ActionRunJob) bool {
+	for _, job := range jobs {
+		if job.Status != StatusBlocked {
+			return false
+		}
+	}
+	return len(jobs) > 0
+}
+
+// IsJobPending returns whether a blocked job only waits for its concurrency group, the jobs are all jobs of its run
+func IsJobPending(job *ActionRunJob, jobs []*ActionRunJob) bool {
+	if job.Status != StatusBlocked {
+		return false
+	}
+	for _, need := range job.Needs {
+		for _, j := range jobs {
+			if j.JobID == need && !j.Status.IsDone() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// GetHeldConcurrencyGroup returns the concurrency group a blocked job waits for, it's empty if the job doesn't wait for a group
+func GetHeldConcurrencyGroup(run *ActionRun, job *ActionRunJob, jobs []*ActionRunJob) string {
+	if run.NeedApproval || !IsJobPending(job, jobs) {
+		return ""
+	}
+	// the needs of the job are done, so it's held by the group of its run or by its own group
+	if run.ConcurrencyGroup != "" && IsRunPending(jobs) {
+		return run.ConcurrencyGroup
+	}
+	return job.ConcurrencyGroup
+}
+
+// ShouldHoldRun returns whether the jobs of a run can't be released because of its concurrency group.
+// A run waits while another run of its group is in progress or while an earlier run of its group is pending too.
+func ShouldHoldRun(ctx context.Context, run *ActionRun) (bool, error) {
+	if run.ConcurrencyGroup == "" {
+		return false, nil
+	}
+	runs, _, err := FindRuns(ctx, FindRunOptions{
+		RepoID:           run.RepoID,
+		ConcurrencyGroup: run.ConcurrencyGroup,
+		Status:           notDoneStatuses,
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, other := range runs {
+		if other.ID == run.ID {
+			continue
+		}
+		if run.ID == 0 || other.ID < run.ID {
+			return true, nil
+		}
+		jobs, err := GetRunJobsByRunID(ctx, other.ID)
+		if err != nil {
+			return false, err
+		}
+		if !IsRunPending(jobs) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ShouldHoldJob returns whether a job can't be released because of its concurrency group.
+// A job waits while another job of its group is in progress or while an earlier job of its group is pending too.
+func ShouldHoldJob(ctx context.Context, job *ActionRunJob) (bool, error) {
+	if job.ConcurrencyGroup == "" {
+		return false, nil
+	}
+	jobs, _, err := FindRunJobs(ctx, FindRunJobOptions{
+		RepoID:           job.RepoID,
+		ConcurrencyGroup: job.ConcurrencyGroup,
+		Statuses:         notDoneStatuses,
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, other := range jobs {
+		if other.ID == job.ID {
+			continue
+		}
+		if other.Status != StatusBlocked {
+			return true, nil
+		}
+		if job.ID != 0 && other.ID > job.ID {
+			continue
+		}
+		runJobs, err := GetRunJobsByRunID(ctx, other.RunID)
+		if err != nil {
+			return false, err
+		}
+		if IsJobPending(other, runJobs) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CancelConcurrencyGroupRuns cancels the runs of a concurrency group before a new run joins it.
+// Pending runs are replaced by the new run, the runs in progress are only cancelled with cancelInProgress.
+// It returns the IDs of the cancelled runs.
+func CancelConcurrencyGroupRuns(ctx context.Context, repoID int64, group string, cancelInProgress bool) ([]int64, error) {
+	if group == "" {
+		return nil, nil
+	}
+	runs, _, err := FindRuns(ctx, FindRunOptions{
+		RepoID:           repoID,
+		ConcurrencyGroup: group,
+		Status:           notDoneStatuses,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var runIDs []int64
+	for _, run := range runs {
+		jobs, err := GetRunJobsByRunID(ctx, run.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !cancelInProgress && !IsRunPending(jobs) {
+			continue
+		}
+		if err := CancelRunJobs(ctx, jobs); err != nil {
+			return nil, err
+		}
+		runIDs = append(runIDs, run.ID)
+	}
+	return runIDs, nil
+}
+
+// CancelConcurrencyGroupJobs cancels the jobs of a concurrency group before a new job joins it.
+// Pending jobs are replaced by the new job, the jobs in progress are only cancelled with cancelInProgress.
+// It returns the IDs of the runs of the cancelled jobs.
+func CancelConcurrencyGroupJobs(ctx context.Context, repoID int64, group string, cancelInProgress bool) ([]int64, error) {
+	if group == "" {
+		return nil, nil
+	}
+	jobs, _, err := FindRunJobs(ctx, FindRunJobOptions{
+		RepoID:           repoID,
+		ConcurrencyGroup: group,
+		Statuses:         notDoneStatuses,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	runIDs := make(container.Set[int64])
+	for _, job := range jobs {
+		if job.Status == StatusBlocked {
+			runJobs, err := GetRunJobsByRunID(ctx, job.RunID)
+			if err != nil {
+				return nil, err
+			}
+			if !IsJobPending(job, runJobs) {
+				// the job hasn't joined the group yet
+				continue
+			}
+		} else if !cancelInProgress {
+			continue
+		}
+		if err := CancelRunJobs(ctx, []*ActionRunJob{job}); err != nil {
+			return nil, err
+		}
+		runIDs.Add(job.RunID)
+	}
+	return runIDs.Values(), nil
+}
+
+// CancelRunJobs cancels the jobs which aren't done yet
+func CancelRunJobs(ctx context.Context, jobs []*ActionRunJob) error {
+	for _, job := range jobs {
+		// Skip jobs that are already in a terminal state (completed, cancelled, etc.).
+		status := job.Status
+		if status.IsDone() {
+			continue
+		}
+
+		// If the job has no associated task (probably an error), set its status to 'Cancelled' and stop it.
+		if job.TaskID == 0 {
+			job.Status = StatusCancelled
+			job.Stopped = timeutil.TimeStampNow()
+
+			// Update the job's status and stopped time in the database.
+			n, err := UpdateRunJob(ctx, job, builder.Eq{"task_id": 0}, "status", "stopped")
+			if err != nil {
+				return err
+			}
+
+			// If the update affected 0 rows, it means the job has changed in the meantime, so we need to try again.
+			if n == 0 {
+				return fmt.Errorf("job has changed, try again")
+			}
+
+			// Continue with the next job.
+			continue
+		}
+
+		// If the job has an associated task, try to stop the task, effectively cancelling the job.
+		if err := StopTask(ctx, job.TaskID, StatusCancelled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
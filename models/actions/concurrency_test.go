@@ -0,0 +1,91 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/nektos/act/pkg/jobparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyGroups(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	insertRun := func(group, jobGroup string) (*ActionRun, []*ActionRunJob) {
+		jobs, err := jobparser.Parse([]byte(`
+on: push
+jobs:
+  deploy:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo deploy
+`))
+		assert.NoError(t, err)
+		run := &ActionRun{
+			Title:            "deploy",
+			RepoID:           1,
+			OwnerID:          2,
+			WorkflowID:       "deploy.yml",
+			TriggerUserID:    2,
+			Ref:              "refs/heads/master",
+			Event:            "push",
+			ConcurrencyGroup: group,
+			Status:           StatusWaiting,
+		}
+		assert.NoError(t, InsertRun(db.DefaultContext, run, jobs, []*RunJobOptions{{ConcurrencyGroup: jobGroup}}))
+		runJobs, err := GetRunJobsByRunID(db.DefaultContext, run.ID)
+		assert.NoError(t, err)
+		assert.Len(t, runJobs, 1)
+		return run, runJobs
+	}
+
+	// the first run of a group starts at once, the next one waits
+	run1, jobs1 := insertRun("production", "")
+	assert.Equal(t, StatusWaiting, jobs1[0].Status)
+	ids, err := CancelConcurrencyGroupRuns(db.DefaultContext, 1, "production", false)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+	run2, jobs2 := insertRun("production", "")
+	assert.Equal(t, StatusBlocked, jobs2[0].Status)
+	assert.True(t, IsRunPending(jobs2))
+	assert.Equal(t, "production", GetHeldConcurrencyGroup(run2, jobs2[0], jobs2))
+	held, err := ShouldHoldRun(db.DefaultContext, run2)
+	assert.NoError(t, err)
+	assert.True(t, held)
+
+	// a new run replaces the pending run
+	ids, err = CancelConcurrencyGroupRuns(db.DefaultContext, 1, "production", false)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{run2.ID}, ids)
+	unittest.AssertExistsAndLoadBean(t, &ActionRunJob{ID: jobs2[0].ID, Status: StatusCancelled})
+	run3, _ := insertRun("production", "")
+
+	// the pending run can start once the run in progress is done
+	assert.NoError(t, CancelRunJobs(db.DefaultContext, jobs1))
+	unittest.AssertExistsAndLoadBean(t, &ActionRun{ID: run1.ID, Status: StatusFailure})
+	held, err = ShouldHoldRun(db.DefaultContext, run3)
+	assert.NoError(t, err)
+	assert.False(t, held)
+
+	// the groups of jobs are independent of the groups of runs
+	_, jobs4 := insertRun("", "deploy")
+	assert.Equal(t, StatusWaiting, jobs4[0].Status)
+	run5, jobs5 := insertRun("", "deploy")
+	assert.Equal(t, StatusBlocked, jobs5[0].Status)
+	assert.Equal(t, "deploy", GetHeldConcurrencyGroup(run5, jobs5[0], jobs5))
+	held, err = ShouldHoldJob(db.DefaultContext, jobs5[0])
+	assert.NoError(t, err)
+	assert.True(t, held)
+
+	// cancel-in-progress cancels the jobs in progress too
+	ids, err = CancelConcurrencyGroupJobs(db.DefaultContext, 1, "deploy", true)
+	assert.NoError(t, err)
+	assert.Len(t, ids, 2)
+	unittest.AssertExistsAndLoadBean(t, &ActionRunJob{ID: jobs4[0].ID, Status: StatusCancelled})
+	unittest.AssertExistsAndLoadBean(t, &ActionRunJob{ID: jobs5[0].ID, Status: StatusCancelled})
+}
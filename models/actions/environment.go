@@ -0,0 +1,181 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	secret_model "code.gitea.io/gitea/models/secret"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/gobwas/glob"
+	"xorm.io/builder"
+)
+
+// ActionEnvironment represents a deployment environment of a repository, the jobs of a workflow deploy to it by its name
+type ActionEnvironment struct {
+	ID        int64
+	RepoID    int64  `xorm:"UNIQUE(repo_name) NOT NULL"`
+	Name      string `xorm:"VARCHAR(255) NOT NULL"`
+	LowerName string `xorm:"VARCHAR(255) UNIQUE(repo_name) NOT NULL"`
+	// BranchPatterns are the glob patterns of the branches and tags which may deploy to the environment, all refs may deploy if it's empty
+	BranchPatterns []string `xorm:"JSON TEXT"`
+	// ReviewerIDs are the users of whom one has to approve a job before it deploys to the environment
+	ReviewerIDs []int64            `xorm:"reviewer_ids JSON TEXT"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+func init() {
+	db.RegisterModel(new(ActionEnvironment))
+}
+
+// ErrEnvironmentAlreadyExist represents an error that an environment with the same name already exists
+type ErrEnvironmentAlreadyExist struct {
+	Name string
+}
+
+func (err ErrEnvironmentAlreadyExist) Error() string {
+	return fmt.Sprintf("environment already exists [name: %s]", err.Name)
+}
+
+func (err ErrEnvironmentAlreadyExist) Unwrap() error {
+	return util.ErrAlreadyExist
+}
+
+// IsRefAllowed returns whether a run of the ref may deploy to the environment
+func (env *ActionEnvironment) IsRefAllowed(ref string) bool {
+	if len(env.BranchPatterns) == 0 {
+		return true
+	}
+	refName := git.RefName(ref)
+	if !refName.IsBranch() && !refName.IsTag() {
+		// e.g. the refs of pull requests
+		return false
+	}
+	name := refName.ShortName()
+	for _, pattern := range env.BranchPatterns {
+		g, err := glob.Compile(pattern, '/')
+		if err != nil {
+			log.Warn("Invalid branch pattern of ActionEnvironment[%d]: %s %v", env.ID, pattern, err)
+			if strings.EqualFold(pattern, name) {
+				return true
+			}
+			continue
+		}
+		if g.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedReview returns whether the jobs which deploy to the environment have to be approved by a reviewer
+func (env *ActionEnvironment) NeedReview() bool {
+	return len(env.ReviewerIDs) > 0
+}
+
+// IsReviewer returns whether the user may approve the jobs which deploy to the environment
+func (env *ActionEnvironment) IsReviewer(userID int64) bool {
+	return slices.Contains(env.ReviewerIDs, userID)
+}
+
+// CreateEnvironment creates an environment of a repository
+func CreateEnvironment(ctx context.Context, env *ActionEnvironment) error {
+	env.LowerName = strings.ToLower(env.Name)
+	has, err := db.GetEngine(ctx).Exist(&ActionEnvironment{RepoID: env.RepoID, LowerName: env.LowerName})
+	if err != nil {
+		return err
+	} else if has {
+		return ErrEnvironmentAlreadyExist{Name: env.Name}
+	}
+	return db.Insert(ctx, env)
+}
+
+// UpdateEnvironment updates the protection rules of an environment, its name can't be changed
+func UpdateEnvironment(ctx context.Context, env *ActionEnvironment) error {
+	_, err := db.GetEngine(ctx).ID(env.ID).Cols("branch_patterns", "reviewer_ids").Update(env)
+	return err
+}
+
+// GetEnvironmentByID returns an environment of a repository by its id
+func GetEnvironmentByID(ctx context.Context, repoID, id int64) (*ActionEnvironment, error) {
+	env := &ActionEnvironment{}
+	has, err := db.GetEngine(ctx).Where("id=? AND repo_id=?", id, repoID).Get(env)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, fmt.Errorf("environment with id %d: %w", id, util.ErrNotExist)
+	}
+	return env, nil
+}
+
+// GetEnvironmentByName returns an environment of a repository by its case-insensitive name
+func GetEnvironmentByName(ctx context.Context, repoID int64, name string) (*ActionEnvironment, error) {
+	env := &ActionEnvironment{}
+	has, err := db.GetEngine(ctx).Where("repo_id=? AND lower_name=?", repoID, strings.ToLower(name)).Get(env)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, fmt.Errorf("environment with name %q: %w", name, util.ErrNotExist)
+	}
+	return env, nil
+}
+
+// FindEnvironments returns the environments of a repository ordered by name
+func FindEnvironments(ctx context.Context, repoID int64) ([]*ActionEnvironment, error) {
+	var envs []*ActionEnvironment
+	return envs, db.GetEngine(ctx).Where("repo_id=?", repoID).OrderBy("lower_name").Find(&envs)
+}
+
+// DeleteEnvironment deletes an environment with its secrets and variables
+func DeleteEnvironment(ctx context.Context, env *ActionEnvironment) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := db.DeleteByBean(ctx, &secret_model.Secret{RepoID: env.RepoID, EnvironmentID: env.ID}); err != nil {
+			return err
+		}
+		if _, err := db.DeleteByBean(ctx, &ActionVariable{RepoID: env.RepoID, EnvironmentID: env.ID}); err != nil {
+			return err
+		}
+		_, err := db.DeleteByID(ctx, env.ID, &ActionEnvironment{})
+		return err
+	})
+}
+
+// FindDeploymentOptions are the options to find the jobs of a repository which deploy to environments
+type FindDeploymentOptions struct {
+	db.ListOptions
+	RepoID      int64
+	Environment string
+}
+
+// FindDeployments returns the jobs which deploy to environments, the latest first
+func FindDeployments(ctx context.Context, opts FindDeploymentOptions) (ActionJobList, int64, error) {
+	cond := builder.NewCond().And(builder.Eq{"repo_id": opts.RepoID}, builder.Neq{"environment": ""})
+	if opts.Environment != "" {
+		cond = cond.And(builder.Eq{"environment": opts.Environment})
+	}
+	e := db.GetEngine(ctx).Where(cond).OrderBy("id DESC")
+	if opts.PageSize > 0 && opts.Page >= 1 {
+		e.Limit(opts.PageSize, (opts.Page-1)*opts.PageSize)
+	}
+	var jobs ActionJobList
+	total, err := e.FindAndCount(&jobs)
+	return jobs, total, err
+}
+
+// GetDeploymentEnvironments returns the names of the environments which the jobs of a repository have deployed to
+func GetDeploymentEnvironments(ctx context.Context, repoID int64) ([]string, error) {
+	var names []string
+	return names, db.GetEngine(ctx).Table("action_run_job").
+		Where(builder.Eq{"repo_id": repoID}.And(builder.Neq{"environment": ""})).
+		Distinct("environment").OrderBy("environment").Find(&names)
+}
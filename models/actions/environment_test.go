@@ -0,0 +1,72 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	secret_model "code.gitea.io/gitea/models/secret"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionEnvironment_IsRefAllowed(t *testing.T) {
+	env := &ActionEnvironment{}
+	assert.True(t, env.IsRefAllowed("refs/heads/feature"))
+	assert.True(t, env.IsRefAllowed("refs/pull/1/head"))
+
+	env.BranchPatterns = []string{"main", "release/*", "v*"}
+	assert.True(t, env.IsRefAllowed("refs/heads/main"))
+	assert.True(t, env.IsRefAllowed("refs/heads/release/1.21"))
+	assert.True(t, env.IsRefAllowed("refs/tags/v1.21.0"))
+	assert.False(t, env.IsRefAllowed("refs/heads/release/1.21/fix"))
+	assert.False(t, env.IsRefAllowed("refs/heads/feature"))
+	assert.False(t, env.IsRefAllowed("refs/pull/1/head"))
+}
+
+func TestEnvironments(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	env := &ActionEnvironment{RepoID: 1, Name: "Production", ReviewerIDs: []int64{2}}
+	assert.NoError(t, CreateEnvironment(db.DefaultContext, env))
+	assert.True(t, env.NeedReview())
+	assert.True(t, env.IsReviewer(2))
+	assert.False(t, env.IsReviewer(3))
+
+	// the names are case-insensitive
+	err := CreateEnvironment(db.DefaultContext, &ActionEnvironment{RepoID: 1, Name: "production"})
+	assert.ErrorIs(t, err, util.ErrAlreadyExist)
+	assert.NoError(t, CreateEnvironment(db.DefaultContext, &ActionEnvironment{RepoID: 2, Name: "production"}))
+
+	got, err := GetEnvironmentByName(db.DefaultContext, 1, "PRODUCTION")
+	assert.NoError(t, err)
+	assert.Equal(t, env.ID, got.ID)
+
+	got.BranchPatterns = []string{"main"}
+	got.ReviewerIDs = nil
+	assert.NoError(t, UpdateEnvironment(db.DefaultContext, got))
+	got, err = GetEnvironmentByID(db.DefaultContext, 1, env.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"main"}, got.BranchPatterns)
+	assert.False(t, got.NeedReview())
+	_, err = GetEnvironmentByID(db.DefaultContext, 2, env.ID)
+	assert.ErrorIs(t, err, util.ErrNotExist)
+
+	// the secrets and variables of the environment are deleted with it
+	_, err = secret_model.InsertEncryptedSecret(db.DefaultContext, 0, 1, env.ID, "TOKEN", "secret")
+	assert.NoError(t, err)
+	_, err = InsertVariable(db.DefaultContext, 0, 1, env.ID, "URL", "https://example.com")
+	assert.NoError(t, err)
+	assert.NoError(t, DeleteEnvironment(db.DefaultContext, got))
+	unittest.AssertNotExistsBean(t, &ActionEnvironment{ID: env.ID})
+	unittest.AssertNotExistsBean(t, &secret_model.Secret{EnvironmentID: env.ID})
+	unittest.AssertNotExistsBean(t, &ActionVariable{EnvironmentID: env.ID})
+
+	envs, err := FindEnvironments(db.DefaultContext, 2)
+	assert.NoError(t, err)
+	assert.Len(t, envs, 1)
+}
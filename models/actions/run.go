@@ -13,6 +13,7 @@ import (
 	"code.gitea.io/gitea/models/db"
 	repo_model "code.gitea.io/gitea/models/repo"
 	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/container"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/json"
 	api "code.gitea.io/gitea/modules/structs"
@@ -44,6 +45,8 @@ type ActionRun struct {
 	Event             webhook_module.HookEventType // the webhook event that causes the workflow to run
 	EventPayload      string                       `xorm:"LONGTEXT"`
 	TriggerEvent      string                       // the trigger event defined in the `on` configuration of the triggered workflow
+	ConcurrencyGroup  string                       `xorm:"VARCHAR(255) index NOT NULL DEFAULT ''"` // the evaluated `concurrency` group of the workflow, only one run of a group is in progress at a time
+	ConcurrencyCancel bool                         `xorm:"NOT NULL DEFAULT false"`                 // whether the run cancels the runs of its group which are in progress instead of waiting for them
 	Status            Status                       `xorm:"index"`
 	Version           int                          `xorm:"version default 0"` // Status could be updated concomitantly, so an optimistic lock is needed
 	Started           timeutil.TimeStamp
@@ -195,38 +198,9 @@ func CancelRunningJobs(ctx context.Context, repoID int64, ref, workflowID string
 			return err
 		}
 
-		// Iterate over each job and attempt to cancel it.
-		for _, job := range jobs {
-			// Skip jobs that are already in a terminal state (completed, cancelled, etc.).
-			status := job.Status
-			if status.IsDone() {
-				continue
-			}
-
-			// If the job has no associated task (probably an error), set its status to 'Cancelled' and stop it.
-			if job.TaskID == 0 {
-				job.Status = StatusCancelled
-				job.Stopped = timeutil.TimeStampNow()
-
-				// Update the job's status and stopped time in the database.
-				n, err := UpdateRunJob(ctx, job, builder.Eq{"task_id": 0}, "status", "stopped")
-				if err != nil {
-					return err
-				}
-
-				// If the update affected 0 rows, it means the job has changed in the meantime, so we need to try again.
-				if n == 0 {
-					return fmt.Errorf("job has changed, try again")
-				}
-
-				// Continue with the next job.
-				continue
-			}
-
-			// If the job has an associated task, try to stop the task, effectively cancelling the job.
-			if err := StopTask(ctx, job.TaskID, StatusCancelled); err != nil {
-				return err
-			}
+		// Cancel the jobs which aren't done yet.
+		if err := CancelRunJobs(ctx, jobs); err != nil {
+			return err
 		}
 	}
 
@@ -234,14 +208,33 @@ func CancelRunningJobs(ctx context.Context, repoID int64, ref, workflowID string
 	return nil
 }
 
-// InsertRun inserts a run
-func InsertRun(ctx context.Context, run *ActionRun, jobs []*jobparser.SingleWorkflow) error {
+// RunJobOptions are the settings of a job which aren't part of its workflow payload
+type RunJobOptions struct {
+	ConcurrencyGroup  string
+	ConcurrencyCancel bool
+	Environment       string
+	EnvironmentURL    string
+	Permissions       map[string]string
+	CallerJobID       string
+	ReusableWorkflow  string
+}
+
+// InsertRun inserts a run, jobOptions are nil or aligned with jobs.
+// The jobs are held back while the concurrency groups of the run or of the jobs are busy,
+// the jobs which deploy to an environment are blocked until the job emitter checks the protection rules of the environment.
+// The jobs which call reusable workflows stay blocked, the job emitter aggregates their statuses from the jobs of the called workflows.
+func InsertRun(ctx context.Context, run *ActionRun, jobs []*jobparser.SingleWorkflow, jobOptions []*RunJobOptions) error {
 	ctx, commiter, err := db.TxContext(ctx)
 	if err != nil {
 		return err
 	}
 	defer commiter.Close()
 
+	runHeld, err := ShouldHoldRun(ctx, run)
+	if err != nil {
+		return err
+	}
+
 	index, err := db.GetNextResourceIndex(ctx, "action_run_index", run.RepoID)
 	if err != nil {
 		return err
@@ -266,21 +259,20 @@ func InsertRun(ctx context.Context, run *ActionRun, jobs []*jobparser.SingleWork
 
 	runJobs := make([]*ActionRunJob, 0, len(jobs))
 	var hasWaiting bool
-	for _, v := range jobs {
+	busyGroups := make(container.Set[string])
+	for i, v := range jobs {
 		id, job := v.Job()
 		needs := job.Needs()
 		if err := v.SetJob(id, job.EraseNeeds()); err != nil {
 			return err
 		}
 		payload, _ := v.Marshal()
-		status := StatusWaiting
-		if len(needs) > 0 || run.NeedApproval {
-			status = StatusBlocked
-		} else {
-			hasWaiting = true
+		opts := &RunJobOptions{}
+		if i < len(jobOptions) && jobOptions[i] != nil {
+			opts = jobOptions[i]
 		}
 		job.Name, _ = util.SplitStringAtByteN(job.Name, 255)
-		runJobs = append(runJobs, &ActionRunJob{
+		runJob := &ActionRunJob{
 			RunID:             run.ID,
 			RepoID:            run.RepoID,
 			OwnerID:           run.OwnerID,
@@ -291,8 +283,33 @@ func InsertRun(ctx context.Context, run *ActionRun, jobs []*jobparser.SingleWork
 			JobID:             id,
 			Needs:             needs,
 			RunsOn:            job.RunsOn(),
-			Status:            status,
-		})
+			ConcurrencyGroup:  opts.ConcurrencyGroup,
+			ConcurrencyCancel: opts.ConcurrencyCancel,
+			Environment:       opts.Environment,
+			EnvironmentURL:    opts.EnvironmentURL,
+			Permissions:       opts.Permissions,
+			CallerJobID:       opts.CallerJobID,
+			ReusableWorkflow:  opts.ReusableWorkflow,
+			Status:            StatusWaiting,
+		}
+		jobHeld := false
+		if runJob.ConcurrencyGroup != "" {
+			// the earlier jobs of the run which haven't been inserted yet occupy the group too
+			if jobHeld, err = ShouldHoldJob(ctx, runJob); err != nil {
+				return err
+			}
+			jobHeld = jobHeld || !busyGroups.Add(runJob.ConcurrencyGroup)
+		}
+		if run.Status.IsDone() {
+			// the run failed before it was inserted, e.g. its workflow couldn't be prepared
+			runJob.Status = run.Status
+			runJob.Stopped = run.Stopped
+		} else if len(needs) > 0 || run.NeedApproval || runHeld || jobHeld || runJob.Environment != "" || runJob.IsReusableWorkflowCaller() {
+			runJob.Status = StatusBlocked
+		} else {
+			hasWaiting = true
+		}
+		runJobs = append(runJobs, runJob)
 	}
 	if err := db.Insert(ctx, runJobs); err != nil {
 		return err
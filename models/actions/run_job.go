@@ -32,11 +32,25 @@ type ActionRunJob struct {
 	Needs             []string `xorm:"JSON TEXT"`
 	RunsOn            []string `xorm:"JSON TEXT"`
 	TaskID            int64    // the latest task of the job
-	Status            Status   `xorm:"index"`
-	Started           timeutil.TimeStamp
-	Stopped           timeutil.TimeStamp
-	Created           timeutil.TimeStamp `xorm:"created"`
-	Updated           timeutil.TimeStamp `xorm:"updated index"`
+	ConcurrencyGroup  string   `xorm:"VARCHAR(255) index NOT NULL DEFAULT ''"` // the evaluated `concurrency` group of the job
+	ConcurrencyCancel bool     `xorm:"NOT NULL DEFAULT false"`
+	// Environment is the evaluated name of the deployment environment of the job
+	Environment    string `xorm:"VARCHAR(255) index NOT NULL DEFAULT ''"`
+	EnvironmentURL string `xorm:"TEXT"`
+	// DeploymentApprovedBy is the reviewer who approved the job to deploy to its environment
+	DeploymentApprovedBy int64 `xorm:"index NOT NULL DEFAULT 0"`
+	// Permissions maps the scopes of the `permissions` setting of the job to their access levels, it's nil if the setting is absent
+	Permissions map[string]string `xorm:"JSON TEXT"`
+	// CallerJobID is the JobID of the job which calls the reusable workflow that the job is expanded from, it's the prefix of the JobID of the job
+	CallerJobID string `xorm:"VARCHAR(255) NOT NULL DEFAULT ''"`
+	// ReusableWorkflow is the `uses` setting of a job which calls a reusable workflow, such a job doesn't run on runners,
+	// its status is aggregated from the jobs of the called workflow
+	ReusableWorkflow string `xorm:"VARCHAR(255) NOT NULL DEFAULT ''"`
+	Status           Status `xorm:"index"`
+	Started          timeutil.TimeStamp
+	Stopped          timeutil.TimeStamp
+	Created          timeutil.TimeStamp `xorm:"created"`
+	Updated          timeutil.TimeStamp `xorm:"updated index"`
 }
 
 func init() {
@@ -47,6 +61,11 @@ func (job *ActionRunJob) Duration() time.Duration {
 	return calculateDuration(job.Started, job.Stopped, job.Status)
 }
 
+// IsReusableWorkflowCaller returns whether the job calls a reusable workflow
+func (job *ActionRunJob) IsReusableWorkflowCaller() bool {
+	return job.ReusableWorkflow != ""
+}
+
 func (job *ActionRunJob) LoadRun(ctx context.Context) error {
 	if job.Run == nil {
 		run, err := GetRunByID(ctx, job.RunID)
@@ -59,6 +59,8 @@ type FindRunJobOptions struct {
 	CommitSHA     string
 	Statuses      []Status
 	UpdatedBefore timeutil.TimeStamp
+	// ConcurrencyGroup filters the jobs of a concurrency group, it's only used with RepoID
+	ConcurrencyGroup string
 }
 
 func (opts FindRunJobOptions) toConds() builder.Cond {
@@ -81,6 +83,9 @@ func (opts FindRunJobOptions) toConds() builder.Cond {
 	if opts.UpdatedBefore > 0 {
 		cond = cond.And(builder.Lt{"updated": opts.UpdatedBefore})
 	}
+	if opts.ConcurrencyGroup != "" {
+		cond = cond.And(builder.Eq{"concurrency_group": opts.ConcurrencyGroup})
+	}
 	return cond
 }
 
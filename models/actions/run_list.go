@@ -73,6 +73,8 @@ type FindRunOptions struct {
 	TriggerUserID int64
 	Approved      bool // not util.OptionalBool, it works only when it's true
 	Status        []Status
+	// ConcurrencyGroup filters the runs of a concurrency group, it's only used with RepoID
+	ConcurrencyGroup string
 }
 
 func (opts FindRunOptions) toConds() builder.Cond {
@@ -98,6 +100,9 @@ func (opts FindRunOptions) toConds() builder.Cond {
 	if opts.Ref != "" {
 		cond = cond.And(builder.Eq{"ref": opts.Ref})
 	}
+	if opts.ConcurrencyGroup != "" {
+		cond = cond.And(builder.Eq{"concurrency_group": opts.ConcurrencyGroup})
+	}
 	return cond
 }
 
@@ -17,13 +17,14 @@ import (
 )
 
 type ActionVariable struct {
-	ID          int64              `xorm:"pk autoincr"`
-	OwnerID     int64              `xorm:"UNIQUE(owner_repo_name)"`
-	RepoID      int64              `xorm:"INDEX UNIQUE(owner_repo_name)"`
-	Name        string             `xorm:"UNIQUE(owner_repo_name) NOT NULL"`
-	Data        string             `xorm:"LONGTEXT NOT NULL"`
-	CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL"`
-	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+	ID            int64              `xorm:"pk autoincr"`
+	OwnerID       int64              `xorm:"UNIQUE(owner_repo_name)"`
+	RepoID        int64              `xorm:"INDEX UNIQUE(owner_repo_name)"`
+	EnvironmentID int64              `xorm:"INDEX UNIQUE(owner_repo_name) NOT NULL DEFAULT 0"` // the deployment environment of the repository, it's only set for the variables of an environment
+	Name          string             `xorm:"UNIQUE(owner_repo_name) NOT NULL"`
+	Data          string             `xorm:"LONGTEXT NOT NULL"`
+	CreatedUnix   timeutil.TimeStamp `xorm:"created NOT NULL"`
+	UpdatedUnix   timeutil.TimeStamp `xorm:"updated"`
 }
 
 func init() {
@@ -34,15 +35,19 @@ func (v *ActionVariable) Validate() error {
 	if v.OwnerID == 0 && v.RepoID == 0 {
 		return errors.New("the variable is not bound to any scope")
 	}
+	if v.EnvironmentID != 0 && v.RepoID == 0 {
+		return errors.New("the variable of an environment is not bound to a repository")
+	}
 	return nil
 }
 
-func InsertVariable(ctx context.Context, ownerID, repoID int64, name, data string) (*ActionVariable, error) {
+func InsertVariable(ctx context.Context, ownerID, repoID, environmentID int64, name, data string) (*ActionVariable, error) {
 	variable := &ActionVariable{
-		OwnerID: ownerID,
-		RepoID:  repoID,
-		Name:    strings.ToUpper(name),
-		Data:    data,
+		OwnerID:       ownerID,
+		RepoID:        repoID,
+		EnvironmentID: environmentID,
+		Name:          strings.ToUpper(name),
+		Data:          data,
 	}
 	if err := variable.Validate(); err != nil {
 		return variable, err
@@ -54,6 +59,8 @@ type FindVariablesOpts struct {
 	db.ListOptions
 	OwnerID int64
 	RepoID  int64
+	// EnvironmentID is always matched, the variables of environments are only found by their environment
+	EnvironmentID int64
 }
 
 func (opts *FindVariablesOpts) toConds() builder.Cond {
@@ -64,6 +71,7 @@ func (opts *FindVariablesOpts) toConds() builder.Cond {
 	if opts.RepoID > 0 {
 		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
 	}
+	cond = cond.And(builder.Eq{"environment_id": opts.EnvironmentID})
 	return cond
 }
 
@@ -0,0 +1,61 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package activities
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// MailDigestItem represents an issue or pull request event which is
+// delivered to the user with the next notification email digest
+type MailDigestItem struct {
+	ID          int64              `xorm:"pk autoincr"`
+	UserID      int64              `xorm:"INDEX NOT NULL"`
+	RepoID      int64              `xorm:"INDEX NOT NULL"`
+	IssueID     int64              `xorm:"INDEX NOT NULL"`
+	CommentID   int64              `xorm:"NOT NULL DEFAULT 0"`
+	DoerID      int64              `xorm:"NOT NULL"`
+	ActionType  ActionType         `xorm:"NOT NULL DEFAULT 0"`
+	IsMention   bool               `xorm:"NOT NULL DEFAULT false"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL"`
+}
+
+func init() {
+	db.RegisterModel(new(MailDigestItem))
+}
+
+// CreateMailDigestItems queues the items for the next digests of their users
+func CreateMailDigestItems(ctx context.Context, items []*MailDigestItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	_, err := db.GetEngine(ctx).Insert(items)
+	return err
+}
+
+// GetMailDigestUserIDs returns the IDs of the users with queued digest items
+// who receive their digests with the given frequency
+func GetMailDigestUserIDs(ctx context.Context, frequency string) ([]int64, error) {
+	ids := make([]int64, 0, 10)
+	return ids, db.GetEngine(ctx).Table("mail_digest_item").
+		Join("INNER", "`user`", "`user`.id = mail_digest_item.user_id").
+		Where("`user`.email_digest_frequency = ?", frequency).
+		Distinct("mail_digest_item.user_id").
+		Find(&ids)
+}
+
+// GetMailDigestItemsByUserID returns the queued digest items of the user in the order they were created
+func GetMailDigestItemsByUserID(ctx context.Context, userID int64) ([]*MailDigestItem, error) {
+	items := make([]*MailDigestItem, 0, 10)
+	return items, db.GetEngine(ctx).Where("user_id = ?", userID).OrderBy("id").Find(&items)
+}
+
+// DeleteMailDigestItemsByUserID removes the queued digest items of the user up to and including maxID
+func DeleteMailDigestItemsByUserID(ctx context.Context, userID, maxID int64) error {
+	_, err := db.GetEngine(ctx).Where("user_id = ? AND id <= ?", userID, maxID).Delete(new(MailDigestItem))
+	return err
+}
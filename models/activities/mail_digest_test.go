@@ -0,0 +1,49 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package activities_test
+
+import (
+	"testing"
+
+	activities_model "code.gitea.io/gitea/models/activities"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMailDigestItems(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	user2 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	user4 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 4})
+	assert.NoError(t, user_model.SetEmailDigestFrequency(db.DefaultContext, user4, user_model.EmailDigestHourly))
+
+	assert.NoError(t, activities_model.CreateMailDigestItems(db.DefaultContext, []*activities_model.MailDigestItem{
+		{UserID: user2.ID, RepoID: 1, IssueID: 1, DoerID: 1, ActionType: activities_model.ActionCommentIssue},
+		{UserID: user4.ID, RepoID: 1, IssueID: 1, DoerID: 1, ActionType: activities_model.ActionCommentIssue},
+		{UserID: user4.ID, RepoID: 1, IssueID: 2, DoerID: 1, ActionType: activities_model.ActionCreatePullRequest},
+	}))
+
+	ids, err := activities_model.GetMailDigestUserIDs(db.DefaultContext, user_model.EmailDigestHourly)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{user4.ID}, ids)
+
+	ids, err = activities_model.GetMailDigestUserIDs(db.DefaultContext, user_model.EmailDigestDaily)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	items, err := activities_model.GetMailDigestItemsByUserID(db.DefaultContext, user4.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, items, 2) {
+		assert.EqualValues(t, 1, items[0].IssueID)
+		assert.EqualValues(t, 2, items[1].IssueID)
+	}
+
+	assert.NoError(t, activities_model.DeleteMailDigestItemsByUserID(db.DefaultContext, user4.ID, items[0].ID))
+	unittest.AssertNotExistsBean(t, &activities_model.MailDigestItem{ID: items[0].ID})
+	unittest.AssertExistsAndLoadBean(t, &activities_model.MailDigestItem{ID: items[1].ID})
+	unittest.AssertExistsAndLoadBean(t, &activities_model.MailDigestItem{UserID: user2.ID})
+}
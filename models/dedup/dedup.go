@@ -0,0 +1,228 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package dedup
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+func init() {
+	db.RegisterModel(new(Blob))
+	db.RegisterModel(new(Reference))
+	storage.RegisterDedupIndex(index{})
+}
+
+// Blob represents a content stored once in the deduplicated storage
+type Blob struct {
+	ID          int64              `xorm:"pk autoincr"`
+	HashSHA256  string             `xorm:"hash_sha256 char(64) UNIQUE NOT NULL"`
+	Size        int64              `xorm:"NOT NULL DEFAULT 0"`
+	RefCount    int64              `xorm:"NOT NULL DEFAULT 0"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created INDEX NOT NULL"`
+}
+
+// TableName sets the table name for the blob
+func (Blob) TableName() string {
+	return "dedup_blob"
+}
+
+// Reference represents a path of a store referencing a blob
+type Reference struct {
+	ID          int64              `xorm:"pk autoincr"`
+	Store       string             `xorm:"UNIQUE(s) NOT NULL"`
+	Path        string             `xorm:"VARCHAR(512) UNIQUE(s) NOT NULL"`
+	BlobID      int64              `xorm:"INDEX NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL"`
+}
+
+// TableName sets the table name for the reference
+func (Reference) TableName() string {
+	return "dedup_reference"
+}
+
+// getReference returns the reference of the path and the referenced blob
+func getReference(ctx context.Context, store, path string) (*Reference, *Blob, error) {
+	ref := &Reference{}
+	has, err := db.GetEngine(ctx).Where("store = ? AND path = ?", store, path).Get(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !has {
+		return nil, nil, os.ErrNotExist
+	}
+	blob := &Blob{}
+	has, err = db.GetEngine(ctx).ID(ref.BlobID).Get(blob)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !has {
+		return nil, nil, os.ErrNotExist
+	}
+	return ref, blob, nil
+}
+
+// releaseBlob decrements the reference count of the blob and deletes it when the last reference is gone.
+// It returns the hash of the deleted blob.
+func releaseBlob(ctx context.Context, blob *Blob) (string, error) {
+	e := db.GetEngine(ctx)
+	if _, err := e.ID(blob.ID).Decr("ref_count").NoAutoTime().Update(new(Blob)); err != nil {
+		return "", err
+	}
+	deleted, err := e.Where("id = ? AND ref_count <= 0", blob.ID).Delete(new(Blob))
+	if err != nil || deleted == 0 {
+		return "", err
+	}
+	return blob.HashSHA256, nil
+}
+
+type index struct{}
+
+// Lookup returns the hash and size of the content referenced by the path of the store
+func (index) Lookup(ctx context.Context, store, path string) (string, int64, error) {
+	_, blob, err := getReference(ctx, store, path)
+	if err != nil {
+		return "", 0, err
+	}
+	return blob.HashSHA256, blob.Size, nil
+}
+
+// addReferenceAttempts is how often a reference is added before the conflicts with concurrent uploads are given up
+const addReferenceAttempts = 3
+
+// AddReference references the content from the path of the store, replacing the previous reference of the path.
+// A concurrent upload of the same content or to the same path may insert the blob or the reference first, which fails
+// the insert on their unique columns. Adding the reference again finds the inserted rows and increments them instead.
+func (index) AddReference(ctx context.Context, store, path, hash string, size int64) (unreferenced string, err error) {
+	for i := 0; i < addReferenceAttempts; i++ {
+		// a failed statement aborts the whole transaction of the caller, so only an own transaction can be retried
+		if unreferenced, err = addReference(ctx, store, path, hash, size); err == nil || db.InTransaction(ctx) {
+			break
+		}
+	}
+	return unreferenced, err
+}
+
+func addReference(ctx context.Context, store, path, hash string, size int64) (string, error) {
+	var unreferenced string
+	return unreferenced, db.WithTx(ctx, func(ctx context.Context) error {
+		e := db.GetEngine(ctx)
+
+		blob := &Blob{HashSHA256: hash}
+		has, err := e.Get(blob)
+		if err != nil {
+			return err
+		}
+		if has {
+			if _, err := e.ID(blob.ID).Incr("ref_count").NoAutoTime().Update(new(Blob)); err != nil {
+				return err
+			}
+		} else {
+			blob.Size = size
+			blob.RefCount = 1
+			if _, err := e.Insert(blob); err != nil {
+				return err
+			}
+		}
+
+		ref, oldBlob, err := getReference(ctx, store, path)
+		if err == os.ErrNotExist {
+			_, err = e.Insert(&Reference{Store: store, Path: path, BlobID: blob.ID})
+			return err
+		} else if err != nil {
+			return err
+		}
+
+		if _, err := e.ID(ref.ID).Cols("blob_id").Update(&Reference{BlobID: blob.ID}); err != nil {
+			return err
+		}
+		unreferenced, err = releaseBlob(ctx, oldBlob)
+		return err
+	})
+}
+
+// RemoveReference removes the reference of the path of the store
+func (index) RemoveReference(ctx context.Context, store, path string) (string, error) {
+	var unreferenced string
+	return unreferenced, db.WithTx(ctx, func(ctx context.Context) error {
+		ref, blob, err := getReference(ctx, store, path)
+		if err != nil {
+			return err
+		}
+		if _, err := db.GetEngine(ctx).ID(ref.ID).Delete(new(Reference)); err != nil {
+			return err
+		}
+		unreferenced, err = releaseBlob(ctx, blob)
+		return err
+	})
+}
+
+// IsReferenced returns whether the content is referenced by any path
+func (index) IsReferenced(ctx context.Context, hash string) (bool, error) {
+	return db.GetEngine(ctx).Exist(&Blob{HashSHA256: hash})
+}
+
+// IterateReferences iterates across the references of the store below the path prefix
+func (index) IterateReferences(ctx context.Context, store, prefix string, fn func(path, hash string) error) error {
+	cond := builder.NewCond().And(builder.Eq{"dedup_reference.store": store})
+	if prefix != "" {
+		cond = cond.And(builder.Like{"dedup_reference.path", strings.TrimSuffix(prefix, "/") + "/%"})
+	}
+
+	type referencedBlob struct {
+		ID         int64
+		Path       string
+		HashSHA256 string `xorm:"hash_sha256"`
+	}
+
+	const batchSize = 100
+	var lastID int64
+	for {
+		refs := make([]*referencedBlob, 0, batchSize)
+		if err := db.GetEngine(ctx).Table("dedup_reference").
+			Select("dedup_reference.id, dedup_reference.path, dedup_blob.hash_sha256").
+			Join("INNER", "dedup_blob", "dedup_blob.id = dedup_reference.blob_id").
+			Where(cond.And(builder.Gt{"dedup_reference.id": lastID})).
+			OrderBy("dedup_reference.id").
+			Limit(batchSize).
+			Find(&refs); err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			if err := fn(ref.Path, ref.HashSHA256); err != nil {
+				return err
+			}
+			lastID = ref.ID
+		}
+		if len(refs) < batchSize {
+			return nil
+		}
+	}
+}
+
+// Stats represents the statistics of the deduplicated storage
+type Stats struct {
+	Blobs          int64
+	StoredSize     int64
+	References     int64
+	ReferencedSize int64
+}
+
+// GetStats returns the number and size of the stored contents and of their references
+func GetStats(ctx context.Context) (*Stats, error) {
+	stats := &Stats{}
+	if _, err := db.GetEngine(ctx).Table("dedup_blob").
+		Select("COUNT(*) AS blob_count, COALESCE(SUM(size), 0) AS stored_size, COALESCE(SUM(ref_count), 0) AS ref_sum, COALESCE(SUM(size * ref_count), 0) AS referenced_size").
+		Get(&stats.Blobs, &stats.StoredSize, &stats.References, &stats.ReferencedSize); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
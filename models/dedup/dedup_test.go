@@ -0,0 +1,100 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package dedup
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	hashA = "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+	hashB = "fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9"
+)
+
+func TestIndex(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	ctx := db.DefaultContext
+	idx := index{}
+
+	unreferenced, err := idx.AddReference(ctx, "lfs", "2c/26/b46b", hashA, 3)
+	assert.NoError(t, err)
+	assert.Empty(t, unreferenced)
+	unreferenced, err = idx.AddReference(ctx, "attachments", "a/b/uuid", hashA, 3)
+	assert.NoError(t, err)
+	assert.Empty(t, unreferenced)
+	unittest.AssertExistsAndLoadBean(t, &Blob{HashSHA256: hashA, RefCount: 2, Size: 3})
+
+	hash, size, err := idx.Lookup(ctx, "attachments", "a/b/uuid")
+	assert.NoError(t, err)
+	assert.Equal(t, hashA, hash)
+	assert.EqualValues(t, 3, size)
+	_, _, err = idx.Lookup(ctx, "packages", "a/b/uuid")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	var paths []string
+	assert.NoError(t, idx.IterateReferences(ctx, "attachments", "a", func(path, hash string) error {
+		paths = append(paths, path)
+		assert.Equal(t, hashA, hash)
+		return nil
+	}))
+	assert.Equal(t, []string{"a/b/uuid"}, paths)
+
+	stats, err := GetStats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &Stats{Blobs: 1, StoredSize: 3, References: 2, ReferencedSize: 6}, stats)
+
+	// replacing the content of a path releases the previous content
+	unreferenced, err = idx.AddReference(ctx, "attachments", "a/b/uuid", hashB, 5)
+	assert.NoError(t, err)
+	assert.Empty(t, unreferenced)
+	unittest.AssertExistsAndLoadBean(t, &Blob{HashSHA256: hashA, RefCount: 1})
+
+	unreferenced, err = idx.RemoveReference(ctx, "lfs", "2c/26/b46b")
+	assert.NoError(t, err)
+	assert.Equal(t, hashA, unreferenced)
+	referenced, err := idx.IsReferenced(ctx, hashA)
+	assert.NoError(t, err)
+	assert.False(t, referenced)
+
+	unreferenced, err = idx.AddReference(ctx, "attachments", "a/b/uuid", hashB, 5)
+	assert.NoError(t, err)
+	assert.Empty(t, unreferenced)
+	unittest.AssertExistsAndLoadBean(t, &Blob{HashSHA256: hashB, RefCount: 1})
+
+	_, err = idx.RemoveReference(ctx, "lfs", "2c/26/b46b")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestIndexConcurrentAddReference(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	idx := index{}
+
+	// concurrent uploads of the same content to the same and to different paths
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			_, err := idx.AddReference(db.DefaultContext, "lfs", "2c/26/b46b", hashA, 3)
+			assert.NoError(t, err)
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			_, err := idx.AddReference(db.DefaultContext, "attachments", fmt.Sprintf("a/b/uuid%d", i), hashA, 3)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	blob := unittest.AssertExistsAndLoadBean(t, &Blob{HashSHA256: hashA, RefCount: 11})
+	unittest.AssertCount(t, &Reference{BlobID: blob.ID}, 11)
+}
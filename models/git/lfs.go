@@ -179,6 +179,15 @@ func GetLFSMetaObjectByOid(ctx context.Context, repoID int64, oid string) (*LFSM
 	return m, nil
 }
 
+// GetLFSMetaObjectsByOids selects the LFSMetaObject entries of the repository having one of the OIDs
+func GetLFSMetaObjectsByOids(ctx context.Context, repoID int64, oids []string) ([]*LFSMetaObject, error) {
+	lfsObjects := make([]*LFSMetaObject, 0, len(oids))
+	if len(oids) == 0 {
+		return lfsObjects, nil
+	}
+	return lfsObjects, db.GetEngine(ctx).Where("repository_id = ?", repoID).In("oid", oids).Find(&lfsObjects)
+}
+
 // RemoveLFSMetaObjectByOid removes a LFSMetaObject entry from database by its OID.
 // It may return ErrLFSObjectNotExist or a database error.
 func RemoveLFSMetaObjectByOid(ctx context.Context, repoID int64, oid string) (int64, error) {
@@ -334,6 +343,18 @@ func GetRepoLFSSize(ctx context.Context, repoID int64) (int64, error) {
 	return lfsSize, nil
 }
 
+// GetOwnerLFSSize returns the size of the lfs files in all repositories of the owner
+func GetOwnerLFSSize(ctx context.Context, ownerID int64) (int64, error) {
+	lfsSize, err := db.GetEngine(ctx).
+		Join("INNER", "repository", "repository.id = lfs_meta_object.repository_id").
+		Where("repository.owner_id = ?", ownerID).
+		SumInt(new(LFSMetaObject), "lfs_meta_object.size")
+	if err != nil {
+		return 0, fmt.Errorf("GetOwnerLFSSize: %w", err)
+	}
+	return lfsSize, nil
+}
+
 // IterateRepositoryIDsWithLFSMetaObjects iterates across the repositories that have LFSMetaObjects
 func IterateRepositoryIDsWithLFSMetaObjects(ctx context.Context, f func(ctx context.Context, repoID, count int64) error) error {
 	batchSize := setting.Database.IterateBufferSize
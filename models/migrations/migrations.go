@@ -550,6 +550,28 @@ var migrations = []Migration{
 	NewMigration("Add auth_token table", v1_22.CreateAuthTokenTable),
 	// v282 -> v283
 	NewMigration("Add Index to pull_auto_merge.doer_id", v1_22.AddIndexToPullAutoMergeDoerID),
+	// v283 -> v284
+	NewMigration("Add LFS size limits to repository and user", v1_22.AddLFSLimitsToRepositoryAndUser),
+	// v284 -> v285
+	NewMigration("Add dedup_blob and dedup_reference tables", v1_22.CreateDedupTables),
+	// v285 -> v286
+	NewMigration("Add object_format_name to repository", v1_22.AddObjectFormatNameToRepository),
+	// v286 -> v287
+	NewMigration("Expand the columns of commit IDs to hold SHA-256 IDs", v1_22.ExpandHashReferencesToSha256),
+	// v287 -> v288
+	NewMigration("Add is_partial_clone_enabled to repository", v1_22.AddIsPartialCloneEnabledToRepository),
+	// v288 -> v289
+	NewMigration("Add path and include_lfs to repo_archiver", v1_22.AddPathAndIncludeLFSToRepoArchiver),
+	// v289 -> v290
+	NewMigration("Add email_digest_frequency to user and mail_digest_item table", v1_22.AddEmailDigestFrequencyToUserAndCreateMailDigestItemTable),
+	// v290 -> v291
+	NewMigration("Add concurrency group to action_run and action_run_job", v1_22.AddConcurrencyToActionRunAndActionRunJob),
+	// v291 -> v292
+	NewMigration("Add action_environment table and environment columns", v1_22.AddActionEnvironmentTable),
+	// v292 -> v293
+	NewMigration("Add permissions column to action_run_job table", v1_22.AddPermissionsToActionRunJob),
+	// v293 -> v294
+	NewMigration("Add reusable workflow columns to action_run_job table", v1_22.AddReusableWorkflowColumnsToActionRunJob),
 }
 
 // GetCurrentDBVersion returns the current db version
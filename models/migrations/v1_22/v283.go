@@ -0,0 +1,25 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_22 //nolint
+
+import (
+	"xorm.io/xorm"
+)
+
+func AddLFSLimitsToRepositoryAndUser(x *xorm.Engine) error {
+	type Repository struct {
+		LFSMaxFileSize int64 `xorm:"NOT NULL DEFAULT 0"`
+		LFSQuota       int64 `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	type User struct {
+		LFSMaxFileSize int64 `xorm:"NOT NULL DEFAULT 0"`
+		LFSQuota       int64 `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	if err := x.Sync(new(Repository)); err != nil {
+		return err
+	}
+	return x.Sync(new(User))
+}
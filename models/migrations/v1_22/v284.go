@@ -0,0 +1,30 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_22 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func CreateDedupTables(x *xorm.Engine) error {
+	type DedupBlob struct {
+		ID          int64              `xorm:"pk autoincr"`
+		HashSHA256  string             `xorm:"hash_sha256 char(64) UNIQUE NOT NULL"`
+		Size        int64              `xorm:"NOT NULL DEFAULT 0"`
+		RefCount    int64              `xorm:"NOT NULL DEFAULT 0"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created INDEX NOT NULL"`
+	}
+
+	type DedupReference struct {
+		ID          int64              `xorm:"pk autoincr"`
+		Store       string             `xorm:"UNIQUE(s) NOT NULL"`
+		Path        string             `xorm:"VARCHAR(512) UNIQUE(s) NOT NULL"`
+		BlobID      int64              `xorm:"INDEX NOT NULL"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL"`
+	}
+
+	return x.Sync(new(DedupBlob), new(DedupReference))
+}
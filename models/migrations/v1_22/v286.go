@@ -0,0 +1,44 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_22 //nolint
+
+import (
+	"code.gitea.io/gitea/models/migrations/base"
+
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+)
+
+func ExpandHashReferencesToSha256(x *xorm.Engine) error {
+	if x.Dialect().URI().DBType == schemas.SQLITE { // For SQLITE, varchar or char will always be represented as TEXT
+		return nil
+	}
+
+	for _, col := range []struct {
+		table, column string
+		notNull       bool
+	}{
+		{"review_state", "commit_sha", true},
+		{"review", "commit_id", false},
+		{"comment", "commit_sha", false},
+		{"pull_request", "merge_base", false},
+		{"pull_request", "merged_commit_id", false},
+		{"release", "sha1", false},
+		{"repo_archiver", "commit_id", false},
+		{"repo_indexer_status", "commit_sha", false},
+	} {
+		if err := base.ModifyColumn(x, col.table, &schemas.Column{
+			Name: col.column,
+			SQLType: schemas.SQLType{
+				Name: "VARCHAR",
+			},
+			Length:         64,
+			Nullable:       !col.notNull,
+			DefaultIsEmpty: true,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_22 //nolint
+
+import (
+	"xorm.io/xorm"
+)
+
+func AddIsPartialCloneEnabledToRepository(x *xorm.Engine) error {
+	type Repository struct {
+		IsPartialCloneEnabled bool `xorm:"NOT NULL DEFAULT true"`
+	}
+
+	return x.Sync(new(Repository))
+}
@@ -0,0 +1,25 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_22 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddPathAndIncludeLFSToRepoArchiver(x *xorm.Engine) error {
+	type RepoArchiver struct {
+		ID          int64 `xorm:"pk autoincr"`
+		RepoID      int64 `xorm:"index unique(s)"`
+		Type        int   `xorm:"unique(s)"`
+		Status      int
+		CommitID    string             `xorm:"VARCHAR(64) unique(s)"`
+		Path        string             `xorm:"VARCHAR(255) NOT NULL DEFAULT '' unique(s)"`
+		IncludeLFS  bool               `xorm:"NOT NULL DEFAULT false unique(s)"`
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX NOT NULL created"`
+	}
+
+	return x.Sync(new(RepoArchiver))
+}
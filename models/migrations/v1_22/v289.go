@@ -0,0 +1,30 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_22 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddEmailDigestFrequencyToUserAndCreateMailDigestItemTable(x *xorm.Engine) error {
+	type User struct {
+		EmailDigestFrequency string `xorm:"VARCHAR(20) NOT NULL DEFAULT 'immediate'"`
+	}
+
+	type MailDigestItem struct {
+		ID          int64              `xorm:"pk autoincr"`
+		UserID      int64              `xorm:"INDEX NOT NULL"`
+		RepoID      int64              `xorm:"INDEX NOT NULL"`
+		IssueID     int64              `xorm:"INDEX NOT NULL"`
+		CommentID   int64              `xorm:"NOT NULL DEFAULT 0"`
+		DoerID      int64              `xorm:"NOT NULL"`
+		ActionType  int                `xorm:"NOT NULL DEFAULT 0"`
+		IsMention   bool               `xorm:"NOT NULL DEFAULT false"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL"`
+	}
+
+	return x.Sync(new(User), new(MailDigestItem))
+}
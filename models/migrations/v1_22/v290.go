@@ -0,0 +1,22 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_22 //nolint
+
+import (
+	"xorm.io/xorm"
+)
+
+func AddConcurrencyToActionRunAndActionRunJob(x *xorm.Engine) error {
+	type ActionRun struct {
+		ConcurrencyGroup  string `xorm:"VARCHAR(255) INDEX NOT NULL DEFAULT ''"`
+		ConcurrencyCancel bool   `xorm:"NOT NULL DEFAULT false"`
+	}
+
+	type ActionRunJob struct {
+		ConcurrencyGroup  string `xorm:"VARCHAR(255) INDEX NOT NULL DEFAULT ''"`
+		ConcurrencyCancel bool   `xorm:"NOT NULL DEFAULT false"`
+	}
+
+	return x.Sync(new(ActionRun), new(ActionRunJob))
+}
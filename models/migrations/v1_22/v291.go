@@ -0,0 +1,48 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_22 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddActionEnvironmentTable(x *xorm.Engine) error {
+	type ActionEnvironment struct {
+		ID             int64
+		RepoID         int64              `xorm:"UNIQUE(repo_name) NOT NULL"`
+		Name           string             `xorm:"VARCHAR(255) NOT NULL"`
+		LowerName      string             `xorm:"VARCHAR(255) UNIQUE(repo_name) NOT NULL"`
+		BranchPatterns []string           `xorm:"JSON TEXT"`
+		ReviewerIDs    []int64            `xorm:"reviewer_ids JSON TEXT"`
+		CreatedUnix    timeutil.TimeStamp `xorm:"created NOT NULL"`
+		UpdatedUnix    timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type ActionRunJob struct {
+		Environment          string `xorm:"VARCHAR(255) INDEX NOT NULL DEFAULT ''"`
+		EnvironmentURL       string `xorm:"TEXT"`
+		DeploymentApprovedBy int64  `xorm:"INDEX NOT NULL DEFAULT 0"`
+	}
+
+	// the environment is a part of the unique names of secrets and variables
+	type Secret struct {
+		ID            int64
+		OwnerID       int64  `xorm:"INDEX UNIQUE(owner_repo_name) NOT NULL"`
+		RepoID        int64  `xorm:"INDEX UNIQUE(owner_repo_name) NOT NULL DEFAULT 0"`
+		EnvironmentID int64  `xorm:"INDEX UNIQUE(owner_repo_name) NOT NULL DEFAULT 0"`
+		Name          string `xorm:"UNIQUE(owner_repo_name) NOT NULL"`
+	}
+
+	type ActionVariable struct {
+		ID            int64  `xorm:"pk autoincr"`
+		OwnerID       int64  `xorm:"UNIQUE(owner_repo_name)"`
+		RepoID        int64  `xorm:"INDEX UNIQUE(owner_repo_name)"`
+		EnvironmentID int64  `xorm:"INDEX UNIQUE(owner_repo_name) NOT NULL DEFAULT 0"`
+		Name          string `xorm:"UNIQUE(owner_repo_name) NOT NULL"`
+	}
+
+	return x.Sync(new(ActionEnvironment), new(ActionRunJob), new(Secret), new(ActionVariable))
+}
@@ -0,0 +1,16 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_22 //nolint
+
+import (
+	"xorm.io/xorm"
+)
+
+func AddPermissionsToActionRunJob(x *xorm.Engine) error {
+	type ActionRunJob struct {
+		Permissions map[string]string `xorm:"JSON TEXT"`
+	}
+
+	return x.Sync(new(ActionRunJob))
+}
@@ -0,0 +1,17 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_22 //nolint
+
+import (
+	"xorm.io/xorm"
+)
+
+func AddReusableWorkflowColumnsToActionRunJob(x *xorm.Engine) error {
+	type ActionRunJob struct {
+		CallerJobID      string `xorm:"VARCHAR(255) NOT NULL DEFAULT ''"`
+		ReusableWorkflow string `xorm:"VARCHAR(255) NOT NULL DEFAULT ''"`
+	}
+
+	return x.Sync(new(ActionRunJob))
+}
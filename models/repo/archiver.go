@@ -5,6 +5,8 @@ package repo
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strconv"
 	"strings"
@@ -33,7 +35,9 @@ type RepoArchiver struct { //revive:disable-line:exported
 	RepoID      int64           `xorm:"index unique(s)"`
 	Type        git.ArchiveType `xorm:"unique(s)"`
 	Status      ArchiverStatus
-	CommitID    string             `xorm:"VARCHAR(40) unique(s)"`
+	CommitID    string             `xorm:"VARCHAR(64) unique(s)"`
+	Path        string             `xorm:"VARCHAR(255) NOT NULL DEFAULT '' unique(s)"` // the archived subdirectory, empty for the whole tree
+	IncludeLFS  bool               `xorm:"NOT NULL DEFAULT false unique(s)"`           // whether LFS pointers are replaced by the LFS content
 	CreatedUnix timeutil.TimeStamp `xorm:"INDEX NOT NULL created"`
 }
 
@@ -43,29 +47,49 @@ func init() {
 
 // RelativePath returns the archive path relative to the archive storage root.
 func (archiver *RepoArchiver) RelativePath() string {
-	return fmt.Sprintf("%d/%s/%s.%s", archiver.RepoID, archiver.CommitID[:2], archiver.CommitID, archiver.Type.String())
+	name := archiver.CommitID
+	if variant := archiver.variant(); variant != "" {
+		name += "-" + variant
+	}
+	return fmt.Sprintf("%d/%s/%s.%s", archiver.RepoID, archiver.CommitID[:2], name, archiver.Type.String())
+}
+
+// variant returns a short hash to distinguish the archives of a subdirectory or with LFS content
+// from the plain archive of the commit, it is empty for the plain archive
+func (archiver *RepoArchiver) variant() string {
+	if archiver.Path == "" && !archiver.IncludeLFS {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%t", archiver.Path, archiver.IncludeLFS)))
+	return hex.EncodeToString(hash[:8])
 }
 
-// repoArchiverForRelativePath takes a relativePath created from (archiver *RepoArchiver) RelativePath() and creates a shell repoArchiver struct representing it
-func repoArchiverForRelativePath(relativePath string) (*RepoArchiver, error) {
+// repoArchiverForRelativePath takes a relativePath created from (archiver *RepoArchiver) RelativePath() and creates a shell repoArchiver struct representing it,
+// the variant of the archive can't be inverted so it is returned separately
+func repoArchiverForRelativePath(relativePath string) (_ *RepoArchiver, variant string, _ error) {
 	parts := strings.SplitN(relativePath, "/", 3)
 	if len(parts) != 3 {
-		return nil, util.SilentWrap{Message: fmt.Sprintf("invalid storage path: %s", relativePath), Err: util.ErrInvalidArgument}
+		return nil, "", util.SilentWrap{Message: fmt.Sprintf("invalid storage path: %s", relativePath), Err: util.ErrInvalidArgument}
 	}
 	repoID, err := strconv.ParseInt(parts[0], 10, 64)
 	if err != nil {
-		return nil, util.SilentWrap{Message: fmt.Sprintf("invalid storage path: %s", relativePath), Err: util.ErrInvalidArgument}
+		return nil, "", util.SilentWrap{Message: fmt.Sprintf("invalid storage path: %s", relativePath), Err: util.ErrInvalidArgument}
 	}
 	nameExts := strings.SplitN(parts[2], ".", 2)
 	if len(nameExts) != 2 {
-		return nil, util.SilentWrap{Message: fmt.Sprintf("invalid storage path: %s", relativePath), Err: util.ErrInvalidArgument}
+		return nil, "", util.SilentWrap{Message: fmt.Sprintf("invalid storage path: %s", relativePath), Err: util.ErrInvalidArgument}
+	}
+
+	commitID, variant, _ := strings.Cut(nameExts[0], "-")
+	if !strings.HasPrefix(commitID, parts[1]) {
+		return nil, "", util.SilentWrap{Message: fmt.Sprintf("invalid storage path: %s", relativePath), Err: util.ErrInvalidArgument}
 	}
 
 	return &RepoArchiver{
 		RepoID:   repoID,
-		CommitID: parts[1] + nameExts[0],
+		CommitID: commitID,
 		Type:     git.ToArchiveType(nameExts[1]),
-	}, nil
+	}, variant, nil
 }
 
 var delRepoArchiver = new(RepoArchiver)
@@ -77,9 +101,10 @@ func DeleteRepoArchiver(ctx context.Context, archiver *RepoArchiver) error {
 }
 
 // GetRepoArchiver get an archiver
-func GetRepoArchiver(ctx context.Context, repoID int64, tp git.ArchiveType, commitID string) (*RepoArchiver, error) {
+func GetRepoArchiver(ctx context.Context, repoID int64, tp git.ArchiveType, commitID, path string, includeLFS bool) (*RepoArchiver, error) {
 	var archiver RepoArchiver
-	has, err := db.GetEngine(ctx).Where("repo_id=?", repoID).And("`type`=?", tp).And("commit_id=?", commitID).Get(&archiver)
+	has, err := db.GetEngine(ctx).Where("repo_id=?", repoID).And("`type`=?", tp).And("commit_id=?", commitID).
+		And("path=?", path).And("include_lfs=?", includeLFS).Get(&archiver)
 	if err != nil {
 		return nil, err
 	}
@@ -92,12 +117,26 @@ func GetRepoArchiver(ctx context.Context, repoID int64, tp git.ArchiveType, comm
 // ExistsRepoArchiverWithStoragePath checks if there is a RepoArchiver for a given storage path
 func ExistsRepoArchiverWithStoragePath(ctx context.Context, storagePath string) (bool, error) {
 	// We need to invert the path provided func (archiver *RepoArchiver) RelativePath() above
-	archiver, err := repoArchiverForRelativePath(storagePath)
+	archiver, variant, err := repoArchiverForRelativePath(storagePath)
 	if err != nil {
 		return false, err
 	}
 
-	return db.GetEngine(ctx).Exist(archiver)
+	if variant == "" {
+		return db.GetEngine(ctx).Where("repo_id=?", archiver.RepoID).And("`type`=?", archiver.Type).And("commit_id=?", archiver.CommitID).
+			And("path=?", "").And("include_lfs=?", false).Exist(new(RepoArchiver))
+	}
+
+	archivers := make([]*RepoArchiver, 0, 2)
+	if err := db.GetEngine(ctx).Where("repo_id=?", archiver.RepoID).And("`type`=?", archiver.Type).And("commit_id=?", archiver.CommitID).Find(&archivers); err != nil {
+		return false, err
+	}
+	for _, a := range archivers {
+		if a.variant() == variant {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 // AddRepoArchiver adds an archiver
@@ -0,0 +1,48 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo_test
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoArchiverStoragePath(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	commitID := "65f1bf27bc3bf70f64657658635e66094edbcb4d"
+	plain := &repo_model.RepoArchiver{RepoID: 1, Type: git.ZIP, CommitID: commitID, Status: repo_model.ArchiverReady}
+	subdir := &repo_model.RepoArchiver{RepoID: 1, Type: git.ZIP, CommitID: commitID, Path: "docs", Status: repo_model.ArchiverReady}
+	withLFS := &repo_model.RepoArchiver{RepoID: 1, Type: git.TARZST, CommitID: commitID, Path: "docs", IncludeLFS: true}
+
+	assert.Equal(t, "1/65/"+commitID+".zip", plain.RelativePath())
+	assert.NotEqual(t, plain.RelativePath(), subdir.RelativePath())
+	assert.Regexp(t, `^1/65/`+commitID+`-[0-9a-f]{16}\.tar\.zst$`, withLFS.RelativePath())
+
+	assert.NoError(t, repo_model.AddRepoArchiver(db.DefaultContext, plain))
+	assert.NoError(t, repo_model.AddRepoArchiver(db.DefaultContext, subdir))
+
+	archiver, err := repo_model.GetRepoArchiver(db.DefaultContext, 1, git.ZIP, commitID, "docs", false)
+	assert.NoError(t, err)
+	assert.EqualValues(t, subdir.ID, archiver.ID)
+
+	for _, tc := range []struct {
+		archiver *repo_model.RepoArchiver
+		exists   bool
+	}{
+		{plain, true},
+		{subdir, true},
+		{withLFS, false},
+	} {
+		exists, err := repo_model.ExistsRepoArchiverWithStoragePath(db.DefaultContext, tc.archiver.RelativePath())
+		assert.NoError(t, err)
+		assert.Equal(t, tc.exists, exists, tc.archiver.RelativePath())
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"code.gitea.io/gitea/models/unit"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/markup"
 	"code.gitea.io/gitea/modules/setting"
@@ -134,6 +135,7 @@ type Repository struct {
 	OriginalServiceType api.GitServiceType `xorm:"index"`
 	OriginalURL         string             `xorm:"VARCHAR(2048)"`
 	DefaultBranch       string
+	ObjectFormatName    string `xorm:"VARCHAR(6) NOT NULL DEFAULT 'sha1'"`
 
 	NumWatches          int
 	NumStars            int
@@ -174,9 +176,12 @@ type Repository struct {
 	Size                            int64              `xorm:"NOT NULL DEFAULT 0"`
 	GitSize                         int64              `xorm:"NOT NULL DEFAULT 0"`
 	LFSSize                         int64              `xorm:"NOT NULL DEFAULT 0"`
+	LFSMaxFileSize                  int64              `xorm:"NOT NULL DEFAULT 0"` // 0 means use the limit of the owner
+	LFSQuota                        int64              `xorm:"NOT NULL DEFAULT 0"` // 0 means unlimited
 	CodeIndexerStatus               *RepoIndexerStatus `xorm:"-"`
 	StatsIndexerStatus              *RepoIndexerStatus `xorm:"-"`
 	IsFsckEnabled                   bool               `xorm:"NOT NULL DEFAULT true"`
+	IsPartialCloneEnabled           bool               `xorm:"NOT NULL DEFAULT true"`
 	CloseIssuesViaCommitInAnyBranch bool               `xorm:"NOT NULL DEFAULT false"`
 	Topics                          []string           `xorm:"TEXT JSON"`
 
@@ -256,6 +261,14 @@ func (repo *Repository) IsBeingCreated() bool {
 	return repo.IsBeingMigrated()
 }
 
+// GetObjectFormat returns the object format of the git repository, SHA-1 for repositories created before it was recorded
+func (repo *Repository) GetObjectFormat() git.ObjectFormat {
+	if objectFormat := git.ObjectFormatFromName(repo.ObjectFormatName); objectFormat != nil {
+		return objectFormat
+	}
+	return git.Sha1ObjectFormat
+}
+
 // IsBroken indicates that repository is broken
 func (repo *Repository) IsBroken() bool {
 	return repo.Status == RepositoryBroken
@@ -313,7 +326,7 @@ func (repo *Repository) HTMLURL() string {
 // CommitLink make link to by commit full ID
 // note: won't check whether it's an right id
 func (repo *Repository) CommitLink(commitID string) (result string) {
-	if commitID == "" || commitID == "0000000000000000000000000000000000000000" {
+	if git.IsEmptyCommitID(commitID) {
 		result = ""
 	} else {
 		result = repo.Link() + "/commit/" + url.PathEscape(commitID)
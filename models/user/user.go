@@ -66,6 +66,15 @@ const (
 	EmailNotificationsAndYourOwn = "andyourown"
 )
 
+const (
+	// EmailDigestImmediate indicates that the user would like to receive an email for every notification
+	EmailDigestImmediate = "immediate"
+	// EmailDigestHourly indicates that the user would like to receive the notifications as an hourly digest
+	EmailDigestHourly = "hourly"
+	// EmailDigestDaily indicates that the user would like to receive the notifications as a daily digest
+	EmailDigestDaily = "daily"
+)
+
 // User represents the object of individual and member of organization.
 type User struct {
 	ID        int64  `xorm:"pk autoincr"`
@@ -76,6 +85,7 @@ type User struct {
 	Email                        string `xorm:"NOT NULL"`
 	KeepEmailPrivate             bool
 	EmailNotificationsPreference string `xorm:"VARCHAR(20) NOT NULL DEFAULT 'enabled'"`
+	EmailDigestFrequency         string `xorm:"VARCHAR(20) NOT NULL DEFAULT 'immediate'"`
 	Passwd                       string `xorm:"NOT NULL"`
 	PasswdHashAlgo               string `xorm:"NOT NULL DEFAULT 'argon2'"`
 
@@ -102,6 +112,10 @@ type User struct {
 	LastRepoVisibility bool
 	// Maximum repository creation limit, -1 means use global default
 	MaxRepoCreation int `xorm:"NOT NULL DEFAULT -1"`
+	// Maximum size of LFS objects in the repositories of the user, 0 means use global default
+	LFSMaxFileSize int64 `xorm:"NOT NULL DEFAULT 0"`
+	// Maximum total size of LFS objects in all repositories of the user, 0 means unlimited
+	LFSQuota int64 `xorm:"NOT NULL DEFAULT 0"`
 
 	// IsActive true: primary email is activated, user can access Web UI and Git SSH.
 	// false: an inactive user can only log in Web UI for account operations (ex: activate the account by email), no other access.
@@ -164,6 +178,12 @@ func (u *User) BeforeUpdate() {
 	if u.MaxRepoCreation < -1 {
 		u.MaxRepoCreation = -1
 	}
+	if u.LFSMaxFileSize < 0 {
+		u.LFSMaxFileSize = 0
+	}
+	if u.LFSQuota < 0 {
+		u.LFSQuota = 0
+	}
 
 	// Organization does not need email
 	u.Email = strings.ToLower(u.Email)
@@ -487,6 +507,21 @@ func SetEmailNotifications(ctx context.Context, u *User, set string) error {
 	return nil
 }
 
+// IsEmailDigestEnabled returns true if the notification emails of the user are collected into digests
+func (u *User) IsEmailDigestEnabled() bool {
+	return u.EmailDigestFrequency == EmailDigestHourly || u.EmailDigestFrequency == EmailDigestDaily
+}
+
+// SetEmailDigestFrequency sets how often the user receives the notification emails
+func SetEmailDigestFrequency(ctx context.Context, u *User, frequency string) error {
+	u.EmailDigestFrequency = frequency
+	if err := UpdateUserCols(ctx, u, "email_digest_frequency"); err != nil {
+		log.Error("SetEmailDigestFrequency: %v", err)
+		return err
+	}
+	return nil
+}
+
 // IsUserExist checks if given user name exist,
 // the user name should be noncased unique.
 // If uid is presented, then check will rule out that one,
@@ -592,6 +627,7 @@ func CreateUser(ctx context.Context, u *User, overwriteDefault ...*CreateUserOve
 	u.Visibility = setting.Service.DefaultUserVisibilityMode
 	u.AllowCreateOrganization = setting.Service.DefaultAllowCreateOrganization && !setting.Admin.DisableRegularOrgCreation
 	u.EmailNotificationsPreference = setting.Admin.DefaultEmailNotification
+	u.EmailDigestFrequency = EmailDigestImmediate
 	u.MaxRepoCreation = -1
 	u.Theme = setting.UI.DefaultTheme
 	u.IsRestricted = setting.Service.DefaultUserIsRestricted
@@ -0,0 +1,89 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/nektos/act/pkg/jobparser"
+	"github.com/nektos/act/pkg/model"
+	"gopkg.in/yaml.v3"
+)
+
+// maxConcurrencyGroupLength limits the length of evaluated concurrency groups, they are stored in an indexed column
+const maxConcurrencyGroupLength = 255
+
+// Concurrency is the `concurrency` setting of a workflow or of a job, both values may contain expressions
+type Concurrency struct {
+	Group            string `yaml:"group"`
+	CancelInProgress string `yaml:"cancel-in-progress"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, the setting is either a group or a mapping
+func (c *Concurrency) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		c.Group = node.Value
+		return nil
+	}
+	type concurrency Concurrency
+	return node.Decode((*concurrency)(c))
+}
+
+// WorkflowConcurrency contains the concurrency settings of a workflow and of its jobs
+type WorkflowConcurrency struct {
+	Workflow *Concurrency
+	Jobs     map[string]*Concurrency
+}
+
+// GetConcurrencyFromContent reads the concurrency settings of a workflow, unset settings are nil
+func GetConcurrencyFromContent(content []byte) (*WorkflowConcurrency, error) {
+	var workflow struct {
+		Concurrency *Concurrency `yaml:"concurrency"`
+		Jobs        map[string]struct {
+			Concurrency *Concurrency `yaml:"concurrency"`
+		} `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal(content, &workflow); err != nil {
+		return nil, err
+	}
+
+	wc := &WorkflowConcurrency{Workflow: workflow.Concurrency, Jobs: make(map[string]*Concurrency, len(workflow.Jobs))}
+	for id, job := range workflow.Jobs {
+		if job.Concurrency != nil {
+			wc.Jobs[id] = job.Concurrency
+		}
+	}
+	return wc, nil
+}
+
+// EvaluateConcurrency interpolates the expressions of a concurrency setting.
+// The job is nil for the setting of the workflow, otherwise its matrix is available to the expressions.
+// An empty group means that the runs or jobs aren't limited.
+func EvaluateConcurrency(c *Concurrency, jobID string, job *jobparser.Job, gitCtx *model.GithubContext) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	evaluator := newJobExpressionEvaluator(jobID, job, gitCtx)
+	group, _ := util.SplitStringAtByteN(strings.TrimSpace(evaluator.Interpolate(c.Group)), maxConcurrencyGroupLength)
+	cancelInProgress := strings.TrimSpace(evaluator.Interpolate(c.CancelInProgress)) == "true"
+	return group, cancelInProgress
+}
+
+// newJobExpressionEvaluator returns an evaluator for the expressions which are evaluated before a run starts,
+// the matrix of the job is available if the job isn't nil
+func newJobExpressionEvaluator(jobID string, job *jobparser.Job, gitCtx *model.GithubContext) *jobparser.ExpressionEvaluator {
+	var matrix map[string]any
+	modelJob := &model.Job{}
+	if job != nil {
+		modelJob.Strategy = &model.Strategy{RawMatrix: job.Strategy.RawMatrix}
+		if matrixes, err := modelJob.GetMatrixes(); err == nil && len(matrixes) > 0 {
+			matrix = matrixes[0]
+		}
+	}
+	results := map[string]*jobparser.JobResult{jobID: {}}
+	return jobparser.NewExpressionEvaluator(jobparser.NewInterpeter(jobID, modelJob, matrix, gitCtx, results))
+}
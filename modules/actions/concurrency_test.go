@@ -0,0 +1,61 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/nektos/act/pkg/jobparser"
+	"github.com/nektos/act/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrency(t *testing.T) {
+	content := []byte(`
+name: test
+on: push
+concurrency: ci-${{ github.ref }}
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        os: [linux, windows]
+    concurrency:
+      group: build-${{ matrix.os }}-${{ github.workflow }}
+      cancel-in-progress: ${{ github.event_name == 'pull_request' }}
+    steps:
+      - run: make
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make test
+`)
+	wc, err := GetConcurrencyFromContent(content)
+	assert.NoError(t, err)
+	assert.Equal(t, &Concurrency{Group: "ci-${{ github.ref }}"}, wc.Workflow)
+	assert.Len(t, wc.Jobs, 1)
+
+	gitCtx := &model.GithubContext{Ref: "refs/heads/main", Workflow: "test.yml", EventName: "pull_request"}
+	group, cancel := EvaluateConcurrency(wc.Workflow, "", nil, gitCtx)
+	assert.Equal(t, "ci-refs/heads/main", group)
+	assert.False(t, cancel)
+
+	jobs, err := jobparser.Parse(content)
+	assert.NoError(t, err)
+	var groups []string
+	for _, swf := range jobs {
+		id, job := swf.Job()
+		group, cancel := EvaluateConcurrency(wc.Jobs[id], id, job, gitCtx)
+		if group != "" {
+			assert.True(t, cancel)
+		}
+		groups = append(groups, group)
+	}
+	assert.ElementsMatch(t, []string{"build-linux-test.yml", "build-windows-test.yml", ""}, groups)
+
+	group, cancel = EvaluateConcurrency(nil, "", nil, gitCtx)
+	assert.Empty(t, group)
+	assert.False(t, cancel)
+}
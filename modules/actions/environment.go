@@ -0,0 +1,66 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/nektos/act/pkg/jobparser"
+	"github.com/nektos/act/pkg/model"
+	"gopkg.in/yaml.v3"
+)
+
+// maxEnvironmentNameLength limits the length of evaluated environment names, they are stored in an indexed column
+const maxEnvironmentNameLength = 255
+
+// Environment is the `environment` setting of a job, both values may contain expressions
+type Environment struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, the setting is either a name or a mapping
+func (e *Environment) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		e.Name = node.Value
+		return nil
+	}
+	type environment Environment
+	return node.Decode((*environment)(e))
+}
+
+// GetEnvironmentsFromContent reads the environment settings of the jobs of a workflow, the jobs without an environment are absent
+func GetEnvironmentsFromContent(content []byte) (map[string]*Environment, error) {
+	var workflow struct {
+		Jobs map[string]struct {
+			Environment *Environment `yaml:"environment"`
+		} `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal(content, &workflow); err != nil {
+		return nil, err
+	}
+
+	environments := make(map[string]*Environment, len(workflow.Jobs))
+	for id, job := range workflow.Jobs {
+		if job.Environment != nil {
+			environments[id] = job.Environment
+		}
+	}
+	return environments, nil
+}
+
+// EvaluateEnvironment interpolates the expressions of the environment setting of a job.
+// An empty name means that the job doesn't deploy to an environment.
+func EvaluateEnvironment(e *Environment, jobID string, job *jobparser.Job, gitCtx *model.GithubContext) (string, string) {
+	if e == nil {
+		return "", ""
+	}
+
+	evaluator := newJobExpressionEvaluator(jobID, job, gitCtx)
+	name, _ := util.SplitStringAtByteN(strings.TrimSpace(evaluator.Interpolate(e.Name)), maxEnvironmentNameLength)
+	url := strings.TrimSpace(evaluator.Interpolate(e.URL))
+	return name, url
+}
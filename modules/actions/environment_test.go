@@ -0,0 +1,47 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/nektos/act/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvironment(t *testing.T) {
+	content := []byte(`
+on: push
+jobs:
+  staging:
+    runs-on: ubuntu-latest
+    environment: staging
+    steps:
+      - run: ./deploy.sh
+  production:
+    runs-on: ubuntu-latest
+    environment:
+      name: production-${{ github.ref_name }}
+      url: https://example.com/${{ github.ref_name }}
+    steps:
+      - run: ./deploy.sh
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make
+`)
+	environments, err := GetEnvironmentsFromContent(content)
+	assert.NoError(t, err)
+	assert.Len(t, environments, 2)
+	assert.Equal(t, &Environment{Name: "staging"}, environments["staging"])
+
+	gitCtx := &model.GithubContext{Ref: "refs/heads/main", RefName: "main"}
+	name, url := EvaluateEnvironment(environments["production"], "production", nil, gitCtx)
+	assert.Equal(t, "production-main", name)
+	assert.Equal(t, "https://example.com/main", url)
+
+	name, url = EvaluateEnvironment(environments["build"], "build", nil, gitCtx)
+	assert.Empty(t, name)
+	assert.Empty(t, url)
+}
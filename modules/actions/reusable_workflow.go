@@ -0,0 +1,263 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nektos/act/pkg/jobparser"
+	"github.com/nektos/act/pkg/model"
+	"gopkg.in/yaml.v3"
+)
+
+// GithubEventWorkflowCall is the event of the workflows which can be called by the jobs of other workflows
+const GithubEventWorkflowCall = "workflow_call"
+
+// ReusableWorkflowUses is the `uses` setting of a job which calls a reusable workflow,
+// it's either `owner/repo/.gitea/workflows/build.yml@ref` or `./.gitea/workflows/build.yml` for a workflow of the repository of the run
+type ReusableWorkflowUses struct {
+	OwnerName string
+	RepoName  string
+	Path      string
+	Ref       string
+}
+
+// IsLocal returns whether the workflow is read from the commit of the run
+func (u *ReusableWorkflowUses) IsLocal() bool {
+	return u.OwnerName == ""
+}
+
+// ParseReusableWorkflowUses parses the `uses` setting of a job, the workflow has to be a file in a workflow directory
+func ParseReusableWorkflowUses(uses string) (*ReusableWorkflowUses, error) {
+	ret := &ReusableWorkflowUses{}
+	if strings.HasPrefix(uses, "./") {
+		ret.Path = strings.TrimPrefix(uses, "./")
+	} else {
+		ref, ok := "", false
+		uses, ref, ok = strings.Cut(uses, "@")
+		if !ok || ref == "" {
+			return nil, fmt.Errorf("reusable workflow %q doesn't specify a ref", uses)
+		}
+		parts := strings.SplitN(uses, "/", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid reusable workflow %q", uses)
+		}
+		ret.OwnerName, ret.RepoName, ret.Path, ret.Ref = parts[0], parts[1], parts[2], ref
+	}
+	if dir := path.Dir(ret.Path); path.Clean(ret.Path) != ret.Path || (dir != ".gitea/workflows" && dir != ".github/workflows") || !IsWorkflow(ret.Path) {
+		return nil, fmt.Errorf("reusable workflow %q isn't a file in a workflow directory", ret.Path)
+	}
+	return ret, nil
+}
+
+// WorkflowCall is the `workflow_call` trigger of a reusable workflow
+type WorkflowCall struct {
+	Inputs  map[string]*WorkflowCallInput  `yaml:"inputs"`
+	Outputs map[string]*WorkflowCallOutput `yaml:"outputs"`
+}
+
+// WorkflowCallInput is an input of a reusable workflow, the type is `string`, `boolean` or `number`
+type WorkflowCallInput struct {
+	Type     string `yaml:"type"`
+	Required bool   `yaml:"required"`
+	Default  string `yaml:"default"`
+}
+
+// WorkflowCallOutput is an output of a reusable workflow, the value is an expression of the outputs of its jobs
+type WorkflowCallOutput struct {
+	Value string `yaml:"value"`
+}
+
+// GetWorkflowCallFromContent reads the `workflow_call` trigger of a workflow, it's nil if the workflow can't be called
+func GetWorkflowCallFromContent(content []byte) (*WorkflowCall, error) {
+	var workflow struct {
+		On yaml.Node `yaml:"on"`
+	}
+	if err := yaml.Unmarshal(content, &workflow); err != nil {
+		return nil, err
+	}
+
+	switch workflow.On.Kind {
+	case yaml.ScalarNode:
+		if workflow.On.Value == GithubEventWorkflowCall {
+			return &WorkflowCall{}, nil
+		}
+	case yaml.SequenceNode:
+		for _, v := range workflow.On.Content {
+			if v.Value == GithubEventWorkflowCall {
+				return &WorkflowCall{}, nil
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(workflow.On.Content); i += 2 {
+			if workflow.On.Content[i].Value != GithubEventWorkflowCall {
+				continue
+			}
+			wc := &WorkflowCall{}
+			if err := workflow.On.Content[i+1].Decode(wc); err != nil {
+				return nil, err
+			}
+			return wc, nil
+		}
+	}
+	return nil, nil
+}
+
+// EvaluateWorkflowCallInputs evaluates the `with` setting of the job which calls a reusable workflow,
+// it returns the inputs as literals of expressions, which replace the references to the inputs in the called workflow.
+func EvaluateWorkflowCallInputs(wc *WorkflowCall, jobID string, job *jobparser.Job, gitCtx *model.GithubContext) (map[string]string, error) {
+	evaluator := newJobExpressionEvaluator(jobID, job, gitCtx)
+	inputs := make(map[string]string, len(wc.Inputs))
+	for name, input := range wc.Inputs {
+		value, ok := job.With[name]
+		if !ok {
+			if input.Required {
+				return nil, fmt.Errorf("input %q is required", name)
+			}
+			value = input.Default
+		}
+		s := fmt.Sprint(value)
+		if str, ok := value.(string); ok {
+			s = strings.TrimSpace(evaluator.Interpolate(str))
+		}
+
+		switch input.Type {
+		case "boolean":
+			if s == "" {
+				s = "false"
+			}
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return nil, fmt.Errorf("input %q isn't a boolean: %q", name, s)
+			}
+			inputs[name] = strconv.FormatBool(b)
+		case "number":
+			if s == "" {
+				s = "0"
+			}
+			if _, err := strconv.ParseFloat(s, 64); err != nil {
+				return nil, fmt.Errorf("input %q isn't a number: %q", name, s)
+			}
+			inputs[name] = s
+		default:
+			inputs[name] = "'" + strings.ReplaceAll(s, "'", "''") + "'"
+		}
+	}
+	for name := range job.With {
+		if _, ok := wc.Inputs[name]; !ok {
+			return nil, fmt.Errorf("input %q isn't defined by the workflow", name)
+		}
+	}
+	return inputs, nil
+}
+
+var (
+	expressionPattern = regexp.MustCompile(`\$\{\{(.*?)\}\}`)
+	// inputsPattern matches the references to the inputs, but not to the properties named `inputs` like `github.event.inputs`
+	inputsPattern     = regexp.MustCompile(`(^|[^\w.])inputs\.([A-Za-z_][\w-]*)`)
+	jobOutputsPattern = regexp.MustCompile(`\$\{\{\s*jobs\.([\w-]+)\.outputs\.([\w-]+)\s*\}\}`)
+)
+
+// InterpolateWorkflowCallInputs replaces the references to the inputs in the expressions of a called workflow with their literals,
+// the undefined inputs are null. The `if` settings are expressions even without `${{ }}`.
+func InterpolateWorkflowCallInputs(content []byte, inputs map[string]string) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(content, &node); err != nil {
+		return nil, err
+	}
+
+	replaceInputs := func(expr string) string {
+		return inputsPattern.ReplaceAllStringFunc(expr, func(s string) string {
+			m := inputsPattern.FindStringSubmatch(s)
+			if v, ok := inputs[m[2]]; ok {
+				return m[1] + v
+			}
+			return m[1] + "null"
+		})
+	}
+	var walk func(n *yaml.Node, isIf bool)
+	walk = func(n *yaml.Node, isIf bool) {
+		switch n.Kind {
+		case yaml.ScalarNode:
+			if isIf && !strings.Contains(n.Value, "${{") {
+				n.Value = replaceInputs(n.Value)
+			} else {
+				n.Value = expressionPattern.ReplaceAllStringFunc(n.Value, replaceInputs)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				walk(n.Content[i+1], n.Content[i].Value == "if")
+			}
+		default:
+			for _, c := range n.Content {
+				walk(c, false)
+			}
+		}
+	}
+	walk(&node, false)
+
+	return yaml.Marshal(&node)
+}
+
+// EvaluateWorkflowCallOutputs evaluates the outputs of a called workflow with the outputs of its jobs
+func EvaluateWorkflowCallOutputs(outputs map[string]string, jobOutputs map[string]map[string]string) map[string]string {
+	ret := make(map[string]string, len(outputs))
+	for name, value := range outputs {
+		ret[name] = jobOutputsPattern.ReplaceAllStringFunc(value, func(s string) string {
+			m := jobOutputsPattern.FindStringSubmatch(s)
+			return jobOutputs[m[1]][m[2]]
+		})
+	}
+	return ret
+}
+
+// CombineIfs combines the `if` setting of the job which calls a reusable workflow with the setting of a job of the called workflow
+func CombineIfs(callerIf, jobIf string) string {
+	unwrap := func(s string) string {
+		s = strings.TrimSpace(s)
+		if strings.HasPrefix(s, "${{") && strings.HasSuffix(s, "}}") && strings.Count(s, "${{") == 1 {
+			s = strings.TrimSpace(s[3 : len(s)-2])
+		}
+		return s
+	}
+	callerIf, jobIf = unwrap(callerIf), unwrap(jobIf)
+	if callerIf == "" {
+		return jobIf
+	}
+	if jobIf == "" {
+		return callerIf
+	}
+	return "(" + callerIf + ") && (" + jobIf + ")"
+}
+
+var secretsPattern = regexp.MustCompile(`\$\{\{\s*secrets\.([\w-]+)\s*\}\}`)
+
+// PassWorkflowCallSecrets returns the secrets which the job calling a reusable workflow passes to the called workflow,
+// they are all the secrets with `secrets: inherit`, otherwise the secrets mapped by the setting. The tokens are always passed.
+func PassWorkflowCallSecrets(rawSecrets *yaml.Node, secrets map[string]string) map[string]string {
+	if rawSecrets.Kind == yaml.ScalarNode && rawSecrets.Value == "inherit" {
+		return secrets
+	}
+
+	ret := map[string]string{}
+	for _, name := range []string{"GITHUB_TOKEN", "GITEA_TOKEN"} {
+		if v, ok := secrets[name]; ok {
+			ret[name] = v
+		}
+	}
+	mapping := map[string]string{}
+	if rawSecrets.Kind == yaml.MappingNode {
+		_ = rawSecrets.Decode(&mapping)
+	}
+	for name, value := range mapping {
+		ret[strings.ToUpper(name)] = secretsPattern.ReplaceAllStringFunc(value, func(s string) string {
+			return secrets[strings.ToUpper(secretsPattern.FindStringSubmatch(s)[1])]
+		})
+	}
+	return ret
+}
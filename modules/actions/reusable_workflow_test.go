@@ -0,0 +1,169 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/nektos/act/pkg/jobparser"
+	"github.com/nektos/act/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseReusableWorkflowUses(t *testing.T) {
+	uses, err := ParseReusableWorkflowUses("org/ci/.gitea/workflows/build.yml@v1")
+	assert.NoError(t, err)
+	assert.Equal(t, &ReusableWorkflowUses{OwnerName: "org", RepoName: "ci", Path: ".gitea/workflows/build.yml", Ref: "v1"}, uses)
+	assert.False(t, uses.IsLocal())
+
+	uses, err = ParseReusableWorkflowUses("./.github/workflows/build.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, &ReusableWorkflowUses{Path: ".github/workflows/build.yaml"}, uses)
+	assert.True(t, uses.IsLocal())
+
+	for _, v := range []string{
+		"org/ci/.gitea/workflows/build.yml",
+		"org/.gitea/workflows/build.yml@main",
+		"org/ci/build.yml@main",
+		"org/ci/.gitea/workflows/../../build.yml@main",
+		"org/ci/.gitea/workflows/sub/build.yml@main",
+		"./.gitea/workflows/build.sh",
+		"actions/checkout@v4",
+	} {
+		_, err = ParseReusableWorkflowUses(v)
+		assert.Error(t, err, v)
+	}
+}
+
+func TestGetWorkflowCallFromContent(t *testing.T) {
+	wc, err := GetWorkflowCallFromContent([]byte(`
+on:
+  push:
+  workflow_call:
+    inputs:
+      target:
+        type: string
+        required: true
+      debug:
+        type: boolean
+        default: false
+    outputs:
+      version:
+        value: ${{ jobs.build.outputs.version }}
+jobs: {}
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, &WorkflowCall{
+		Inputs: map[string]*WorkflowCallInput{
+			"target": {Type: "string", Required: true},
+			"debug":  {Type: "boolean", Default: "false"},
+		},
+		Outputs: map[string]*WorkflowCallOutput{
+			"version": {Value: "${{ jobs.build.outputs.version }}"},
+		},
+	}, wc)
+
+	wc, err = GetWorkflowCallFromContent([]byte("on: [push, workflow_call]\njobs: {}\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, &WorkflowCall{}, wc)
+
+	wc, err = GetWorkflowCallFromContent([]byte("on: push\njobs: {}\n"))
+	assert.NoError(t, err)
+	assert.Nil(t, wc)
+
+	// the workflow can still be triggered by its other events
+	events, err := GetEventsFromContent([]byte("on:\n  push:\n  workflow_call:\n    inputs:\n      target:\n        type: string\njobs: {}\n"))
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+func TestEvaluateWorkflowCallInputs(t *testing.T) {
+	wc := &WorkflowCall{Inputs: map[string]*WorkflowCallInput{
+		"target":  {Type: "string", Required: true},
+		"debug":   {Type: "boolean"},
+		"retries": {Type: "number", Default: "3"},
+	}}
+	gitCtx := &model.GithubContext{RefName: "main"}
+
+	inputs, err := EvaluateWorkflowCallInputs(wc, "call", &jobparser.Job{With: map[string]any{"target": "it's ${{ github.ref_name }}", "debug": true}}, gitCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"target": "'it''s main'", "debug": "true", "retries": "3"}, inputs)
+
+	_, err = EvaluateWorkflowCallInputs(wc, "call", &jobparser.Job{}, gitCtx)
+	assert.ErrorContains(t, err, `input "target" is required`)
+	_, err = EvaluateWorkflowCallInputs(wc, "call", &jobparser.Job{With: map[string]any{"target": "main", "debug": "maybe"}}, gitCtx)
+	assert.ErrorContains(t, err, `input "debug" isn't a boolean`)
+	_, err = EvaluateWorkflowCallInputs(wc, "call", &jobparser.Job{With: map[string]any{"target": "main", "unknown": 1}}, gitCtx)
+	assert.ErrorContains(t, err, `input "unknown" isn't defined`)
+}
+
+func TestInterpolateWorkflowCallInputs(t *testing.T) {
+	content, err := InterpolateWorkflowCallInputs([]byte(`
+on: workflow_call
+jobs:
+  build:
+    if: inputs.debug && github.event.inputs.debug
+    runs-on: ${{ inputs.runner }}
+    steps:
+      - run: echo ${{ inputs.target }} inputs.target ${{ inputs.unknown }}
+`), map[string]string{"debug": "true", "runner": "'ubuntu-latest'", "target": "'main'"})
+	assert.NoError(t, err)
+	assert.Equal(t, `on: workflow_call
+jobs:
+    build:
+        if: true && github.event.inputs.debug
+        runs-on: ${{ 'ubuntu-latest' }}
+        steps:
+            - run: echo ${{ 'main' }} inputs.target ${{ null }}
+`, string(content))
+}
+
+func TestEvaluateWorkflowCallOutputs(t *testing.T) {
+	outputs := EvaluateWorkflowCallOutputs(map[string]string{
+		"version": "${{ jobs.build.outputs.version }}",
+		"summary": "${{ jobs.build.outputs.version }}-${{ jobs.test.outputs.result }}",
+		"missing": "${{ jobs.deploy.outputs.url }}",
+	}, map[string]map[string]string{
+		"build": {"version": "1.0.0"},
+		"test":  {"result": "passed"},
+	})
+	assert.Equal(t, map[string]string{"version": "1.0.0", "summary": "1.0.0-passed", "missing": ""}, outputs)
+}
+
+func TestCombineIfs(t *testing.T) {
+	assert.Equal(t, "", CombineIfs("", ""))
+	assert.Equal(t, "success()", CombineIfs("${{ success() }}", ""))
+	assert.Equal(t, "inputs.debug", CombineIfs("", "inputs.debug"))
+	assert.Equal(t, "(github.ref_name == 'main') && (always())", CombineIfs("github.ref_name == 'main'", "${{ always() }}"))
+}
+
+func TestPermissionsLimit(t *testing.T) {
+	assert.Nil(t, Permissions(nil).Limit(nil))
+	assert.Equal(t, Permissions{"contents": "read"}, Permissions{"contents": "read"}.Limit(nil))
+	assert.Equal(t, Permissions{"*": "read"}, Permissions(nil).Limit(Permissions{"*": "read"}))
+
+	limited := Permissions{"*": "write"}.Limit(Permissions{"contents": "read", "issues": "write"})
+	assert.Equal(t, "read", limited.Level("contents"))
+	assert.Equal(t, "write", limited.Level("issues"))
+	assert.Equal(t, "none", limited.Level("packages"))
+	assert.Equal(t, "none", limited.Level("id-token"))
+}
+
+func TestPassWorkflowCallSecrets(t *testing.T) {
+	secrets := map[string]string{"GITEA_TOKEN": "token", "GITHUB_TOKEN": "token", "DEPLOY_KEY": "key", "OTHER": "other"}
+
+	parse := func(content string) *yaml.Node {
+		node := &yaml.Node{}
+		assert.NoError(t, yaml.Unmarshal([]byte(content), node))
+		if node.Kind == yaml.DocumentNode {
+			return node.Content[0]
+		}
+		return node
+	}
+	assert.Equal(t, secrets, PassWorkflowCallSecrets(parse("inherit"), secrets))
+	assert.Equal(t, map[string]string{"GITEA_TOKEN": "token", "GITHUB_TOKEN": "token"}, PassWorkflowCallSecrets(&yaml.Node{}, secrets))
+	assert.Equal(t, map[string]string{"GITEA_TOKEN": "token", "GITHUB_TOKEN": "token", "SSH_KEY": "key", "MISSING": ""},
+		PassWorkflowCallSecrets(parse("ssh_key: ${{ secrets.deploy_key }}\nmissing: ${{ secrets.MISSING }}"), secrets))
+}
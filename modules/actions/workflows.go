@@ -5,9 +5,14 @@ package actions
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"slices"
 	"strings"
 
+	"code.gitea.io/gitea/models/perm"
+	"code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/modules/container"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	api "code.gitea.io/gitea/modules/structs"
@@ -87,7 +92,17 @@ func GetEventsFromContent(content []byte) ([]*jobparser.Event, error) {
 	if err != nil {
 		return nil, err
 	}
-	events, err := jobparser.ParseRawOn(&workflow.RawOn)
+	rawOn := workflow.RawOn
+	if rawOn.Kind == yaml.MappingNode {
+		// the inputs, outputs and secrets of `workflow_call` can't be parsed as filters of an event
+		rawOn.Content = slices.Clone(rawOn.Content)
+		for i := 0; i+1 < len(rawOn.Content); i += 2 {
+			if rawOn.Content[i].Value == GithubEventWorkflowCall {
+				rawOn.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+			}
+		}
+	}
+	events, err := jobparser.ParseRawOn(&rawOn)
 	if err != nil {
 		return nil, err
 	}
@@ -95,6 +110,152 @@ func GetEventsFromContent(content []byte) ([]*jobparser.Event, error) {
 	return events, nil
 }
 
+// The access levels of the `permissions` setting
+const (
+	PermissionNone  = "none"
+	PermissionRead  = "read"
+	PermissionWrite = "write"
+)
+
+// permissionsAllScopes is the scope of the `read-all` and `write-all` settings
+const permissionsAllScopes = "*"
+
+// Permissions is the `permissions` setting of a workflow or of a job, it maps the scopes to their access levels.
+// The setting is either `read-all`, `write-all` or a mapping, the scopes which aren't listed in a mapping aren't accessible.
+type Permissions map[string]string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (p *Permissions) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		switch node.Value {
+		case "read-all":
+			*p = Permissions{permissionsAllScopes: PermissionRead}
+		case "write-all":
+			*p = Permissions{permissionsAllScopes: PermissionWrite}
+		default:
+			return fmt.Errorf("invalid permissions %q", node.Value)
+		}
+		return nil
+	}
+	scopes := map[string]string{}
+	if err := node.Decode(&scopes); err != nil {
+		return err
+	}
+	*p = scopes
+	return nil
+}
+
+// Level returns the access level of a scope
+func (p Permissions) Level(scope string) string {
+	if level, ok := p[permissionsAllScopes]; ok {
+		return level
+	}
+	if level, ok := p[scope]; ok {
+		return level
+	}
+	return PermissionNone
+}
+
+// permissionScopeUnits maps the scopes of the `permissions` setting to the units of a repository,
+// the units which aren't listed are always readable like the `metadata` scope of GitHub.
+// The `packages` scope isn't supported, as the package registry doesn't check the permissions of the tokens of the tasks.
+var permissionScopeUnits = map[string][]unit.Type{
+	"contents":            {unit.TypeCode, unit.TypeReleases, unit.TypeWiki},
+	"issues":              {unit.TypeIssues},
+	"pull-requests":       {unit.TypePullRequests},
+	"actions":             {unit.TypeActions},
+	"repository-projects": {unit.TypeProjects},
+}
+
+// UnitsMode translates the permissions into the access modes of the units of a repository, none of them exceeds maxMode.
+// All the units have maxMode if the `permissions` setting is absent.
+func (p Permissions) UnitsMode(maxMode perm.AccessMode) map[unit.Type]perm.AccessMode {
+	defaultMode := maxMode
+	if p != nil {
+		defaultMode = min(perm.AccessModeRead, maxMode)
+	}
+	unitsMode := make(map[unit.Type]perm.AccessMode, len(unit.AllRepoUnitTypes))
+	for _, t := range unit.AllRepoUnitTypes {
+		unitsMode[t] = defaultMode
+	}
+	if p == nil {
+		return unitsMode
+	}
+
+	for scope, units := range permissionScopeUnits {
+		mode := perm.AccessModeNone
+		switch p.Level(scope) {
+		case PermissionRead:
+			mode = perm.AccessModeRead
+		case PermissionWrite:
+			mode = perm.AccessModeWrite
+		}
+		for _, t := range units {
+			unitsMode[t] = min(mode, maxMode)
+		}
+	}
+	return unitsMode
+}
+
+// Limit returns the permissions whose levels don't exceed the ones of limit, e.g. the permissions of the job which calls a reusable workflow.
+// The permissions are nil only if both are nil, absent permissions are limited to limit.
+func (p Permissions) Limit(limit Permissions) Permissions {
+	if limit == nil {
+		return p
+	}
+	if p == nil {
+		return limit
+	}
+
+	levels := map[string]int{PermissionNone: 0, PermissionRead: 1, PermissionWrite: 2}
+	scopes := make(container.Set[string])
+	scopes.Add("id-token")
+	for scope := range permissionScopeUnits {
+		scopes.Add(scope)
+	}
+	for scope := range p {
+		scopes.Add(scope)
+	}
+	for scope := range limit {
+		scopes.Add(scope)
+	}
+	scopes.Remove(permissionsAllScopes)
+
+	ret := make(Permissions, len(scopes))
+	for scope := range scopes {
+		level, limitLevel := p.Level(scope), limit.Level(scope)
+		if levels[limitLevel] < levels[level] {
+			level = limitLevel
+		}
+		ret[scope] = level
+	}
+	return ret
+}
+
+// GetPermissionsFromContent reads the permissions of the jobs of a workflow,
+// the setting of a job replaces the one of the workflow, the jobs without any setting are absent
+func GetPermissionsFromContent(content []byte) (map[string]Permissions, error) {
+	var workflow struct {
+		Permissions Permissions `yaml:"permissions"`
+		Jobs        map[string]struct {
+			Permissions Permissions `yaml:"permissions"`
+		} `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal(content, &workflow); err != nil {
+		return nil, err
+	}
+
+	permissions := make(map[string]Permissions, len(workflow.Jobs))
+	for id, job := range workflow.Jobs {
+		if job.Permissions != nil {
+			permissions[id] = job.Permissions
+		} else if workflow.Permissions != nil {
+			permissions[id] = workflow.Permissions
+		}
+	}
+	return permissions, nil
+}
+
 func DetectWorkflows(
 	gitRepo *git.Repository,
 	commit *git.Commit,
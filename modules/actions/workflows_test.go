@@ -6,6 +6,8 @@ package actions
 import (
 	"testing"
 
+	"code.gitea.io/gitea/models/perm"
+	"code.gitea.io/gitea/models/unit"
 	"code.gitea.io/gitea/modules/git"
 	api "code.gitea.io/gitea/modules/structs"
 	webhook_module "code.gitea.io/gitea/modules/webhook"
@@ -129,3 +131,63 @@ func TestDetectMatched(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPermissionsFromContent(t *testing.T) {
+	content := []byte(`
+on: push
+permissions:
+  contents: read
+  id-token: write
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make
+  release:
+    runs-on: ubuntu-latest
+    permissions: write-all
+    steps:
+      - run: make release
+  lint:
+    runs-on: ubuntu-latest
+    permissions: {}
+    steps:
+      - run: make lint
+`)
+	permissions, err := GetPermissionsFromContent(content)
+	assert.NoError(t, err)
+	assert.Len(t, permissions, 3)
+
+	assert.Equal(t, PermissionRead, permissions["build"].Level("contents"))
+	assert.Equal(t, PermissionWrite, permissions["build"].Level("id-token"))
+	assert.Equal(t, PermissionNone, permissions["build"].Level("issues"))
+	assert.Equal(t, PermissionWrite, permissions["release"].Level("issues"))
+	assert.Equal(t, PermissionNone, permissions["lint"].Level("contents"))
+
+	permissions, err = GetPermissionsFromContent([]byte("on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n"))
+	assert.NoError(t, err)
+	assert.Empty(t, permissions)
+
+	_, err = GetPermissionsFromContent([]byte("on: push\npermissions: everything\n"))
+	assert.Error(t, err)
+}
+
+func TestPermissionsUnitsMode(t *testing.T) {
+	unitsMode := Permissions(nil).UnitsMode(perm.AccessModeWrite)
+	assert.Equal(t, perm.AccessModeWrite, unitsMode[unit.TypeCode])
+	assert.Equal(t, perm.AccessModeWrite, unitsMode[unit.TypeIssues])
+
+	p := Permissions{"contents": PermissionRead, "issues": PermissionWrite}
+	unitsMode = p.UnitsMode(perm.AccessModeWrite)
+	assert.Equal(t, perm.AccessModeRead, unitsMode[unit.TypeCode])
+	assert.Equal(t, perm.AccessModeRead, unitsMode[unit.TypeReleases])
+	assert.Equal(t, perm.AccessModeWrite, unitsMode[unit.TypeIssues])
+	assert.Equal(t, perm.AccessModeNone, unitsMode[unit.TypePullRequests])
+	assert.Equal(t, perm.AccessModeRead, unitsMode[unit.TypeExternalTracker])
+
+	// the runs of pull requests from forks can't write
+	unitsMode = p.UnitsMode(perm.AccessModeRead)
+	assert.Equal(t, perm.AccessModeRead, unitsMode[unit.TypeIssues])
+	unitsMode = Permissions{permissionsAllScopes: PermissionWrite}.UnitsMode(perm.AccessModeRead)
+	assert.Equal(t, perm.AccessModeRead, unitsMode[unit.TypeCode])
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"time"
 
+	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/services/repository"
@@ -23,6 +24,15 @@ func init() {
 		SkipDatabaseInitialization: false,
 		Priority:                   1,
 	})
+	Register(&Check{
+		Title:                      "Check if there are abandoned LFS multipart uploads",
+		Name:                       "lfs-multipart-uploads",
+		IsDefault:                  false,
+		Run:                        checkLFSMultipartUploads,
+		AbortIfFailed:              false,
+		SkipDatabaseInitialization: false,
+		Priority:                   1,
+	})
 }
 
 func garbageCollectLFSCheck(ctx context.Context, logger log.Logger, autofix bool) error {
@@ -49,3 +59,28 @@ func garbageCollectLFSCheck(ctx context.Context, logger log.Logger, autofix bool
 
 	return checkStorage(&checkStorageOptions{LFS: true})(ctx, logger, autofix)
 }
+
+func checkLFSMultipartUploads(ctx context.Context, logger log.Logger, autofix bool) error {
+	if !setting.LFS.StartServer {
+		return fmt.Errorf("LFS support is disabled")
+	}
+
+	count, size, err := repository.CleanupLFSMultipartUploads(ctx, repository.CleanupLFSMultipartUploadsOptions{
+		LogDetail: logger.Info,
+		AutoFix:   autofix,
+		// Uploads which are still in progress could be resumed, so only report the ones older than a day
+		OlderThan: time.Now().Add(-24 * time.Hour),
+	})
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		logger.Info("Found no abandoned LFS multipart uploads")
+	} else if autofix {
+		logger.Info("Aborted %d abandoned LFS multipart uploads, reclaimed %s", count, base.FileSize(size))
+	} else {
+		logger.Warn("Found %d abandoned LFS multipart uploads with %s of orphaned parts", count, base.FileSize(size))
+	}
+	return nil
+}
@@ -207,7 +207,68 @@ func checkStorage(opts *checkStorageOptions) func(ctx context.Context, logger lo
 	}
 }
 
+// checkStorageReplication compares the secondary storages of the replicated storages with their primary storage
+// and makes the secondary storages copies of the primary storage if autofix is set
+func checkStorageReplication(ctx context.Context, logger log.Logger, autofix bool) error {
+	if err := storage.Init(); err != nil {
+		logger.Error("storage.Init failed: %v", err)
+		return err
+	}
+
+	found := false
+	for _, s := range []struct {
+		name   string
+		storer storage.ObjectStorage
+	}{
+		{"attachment", storage.Attachments},
+		{"LFS", storage.LFS},
+		{"avatar", storage.Avatars},
+		{"repo avatar", storage.RepoAvatars},
+		{"repo archive", storage.RepoArchives},
+		{"package", storage.Packages},
+		{"actions log", storage.Actions},
+		{"actions artifact", storage.ActionsArtifacts},
+	} {
+		replicated, ok := storage.Unwrap(s.storer).(*storage.ReplicatedStorage)
+		if !ok {
+			continue
+		}
+		found = true
+
+		for i, secondary := range replicated.Secondaries() {
+			result, err := replicated.Reconcile(ctx, secondary, autofix)
+			if err != nil {
+				logger.Error("Error whilst reconciling secondary %s storage %d: %v", s.name, i+1, err)
+				return err
+			}
+			if result.MissingInPrimary+result.MissingInSecondary+result.SizeMismatch == 0 {
+				logger.Info("Secondary %s storage %d is in sync", s.name, i+1)
+			} else if autofix {
+				logger.Info("Reconciled secondary %s storage %d: deleted %d object(s) missing in the primary storage and copied %d to the secondary storage",
+					s.name, i+1, result.MissingInPrimary, result.MissingInSecondary+result.SizeMismatch)
+			} else {
+				logger.Warn("Secondary %s storage %d differs: %d object(s) missing in the primary storage, %d missing in the secondary storage, %d with different size",
+					s.name, i+1, result.MissingInPrimary, result.MissingInSecondary, result.SizeMismatch)
+			}
+		}
+	}
+	if !found {
+		logger.Info("No replicated storage configured (skipped)")
+	}
+	return nil
+}
+
 func init() {
+	Register(&Check{
+		Title:                      "Check if the replicated storages are in sync",
+		Name:                       "storage-replication",
+		IsDefault:                  false,
+		Run:                        checkStorageReplication,
+		AbortIfFailed:              false,
+		SkipDatabaseInitialization: true,
+		Priority:                   1,
+	})
+
 	Register(&Check{
 		Title:                      "Check if there are orphaned storage files",
 		Name:                       "storages",
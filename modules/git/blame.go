@@ -36,7 +36,7 @@ func (r *BlameReader) UsesIgnoreRevs() bool {
 	return r.ignoreRevsFile != nil
 }
 
-var shaLineRegex = regexp.MustCompile("^([a-z0-9]{40})")
+var shaLineRegex = regexp.MustCompile("^([a-z0-9]{40}|[a-z0-9]{64}) ")
 
 // NextPart returns next part of blame (sequential code lines with the same commit)
 func (r *BlameReader) NextPart() (*BlamePart, error) {
@@ -14,7 +14,7 @@ import (
 // We need this to interpret commits from cat-file or cat-file --batch
 //
 // If used as part of a cat-file --batch stream you need to limit the reader to the correct size
-func CommitFromReader(gitRepo *Repository, sha SHA1, reader io.Reader) (*Commit, error) {
+func CommitFromReader(gitRepo *Repository, sha ObjectID, reader io.Reader) (*Commit, error) {
 	commit := &Commit{
 		ID:        sha,
 		Author:    &Signature{},
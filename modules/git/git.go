@@ -13,6 +13,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -35,6 +36,8 @@ var (
 
 	// SupportProcReceive version >= 2.29.0
 	SupportProcReceive bool
+	// SupportHashSha256 version >= 2.29.0, repositories can be created with the SHA-256 object format
+	SupportHashSha256 bool
 
 	gitVersion *version.Version
 )
@@ -189,6 +192,12 @@ func InitFull(ctx context.Context) (err error) {
 		globalCommandArgs = append(globalCommandArgs, "-c", "credential.helper=")
 	}
 	SupportProcReceive = CheckGitVersionAtLeast("2.29") == nil
+	SupportHashSha256 = CheckGitVersionAtLeast("2.29") == nil && !isGogit
+	if SupportHashSha256 {
+		SupportedObjectFormats = []ObjectFormat{Sha1ObjectFormat, Sha256ObjectFormat}
+	} else {
+		log.Warn("sha256 hash support is disabled - requires Git >= 2.29 and a build without the gogit tag")
+	}
 
 	if setting.LFS.StartServer {
 		if CheckGitVersionAtLeast("2.1.2") != nil {
@@ -303,6 +312,15 @@ func syncGitConfig() (err error) {
 	return err
 }
 
+// PartialCloneEnvs returns the environment variables for a "git upload-pack" process which override the global
+// partial clone config ("uploadpack.allowFilter" and "uploadpack.allowAnySHA1InWant") for a single repository.
+// Partial clones can only be enabled if they are not disabled globally and the git version supports them.
+func PartialCloneEnvs(enabled bool) []string {
+	enabled = enabled && !setting.Git.DisablePartialClone && CheckGitVersionAtLeast("2.22") == nil
+	value := strconv.FormatBool(enabled)
+	return []string{"GIT_CONFIG_PARAMETERS='uploadpack.allowfilter=" + value + "' 'uploadpack.allowanysha1inwant=" + value + "'"}
+}
+
 // CheckGitVersionAtLeast check git version is at least the constraint version
 func CheckGitVersionAtLeast(atLeast string) error {
 	if _, err := loadGitVersion(); err != nil {
@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
 	"code.gitea.io/gitea/modules/util"
 
 	"github.com/stretchr/testify/assert"
@@ -93,3 +94,15 @@ func TestSyncConfig(t *testing.T) {
 	assert.True(t, gitConfigContains("[sync-test]"))
 	assert.True(t, gitConfigContains("cfg-key-a = CfgValA"))
 }
+
+func TestPartialCloneEnvs(t *testing.T) {
+	defer test.MockVariableValue(&setting.Git.DisablePartialClone, false)()
+
+	assert.Equal(t, []string{"GIT_CONFIG_PARAMETERS='uploadpack.allowfilter=false' 'uploadpack.allowanysha1inwant=false'"}, PartialCloneEnvs(false))
+	if CheckGitVersionAtLeast("2.22") == nil {
+		assert.Equal(t, []string{"GIT_CONFIG_PARAMETERS='uploadpack.allowfilter=true' 'uploadpack.allowanysha1inwant=true'"}, PartialCloneEnvs(true))
+	}
+
+	setting.Git.DisablePartialClone = true
+	assert.Equal(t, []string{"GIT_CONFIG_PARAMETERS='uploadpack.allowfilter=false' 'uploadpack.allowanysha1inwant=false'"}, PartialCloneEnvs(true))
+}
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"path"
 	"sort"
@@ -143,8 +144,12 @@ func (g *LogNameStatusRepoParser) Next(treepath string, paths2ids map[string]int
 	}
 
 	// Our "line" must look like: <commitid> SP (<parent> SP) * NUL
-	ret.CommitID = string(g.next[0:40])
-	parents := string(g.next[41:])
+	commitIDEnd := bytes.IndexByte(g.next, ' ')
+	if commitIDEnd < 0 {
+		return nil, fmt.Errorf("invalid commit line: %q", g.next)
+	}
+	ret.CommitID = string(g.next[:commitIDEnd])
+	parents := string(g.next[commitIDEnd+1:])
 	if g.buffull {
 		more, err := g.rd.ReadString('\x00')
 		if err != nil {
@@ -0,0 +1,145 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// sha1Pattern can be used to determine if a string is an valid sha
+var sha1Pattern = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+
+// sha256Pattern can be used to determine if a string is an valid sha
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{4,64}$`)
+
+// ObjectFormat is the hash algorithm used to name the objects of a repository
+type ObjectFormat interface {
+	// Name returns the name of the object format as used by git --object-format
+	Name() string
+	// EmptyObjectID creates a new empty ObjectID, used for undefined or non-existent objects
+	EmptyObjectID() ObjectID
+	// EmptyTree returns the ID of an empty tree, the root of all git repositories
+	EmptyTree() ObjectID
+	// FullLength is the length of the hex string of an ID
+	FullLength() int
+	// IsValid returns whether the input is a valid, possibly abbreviated, hex string of an ID
+	IsValid(input string) bool
+	// MustID creates a new ObjectID from the raw bytes of an ID with no validation of input
+	MustID(b []byte) ObjectID
+	// ComputeHash computes the ID of an object of the given type and content
+	ComputeHash(t ObjectType, content []byte) ObjectID
+}
+
+// Sha1ObjectFormatImpl is the SHA-1 object format, the default one of git
+type Sha1ObjectFormatImpl struct{}
+
+var (
+	emptySha1ObjectID = Sha1Hash{}
+	emptySha1Tree     = Sha1Hash{
+		0x4b, 0x82, 0x5d, 0xc6, 0x42, 0xcb, 0x6e, 0xb9, 0xa0, 0x60,
+		0xe5, 0x4b, 0xf8, 0xd6, 0x92, 0x88, 0xfb, 0xee, 0x49, 0x04,
+	}
+)
+
+func (Sha1ObjectFormatImpl) Name() string { return "sha1" }
+
+func (Sha1ObjectFormatImpl) EmptyObjectID() ObjectID { return emptySha1ObjectID }
+
+func (Sha1ObjectFormatImpl) EmptyTree() ObjectID { return emptySha1Tree }
+
+func (Sha1ObjectFormatImpl) FullLength() int { return 40 }
+
+func (Sha1ObjectFormatImpl) IsValid(input string) bool { return sha1Pattern.MatchString(input) }
+
+func (Sha1ObjectFormatImpl) MustID(b []byte) ObjectID {
+	var id Sha1Hash
+	copy(id[:], b)
+	return id
+}
+
+func (h Sha1ObjectFormatImpl) ComputeHash(t ObjectType, content []byte) ObjectID {
+	hasher := sha1.New()
+	writeObjectHeader(hasher, t, int64(len(content)))
+	_, _ = hasher.Write(content)
+	return h.MustID(hasher.Sum(nil))
+}
+
+// Sha256ObjectFormatImpl is the SHA-256 object format, enabled by git init --object-format=sha256
+type Sha256ObjectFormatImpl struct{}
+
+var (
+	emptySha256ObjectID = Sha256Hash{}
+	emptySha256Tree     = Sha256Hash{
+		0x6e, 0xf1, 0x9b, 0x41, 0x22, 0x5c, 0x53, 0x69, 0xf1, 0xc1,
+		0x04, 0xd4, 0x5d, 0x8d, 0x85, 0xef, 0xa9, 0xb0, 0x57, 0xb5,
+		0x3b, 0x14, 0xb4, 0xb9, 0xb9, 0x39, 0xdd, 0x74, 0xde, 0xcc,
+		0x53, 0x21,
+	}
+)
+
+func (Sha256ObjectFormatImpl) Name() string { return "sha256" }
+
+func (Sha256ObjectFormatImpl) EmptyObjectID() ObjectID { return emptySha256ObjectID }
+
+func (Sha256ObjectFormatImpl) EmptyTree() ObjectID { return emptySha256Tree }
+
+func (Sha256ObjectFormatImpl) FullLength() int { return 64 }
+
+func (Sha256ObjectFormatImpl) IsValid(input string) bool { return sha256Pattern.MatchString(input) }
+
+func (Sha256ObjectFormatImpl) MustID(b []byte) ObjectID {
+	var id Sha256Hash
+	copy(id[:], b)
+	return id
+}
+
+func (h Sha256ObjectFormatImpl) ComputeHash(t ObjectType, content []byte) ObjectID {
+	hasher := sha256.New()
+	writeObjectHeader(hasher, t, int64(len(content)))
+	_, _ = hasher.Write(content)
+	return h.MustID(hasher.Sum(nil))
+}
+
+// writeObjectHeader writes the header git prefixes the content of an object with before hashing it
+func writeObjectHeader(w io.Writer, t ObjectType, size int64) {
+	_, _ = w.Write(t.Bytes())
+	_, _ = w.Write([]byte(" "))
+	_, _ = w.Write([]byte(strconv.FormatInt(size, 10)))
+	_, _ = w.Write([]byte{0})
+}
+
+var (
+	Sha1ObjectFormat   ObjectFormat = Sha1ObjectFormatImpl{}
+	Sha256ObjectFormat ObjectFormat = Sha256ObjectFormatImpl{}
+)
+
+// SupportedObjectFormats are the object formats new repositories can be created with,
+// SHA-256 is added by InitFull if it's supported by the git version
+var SupportedObjectFormats = []ObjectFormat{
+	Sha1ObjectFormat,
+}
+
+// ObjectFormatFromName returns the object format of the name, nil if it isn't known
+func ObjectFormatFromName(name string) ObjectFormat {
+	for _, objectFormat := range []ObjectFormat{Sha1ObjectFormat, Sha256ObjectFormat} {
+		if name == objectFormat.Name() {
+			return objectFormat
+		}
+	}
+	return nil
+}
+
+// IsValidObjectFormat returns whether new repositories can be created with the object format
+func IsValidObjectFormat(name string) bool {
+	for _, objectFormat := range SupportedObjectFormats {
+		if name == objectFormat.Name() {
+			return true
+		}
+	}
+	return false
+}
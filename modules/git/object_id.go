@@ -0,0 +1,116 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ObjectID is the name of a git object, a hash of its content in the object format of the repository
+type ObjectID interface {
+	// String returns the hex string of the ID
+	String() string
+	// IsZero returns whether the ID is the empty ID of its object format
+	IsZero() bool
+	// RawValue returns the raw bytes of the ID
+	RawValue() []byte
+	// Type returns the object format of the ID
+	Type() ObjectFormat
+}
+
+// Sha1Hash is the ID of an object of a SHA-1 repository
+type Sha1Hash [20]byte
+
+func (h Sha1Hash) String() string { return hex.EncodeToString(h[:]) }
+
+func (h Sha1Hash) IsZero() bool { return h == emptySha1ObjectID }
+
+func (h Sha1Hash) RawValue() []byte { return h[:] }
+
+func (Sha1Hash) Type() ObjectFormat { return Sha1ObjectFormat }
+
+// Sha256Hash is the ID of an object of a SHA-256 repository
+type Sha256Hash [32]byte
+
+func (h Sha256Hash) String() string { return hex.EncodeToString(h[:]) }
+
+func (h Sha256Hash) IsZero() bool { return h == emptySha256ObjectID }
+
+func (h Sha256Hash) RawValue() []byte { return h[:] }
+
+func (Sha256Hash) Type() ObjectFormat { return Sha256ObjectFormat }
+
+// ErrInvalidSHA is returned for strings which aren't valid IDs
+type ErrInvalidSHA struct {
+	SHA string
+}
+
+func (err ErrInvalidSHA) Error() string {
+	return fmt.Sprintf("invalid sha: %s", err.SHA)
+}
+
+// IsValidSHAPattern will check if the provided string is a valid, possibly abbreviated, ID of any object format
+func IsValidSHAPattern(sha string) bool {
+	return sha256Pattern.MatchString(sha)
+}
+
+// IsFullSHA returns whether the string has the length of a full ID of any object format
+func IsFullSHA(sha string) bool {
+	return len(sha) == Sha1ObjectFormat.FullLength() || len(sha) == Sha256ObjectFormat.FullLength()
+}
+
+// objectFormatFromLength returns the object format of the full IDs of the length
+func objectFormatFromLength(length int) ObjectFormat {
+	switch length {
+	case Sha1ObjectFormat.FullLength():
+		return Sha1ObjectFormat
+	case Sha256ObjectFormat.FullLength():
+		return Sha256ObjectFormat
+	}
+	return nil
+}
+
+// NewIDFromString creates a new ObjectID from the full hex string of an ID, the object format is detected from its length
+func NewIDFromString(hexHash string) (ObjectID, error) {
+	hexHash = strings.TrimSpace(hexHash)
+	objectFormat := objectFormatFromLength(len(hexHash))
+	if objectFormat == nil {
+		return nil, ErrInvalidSHA{SHA: hexHash}
+	}
+	b, err := hex.DecodeString(hexHash)
+	if err != nil {
+		return nil, err
+	}
+	return objectFormat.MustID(b), nil
+}
+
+// MustIDFromString always creates a new ObjectID from the full hex string of an ID with no validation of input
+func MustIDFromString(hexHash string) ObjectID {
+	b, _ := hex.DecodeString(hexHash)
+	if len(hexHash) == Sha256ObjectFormat.FullLength() {
+		return Sha256ObjectFormat.MustID(b)
+	}
+	return Sha1ObjectFormat.MustID(b)
+}
+
+// IsEmptyCommitID returns whether the commit ID is empty, or the empty ID of any object format
+// which denotes a non-existent commit, e.g. the old commit of a pushed new branch
+func IsEmptyCommitID(commitID string) bool {
+	if commitID == "" {
+		return true
+	}
+	id, err := NewIDFromString(commitID)
+	if err != nil {
+		return false
+	}
+	return id.IsZero()
+}
+
+// ComputeBlobHash computes the ID of a blob of the content
+func ComputeBlobHash(objectFormat ObjectFormat, content []byte) ObjectID {
+	return objectFormat.ComputeHash(ObjectBlob, content)
+}
@@ -0,0 +1,38 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+//go:build gogit
+
+package git
+
+import (
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/hash"
+)
+
+// ParseGogitHash converts a go-git hash to an ObjectID
+func ParseGogitHash(h plumbing.Hash) ObjectID {
+	switch hash.Size {
+	case 20:
+		return Sha1ObjectFormat.MustID(h[:])
+	case 32:
+		return Sha256ObjectFormat.MustID(h[:])
+	}
+	return nil
+}
+
+// ParseGogitHashArray converts go-git hashes to ObjectIDs
+func ParseGogitHashArray(objectIDs []plumbing.Hash) []ObjectID {
+	ret := make([]ObjectID, len(objectIDs))
+	for i, h := range objectIDs {
+		ret[i] = ParseGogitHash(h)
+	}
+	return ret
+}
+
+// ToGogitHash converts an ObjectID to a go-git hash
+func ToGogitHash(id ObjectID) plumbing.Hash {
+	var h plumbing.Hash
+	copy(h[:], id.RawValue())
+	return h
+}
@@ -0,0 +1,49 @@
+// Copyright 2022 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidSHAPattern(t *testing.T) {
+	assert.True(t, IsValidSHAPattern("fee1"))
+	assert.True(t, IsValidSHAPattern("abc000"))
+	assert.True(t, IsValidSHAPattern("9023902390239023902390239023902390239023"))
+	assert.True(t, IsValidSHAPattern("9023902390239023902390239023902390239023902390239023902390239023"))
+	assert.False(t, IsValidSHAPattern("90239023902390239023902390239023902390239023902390239023902390239023"))
+	assert.False(t, IsValidSHAPattern("abc"))
+	assert.False(t, IsValidSHAPattern("123g"))
+	assert.False(t, IsValidSHAPattern("some random text"))
+}
+
+func TestNewIDFromString(t *testing.T) {
+	id, err := NewIDFromString("4b825dc642cb6eb9a060e54bf8d69288fbee4904")
+	assert.NoError(t, err)
+	assert.Equal(t, Sha1ObjectFormat, id.Type())
+	assert.Equal(t, Sha1ObjectFormat.EmptyTree(), id)
+
+	id, err = NewIDFromString("6ef19b41225c5369f1c104d45d8d85efa9b057b53b14b4b9b939dd74decc5321")
+	assert.NoError(t, err)
+	assert.Equal(t, Sha256ObjectFormat, id.Type())
+	assert.Equal(t, Sha256ObjectFormat.EmptyTree(), id)
+
+	_, err = NewIDFromString("4b825dc642cb6eb9a060e54bf8d69288fbee49")
+	assert.Error(t, err)
+}
+
+func TestIsEmptyCommitID(t *testing.T) {
+	assert.True(t, IsEmptyCommitID(""))
+	assert.True(t, IsEmptyCommitID(Sha1ObjectFormat.EmptyObjectID().String()))
+	assert.True(t, IsEmptyCommitID(Sha256ObjectFormat.EmptyObjectID().String()))
+	assert.False(t, IsEmptyCommitID("4b825dc642cb6eb9a060e54bf8d69288fbee4904"))
+	assert.False(t, IsEmptyCommitID("0000"))
+}
+
+func TestComputeBlobHash(t *testing.T) {
+	assert.Equal(t, "b45ef6fec89518d314f546fd6c3025367b721684", ComputeBlobHash(Sha1ObjectFormat, []byte("Hello, World!")).String())
+	assert.Equal(t, "e118a058f018dda253bb692320c940091b15e4f19067e12fff110606a111f5da", ComputeBlobHash(Sha256ObjectFormat, []byte("Hello, World!")).String())
+}
@@ -8,6 +8,7 @@ package git
 import (
 	"testing"
 
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
@@ -28,7 +29,7 @@ func TestParseTreeEntries(t *testing.T) {
 				{
 					ID: MustIDFromString("61ab7345a1a3bbc590068ccae37b8515cfc5843c"),
 					gogitTreeEntry: &object.TreeEntry{
-						Hash: MustIDFromString("61ab7345a1a3bbc590068ccae37b8515cfc5843c"),
+						Hash: plumbing.NewHash("61ab7345a1a3bbc590068ccae37b8515cfc5843c"),
 						Name: "example/file2.txt",
 						Mode: filemode.Regular,
 					},
@@ -44,7 +45,7 @@ func TestParseTreeEntries(t *testing.T) {
 				{
 					ID: MustIDFromString("61ab7345a1a3bbc590068ccae37b8515cfc5843c"),
 					gogitTreeEntry: &object.TreeEntry{
-						Hash: MustIDFromString("61ab7345a1a3bbc590068ccae37b8515cfc5843c"),
+						Hash: plumbing.NewHash("61ab7345a1a3bbc590068ccae37b8515cfc5843c"),
 						Name: "example/\n.txt",
 						Mode: filemode.Symlink,
 					},
@@ -55,7 +56,7 @@ func TestParseTreeEntries(t *testing.T) {
 					ID:    MustIDFromString("1d01fb729fb0db5881daaa6030f9f2d3cd3d5ae8"),
 					sized: true,
 					gogitTreeEntry: &object.TreeEntry{
-						Hash: MustIDFromString("1d01fb729fb0db5881daaa6030f9f2d3cd3d5ae8"),
+						Hash: plumbing.NewHash("1d01fb729fb0db5881daaa6030f9f2d3cd3d5ae8"),
 						Name: "example",
 						Mode: filemode.Dir,
 					},
@@ -62,14 +62,25 @@ func IsRepoURLAccessible(ctx context.Context, url string) bool {
 	return err == nil
 }
 
-// InitRepository initializes a new Git repository.
-func InitRepository(ctx context.Context, repoPath string, bare bool) error {
+// InitRepository initializes a new Git repository with the object format, SHA-1 if the name is empty.
+func InitRepository(ctx context.Context, repoPath string, bare bool, objectFormatName string) error {
+	if objectFormatName == "" {
+		objectFormatName = Sha1ObjectFormat.Name()
+	}
+	if !IsValidObjectFormat(objectFormatName) {
+		return fmt.Errorf("invalid object format: %s", objectFormatName)
+	}
+
 	err := os.MkdirAll(repoPath, os.ModePerm)
 	if err != nil {
 		return err
 	}
 
 	cmd := NewCommand(ctx, "init")
+	if SupportHashSha256 {
+		// git versions knowing SHA-256 may be configured to use it by default
+		cmd.AddOptionFormat("--object-format=%s", objectFormatName)
+	}
 	if bare {
 		cmd.AddArguments("--bare")
 	}
@@ -77,6 +88,31 @@ func InitRepository(ctx context.Context, repoPath string, bare bool) error {
 	return err
 }
 
+// GetObjectFormatOfRepo returns the object format of the repository at the path
+func GetObjectFormatOfRepo(ctx context.Context, repoPath string) (ObjectFormat, error) {
+	stdout, _, err := NewCommand(ctx, "rev-parse", "--show-object-format").RunStdString(&RunOpts{Dir: repoPath})
+	if err != nil {
+		return nil, err
+	}
+	if objectFormat := ObjectFormatFromName(strings.TrimSpace(stdout)); objectFormat != nil {
+		return objectFormat, nil
+	}
+	// git versions without SHA-256 support echo the unknown option, their repositories use SHA-1
+	return Sha1ObjectFormat, nil
+}
+
+// GetObjectFormat returns the object format of the repository
+func (repo *Repository) GetObjectFormat() (ObjectFormat, error) {
+	if repo.objectFormat == nil {
+		objectFormat, err := GetObjectFormatOfRepo(repo.Ctx, repo.Path)
+		if err != nil {
+			return nil, err
+		}
+		repo.objectFormat = objectFormat
+	}
+	return repo.objectFormat, nil
+}
+
 // IsEmpty Check if repository is empty.
 func (repo *Repository) IsEmpty() (bool, error) {
 	var errbuf, output strings.Builder
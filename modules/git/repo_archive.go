@@ -5,11 +5,15 @@
 package git
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
 	"path/filepath"
 	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
 )
 
 // ArchiveType archive types
@@ -22,6 +26,12 @@ const (
 	TARGZ
 	// BUNDLE bundle archive type
 	BUNDLE
+	// TARZST tar zst archive type
+	TARZST
+	// TARXZ tar xz archive type
+	TARXZ
+	// TAR uncompressed tar archive type
+	TAR
 )
 
 // String converts an ArchiveType to string
@@ -33,6 +43,12 @@ func (a ArchiveType) String() string {
 		return "tar.gz"
 	case BUNDLE:
 		return "bundle"
+	case TARZST:
+		return "tar.zst"
+	case TARXZ:
+		return "tar.xz"
+	case TAR:
+		return "tar"
 	}
 	return "unknown"
 }
@@ -45,13 +61,46 @@ func ToArchiveType(s string) ArchiveType {
 		return TARGZ
 	case "bundle":
 		return BUNDLE
+	case "tar.zst":
+		return TARZST
+	case "tar.xz":
+		return TARXZ
+	case "tar":
+		return TAR
 	}
 	return 0
 }
 
-// CreateArchive create archive content to the target path
-func (repo *Repository) CreateArchive(ctx context.Context, format ArchiveType, target io.Writer, usePrefix bool, commitID string) error {
-	if format.String() == "unknown" {
+// IsTar returns whether the archive type is a (compressed) tar archive
+func (a ArchiveType) IsTar() bool {
+	return a == TAR || a == TARGZ || a == TARZST || a == TARXZ
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// NewTarCompressor returns a writer which compresses a tar stream written to it according to the tar archive type.
+// The returned writer must be closed to flush the compressed data, closing it doesn't close the target.
+func NewTarCompressor(format ArchiveType, target io.Writer) (io.WriteCloser, error) {
+	switch format {
+	case TAR:
+		return nopWriteCloser{target}, nil
+	case TARGZ:
+		return gzip.NewWriter(target), nil
+	case TARZST:
+		return zstd.NewWriter(target)
+	case TARXZ:
+		return xz.NewWriter(target)
+	}
+	return nil, fmt.Errorf("not a tar format: %v", format)
+}
+
+// CreateArchive create archive content to the target path, if paths are given only these paths of the commit are archived
+func (repo *Repository) CreateArchive(ctx context.Context, format ArchiveType, target io.Writer, usePrefix bool, commitID string, paths ...string) error {
+	if format.String() == "unknown" || format == BUNDLE {
 		return fmt.Errorf("unknown format: %v", format)
 	}
 
@@ -59,8 +108,24 @@ func (repo *Repository) CreateArchive(ctx context.Context, format ArchiveType, t
 	if usePrefix {
 		cmd.AddOptionFormat("--prefix=%s", filepath.Base(strings.TrimSuffix(repo.Path, ".git"))+"/")
 	}
-	cmd.AddOptionFormat("--format=%s", format.String())
+
+	// git only knows about zip, tar and tar.gz, the other tar formats are compressed here
+	var compressor io.WriteCloser
+	switch format {
+	case TARZST, TARXZ:
+		var err error
+		if compressor, err = NewTarCompressor(format, target); err != nil {
+			return err
+		}
+		target = compressor
+		cmd.AddArguments("--format=tar")
+	default:
+		cmd.AddOptionFormat("--format=%s", format.String())
+	}
 	cmd.AddDynamicArguments(commitID)
+	if len(paths) > 0 {
+		cmd.AddDashesAndList(paths...)
+	}
 
 	var stderr strings.Builder
 	err := cmd.Run(&RunOpts{
@@ -71,5 +136,8 @@ func (repo *Repository) CreateArchive(ctx context.Context, format ArchiveType, t
 	if err != nil {
 		return ConcatenateError(err, stderr.String())
 	}
+	if compressor != nil {
+		return compressor.Close()
+	}
 	return nil
 }
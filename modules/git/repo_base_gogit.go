@@ -20,12 +20,16 @@ import (
 	"github.com/go-git/go-git/v5/storage/filesystem"
 )
 
+const isGogit = true
+
 // Repository represents a Git repository.
 type Repository struct {
 	Path string
 
 	tagCache *ObjectCache
 
+	objectFormat ObjectFormat
+
 	gogitRepo    *gogit.Repository
 	gogitStorage *filesystem.Storage
 	gpgSettings  *GPGSettings
@@ -15,12 +15,16 @@ import (
 	"code.gitea.io/gitea/modules/log"
 )
 
+const isGogit = false
+
 // Repository represents a Git repository.
 type Repository struct {
 	Path string
 
 	tagCache *ObjectCache
 
+	objectFormat ObjectFormat
+
 	gpgSettings *GPGSettings
 
 	batchCancel context.CancelFunc
@@ -9,6 +9,10 @@ import (
 
 // LineBlame returns the latest commit at the given line
 func (repo *Repository) LineBlame(revision, path, file string, line uint) (*Commit, error) {
+	objectFormat, err := repo.GetObjectFormat()
+	if err != nil {
+		return nil, err
+	}
 	res, _, err := NewCommand(repo.Ctx, "blame").
 		AddOptionFormat("-L %d,%d", line, line).
 		AddOptionValues("-p", revision).
@@ -16,8 +20,8 @@ func (repo *Repository) LineBlame(revision, path, file string, line uint) (*Comm
 	if err != nil {
 		return nil, err
 	}
-	if len(res) < 40 {
+	if len(res) < objectFormat.FullLength() {
 		return nil, fmt.Errorf("invalid result of blame: %s", res)
 	}
-	return repo.GetCommit(res[:40])
+	return repo.GetCommit(res[:objectFormat.FullLength()])
 }
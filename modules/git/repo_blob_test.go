@@ -4,7 +4,6 @@
 package git
 
 import (
-	"fmt"
 	"io"
 	"path/filepath"
 	"testing"
@@ -61,7 +60,7 @@ func TestRepository_GetBlob_NoId(t *testing.T) {
 	defer r.Close()
 
 	testCase := ""
-	testError := fmt.Errorf("Length must be 40: %s", testCase)
+	testError := ErrInvalidSHA{SHA: testCase}
 
 	blob, err := r.GetBlob(testCase)
 	assert.Nil(t, blob)
@@ -31,11 +31,11 @@ func (o ObjectType) Bytes() []byte {
 	return []byte(o)
 }
 
-// HashObject takes a reader and returns SHA1 hash for that reader
-func (repo *Repository) HashObject(reader io.Reader) (SHA1, error) {
+// HashObject takes a reader and returns hash for that reader
+func (repo *Repository) HashObject(reader io.Reader) (ObjectID, error) {
 	idStr, err := repo.hashObject(reader)
 	if err != nil {
-		return SHA1{}, err
+		return nil, err
 	}
 	return NewIDFromString(idStr)
 }
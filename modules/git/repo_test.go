@@ -5,6 +5,7 @@ package git
 
 import (
 	"context"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -54,3 +55,66 @@ func TestRepoGetDivergingCommits(t *testing.T) {
 		Behind: 2,
 	}, do)
 }
+
+func TestSha256Repository(t *testing.T) {
+	if !SupportHashSha256 {
+		t.Skip("skipping because the git version doesn't support SHA-256")
+	}
+
+	repoPath := t.TempDir()
+	assert.NoError(t, InitRepository(DefaultContext, repoPath, false, Sha256ObjectFormat.Name()))
+	assert.NoError(t, os.MkdirAll(filepath.Join(repoPath, "dir"), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(repoPath, "a.txt"), []byte("Hello, World!"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(repoPath, "dir", "b.txt"), []byte("b"), 0o644))
+	env := append(os.Environ(),
+		"GIT_AUTHOR_NAME=Gitea", "GIT_AUTHOR_EMAIL=gitea@example.com",
+		"GIT_COMMITTER_NAME=Gitea", "GIT_COMMITTER_EMAIL=gitea@example.com",
+	)
+	assert.NoError(t, NewCommand(DefaultContext, "add", "--all").Run(&RunOpts{Dir: repoPath, Env: env}))
+	assert.NoError(t, NewCommand(DefaultContext, "commit", "--message=initial").Run(&RunOpts{Dir: repoPath, Env: env}))
+
+	repo, err := openRepositoryWithDefaultContext(repoPath)
+	assert.NoError(t, err)
+	defer repo.Close()
+
+	objectFormat, err := repo.GetObjectFormat()
+	assert.NoError(t, err)
+	assert.Equal(t, Sha256ObjectFormat, objectFormat)
+
+	commit, err := repo.GetCommit("HEAD")
+	assert.NoError(t, err)
+	assert.Len(t, commit.ID.String(), 64)
+	assert.Equal(t, Sha256ObjectFormat, commit.ID.Type())
+	assert.Equal(t, "initial", commit.Summary())
+
+	entry, err := commit.GetTreeEntryByPath("a.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "e118a058f018dda253bb692320c940091b15e4f19067e12fff110606a111f5da", entry.ID.String())
+	assert.Equal(t, entry.ID, ComputeBlobHash(objectFormat, []byte("Hello, World!")))
+
+	entries, err := commit.Tree.ListEntries()
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "a.txt", entries[0].Name())
+		assert.Equal(t, "dir", entries[1].Name())
+	}
+
+	commitsInfo, _, err := entries.GetCommitsInfo(DefaultContext, commit, "")
+	assert.NoError(t, err)
+	for _, commitInfo := range commitsInfo {
+		assert.Equal(t, commit.ID.String(), commitInfo.Commit.ID.String())
+	}
+
+	blameReader, err := CreateBlameReader(DefaultContext, repoPath, commit, "a.txt", false)
+	assert.NoError(t, err)
+	defer blameReader.Close()
+	blamePart, err := blameReader.NextPart()
+	assert.NoError(t, err)
+	if assert.NotNil(t, blamePart) {
+		assert.Equal(t, commit.ID.String(), blamePart.Sha)
+	}
+
+	lineCommit, err := repo.LineBlame("HEAD", repoPath, "a.txt", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, commit.ID.String(), lineCommit.ID.String())
+}
@@ -4,15 +4,16 @@
 package lfs
 
 import (
-	"code.gitea.io/gitea/modules/structs"
 	"encoding/hex"
 	"errors"
 	"hash"
 	"io"
+	"net/url"
 	"os"
 
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/structs"
 
 	"github.com/minio/sha256-simd"
 )
@@ -107,8 +108,12 @@ func (s *ContentStore) Verify(pointer Pointer) (bool, error) {
 
 // CommitAndVerify returns true if the object exists in the content store and size is correct.
 func (s *ContentStore) CommitAndVerify(pointer Pointer, commitParameter string) (bool, error) {
+	multipart, ok := s.ObjectStorage.(storage.MultipartStorage)
+	if !ok {
+		return false, storage.ErrMultipartNotSupported
+	}
 	p := pointer.RelativePath()
-	err := s.ObjectStorage.CommitUpload(p, commitParameter)
+	err := multipart.CommitUpload(p, commitParameter)
 	if err != nil {
 		log.Error("lfs[multipart] Unable commit file: %s for LFS OID[%s] Error: %v", p, pointer.Oid, err)
 		return false, err
@@ -125,12 +130,57 @@ func (s *ContentStore) CommitAndVerify(pointer Pointer, commitParameter string)
 		log.Error("lfs[multipart] Unable stat file: %s for LFS OID[%s] Error: %v", p, pointer.Oid, err)
 		return false, err
 	}
+	if _, ok := s.ObjectStorage.(storage.MultipartPartReceiver); ok {
+		// the parts have been merged by gitea, so the content can be hashed cheaply
+		if err := s.verifyHash(pointer); err != nil {
+			if errDel := s.Delete(p); errDel != nil {
+				log.Error("lfs[multipart] Cleaning the LFS OID[%s] failed: %v", pointer.Oid, errDel)
+			}
+			return false, err
+		}
+	}
 	return true, nil
 }
 
+// verifyHash reads the stored content and checks it against the pointer
+func (s *ContentStore) verifyHash(pointer Pointer) error {
+	f, err := s.Open(pointer.RelativePath())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rd := newHashingReader(pointer.Size, pointer.Oid, f)
+	if _, err := io.Copy(io.Discard, rd); err != nil {
+		return err
+	}
+	if rd.lastError != nil && !errors.Is(rd.lastError, io.EOF) {
+		return rd.lastError
+	}
+	return nil
+}
+
+// UploadPart passes a part of a multipart upload to storages which receive the parts through gitea
+func (s *ContentStore) UploadPart(pointer Pointer, index int, query url.Values, r io.Reader) (string, error) {
+	receiver, ok := s.ObjectStorage.(storage.MultipartPartReceiver)
+	if !ok {
+		return "", storage.ErrInvalidMultipartPart
+	}
+	return receiver.UploadPart(pointer.RelativePath(), index, query, r)
+}
+
+// SupportsMultipart returns whether the objects can be uploaded in parts to the content store
+func (s *ContentStore) SupportsMultipart() bool {
+	return storage.SupportsMultipart(s.ObjectStorage)
+}
+
+// GenerateMultipartParts returns the endpoints for uploading the object in parts
 func (s *ContentStore) GenerateMultipartParts(pointer Pointer) (parts []*structs.MultipartObjectPart, abort *structs.MultipartEndpoint, verify *structs.MultipartEndpoint, err error) {
-	p := pointer.RelativePath()
-	return s.ObjectStorage.GenerateMultipartParts(p, pointer.Size)
+	multipart, ok := s.ObjectStorage.(storage.MultipartStorage)
+	if !ok {
+		return nil, nil, nil, storage.ErrMultipartNotSupported
+	}
+	return multipart.GenerateMultipartParts(pointer.RelativePath(), pointer.Size)
 }
 
 // ReadMetaObject will read a git_model.LFSMetaObject and return a reader
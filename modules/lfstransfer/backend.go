@@ -0,0 +1,225 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package lfstransfer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/lfs"
+	"code.gitea.io/gitea/modules/private"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// httpBackend passes the requests to the LFS server of the Gitea instance,
+// which checks the permissions of the token and handles the storage
+type httpBackend struct {
+	client        *http.Client
+	baseURL       string
+	authorization string
+}
+
+// NewHTTPBackend returns a backend for the LFS endpoint of a repository, e.g. "http://localhost:3000/owner/repo.git/info/lfs"
+func NewHTTPBackend(baseURL, authorization string) Backend {
+	transport := private.NewLocalTransport()
+	// the object sizes are taken from the responses, so they must not be compressed
+	transport.DisableCompression = true
+	return &httpBackend{
+		client:        &http.Client{Transport: transport},
+		baseURL:       strings.TrimSuffix(baseURL, "/"),
+		authorization: authorization,
+	}
+}
+
+func (b *httpBackend) newRequest(ctx context.Context, method, path string, body any) (*http.Request, error) {
+	var r io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+path, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", b.authorization)
+	req.Header.Set("Accept", lfs.MediaType)
+	if body != nil {
+		req.Header.Set("Content-Type", lfs.MediaType)
+	}
+	return req, nil
+}
+
+// do performs the request and decodes the response into result, error responses are returned as StatusError
+func (b *httpBackend) do(req *http.Request, result any) error {
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeStatusError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func decodeStatusError(resp *http.Response) error {
+	var errResp api.LFSLockError
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil || errResp.Message == "" {
+		errResp.Message = http.StatusText(resp.StatusCode)
+	}
+	return &StatusError{Code: resp.StatusCode, Message: errResp.Message, Lock: errResp.Lock}
+}
+
+func (b *httpBackend) Batch(ctx context.Context, operation, refname string, pointers []lfs.Pointer) ([]*BatchItem, error) {
+	request := &lfs.BatchRequest{
+		Operation: operation,
+		Transfers: []string{"basic"},
+		Objects:   pointers,
+	}
+	if refname != "" {
+		request.Ref = &lfs.Reference{Name: refname}
+	}
+	req, err := b.newRequest(ctx, http.MethodPost, "/objects/batch", request)
+	if err != nil {
+		return nil, err
+	}
+	var response lfs.BatchResponse
+	if err := b.do(req, &response); err != nil {
+		return nil, err
+	}
+
+	items := make([]*BatchItem, 0, len(response.Objects))
+	for _, object := range response.Objects {
+		item := &BatchItem{Pointer: object.Pointer, Action: ActionNoop, Error: object.Error}
+		if _, ok := object.Actions[operation]; ok {
+			item.Action = operation
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (b *httpBackend) Upload(ctx context.Context, p lfs.Pointer, r io.Reader) error {
+	req, err := b.newRequest(ctx, http.MethodPut, fmt.Sprintf("/objects/%s/%d", url.PathEscape(p.Oid), p.Size), nil)
+	if err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(r)
+	req.ContentLength = p.Size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	return b.do(req, nil)
+}
+
+func (b *httpBackend) Verify(ctx context.Context, p lfs.Pointer) error {
+	req, err := b.newRequest(ctx, http.MethodPost, "/verify", &p)
+	if err != nil {
+		return err
+	}
+	return b.do(req, nil)
+}
+
+func (b *httpBackend) Download(ctx context.Context, oid string) (io.ReadCloser, int64, error) {
+	req, err := b.newRequest(ctx, http.MethodGet, "/objects/"+url.PathEscape(oid), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Del("Accept")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		return nil, 0, decodeStatusError(resp)
+	}
+	if resp.ContentLength < 0 {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("unknown size of object %s", oid)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+func (b *httpBackend) Lock(ctx context.Context, path, refname string) (*api.LFSLock, error) {
+	request := &api.LFSLockRequest{Path: path}
+	req, err := b.newRequest(ctx, http.MethodPost, "/locks", request)
+	if err != nil {
+		return nil, err
+	}
+	var response api.LFSLockResponse
+	if err := b.do(req, &response); err != nil {
+		return nil, err
+	}
+	return response.Lock, nil
+}
+
+func (b *httpBackend) Unlock(ctx context.Context, id string, force bool) (*api.LFSLock, error) {
+	request := &api.LFSLockDeleteRequest{Force: force}
+	req, err := b.newRequest(ctx, http.MethodPost, "/locks/"+url.PathEscape(id)+"/unlock", request)
+	if err != nil {
+		return nil, err
+	}
+	var response api.LFSLockResponse
+	if err := b.do(req, &response); err != nil {
+		return nil, err
+	}
+	return response.Lock, nil
+}
+
+func (b *httpBackend) ListLocks(ctx context.Context, id, path, cursor string, limit int) (*api.LFSLockList, error) {
+	query := url.Values{}
+	if id != "" {
+		query.Set("id", id)
+	}
+	if path != "" {
+		query.Set("path", path)
+	}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	req, err := b.newRequest(ctx, http.MethodGet, "/locks?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var response api.LFSLockList
+	if err := b.do(req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (b *httpBackend) VerifyLocks(ctx context.Context, cursor string, limit int) (*api.LFSLockListVerify, error) {
+	query := url.Values{}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	req, err := b.newRequest(ctx, http.MethodPost, "/locks/verify?"+query.Encode(), struct{}{})
+	if err != nil {
+		return nil, err
+	}
+	var response api.LFSLockListVerify
+	if err := b.do(req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
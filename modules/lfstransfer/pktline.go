@@ -0,0 +1,168 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package lfstransfer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxPktPayload is the maximum size of the payload of a pkt-line
+	maxPktPayload = 65516
+
+	flushPkt = "0000"
+	delimPkt = "0001"
+)
+
+var (
+	errFlush = errors.New("flush packet")
+	errDelim = errors.New("delim packet")
+)
+
+// pktReader reads pkt-lines, flush and delim packets are returned as errFlush and errDelim
+type pktReader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+func newPktReader(r io.Reader) *pktReader {
+	return &pktReader{r: bufio.NewReader(r), buf: make([]byte, maxPktPayload)}
+}
+
+// readPacket returns the payload of the next packet, it's only valid until the next read
+func (p *pktReader) readPacket() ([]byte, error) {
+	var lengthHex [4]byte
+	if _, err := io.ReadFull(p.r, lengthHex[:]); err != nil {
+		return nil, err
+	}
+	length, err := strconv.ParseUint(string(lengthHex[:]), 16, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pkt-line length %q", lengthHex)
+	}
+	switch {
+	case length == 0:
+		return nil, errFlush
+	case length == 1:
+		return nil, errDelim
+	case length < 4 || length-4 > maxPktPayload:
+		return nil, fmt.Errorf("invalid pkt-line length %d", length)
+	}
+	payload := p.buf[:length-4]
+	if _, err := io.ReadFull(p.r, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// readLine returns the next packet as text without the trailing newline
+func (p *pktReader) readLine() (string, error) {
+	payload, err := p.readPacket()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(payload), "\n"), nil
+}
+
+// readLines reads text packets until the next flush or delim packet, which is returned as error
+func (p *pktReader) readLines() ([]string, error) {
+	var lines []string
+	for {
+		line, err := p.readLine()
+		if err != nil {
+			return lines, err
+		}
+		lines = append(lines, line)
+	}
+}
+
+// dataReader reads the payload of the packets up to the next flush packet
+type dataReader struct {
+	p    *pktReader
+	rest []byte
+	done bool
+}
+
+func (d *dataReader) Read(b []byte) (int, error) {
+	for len(d.rest) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		payload, err := d.p.readPacket()
+		if err == errFlush {
+			d.done = true
+			return 0, io.EOF
+		} else if err == errDelim {
+			return 0, errors.New("unexpected delim packet in data")
+		} else if err != nil {
+			return 0, err
+		}
+		d.rest = payload
+	}
+	n := copy(b, d.rest)
+	d.rest = d.rest[n:]
+	return n, nil
+}
+
+// pktWriter writes pkt-lines
+type pktWriter struct {
+	w *bufio.Writer
+}
+
+func newPktWriter(w io.Writer) *pktWriter {
+	return &pktWriter{w: bufio.NewWriter(w)}
+}
+
+func (p *pktWriter) writePacket(payload []byte) error {
+	if _, err := fmt.Fprintf(p.w, "%04x", len(payload)+4); err != nil {
+		return err
+	}
+	_, err := p.w.Write(payload)
+	return err
+}
+
+// writeLines writes every line as text packet
+func (p *pktWriter) writeLines(lines ...string) error {
+	for _, line := range lines {
+		if err := p.writePacket([]byte(line + "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeData writes the content split into packets of the maximum size
+func (p *pktWriter) writeData(r io.Reader) error {
+	buf := make([]byte, maxPktPayload)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			if err := p.writePacket(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *pktWriter) writeDelim() error {
+	_, err := p.w.WriteString(delimPkt)
+	return err
+}
+
+// writeFlush writes a flush packet and sends all buffered packets
+func (p *pktWriter) writeFlush() error {
+	if _, err := p.w.WriteString(flushPkt); err != nil {
+		return err
+	}
+	return p.w.Flush()
+}
@@ -0,0 +1,493 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+// Package lfstransfer implements the server side of the git-lfs-transfer protocol,
+// which transfers LFS objects over the SSH connection instead of HTTP.
+// https://github.com/git-lfs/git-lfs/blob/main/docs/proposals/ssh_adapter.md
+package lfstransfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/lfs"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// Operations requested by the client when starting git-lfs-transfer
+const (
+	OperationUpload   = "upload"
+	OperationDownload = "download"
+)
+
+// Actions of the objects in a batch response
+const (
+	ActionUpload   = "upload"
+	ActionDownload = "download"
+	ActionNoop     = "noop"
+)
+
+// BatchItem is an object of a batch response
+type BatchItem struct {
+	lfs.Pointer
+	Action string
+	// Error is set if the object can't be transferred
+	Error *lfs.ObjectError
+}
+
+// Backend stores and retrieves the objects and locks of a repository
+type Backend interface {
+	Batch(ctx context.Context, operation, refname string, pointers []lfs.Pointer) ([]*BatchItem, error)
+	Upload(ctx context.Context, p lfs.Pointer, r io.Reader) error
+	Verify(ctx context.Context, p lfs.Pointer) error
+	Download(ctx context.Context, oid string) (io.ReadCloser, int64, error)
+
+	Lock(ctx context.Context, path, refname string) (*api.LFSLock, error)
+	Unlock(ctx context.Context, id string, force bool) (*api.LFSLock, error)
+	ListLocks(ctx context.Context, id, path, cursor string, limit int) (*api.LFSLockList, error)
+	VerifyLocks(ctx context.Context, cursor string, limit int) (*api.LFSLockListVerify, error)
+}
+
+// StatusError is an error which is reported to the client with the status code
+type StatusError struct {
+	Code    int
+	Message string
+	// Lock is the conflicting lock of a lock request
+	Lock *api.LFSLock
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.Code, e.Message)
+}
+
+func newStatusError(code int, format string, args ...any) *StatusError {
+	return &StatusError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+type processor struct {
+	ctx       context.Context
+	backend   Backend
+	operation string
+	r         *pktReader
+	w         *pktWriter
+
+	// uploads holds the objects which may be uploaded, only objects accepted by a batch request may be put
+	uploads map[string]int64
+}
+
+// Run serves the git-lfs-transfer protocol on the connection until the client quits
+func Run(ctx context.Context, backend Backend, operation string, r io.Reader, w io.Writer) error {
+	if operation != OperationUpload && operation != OperationDownload {
+		return fmt.Errorf("unknown operation %q", operation)
+	}
+
+	p := &processor{
+		ctx:       ctx,
+		backend:   backend,
+		operation: operation,
+		r:         newPktReader(r),
+		w:         newPktWriter(w),
+		uploads:   make(map[string]int64),
+	}
+
+	// advertise the capabilities
+	if err := p.w.writeLines("version=1"); err != nil {
+		return err
+	}
+	if err := p.w.writeFlush(); err != nil {
+		return err
+	}
+
+	for {
+		command, err := p.r.readLine()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		verb, arg, _ := strings.Cut(command, " ")
+		log.Trace("lfs-transfer command %s %s", verb, arg)
+		switch verb {
+		case "version":
+			err = p.version(arg)
+		case "batch":
+			err = p.batch()
+		case "put-object":
+			err = p.putObject(arg)
+		case "verify-object":
+			err = p.verifyObject(arg)
+		case "get-object":
+			err = p.getObject(arg)
+		case "lock":
+			err = p.lock()
+		case "list-lock":
+			err = p.listLock()
+		case "unlock":
+			err = p.unlock(arg)
+		case "quit":
+			if _, err := p.readArgs(); err != nil {
+				return err
+			}
+			return p.writeStatus(http.StatusOK)
+		default:
+			if _, err := p.readArgs(); err != nil {
+				return err
+			}
+			err = newStatusError(http.StatusBadRequest, "unknown command %q", verb)
+		}
+
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) {
+			err = p.writeError(statusErr)
+		} else if err != nil {
+			log.Error("lfs-transfer command %s failed: %v", verb, err)
+			err = p.writeError(newStatusError(http.StatusInternalServerError, "internal server error"))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readArgs reads the key=value arguments of a command up to the flush packet.
+// Commands which have content after the arguments are read by readArgsUntilDelim.
+func (p *processor) readArgs() (map[string]string, error) {
+	lines, err := p.r.readLines()
+	if err == errDelim {
+		// skip the unexpected content of the command
+		if _, err = p.r.readLines(); err == errDelim {
+			err = errors.New("unexpected delim packet")
+		}
+	}
+	if err != errFlush {
+		return nil, err
+	}
+	return parseArgs(lines), nil
+}
+
+// readArgsUntilDelim reads the key=value arguments of a command which are followed by a delim packet,
+// ok is false if the arguments end with the flush packet instead
+func (p *processor) readArgsUntilDelim() (args map[string]string, ok bool, err error) {
+	lines, err := p.r.readLines()
+	if err == errDelim {
+		return parseArgs(lines), true, nil
+	} else if err == errFlush {
+		return parseArgs(lines), false, nil
+	}
+	return nil, false, err
+}
+
+func parseArgs(lines []string) map[string]string {
+	args := make(map[string]string, len(lines))
+	for _, line := range lines {
+		key, value, _ := strings.Cut(line, "=")
+		args[key] = value
+	}
+	return args
+}
+
+func (p *processor) writeStatus(code int, args ...string) error {
+	if err := p.w.writeLines("status " + strconv.Itoa(code)); err != nil {
+		return err
+	}
+	if err := p.w.writeLines(args...); err != nil {
+		return err
+	}
+	return p.w.writeFlush()
+}
+
+func (p *processor) writeError(statusErr *StatusError) error {
+	if err := p.w.writeLines("status " + strconv.Itoa(statusErr.Code)); err != nil {
+		return err
+	}
+	if statusErr.Lock != nil {
+		if err := p.w.writeLines(lockArgs(statusErr.Lock)...); err != nil {
+			return err
+		}
+	}
+	if err := p.w.writeDelim(); err != nil {
+		return err
+	}
+	if err := p.w.writeLines(statusErr.Message); err != nil {
+		return err
+	}
+	return p.w.writeFlush()
+}
+
+func (p *processor) version(arg string) error {
+	if _, err := p.readArgs(); err != nil {
+		return err
+	}
+	if arg != "1" {
+		return newStatusError(http.StatusBadRequest, "unsupported version %q", arg)
+	}
+	return p.writeStatus(http.StatusOK)
+}
+
+func (p *processor) batch() error {
+	args, hasObjects, err := p.readArgsUntilDelim()
+	if err != nil {
+		return err
+	}
+	var lines []string
+	if hasObjects {
+		if lines, err = p.r.readLines(); err != errFlush {
+			if err == errDelim {
+				err = errors.New("unexpected delim packet")
+			}
+			return err
+		}
+	}
+
+	if algo, ok := args["hash-algo"]; ok && algo != "sha256" {
+		return newStatusError(http.StatusConflict, "unsupported hash algorithm %q", algo)
+	}
+	if transfer, ok := args["transfer"]; ok && transfer != "basic" {
+		return newStatusError(http.StatusConflict, "unsupported transfer %q", transfer)
+	}
+
+	pointers := make([]lfs.Pointer, 0, len(lines))
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return newStatusError(http.StatusBadRequest, "invalid object %q", line)
+		}
+		pointer := lfs.Pointer{Oid: fields[0]}
+		if pointer.Size, err = strconv.ParseInt(fields[1], 10, 64); err != nil || !pointer.IsValid() {
+			return newStatusError(http.StatusBadRequest, "invalid object %q", line)
+		}
+		pointers = append(pointers, pointer)
+	}
+
+	items, err := p.backend.Batch(p.ctx, p.operation, args["refname"], pointers)
+	if err != nil {
+		return err
+	}
+
+	response := make([]string, 0, len(items))
+	for _, item := range items {
+		action := item.Action
+		if item.Error != nil {
+			// objects which can't be uploaded fail the whole batch, otherwise the push would succeed without them
+			if p.operation == OperationUpload {
+				return newStatusError(item.Error.Code, "%s: %s", item.Oid, item.Error.Message)
+			}
+			action = ActionNoop
+		}
+		if action == ActionUpload {
+			p.uploads[item.Oid] = item.Size
+		}
+		response = append(response, fmt.Sprintf("%s %d %s", item.Oid, item.Size, action))
+	}
+
+	if err := p.w.writeLines("status " + strconv.Itoa(http.StatusOK)); err != nil {
+		return err
+	}
+	if err := p.w.writeDelim(); err != nil {
+		return err
+	}
+	if err := p.w.writeLines(response...); err != nil {
+		return err
+	}
+	return p.w.writeFlush()
+}
+
+func (p *processor) putObject(oid string) error {
+	args, hasData, err := p.readArgsUntilDelim()
+	if err != nil {
+		return err
+	}
+	data := &dataReader{p: p.r, done: !hasData}
+	// the content has to be consumed even if it isn't stored
+	defer func() {
+		_, _ = io.Copy(io.Discard, data)
+	}()
+
+	if p.operation != OperationUpload {
+		return newStatusError(http.StatusForbidden, "objects can't be uploaded in a download session")
+	}
+	size, err := strconv.ParseInt(args["size"], 10, 64)
+	if err != nil {
+		return newStatusError(http.StatusBadRequest, "invalid size %q", args["size"])
+	}
+	if expected, ok := p.uploads[oid]; !ok || expected != size {
+		return newStatusError(http.StatusBadRequest, "object %s %d wasn't requested by a batch", oid, size)
+	}
+
+	if err := p.backend.Upload(p.ctx, lfs.Pointer{Oid: oid, Size: size}, data); err != nil {
+		return err
+	}
+	delete(p.uploads, oid)
+	return p.writeStatus(http.StatusOK)
+}
+
+func (p *processor) verifyObject(oid string) error {
+	args, err := p.readArgs()
+	if err != nil {
+		return err
+	}
+	size, err := strconv.ParseInt(args["size"], 10, 64)
+	if err != nil {
+		return newStatusError(http.StatusBadRequest, "invalid size %q", args["size"])
+	}
+	if err := p.backend.Verify(p.ctx, lfs.Pointer{Oid: oid, Size: size}); err != nil {
+		return err
+	}
+	return p.writeStatus(http.StatusOK)
+}
+
+func (p *processor) getObject(oid string) error {
+	if _, err := p.readArgs(); err != nil {
+		return err
+	}
+	if !(lfs.Pointer{Oid: oid}).IsValid() {
+		return newStatusError(http.StatusBadRequest, "invalid object %q", oid)
+	}
+
+	content, size, err := p.backend.Download(p.ctx, oid)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	if err := p.w.writeLines("status "+strconv.Itoa(http.StatusOK), "size="+strconv.FormatInt(size, 10)); err != nil {
+		return err
+	}
+	if err := p.w.writeDelim(); err != nil {
+		return err
+	}
+	// the status has been sent already, so errors can only be reported by closing the connection
+	if err := p.w.writeData(io.LimitReader(content, size)); err != nil {
+		return err
+	}
+	return p.w.writeFlush()
+}
+
+func (p *processor) lock() error {
+	args, err := p.readArgs()
+	if err != nil {
+		return err
+	}
+	if p.operation != OperationUpload {
+		return newStatusError(http.StatusForbidden, "locks can't be created in a download session")
+	}
+	if args["path"] == "" {
+		return newStatusError(http.StatusBadRequest, "missing path")
+	}
+
+	lock, err := p.backend.Lock(p.ctx, args["path"], args["refname"])
+	if err != nil {
+		return err
+	}
+	return p.writeStatus(http.StatusCreated, lockArgs(lock)...)
+}
+
+func (p *processor) unlock(id string) error {
+	args, err := p.readArgs()
+	if err != nil {
+		return err
+	}
+	if p.operation != OperationUpload {
+		return newStatusError(http.StatusForbidden, "locks can't be removed in a download session")
+	}
+
+	lock, err := p.backend.Unlock(p.ctx, id, args["force"] == "true")
+	if err != nil {
+		return err
+	}
+	return p.writeStatus(http.StatusOK, lockArgs(lock)...)
+}
+
+func (p *processor) listLock() error {
+	args, err := p.readArgs()
+	if err != nil {
+		return err
+	}
+	limit := 0
+	if args["limit"] != "" {
+		if limit, err = strconv.Atoi(args["limit"]); err != nil || limit < 0 {
+			return newStatusError(http.StatusBadRequest, "invalid limit %q", args["limit"])
+		}
+	}
+
+	var lines []string
+	var next string
+	if p.operation == OperationUpload && args["id"] == "" && args["path"] == "" {
+		// the client verifies the locks before pushing, so tell it which are its own
+		list, err := p.backend.VerifyLocks(p.ctx, args["cursor"], limit)
+		if err != nil {
+			return err
+		}
+		for _, lock := range list.Ours {
+			lines = append(lines, lockLines(lock, "ours")...)
+		}
+		for _, lock := range list.Theirs {
+			lines = append(lines, lockLines(lock, "theirs")...)
+		}
+		next = list.Next
+	} else {
+		list, err := p.backend.ListLocks(p.ctx, args["id"], args["path"], args["cursor"], limit)
+		if err != nil {
+			return err
+		}
+		for _, lock := range list.Locks {
+			lines = append(lines, lockLines(lock, "")...)
+		}
+		next = list.Next
+	}
+
+	if err := p.w.writeLines("status " + strconv.Itoa(http.StatusOK)); err != nil {
+		return err
+	}
+	if next != "" {
+		if err := p.w.writeLines("next-cursor=" + next); err != nil {
+			return err
+		}
+	}
+	if err := p.w.writeDelim(); err != nil {
+		return err
+	}
+	if err := p.w.writeLines(lines...); err != nil {
+		return err
+	}
+	return p.w.writeFlush()
+}
+
+func lockOwnerName(lock *api.LFSLock) string {
+	if lock.Owner == nil {
+		return ""
+	}
+	return lock.Owner.Name
+}
+
+// lockArgs returns the arguments describing a single lock
+func lockArgs(lock *api.LFSLock) []string {
+	return []string{
+		"id=" + lock.ID,
+		"path=" + lock.Path,
+		"locked-at=" + lock.LockedAt.UTC().Format(time.RFC3339),
+		"ownername=" + lockOwnerName(lock),
+	}
+}
+
+// lockLines returns the lines describing a lock in a list, owner is "ours" or "theirs" when verifying locks
+func lockLines(lock *api.LFSLock, owner string) []string {
+	lines := []string{
+		"lock " + lock.ID,
+		"path " + lock.ID + " " + lock.Path,
+		"locked-at " + lock.ID + " " + lock.LockedAt.UTC().Format(time.RFC3339),
+		"ownername " + lock.ID + " " + lockOwnerName(lock),
+	}
+	if owner != "" {
+		lines = append(lines, "owner "+lock.ID+" "+owner)
+	}
+	return lines
+}
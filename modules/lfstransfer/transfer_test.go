@@ -0,0 +1,228 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package lfstransfer
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/lfs"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testBackend struct {
+	objects map[string][]byte
+	locks   []*api.LFSLock
+}
+
+func (b *testBackend) Batch(_ context.Context, operation, _ string, pointers []lfs.Pointer) ([]*BatchItem, error) {
+	items := make([]*BatchItem, 0, len(pointers))
+	for _, p := range pointers {
+		item := &BatchItem{Pointer: p, Action: ActionNoop}
+		_, exists := b.objects[p.Oid]
+		switch {
+		case operation == OperationDownload && !exists:
+			item.Error = &lfs.ObjectError{Code: http.StatusNotFound, Message: "Not found"}
+		case operation == OperationDownload:
+			item.Action = ActionDownload
+		case p.Size > 100:
+			item.Error = &lfs.ObjectError{Code: http.StatusUnprocessableEntity, Message: "Size must be less than or equal to 100"}
+		case !exists:
+			item.Action = ActionUpload
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (b *testBackend) Upload(_ context.Context, p lfs.Pointer, r io.Reader) error {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	b.objects[p.Oid] = content
+	return nil
+}
+
+func (b *testBackend) Verify(_ context.Context, p lfs.Pointer) error {
+	if content, ok := b.objects[p.Oid]; !ok || int64(len(content)) != p.Size {
+		return &StatusError{Code: http.StatusNotFound, Message: "Not found"}
+	}
+	return nil
+}
+
+func (b *testBackend) Download(_ context.Context, oid string) (io.ReadCloser, int64, error) {
+	content, ok := b.objects[oid]
+	if !ok {
+		return nil, 0, &StatusError{Code: http.StatusNotFound, Message: "Not found"}
+	}
+	return io.NopCloser(bytes.NewReader(content)), int64(len(content)), nil
+}
+
+func (b *testBackend) Lock(_ context.Context, path, _ string) (*api.LFSLock, error) {
+	for _, lock := range b.locks {
+		if lock.Path == path {
+			return nil, &StatusError{Code: http.StatusConflict, Message: "already created lock", Lock: lock}
+		}
+	}
+	lock := &api.LFSLock{ID: "2", Path: path, LockedAt: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), Owner: &api.LFSLockOwner{Name: "user2"}}
+	b.locks = append(b.locks, lock)
+	return lock, nil
+}
+
+func (b *testBackend) Unlock(_ context.Context, id string, _ bool) (*api.LFSLock, error) {
+	for i, lock := range b.locks {
+		if lock.ID == id {
+			b.locks = append(b.locks[:i], b.locks[i+1:]...)
+			return lock, nil
+		}
+	}
+	return nil, &StatusError{Code: http.StatusNotFound, Message: "Not found"}
+}
+
+func (b *testBackend) ListLocks(context.Context, string, string, string, int) (*api.LFSLockList, error) {
+	return &api.LFSLockList{Locks: b.locks}, nil
+}
+
+func (b *testBackend) VerifyLocks(context.Context, string, int) (*api.LFSLockListVerify, error) {
+	return &api.LFSLockListVerify{Ours: b.locks}, nil
+}
+
+// data is the binary content of a packet, which isn't terminated by a newline
+type data string
+
+func pkt(lines ...any) string {
+	var buf bytes.Buffer
+	w := newPktWriter(&buf)
+	for _, line := range lines {
+		switch line := line.(type) {
+		case data:
+			_ = w.writePacket([]byte(line))
+		case string:
+			switch line {
+			case flushPkt:
+				_ = w.writeFlush()
+			case delimPkt:
+				_ = w.writeDelim()
+			default:
+				_ = w.writeLines(line)
+			}
+		}
+	}
+	_ = w.w.Flush()
+	return buf.String()
+}
+
+func runTransfer(t *testing.T, backend Backend, operation string, request ...any) []string {
+	var out bytes.Buffer
+	assert.NoError(t, Run(context.Background(), backend, operation, strings.NewReader(pkt(request...)), &out))
+
+	var lines []string
+	r := newPktReader(&out)
+	for {
+		line, err := r.readLine()
+		switch err {
+		case nil:
+			lines = append(lines, line)
+		case errFlush:
+			lines = append(lines, flushPkt)
+		case errDelim:
+			lines = append(lines, delimPkt)
+		case io.EOF:
+			return lines
+		default:
+			assert.NoError(t, err)
+			return lines
+		}
+	}
+}
+
+const (
+	testOid     = "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+	testContent = "foo"
+	missingOid  = "fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9"
+)
+
+func TestRunDownload(t *testing.T) {
+	backend := &testBackend{objects: map[string][]byte{testOid: []byte(testContent)}}
+
+	lines := runTransfer(t, backend, OperationDownload,
+		"version 1", flushPkt,
+		"batch", "hash-algo=sha256", delimPkt, testOid+" 3", missingOid+" 5", flushPkt,
+		"get-object "+testOid, flushPkt,
+		"quit", flushPkt,
+	)
+	assert.Equal(t, []string{
+		"version=1", flushPkt,
+		"status 200", flushPkt,
+		"status 200", delimPkt, testOid + " 3 download", missingOid + " 5 noop", flushPkt,
+		"status 200", "size=3", delimPkt, testContent, flushPkt,
+		"status 200", flushPkt,
+	}, lines)
+
+	lines = runTransfer(t, backend, OperationDownload,
+		"batch", "hash-algo=sha1", delimPkt, testOid+" 3", flushPkt,
+		"put-object "+testOid, "size=3", delimPkt, data(testContent), flushPkt,
+		"lock", "path=README.md", flushPkt,
+	)
+	assert.Equal(t, []string{
+		"version=1", flushPkt,
+		"status 409", delimPkt, `unsupported hash algorithm "sha1"`, flushPkt,
+		"status 403", delimPkt, "objects can't be uploaded in a download session", flushPkt,
+		"status 403", delimPkt, "locks can't be created in a download session", flushPkt,
+	}, lines)
+}
+
+func TestRunUpload(t *testing.T) {
+	backend := &testBackend{objects: map[string][]byte{}}
+
+	lines := runTransfer(t, backend, OperationUpload,
+		"put-object "+testOid, "size=3", delimPkt, data(testContent), flushPkt,
+		"batch", delimPkt, testOid+" 3", flushPkt,
+		"put-object "+testOid, "size=3", delimPkt, data(testContent), flushPkt,
+		"verify-object "+testOid, "size=3", flushPkt,
+		"batch", delimPkt, testOid+" 3", missingOid+" 500", flushPkt,
+	)
+	assert.Equal(t, []string{
+		"version=1", flushPkt,
+		"status 400", delimPkt, "object " + testOid + " 3 wasn't requested by a batch", flushPkt,
+		"status 200", delimPkt, testOid + " 3 upload", flushPkt,
+		"status 200", flushPkt,
+		"status 200", flushPkt,
+		"status 422", delimPkt, missingOid + ": Size must be less than or equal to 100", flushPkt,
+	}, lines)
+	assert.Equal(t, testContent, string(backend.objects[testOid]))
+}
+
+func TestRunLocks(t *testing.T) {
+	backend := &testBackend{
+		locks: []*api.LFSLock{
+			{ID: "1", Path: "a.bin", LockedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Owner: &api.LFSLockOwner{Name: "user2"}},
+		},
+	}
+
+	lines := runTransfer(t, backend, OperationUpload,
+		"lock", "path=a.bin", flushPkt,
+		"lock", "path=b.bin", flushPkt,
+		"list-lock", "limit=10", flushPkt,
+		"unlock 2", "force=true", flushPkt,
+	)
+	assert.Equal(t, []string{
+		"version=1", flushPkt,
+		"status 409", "id=1", "path=a.bin", "locked-at=2023-01-01T00:00:00Z", "ownername=user2", delimPkt, "already created lock", flushPkt,
+		"status 201", "id=2", "path=b.bin", "locked-at=2023-01-02T03:04:05Z", "ownername=user2", flushPkt,
+		"status 200", delimPkt,
+		"lock 1", "path 1 a.bin", "locked-at 1 2023-01-01T00:00:00Z", "ownername 1 user2", "owner 1 ours",
+		"lock 2", "path 2 b.bin", "locked-at 2 2023-01-02T03:04:05Z", "ownername 2 user2", "owner 2 ours",
+		flushPkt,
+		"status 200", "id=2", "path=b.bin", "locked-at=2023-01-02T03:04:05Z", "ownername=user2", flushPkt,
+	}, lines)
+}
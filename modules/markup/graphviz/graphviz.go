@@ -0,0 +1,109 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package graphviz
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// MarkupName describes markup's name
+var MarkupName = "graphviz"
+
+// maxInputSize limits the size of the graphs which are laid out
+const maxInputSize = 256 * 1024
+
+func init() {
+	markup.RegisterRenderer(Renderer{})
+}
+
+// Renderer implements markup.Renderer for Graphviz DOT files
+type Renderer struct{}
+
+// Name implements markup.Renderer
+func (Renderer) Name() string {
+	return MarkupName
+}
+
+// Extensions implements markup.Renderer
+func (Renderer) Extensions() []string {
+	return []string{".dot", ".gv"}
+}
+
+// SanitizerRules implements markup.Renderer
+func (Renderer) SanitizerRules() []setting.MarkupSanitizerRule {
+	return SanitizerRules()
+}
+
+// Render renders a DOT graph to an SVG image
+func (Renderer) Render(ctx *markup.RenderContext, input io.Reader, output io.Writer) error {
+	buf, err := io.ReadAll(io.LimitReader(input, maxInputSize+1))
+	if err != nil {
+		return err
+	}
+	svg, err := RenderSVG(buf)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(output, svg)
+	return err
+}
+
+// RenderSVG lays out a graph in the DOT language and returns it as an SVG image wrapped in a div of the class "graphviz"
+func RenderSVG(source []byte) (string, error) {
+	if len(source) > maxInputSize {
+		return "", fmt.Errorf("graph is larger than %d bytes", maxInputSize)
+	}
+	g, err := Parse(string(source))
+	if err != nil {
+		return "", err
+	}
+	l, err := newLayout(g)
+	if err != nil {
+		return "", err
+	}
+	return `<div class="graphviz">` + writeSVG(l) + `</div>`, nil
+}
+
+var (
+	numberRegexp    = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+	pointsRegexp    = regexp.MustCompile(`^[\d\s.,-]+$`)
+	dashArrayRegexp = regexp.MustCompile(`^\d+(,\d+)*$`)
+)
+
+// SanitizerRules returns the rules which are needed to keep the SVG images of the graphs
+// The attributes of the svg element and of paths are allowed by the default policy already.
+func SanitizerRules() []setting.MarkupSanitizerRule {
+	rules := []setting.MarkupSanitizerRule{
+		{Element: "div", AllowAttr: "class", Regexp: regexp.MustCompile(`^graphviz$`)},
+		{Element: "g", AllowAttr: "class", Regexp: regexp.MustCompile(`^(graph|node|edge)$`)},
+		{Element: "polygon", AllowAttr: "points", Regexp: pointsRegexp},
+		{Element: "text", AllowAttr: "text-anchor", Regexp: regexp.MustCompile(`^middle$`)},
+		{Element: "text", AllowAttr: "font-family", Regexp: regexp.MustCompile(`^sans-serif$`)},
+		{Element: "text", AllowAttr: "fill", Regexp: colorRegexp},
+	}
+	numbers := map[string][]string{
+		"ellipse": {"cx", "cy", "rx", "ry"},
+		"rect":    {"x", "y", "width", "height", "rx"},
+		"text":    {"x", "y", "font-size"},
+	}
+	for _, element := range []string{"ellipse", "rect", "text"} {
+		for _, attr := range numbers[element] {
+			rules = append(rules, setting.MarkupSanitizerRule{Element: element, AllowAttr: attr, Regexp: numberRegexp})
+		}
+	}
+	for _, element := range []string{"ellipse", "rect", "polygon", "path"} {
+		rules = append(rules,
+			setting.MarkupSanitizerRule{Element: element, AllowAttr: "fill", Regexp: colorRegexp},
+			setting.MarkupSanitizerRule{Element: element, AllowAttr: "stroke", Regexp: colorRegexp},
+			setting.MarkupSanitizerRule{Element: element, AllowAttr: "stroke-width", Regexp: numberRegexp},
+			setting.MarkupSanitizerRule{Element: element, AllowAttr: "stroke-dasharray", Regexp: dashArrayRegexp},
+		)
+	}
+	return rules
+}
@@ -0,0 +1,164 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package graphviz
+
+import (
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/markup"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	g, err := Parse(`
+# preprocessor output
+strict digraph "G" {
+	// comment
+	rankdir = LR; label = "Title"
+	node [shape=box]
+	a -> b -> {c; d} [label="x"]
+	a -> b /* duplicated */
+	subgraph cluster_0 { node [color=red]; e:port:n; }
+	"quoted \"id\"" [label=<first<br/>second &amp; more>]
+	f -- g
+}`)
+	assert.Nil(t, g)
+	assert.ErrorContains(t, err, `line 11: edge operator "--" can't be used in this graph`)
+
+	g, err = Parse(`
+strict digraph "G" {
+	rankdir = LR; label = "Title"
+	node [shape=box]
+	a -> b -> {c; d} [label="x"]
+	a -> b
+	subgraph cluster_0 { node [color=red]; e:port:n; }
+	"quoted \"id\"" [label=<first<br/>second &amp; more>]
+}`)
+	assert.NoError(t, err)
+	assert.True(t, g.Directed)
+	assert.True(t, g.Strict)
+	assert.Equal(t, Attrs{"rankdir": "LR", "label": "Title"}, g.Attrs)
+
+	ids := make([]string, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		ids = append(ids, n.ID)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d", "e", `quoted "id"`}, ids)
+	assert.Equal(t, Attrs{"shape": "box", "color": "red"}, g.Nodes[4].Attrs)
+	assert.Equal(t, []string{"first", "second & more"}, labelLines(g.Nodes[5].Attrs["label"], g.Nodes[5].ID))
+
+	edges := make([]string, 0, len(g.Edges))
+	for _, e := range g.Edges {
+		edges = append(edges, e.From.ID+"->"+e.To.ID+":"+e.Attrs["label"])
+	}
+	assert.Equal(t, []string{"a->b:x", "b->c:x", "b->d:x"}, edges)
+
+	for _, input := range []string{
+		``,
+		`graph {`,
+		`graph { a [label="unterminated] }`,
+		`digraph { a -> }`,
+		`foo { }`,
+	} {
+		_, err := Parse(input)
+		assert.Error(t, err, input)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("digraph {")
+	for i := 0; i <= maxEdges; i++ {
+		sb.WriteString("a -> b;")
+	}
+	sb.WriteString("}")
+	_, err = Parse(sb.String())
+	assert.ErrorAs(t, err, &ErrGraphTooLarge{})
+}
+
+func TestLabelLines(t *testing.T) {
+	assert.Equal(t, []string{"node"}, labelLines(`\N`, "node"))
+	assert.Equal(t, []string{"left", "right"}, labelLines(`left\lright\l`, ""))
+	assert.Equal(t, []string{`back\slash`}, labelLines(`back\\slash`, ""))
+	assert.Nil(t, labelLines("", "node"))
+}
+
+func TestLayout(t *testing.T) {
+	for _, rankDir := range []string{"TB", "LR", "BT", "RL"} {
+		g, err := Parse(`digraph {
+			rankdir=` + rankDir + `
+			a -> b -> c -> a
+			a -> d -> c [label="long label"]
+			a -> c
+			b -> b
+			e
+		}`)
+		assert.NoError(t, err)
+		l, err := newLayout(g)
+		assert.NoError(t, err)
+
+		// ranks follow the edges except for the edge breaking the cycle, labelled edges span two ranks
+		a, b, c, d := l.nodes[g.Nodes[0]], l.nodes[g.Nodes[1]], l.nodes[g.Nodes[2]], l.nodes[g.Nodes[3]]
+		assert.Equal(t, []int{0, 1, 4, 2}, []int{a.rank, b.rank, c.rank, d.rank}, rankDir)
+		assert.Len(t, l.loops, 1)
+
+		// all nodes are in the image and don't overlap
+		for _, n := range g.Nodes {
+			ln := l.nodes[n]
+			assert.GreaterOrEqual(t, ln.x-ln.style.width/2, 0.0, rankDir)
+			assert.GreaterOrEqual(t, ln.y-ln.style.height/2, 0.0, rankDir)
+			assert.LessOrEqual(t, ln.x+ln.style.width/2, l.width, rankDir)
+			assert.LessOrEqual(t, ln.y+ln.style.height/2, l.height, rankDir)
+			for _, other := range g.Nodes {
+				lo := l.nodes[other]
+				if ln != lo {
+					overlapX := ln.x-ln.style.width/2 < lo.x+lo.style.width/2 && lo.x-lo.style.width/2 < ln.x+ln.style.width/2
+					overlapY := ln.y-ln.style.height/2 < lo.y+lo.style.height/2 && lo.y-lo.style.height/2 < ln.y+ln.style.height/2
+					assert.False(t, overlapX && overlapY, "%s: %s and %s overlap", rankDir, n.ID, other.ID)
+				}
+			}
+		}
+
+		// the edges start at their tail and end at their head
+		for _, le := range l.edges {
+			from, to := l.nodes[le.edge.From], l.nodes[le.edge.To]
+			assert.Equal(t, [2]float64{from.x, from.y}, le.points[0])
+			assert.Equal(t, [2]float64{to.x, to.y}, le.points[len(le.points)-1])
+		}
+	}
+}
+
+func TestCrossings(t *testing.T) {
+	g, err := Parse(`digraph { a -> d; b -> c; a -> c; b -> d; e -> f; e -> g; }`)
+	assert.NoError(t, err)
+	l, err := newLayout(g)
+	assert.NoError(t, err)
+	// the crossing of the edges between a, b and c, d can't be avoided
+	assert.Equal(t, 1, l.crossings())
+}
+
+func TestRender(t *testing.T) {
+	var buf strings.Builder
+	err := markup.Render(&markup.RenderContext{
+		Ctx:          git.DefaultContext,
+		RelativePath: "test.dot",
+	}, strings.NewReader(`digraph {
+		a [shape=box, style=filled, fillcolor="#ffcc00", label="<script>"]
+		a -> b [color="red" onclick="alert(1)"]
+	}`), &buf)
+	assert.NoError(t, err)
+
+	res := buf.String()
+	assert.True(t, strings.HasPrefix(res, `<div class="graphviz"><svg width="`), res)
+	assert.Contains(t, res, `<g class="node"><rect x="`)
+	assert.Contains(t, res, `fill="#ffcc00" stroke="currentColor"/><text`)
+	assert.Contains(t, res, `>&lt;script&gt;</text>`)
+	assert.Contains(t, res, `<g class="edge"><path d="M`)
+	assert.Contains(t, res, `fill="none" stroke="red"/><polygon points="`)
+	assert.NotContains(t, res, "onclick")
+
+	_, err = RenderSVG([]byte(`digraph { a -> }`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,538 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package graphviz
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultNodeSep = 0.25 * pointsPerInch
+	defaultRankSep = 0.5 * pointsPerInch
+	margin         = 8.0
+	loopSize       = 24.0
+
+	// maxVirtualNodes limits the number of virtual nodes inserted for edges spanning several ranks
+	maxVirtualNodes    = 5000
+	orderIterations    = 24
+	positionIterations = 8
+)
+
+// layoutNode is a node placed in a rank. Edges spanning several ranks get virtual nodes without a graph node.
+type layoutNode struct {
+	node  *Node
+	style *nodeStyle
+
+	rank  int
+	order int
+	// x and y are the center of the node, width and height are its size in the direction of the ranks
+	x, y          float64
+	width, height float64
+	// extraRight is the space needed right of the node for self loops
+	extraRight float64
+
+	ups   []*layoutNode
+	downs []*layoutNode
+	key   float64
+}
+
+type layoutEdge struct {
+	edge  *Edge
+	style *edgeStyle
+	// chain are the nodes the edge passes from the lower to the higher rank
+	chain    []*layoutNode
+	reversed bool
+	// label is the virtual node reserving the space of the label
+	label *layoutNode
+	// points are the points of the edge from its tail to its head after the layout
+	points [][2]float64
+	labelX float64
+	labelY float64
+}
+
+type layout struct {
+	graph    *Graph
+	nodes    map[*Node]*layoutNode
+	ranks    [][]*layoutNode
+	edges    []*layoutEdge
+	loops    []*layoutEdge
+	rankDir  string
+	nodeSep  float64
+	rankSep  float64
+	width    float64
+	height   float64
+	titleY   float64
+	title    []string
+	fontSize float64
+}
+
+func (l *layout) horizontal() bool {
+	return l.rankDir == "LR" || l.rankDir == "RL"
+}
+
+// newLayout places the nodes of the graph in ranks, so that edges point to higher ranks, and positions them
+func newLayout(g *Graph) (*layout, error) {
+	// the rank separation may be followed by "equally", which isn't supported
+	rankSep, _, _ := strings.Cut(g.Attrs["ranksep"], " ")
+	l := &layout{
+		graph:    g,
+		nodes:    make(map[*Node]*layoutNode, len(g.Nodes)),
+		rankDir:  strings.ToUpper(g.Attrs["rankdir"]),
+		nodeSep:  parseFloat(g.Attrs["nodesep"], defaultNodeSep/pointsPerInch, 0.02, 10) * pointsPerInch,
+		rankSep:  parseFloat(rankSep, defaultRankSep/pointsPerInch, 0.02, 10) * pointsPerInch,
+		fontSize: parseFloat(g.Attrs["fontsize"], defaultFontSize, 6, 72),
+	}
+	if label, ok := g.Attrs["label"]; ok {
+		l.title = labelLines(label, "")
+	}
+
+	for _, n := range g.Nodes {
+		ln := &layoutNode{node: n, style: newNodeStyle(n)}
+		ln.width, ln.height = ln.style.width, ln.style.height
+		if l.horizontal() {
+			ln.width, ln.height = ln.height, ln.width
+		}
+		l.nodes[n] = ln
+	}
+
+	for _, e := range g.Edges {
+		le := &layoutEdge{edge: e, style: newEdgeStyle(g, e)}
+		if e.From == e.To {
+			l.nodes[e.From].extraRight = loopSize
+			l.loops = append(l.loops, le)
+			continue
+		}
+		l.edges = append(l.edges, le)
+	}
+
+	l.breakCycles()
+	l.assignRanks()
+	if err := l.buildChains(); err != nil {
+		return nil, err
+	}
+	l.orderRanks()
+	l.assignPositions()
+	l.transform()
+	return l, nil
+}
+
+func (l *layout) tail(le *layoutEdge) *layoutNode {
+	if le.reversed {
+		return l.nodes[le.edge.To]
+	}
+	return l.nodes[le.edge.From]
+}
+
+func (l *layout) head(le *layoutEdge) *layoutNode {
+	if le.reversed {
+		return l.nodes[le.edge.From]
+	}
+	return l.nodes[le.edge.To]
+}
+
+// breakCycles reverses the back edges found by a depth-first search to get an acyclic graph
+func (l *layout) breakCycles() {
+	out := make(map[*Node][]*layoutEdge, len(l.graph.Nodes))
+	for _, le := range l.edges {
+		out[le.edge.From] = append(out[le.edge.From], le)
+	}
+
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	state := make(map[*Node]int, len(l.graph.Nodes))
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		state[n] = onStack
+		for _, le := range out[n] {
+			switch state[le.edge.To] {
+			case unvisited:
+				visit(le.edge.To)
+			case onStack:
+				le.reversed = true
+			}
+		}
+		state[n] = done
+	}
+	for _, n := range l.graph.Nodes {
+		if state[n] == unvisited {
+			visit(n)
+		}
+	}
+}
+
+func minLen(le *layoutEdge) int {
+	length := 1
+	if v, err := strconv.Atoi(le.edge.Attrs["minlen"]); err == nil && v >= 0 {
+		length = min(v, 10)
+	}
+	if len(le.style.lines) > 0 {
+		// labels are placed on a virtual node in the middle of the edge
+		length = max(length, 2)
+	}
+	return length
+}
+
+// assignRanks assigns the ranks by the longest path from the sources of the graph
+func (l *layout) assignRanks() {
+	in := make(map[*layoutNode][]*layoutEdge)
+	out := make(map[*layoutNode][]*layoutEdge)
+	for _, le := range l.edges {
+		out[l.tail(le)] = append(out[l.tail(le)], le)
+		in[l.head(le)] = append(in[l.head(le)], le)
+	}
+
+	// topological order
+	indegree := make(map[*layoutNode]int)
+	queue := make([]*layoutNode, 0, len(l.graph.Nodes))
+	for _, n := range l.graph.Nodes {
+		ln := l.nodes[n]
+		indegree[ln] = len(in[ln])
+		if indegree[ln] == 0 {
+			queue = append(queue, ln)
+		}
+	}
+	for i := 0; i < len(queue); i++ {
+		for _, le := range out[queue[i]] {
+			head := l.head(le)
+			head.rank = max(head.rank, queue[i].rank+minLen(le))
+			indegree[head]--
+			if indegree[head] == 0 {
+				queue = append(queue, head)
+			}
+		}
+	}
+
+	// move sources down next to their successors to shorten their edges
+	for i := len(queue) - 1; i >= 0; i-- {
+		ln := queue[i]
+		if len(in[ln]) > 0 || len(out[ln]) == 0 {
+			continue
+		}
+		rank := math.MaxInt
+		for _, le := range out[ln] {
+			rank = min(rank, l.head(le).rank-minLen(le))
+		}
+		ln.rank = rank
+	}
+
+	minRank := math.MaxInt
+	for _, ln := range l.nodes {
+		minRank = min(minRank, ln.rank)
+	}
+	maxRank := 0
+	for _, ln := range l.nodes {
+		ln.rank -= minRank
+		maxRank = max(maxRank, ln.rank)
+	}
+	if len(l.nodes) > 0 {
+		l.ranks = make([][]*layoutNode, maxRank+1)
+	}
+}
+
+// buildChains inserts virtual nodes into edges spanning more than one rank
+func (l *layout) buildChains() error {
+	virtualNodes := 0
+	for _, le := range l.edges {
+		tail, head := l.tail(le), l.head(le)
+		virtualNodes += max(head.rank-tail.rank-1, 0)
+		if virtualNodes > maxVirtualNodes {
+			return ErrGraphTooLarge{Nodes: len(l.graph.Nodes), Edges: len(l.graph.Edges)}
+		}
+
+		le.chain = []*layoutNode{tail}
+		for rank := tail.rank + 1; rank < head.rank; rank++ {
+			le.chain = append(le.chain, &layoutNode{rank: rank, width: l.nodeSep / 2, height: 0})
+		}
+		le.chain = append(le.chain, head)
+		for i := 1; i < len(le.chain); i++ {
+			le.chain[i-1].downs = append(le.chain[i-1].downs, le.chain[i])
+			le.chain[i].ups = append(le.chain[i].ups, le.chain[i-1])
+		}
+
+		if len(le.style.lines) > 0 && len(le.chain) > 2 {
+			// the label is placed next to the edge, so reserve its size on both sides
+			le.label = le.chain[len(le.chain)/2]
+			w, h := textSize(le.style.lines, le.style.fontSize)
+			if l.horizontal() {
+				le.label.width, le.label.height = 2*(h+4), w
+			} else {
+				le.label.width, le.label.height = 2*(w+8), h
+			}
+		}
+	}
+	return nil
+}
+
+// orderRanks orders the nodes in the ranks to reduce the number of edge crossings
+func (l *layout) orderRanks() {
+	if len(l.ranks) == 0 {
+		return
+	}
+
+	// initial order by a depth-first search, which keeps connected nodes together
+	visited := make(map[*layoutNode]bool)
+	var visit func(ln *layoutNode)
+	visit = func(ln *layoutNode) {
+		visited[ln] = true
+		ln.order = len(l.ranks[ln.rank])
+		l.ranks[ln.rank] = append(l.ranks[ln.rank], ln)
+		for _, down := range ln.downs {
+			if !visited[down] {
+				visit(down)
+			}
+		}
+	}
+	for _, n := range l.graph.Nodes {
+		if ln := l.nodes[n]; !visited[ln] && len(ln.ups) == 0 {
+			visit(ln)
+		}
+	}
+	for _, n := range l.graph.Nodes {
+		if ln := l.nodes[n]; !visited[ln] {
+			visit(ln)
+		}
+	}
+
+	best := l.saveOrder()
+	bestCrossings := l.crossings()
+	for i := 0; i < orderIterations && bestCrossings > 0; i++ {
+		if i%2 == 0 {
+			for r := 1; r < len(l.ranks); r++ {
+				sortByBarycenter(l.ranks[r], func(ln *layoutNode) []*layoutNode { return ln.ups })
+			}
+		} else {
+			for r := len(l.ranks) - 2; r >= 0; r-- {
+				sortByBarycenter(l.ranks[r], func(ln *layoutNode) []*layoutNode { return ln.downs })
+			}
+		}
+		if crossings := l.crossings(); crossings < bestCrossings {
+			best, bestCrossings = l.saveOrder(), crossings
+		}
+	}
+	l.restoreOrder(best)
+}
+
+func sortByBarycenter(rank []*layoutNode, neighbors func(*layoutNode) []*layoutNode) {
+	for _, ln := range rank {
+		ln.key = float64(ln.order)
+		if ns := neighbors(ln); len(ns) > 0 {
+			sum := 0.0
+			for _, n := range ns {
+				sum += float64(n.order)
+			}
+			ln.key = sum / float64(len(ns))
+		}
+	}
+	sort.SliceStable(rank, func(i, j int) bool { return rank[i].key < rank[j].key })
+	for i, ln := range rank {
+		ln.order = i
+	}
+}
+
+func (l *layout) saveOrder() [][]*layoutNode {
+	saved := make([][]*layoutNode, len(l.ranks))
+	for r, rank := range l.ranks {
+		saved[r] = append([]*layoutNode(nil), rank...)
+	}
+	return saved
+}
+
+func (l *layout) restoreOrder(saved [][]*layoutNode) {
+	l.ranks = saved
+	for _, rank := range l.ranks {
+		for i, ln := range rank {
+			ln.order = i
+		}
+	}
+}
+
+// crossings counts the edge crossings between all adjacent ranks
+func (l *layout) crossings() int {
+	total := 0
+	for r := 0; r+1 < len(l.ranks); r++ {
+		// the crossings are the inversions of the lower ends when the edges are sorted by their upper ends
+		var lower []int
+		for _, ln := range l.ranks[r] {
+			orders := make([]int, 0, len(ln.downs))
+			for _, down := range ln.downs {
+				orders = append(orders, down.order)
+			}
+			sort.Ints(orders)
+			lower = append(lower, orders...)
+		}
+
+		// count the inversions with a Fenwick tree
+		size := len(l.ranks[r+1])
+		tree := make([]int, size+1)
+		for i, order := range lower {
+			greater := i
+			for j := order + 1; j > 0; j -= j & -j {
+				greater -= tree[j]
+			}
+			total += greater
+			for j := order + 1; j <= size; j += j & -j {
+				tree[j]++
+			}
+		}
+	}
+	return total
+}
+
+// assignPositions places the ranks below each other and moves the nodes towards their neighbors
+func (l *layout) assignPositions() {
+	y := 0.0
+	for r, rank := range l.ranks {
+		height := 0.0
+		for _, ln := range rank {
+			height = math.Max(height, ln.height)
+		}
+		if r > 0 {
+			y += l.rankSep
+		}
+		for _, ln := range rank {
+			ln.y = y + height/2
+		}
+		y += height
+
+		x := 0.0
+		for i, ln := range rank {
+			if i > 0 {
+				x += l.separation(rank[i-1], ln)
+			}
+			ln.x = x
+		}
+	}
+
+	for i := 0; i < positionIterations; i++ {
+		for r := 1; r < len(l.ranks); r++ {
+			l.placeRank(l.ranks[r], func(ln *layoutNode) []*layoutNode { return ln.ups })
+		}
+		for r := len(l.ranks) - 2; r >= 0; r-- {
+			l.placeRank(l.ranks[r], func(ln *layoutNode) []*layoutNode { return ln.downs })
+		}
+	}
+}
+
+func (l *layout) separation(left, right *layoutNode) float64 {
+	sep := l.nodeSep
+	if left.node == nil || right.node == nil {
+		sep /= 2
+	}
+	return left.width/2 + left.extraRight + sep + right.width/2
+}
+
+// placeRank moves the nodes of a rank to the mean position of their neighbors without changing their order
+func (l *layout) placeRank(rank []*layoutNode, neighbors func(*layoutNode) []*layoutNode) {
+	desired := make([]float64, len(rank))
+	for i, ln := range rank {
+		desired[i] = ln.x
+		if ns := neighbors(ln); len(ns) > 0 {
+			sum := 0.0
+			for _, n := range ns {
+				sum += n.x
+			}
+			desired[i] = sum / float64(len(ns))
+		}
+	}
+
+	// the mean of the placements packed from the left and from the right keeps the separations
+	left := make([]float64, len(rank))
+	for i := range rank {
+		left[i] = desired[i]
+		if i > 0 {
+			left[i] = math.Max(left[i], left[i-1]+l.separation(rank[i-1], rank[i]))
+		}
+	}
+	right := make([]float64, len(rank))
+	for i := len(rank) - 1; i >= 0; i-- {
+		right[i] = desired[i]
+		if i < len(rank)-1 {
+			right[i] = math.Min(right[i], right[i+1]-l.separation(rank[i], rank[i+1]))
+		}
+	}
+	for i, ln := range rank {
+		ln.x = (left[i] + right[i]) / 2
+	}
+}
+
+// transform rotates the layout according to the rank direction and computes the points of the edges
+func (l *layout) transform() {
+	minX, maxX, maxY := math.Inf(1), math.Inf(-1), 0.0
+	for _, rank := range l.ranks {
+		for _, ln := range rank {
+			minX = math.Min(minX, ln.x-ln.width/2)
+			maxX = math.Max(maxX, ln.x+ln.width/2+ln.extraRight)
+			maxY = math.Max(maxY, ln.y+ln.height/2)
+		}
+	}
+	if math.IsInf(minX, 1) {
+		minX, maxX = 0, 0
+	}
+
+	// the coordinates of the layout are always from the top to the bottom
+	width, height := maxX-minX, maxY
+	point := func(ln *layoutNode) (float64, float64) {
+		x, y := ln.x-minX, ln.y
+		switch l.rankDir {
+		case "BT":
+			return margin + x, margin + height - y
+		case "LR":
+			return margin + y, margin + x
+		case "RL":
+			return margin + height - y, margin + x
+		}
+		return margin + x, margin + y
+	}
+
+	for _, rank := range l.ranks {
+		for _, ln := range rank {
+			ln.x, ln.y = point(ln)
+			if l.horizontal() {
+				ln.width, ln.height = ln.height, ln.width
+			}
+		}
+	}
+	if l.horizontal() {
+		width, height = height, width
+	}
+
+	for _, le := range l.edges {
+		le.points = make([][2]float64, 0, len(le.chain))
+		for _, ln := range le.chain {
+			le.points = append(le.points, [2]float64{ln.x, ln.y})
+		}
+		if le.reversed {
+			for i, j := 0, len(le.points)-1; i < j; i, j = i+1, j-1 {
+				le.points[i], le.points[j] = le.points[j], le.points[i]
+			}
+		}
+		if le.label != nil {
+			le.labelX, le.labelY = le.label.x, le.label.y
+		} else {
+			// edges of adjacent ranks have their label at the middle
+			le.labelX = (le.points[0][0] + le.points[len(le.points)-1][0]) / 2
+			le.labelY = (le.points[0][1] + le.points[len(le.points)-1][1]) / 2
+		}
+	}
+
+	l.width = width + 2*margin
+	l.height = height + 2*margin
+	for _, le := range l.loops {
+		ln := l.nodes[le.edge.From]
+		labelW, _ := textSize(le.style.lines, le.style.fontSize)
+		l.width = math.Max(l.width, ln.x+ln.style.width/2+loopSize+labelW+2*margin)
+	}
+	if len(l.title) > 0 {
+		titleW, titleH := textSize(l.title, l.fontSize)
+		l.width = math.Max(l.width, titleW+2*margin)
+		l.titleY = l.height
+		l.height += titleH + margin
+	}
+}
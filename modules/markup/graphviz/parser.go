@@ -0,0 +1,629 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package graphviz
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxNodes = 500
+	maxEdges = 1000
+)
+
+// Attrs are the attributes of a graph, node or edge
+type Attrs map[string]string
+
+func (a Attrs) clone() Attrs {
+	c := make(Attrs, len(a))
+	for k, v := range a {
+		c[k] = v
+	}
+	return c
+}
+
+// Node is a node of a graph
+type Node struct {
+	ID    string
+	Attrs Attrs
+}
+
+// Edge is an edge between two nodes of a graph
+type Edge struct {
+	From  *Node
+	To    *Node
+	Attrs Attrs
+}
+
+// Graph is a parsed DOT graph. Subgraphs are flattened into their parent graph.
+type Graph struct {
+	Directed bool
+	Strict   bool
+	Attrs    Attrs
+	Nodes    []*Node
+	Edges    []*Edge
+
+	nodeMap map[string]*Node
+}
+
+// ErrGraphTooLarge is returned if a graph has too many nodes or edges to be laid out
+type ErrGraphTooLarge struct {
+	Nodes int
+	Edges int
+}
+
+func (err ErrGraphTooLarge) Error() string {
+	return fmt.Sprintf("graph too large: %d nodes and %d edges", err.Nodes, err.Edges)
+}
+
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenID
+	tokenLBrace
+	tokenRBrace
+	tokenLBracket
+	tokenRBracket
+	tokenEqual
+	tokenSemicolon
+	tokenComma
+	tokenColon
+	tokenEdgeOp
+)
+
+type token struct {
+	typ  tokenType
+	val  string
+	line int
+	// quoted is set for quoted and HTML strings which are never keywords
+	quoted bool
+	html   bool
+}
+
+func (t token) String() string {
+	if t.typ == tokenEOF {
+		return "end of input"
+	}
+	return fmt.Sprintf("%q", t.val)
+}
+
+type lexer struct {
+	input string
+	pos   int
+	line  int
+}
+
+func (l *lexer) peekRune(offset int) rune {
+	if l.pos+offset >= len(l.input) {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(l.input[l.pos+offset:])
+	return r
+}
+
+// skipSpace skips whitespace, comments and preprocessor lines
+func (l *lexer) skipSpace() {
+	atLineStart := l.pos == 0
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+		switch {
+		case c == '\n':
+			l.line++
+			l.pos++
+			atLineStart = true
+		case c == ' ' || c == '\t' || c == '\r':
+			l.pos++
+		case c == '#' && atLineStart:
+			for l.pos < len(l.input) && l.input[l.pos] != '\n' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.input[l.pos:], "//"):
+			for l.pos < len(l.input) && l.input[l.pos] != '\n' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.input[l.pos:], "/*"):
+			end := strings.Index(l.input[l.pos+2:], "*/")
+			if end < 0 {
+				end = len(l.input) - l.pos - 2
+			} else {
+				end += 2
+			}
+			l.line += strings.Count(l.input[l.pos:l.pos+2+end], "\n")
+			l.pos += 2 + end
+		default:
+			return
+		}
+	}
+}
+
+func isIDRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || r >= 0x80
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipSpace()
+	if l.pos >= len(l.input) {
+		return token{typ: tokenEOF, line: l.line}, nil
+	}
+
+	line := l.line
+	c := l.input[l.pos]
+	single := map[byte]tokenType{
+		'{': tokenLBrace, '}': tokenRBrace, '[': tokenLBracket, ']': tokenRBracket,
+		'=': tokenEqual, ';': tokenSemicolon, ',': tokenComma, ':': tokenColon,
+	}
+	if typ, ok := single[c]; ok {
+		l.pos++
+		return token{typ: typ, val: string(c), line: line}, nil
+	}
+
+	switch {
+	case strings.HasPrefix(l.input[l.pos:], "->") || strings.HasPrefix(l.input[l.pos:], "--"):
+		val := l.input[l.pos : l.pos+2]
+		l.pos += 2
+		return token{typ: tokenEdgeOp, val: val, line: line}, nil
+	case c == '"':
+		return l.quoted(line)
+	case c == '<':
+		return l.html(line)
+	case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		start := l.pos
+		l.pos++
+		for l.pos < len(l.input) && (l.input[l.pos] == '.' || (l.input[l.pos] >= '0' && l.input[l.pos] <= '9')) {
+			l.pos++
+		}
+		return token{typ: tokenID, val: l.input[start:l.pos], line: line}, nil
+	}
+
+	start := l.pos
+	for l.pos < len(l.input) {
+		r, size := utf8.DecodeRuneInString(l.input[l.pos:])
+		if !isIDRune(r) {
+			break
+		}
+		l.pos += size
+	}
+	if l.pos == start {
+		return token{}, fmt.Errorf("line %d: unexpected character %q", line+1, l.peekRune(0))
+	}
+	return token{typ: tokenID, val: l.input[start:l.pos], line: line}, nil
+}
+
+// quoted reads a double-quoted string, including strings concatenated with '+'
+func (l *lexer) quoted(line int) (token, error) {
+	var sb strings.Builder
+	for {
+		l.pos++ // opening quote
+		closed := false
+		for l.pos < len(l.input) {
+			c := l.input[l.pos]
+			if c == '\\' && l.pos+1 < len(l.input) {
+				next := l.input[l.pos+1]
+				switch next {
+				case '"':
+					sb.WriteByte('"')
+					l.pos += 2
+					continue
+				case '\n':
+					// line continuation
+					l.line++
+					l.pos += 2
+					continue
+				}
+				sb.WriteByte(c)
+				sb.WriteByte(next)
+				l.pos += 2
+				continue
+			}
+			l.pos++
+			if c == '"' {
+				closed = true
+				break
+			}
+			if c == '\n' {
+				l.line++
+			}
+			sb.WriteByte(c)
+		}
+		if !closed {
+			return token{}, fmt.Errorf("line %d: unterminated string", line+1)
+		}
+
+		// "a" + "b" is the concatenation of both strings
+		save, saveLine := l.pos, l.line
+		l.skipSpace()
+		if l.pos < len(l.input) && l.input[l.pos] == '+' {
+			l.pos++
+			l.skipSpace()
+			if l.pos < len(l.input) && l.input[l.pos] == '"' {
+				continue
+			}
+		}
+		l.pos, l.line = save, saveLine
+		return token{typ: tokenID, val: sb.String(), line: line, quoted: true}, nil
+	}
+}
+
+// html reads an HTML string delimited by balanced angle brackets
+func (l *lexer) html(line int) (token, error) {
+	depth := 0
+	start := l.pos
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+		l.pos++
+		switch c {
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				return token{typ: tokenID, val: l.input[start+1 : l.pos-1], line: line, quoted: true, html: true}, nil
+			}
+		case '\n':
+			l.line++
+		}
+	}
+	return token{}, fmt.Errorf("line %d: unterminated HTML string", line+1)
+}
+
+type scope struct {
+	nodeAttrs Attrs
+	edgeAttrs Attrs
+}
+
+type parser struct {
+	lex   *lexer
+	tok   token
+	graph *Graph
+}
+
+// Parse parses a graph in the DOT language
+func Parse(input string) (*Graph, error) {
+	p := &parser{
+		lex:   &lexer{input: input},
+		graph: &Graph{Attrs: Attrs{}, nodeMap: map[string]*Node{}},
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if err := p.parseGraph(); err != nil {
+		return nil, err
+	}
+	return p.graph, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.tok.line+1, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	return p.tok.typ == tokenID && !p.tok.quoted && strings.EqualFold(p.tok.val, keyword)
+}
+
+func (p *parser) expect(typ tokenType, what string) error {
+	if p.tok.typ != typ {
+		return p.errorf("expected %s but found %s", what, p.tok)
+	}
+	return p.advance()
+}
+
+func (p *parser) parseGraph() error {
+	if p.isKeyword("strict") {
+		p.graph.Strict = true
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+	switch {
+	case p.isKeyword("digraph"):
+		p.graph.Directed = true
+	case p.isKeyword("graph"):
+	default:
+		return p.errorf("expected graph or digraph but found %s", p.tok)
+	}
+	if err := p.advance(); err != nil {
+		return err
+	}
+	if p.tok.typ == tokenID {
+		// the graph name isn't used
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+	if err := p.expect(tokenLBrace, `"{"`); err != nil {
+		return err
+	}
+	if _, err := p.parseStatements(&scope{nodeAttrs: Attrs{}, edgeAttrs: Attrs{}}, true); err != nil {
+		return err
+	}
+	if err := p.expect(tokenRBrace, `"}"`); err != nil {
+		return err
+	}
+	if p.tok.typ != tokenEOF {
+		return p.errorf("unexpected %s after the graph", p.tok)
+	}
+	return nil
+}
+
+// parseStatements parses a statement list and returns the nodes used in it
+func (p *parser) parseStatements(s *scope, root bool) ([]*Node, error) {
+	var nodes []*Node
+	for p.tok.typ != tokenRBrace && p.tok.typ != tokenEOF {
+		stmtNodes, err := p.parseStatement(s, root)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, stmtNodes...)
+		if p.tok.typ == tokenSemicolon {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nodes, nil
+}
+
+func (p *parser) parseStatement(s *scope, root bool) ([]*Node, error) {
+	switch {
+	case p.isKeyword("graph"), p.isKeyword("node"), p.isKeyword("edge"):
+		kind := strings.ToLower(p.tok.val)
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		attrs, err := p.parseAttrLists()
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case "graph":
+			if root {
+				for k, v := range attrs {
+					p.graph.Attrs[k] = v
+				}
+			}
+		case "node":
+			for k, v := range attrs {
+				s.nodeAttrs[k] = v
+			}
+		case "edge":
+			for k, v := range attrs {
+				s.edgeAttrs[k] = v
+			}
+		}
+		return nil, nil
+	case p.isKeyword("subgraph") || p.tok.typ == tokenLBrace:
+		nodes, err := p.parseSubgraph(s)
+		if err != nil {
+			return nil, err
+		}
+		return p.parseEdgeRHS(s, nodes)
+	case p.tok.typ == tokenID:
+		id := p.tok
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.typ == tokenEqual {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if p.tok.typ != tokenID {
+				return nil, p.errorf("expected a value but found %s", p.tok)
+			}
+			if root {
+				p.graph.Attrs[id.val] = attrValue(p.tok)
+			}
+			return nil, p.advance()
+		}
+		if err := p.skipPort(); err != nil {
+			return nil, err
+		}
+		node, err := p.node(s, id.val)
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.typ == tokenEdgeOp {
+			return p.parseEdgeRHS(s, []*Node{node})
+		}
+		attrs, err := p.parseAttrLists()
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range attrs {
+			node.Attrs[k] = v
+		}
+		return []*Node{node}, nil
+	}
+	return nil, p.errorf("unexpected %s", p.tok)
+}
+
+func (p *parser) parseSubgraph(s *scope) ([]*Node, error) {
+	if p.isKeyword("subgraph") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.typ == tokenID {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := p.expect(tokenLBrace, `"{"`); err != nil {
+		return nil, err
+	}
+	nodes, err := p.parseStatements(&scope{nodeAttrs: s.nodeAttrs.clone(), edgeAttrs: s.edgeAttrs.clone()}, false)
+	if err != nil {
+		return nil, err
+	}
+	return nodes, p.expect(tokenRBrace, `"}"`)
+}
+
+// parseEdgeRHS parses the remaining part of an edge statement, starting at the first edge operator
+func (p *parser) parseEdgeRHS(s *scope, from []*Node) ([]*Node, error) {
+	if p.tok.typ != tokenEdgeOp {
+		return from, nil
+	}
+
+	operands := [][]*Node{from}
+	for p.tok.typ == tokenEdgeOp {
+		if (p.tok.val == "->") != p.graph.Directed {
+			return nil, p.errorf("edge operator %s can't be used in this graph", p.tok)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		switch {
+		case p.isKeyword("subgraph") || p.tok.typ == tokenLBrace:
+			nodes, err := p.parseSubgraph(s)
+			if err != nil {
+				return nil, err
+			}
+			operands = append(operands, nodes)
+		case p.tok.typ == tokenID:
+			id := p.tok.val
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if err := p.skipPort(); err != nil {
+				return nil, err
+			}
+			node, err := p.node(s, id)
+			if err != nil {
+				return nil, err
+			}
+			operands = append(operands, []*Node{node})
+		default:
+			return nil, p.errorf("expected a node or subgraph but found %s", p.tok)
+		}
+	}
+
+	attrs, err := p.parseAttrLists()
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []*Node
+	for i := 0; i < len(operands)-1; i++ {
+		for _, tail := range operands[i] {
+			for _, head := range operands[i+1] {
+				if err := p.edge(s, tail, head, attrs); err != nil {
+					return nil, err
+				}
+			}
+		}
+		nodes = append(nodes, operands[i]...)
+	}
+	return append(nodes, operands[len(operands)-1]...), nil
+}
+
+// skipPort skips the port of a node id, ports aren't used for the layout
+func (p *parser) skipPort() error {
+	for p.tok.typ == tokenColon {
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if p.tok.typ != tokenID {
+			return p.errorf("expected a port but found %s", p.tok)
+		}
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseAttrLists() (Attrs, error) {
+	attrs := Attrs{}
+	for p.tok.typ == tokenLBracket {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for p.tok.typ != tokenRBracket {
+			if p.tok.typ != tokenID {
+				return nil, p.errorf("expected an attribute but found %s", p.tok)
+			}
+			key := p.tok.val
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			value := "true"
+			if p.tok.typ == tokenEqual {
+				if err := p.advance(); err != nil {
+					return nil, err
+				}
+				if p.tok.typ != tokenID {
+					return nil, p.errorf("expected a value but found %s", p.tok)
+				}
+				value = attrValue(p.tok)
+				if err := p.advance(); err != nil {
+					return nil, err
+				}
+			}
+			attrs[key] = value
+			if p.tok.typ == tokenComma || p.tok.typ == tokenSemicolon {
+				if err := p.advance(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	return attrs, nil
+}
+
+// htmlLabelPrefix marks attribute values which have been given as HTML strings
+const htmlLabelPrefix = "\x00html:"
+
+func attrValue(tok token) string {
+	if tok.html {
+		return htmlLabelPrefix + tok.val
+	}
+	return tok.val
+}
+
+func (p *parser) node(s *scope, id string) (*Node, error) {
+	if node, ok := p.graph.nodeMap[id]; ok {
+		return node, nil
+	}
+	if len(p.graph.Nodes) >= maxNodes {
+		return nil, ErrGraphTooLarge{Nodes: len(p.graph.Nodes) + 1, Edges: len(p.graph.Edges)}
+	}
+	node := &Node{ID: id, Attrs: s.nodeAttrs.clone()}
+	p.graph.nodeMap[id] = node
+	p.graph.Nodes = append(p.graph.Nodes, node)
+	return node, nil
+}
+
+func (p *parser) edge(s *scope, tail, head *Node, attrs Attrs) error {
+	if p.graph.Strict {
+		for _, e := range p.graph.Edges {
+			if (e.From == tail && e.To == head) || (!p.graph.Directed && e.From == head && e.To == tail) {
+				return nil
+			}
+		}
+	}
+	if len(p.graph.Edges) >= maxEdges {
+		return ErrGraphTooLarge{Nodes: len(p.graph.Nodes), Edges: len(p.graph.Edges) + 1}
+	}
+	edgeAttrs := s.edgeAttrs.clone()
+	for k, v := range attrs {
+		edgeAttrs[k] = v
+	}
+	p.graph.Edges = append(p.graph.Edges, &Edge{From: tail, To: head, Attrs: edgeAttrs})
+	return nil
+}
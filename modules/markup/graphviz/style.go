@@ -0,0 +1,283 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package graphviz
+
+import (
+	"html"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultFontSize = 14.0
+	lineHeight      = 1.2
+	// pointsPerInch converts the sizes of the DOT language, which are given in inches
+	pointsPerInch = 72.0
+	maxInches     = 20.0
+)
+
+var (
+	colorRegexp   = regexp.MustCompile(`^(#[0-9a-fA-F]{3}|#[0-9a-fA-F]{6}|#[0-9a-fA-F]{8}|[a-zA-Z]+)$`)
+	htmlBreakRe   = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlTagRegexp = regexp.MustCompile(`<[^>]*>`)
+)
+
+type shapeKind int
+
+const (
+	shapeBox shapeKind = iota
+	shapeEllipse
+	shapeCircle
+	shapeDoubleCircle
+	shapeDiamond
+	shapePoint
+	shapeText
+)
+
+type nodeStyle struct {
+	shape     shapeKind
+	lines     []string
+	fontSize  float64
+	color     string
+	fillColor string
+	fontColor string
+	penWidth  float64
+	dashArray string
+	rounded   bool
+	invisible bool
+	width     float64
+	height    float64
+}
+
+type edgeStyle struct {
+	lines     []string
+	fontSize  float64
+	color     string
+	fontColor string
+	penWidth  float64
+	dashArray string
+	invisible bool
+	arrowHead bool
+	arrowTail bool
+}
+
+// parseStyle returns the set of the comma separated styles
+func parseStyle(style string) map[string]bool {
+	styles := map[string]bool{}
+	for _, s := range strings.Split(style, ",") {
+		styles[strings.TrimSpace(strings.ToLower(s))] = true
+	}
+	return styles
+}
+
+// parseColor returns the first color of a color list if it can be used in the SVG output
+func parseColor(value, fallback string) string {
+	if i := strings.IndexAny(value, ":;"); i >= 0 {
+		value = value[:i]
+	}
+	value = strings.TrimSpace(value)
+	if !colorRegexp.MatchString(value) {
+		return fallback
+	}
+	return value
+}
+
+func parseFloat(value string, fallback, min, max float64) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(f) {
+		return fallback
+	}
+	return math.Max(min, math.Min(max, f))
+}
+
+func dashArray(styles map[string]bool) string {
+	switch {
+	case styles["dashed"]:
+		return "5,2"
+	case styles["dotted"]:
+		return "1,5"
+	}
+	return ""
+}
+
+func penWidth(attrs Attrs, styles map[string]bool) float64 {
+	width := parseFloat(attrs["penwidth"], 1, 0, 10)
+	if styles["bold"] && width < 2 {
+		width = 2
+	}
+	return width
+}
+
+// labelLines returns the lines of a label, escape sequences are resolved for the given object name
+func labelLines(label, name string) []string {
+	if strings.HasPrefix(label, htmlLabelPrefix) {
+		// only the text of HTML labels is shown
+		label = htmlBreakRe.ReplaceAllString(label[len(htmlLabelPrefix):], "\n")
+		label = html.UnescapeString(htmlTagRegexp.ReplaceAllString(label, ""))
+		lines := strings.Split(label, "\n")
+		for i := range lines {
+			lines[i] = strings.TrimSpace(lines[i])
+		}
+		return lines
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(label); i++ {
+		if label[i] != '\\' || i == len(label)-1 {
+			sb.WriteByte(label[i])
+			continue
+		}
+		i++
+		switch label[i] {
+		case 'n', 'l', 'r':
+			sb.WriteByte('\n')
+		case 'N', 'E', 'T', 'H', 'G':
+			sb.WriteString(name)
+		default:
+			sb.WriteByte(label[i])
+		}
+	}
+	label = strings.TrimSuffix(sb.String(), "\n")
+	if label == "" {
+		return nil
+	}
+	return strings.Split(label, "\n")
+}
+
+// textWidth estimates the width of a text as no font metrics are available
+func textWidth(line string, fontSize float64) float64 {
+	width := 0.0
+	for _, r := range line {
+		if r >= 0x2e80 {
+			// CJK and other wide characters
+			width += 1
+		} else {
+			width += 0.58
+		}
+	}
+	return width * fontSize
+}
+
+func textSize(lines []string, fontSize float64) (float64, float64) {
+	width := 0.0
+	for _, line := range lines {
+		width = math.Max(width, textWidth(line, fontSize))
+	}
+	return width, float64(len(lines)) * fontSize * lineHeight
+}
+
+func newNodeStyle(n *Node) *nodeStyle {
+	styles := parseStyle(n.Attrs["style"])
+	s := &nodeStyle{
+		fontSize:  parseFloat(n.Attrs["fontsize"], defaultFontSize, 6, 72),
+		color:     parseColor(n.Attrs["color"], "currentColor"),
+		penWidth:  penWidth(n.Attrs, styles),
+		dashArray: dashArray(styles),
+		rounded:   styles["rounded"],
+		invisible: styles["invis"],
+	}
+	s.fontColor = parseColor(n.Attrs["fontcolor"], "currentColor")
+	s.fillColor = "none"
+	if styles["filled"] {
+		s.fillColor = parseColor(n.Attrs["fillcolor"], parseColor(n.Attrs["color"], "lightgrey"))
+		s.fontColor = parseColor(n.Attrs["fontcolor"], "black")
+	}
+
+	label, ok := n.Attrs["label"]
+	if !ok {
+		label = `\N`
+	}
+	s.lines = labelLines(label, n.ID)
+
+	switch strings.ToLower(n.Attrs["shape"]) {
+	case "", "ellipse", "oval":
+		s.shape = shapeEllipse
+	case "circle":
+		s.shape = shapeCircle
+	case "doublecircle":
+		s.shape = shapeDoubleCircle
+	case "diamond":
+		s.shape = shapeDiamond
+	case "point":
+		s.shape = shapePoint
+		s.lines = nil
+		if !styles["filled"] {
+			s.fillColor = s.color
+		}
+	case "plaintext", "plain", "none":
+		s.shape = shapeText
+	case "mrecord":
+		s.rounded = true
+	}
+
+	textW, textH := textSize(s.lines, s.fontSize)
+	switch s.shape {
+	case shapeEllipse:
+		s.width, s.height = math.Max(54, (textW+8)*math.Sqrt2), math.Max(36, (textH+4)*math.Sqrt2)
+	case shapeCircle, shapeDoubleCircle:
+		d := math.Max(36, math.Max(textW+8, textH+4)*math.Sqrt2)
+		if s.shape == shapeDoubleCircle {
+			d += 8
+		}
+		s.width, s.height = d, d
+	case shapeDiamond:
+		s.width, s.height = math.Max(54, 2*textW+8), math.Max(36, 2*textH)
+	case shapePoint:
+		s.width, s.height = 8, 8
+	case shapeText:
+		s.width, s.height = textW+16, textH+8
+	default:
+		s.width, s.height = math.Max(54, textW+16), math.Max(36, textH+8)
+		if strings.EqualFold(n.Attrs["shape"], "square") {
+			s.width = math.Max(s.width, s.height)
+			s.height = s.width
+		}
+	}
+
+	// width and height are minimum sizes unless the size is fixed
+	fixed := n.Attrs["fixedsize"] == "true"
+	if w, ok := n.Attrs["width"]; ok {
+		w := parseFloat(w, 0, 0.02, maxInches) * pointsPerInch
+		if fixed || w > s.width {
+			s.width = w
+		}
+	}
+	if h, ok := n.Attrs["height"]; ok {
+		h := parseFloat(h, 0, 0.02, maxInches) * pointsPerInch
+		if fixed || h > s.height {
+			s.height = h
+		}
+	}
+	if s.shape == shapeCircle || s.shape == shapeDoubleCircle || s.shape == shapePoint {
+		d := math.Max(s.width, s.height)
+		s.width, s.height = d, d
+	}
+	return s
+}
+
+func newEdgeStyle(g *Graph, e *Edge) *edgeStyle {
+	styles := parseStyle(e.Attrs["style"])
+	s := &edgeStyle{
+		fontSize:  parseFloat(e.Attrs["fontsize"], defaultFontSize, 6, 72),
+		color:     parseColor(e.Attrs["color"], "currentColor"),
+		penWidth:  penWidth(e.Attrs, styles),
+		dashArray: dashArray(styles),
+		invisible: styles["invis"],
+	}
+	s.fontColor = parseColor(e.Attrs["fontcolor"], "currentColor")
+	s.lines = labelLines(e.Attrs["label"], e.From.ID+"->"+e.To.ID)
+
+	dir := "none"
+	if g.Directed {
+		dir = "forward"
+	}
+	if d, ok := e.Attrs["dir"]; ok {
+		dir = strings.ToLower(d)
+	}
+	s.arrowHead = (dir == "forward" || dir == "both") && e.Attrs["arrowhead"] != "none"
+	s.arrowTail = (dir == "back" || dir == "both") && e.Attrs["arrowtail"] != "none"
+	return s
+}
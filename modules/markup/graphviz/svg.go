@@ -0,0 +1,250 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package graphviz
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	arrowLength = 10.0
+	arrowWidth  = 4.0
+)
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+type svgWriter struct {
+	strings.Builder
+}
+
+// element writes an empty element with the attributes given as name and value pairs, empty values are skipped
+func (w *svgWriter) element(name string, attrs ...string) {
+	w.WriteString("<" + name)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if attrs[i+1] == "" {
+			continue
+		}
+		w.WriteString(" " + attrs[i] + `="` + html.EscapeString(attrs[i+1]) + `"`)
+	}
+	w.WriteString("/>")
+}
+
+// text writes the lines of a label centered at the given point
+func (w *svgWriter) text(lines []string, x, y, fontSize float64, color string) {
+	lineH := fontSize * lineHeight
+	top := y - float64(len(lines))*lineH/2
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		baseline := top + float64(i)*lineH + lineH/2 + fontSize*0.35
+		fmt.Fprintf(&w.Builder, `<text x="%s" y="%s" text-anchor="middle" font-family="sans-serif" font-size="%s" fill="%s">%s</text>`,
+			formatFloat(x), formatFloat(baseline), formatFloat(fontSize), html.EscapeString(color), html.EscapeString(line))
+	}
+}
+
+func stroke(color string, width float64, dashArray string) []string {
+	attrs := []string{"stroke", color, "stroke-dasharray", dashArray}
+	if width != 1 {
+		attrs = append(attrs, "stroke-width", formatFloat(width))
+	}
+	return attrs
+}
+
+// writeSVG writes the SVG image of the layout
+func writeSVG(l *layout) string {
+	w := &svgWriter{}
+	fmt.Fprintf(&w.Builder, `<svg width="%s" height="%s" viewBox="0 0 %[1]s %[2]s">`, formatFloat(l.width), formatFloat(l.height))
+	w.WriteString(`<g class="graph">`)
+	if bg := parseColor(l.graph.Attrs["bgcolor"], ""); bg != "" {
+		w.element("rect", "x", "0", "y", "0", "width", formatFloat(l.width), "height", formatFloat(l.height), "fill", bg)
+	}
+	if len(l.title) > 0 {
+		_, titleH := textSize(l.title, l.fontSize)
+		w.text(l.title, l.width/2, l.titleY+titleH/2, l.fontSize, parseColor(l.graph.Attrs["fontcolor"], "currentColor"))
+	}
+
+	for _, le := range l.edges {
+		writeEdge(w, l, le)
+	}
+	for _, le := range l.loops {
+		writeLoop(w, l.nodes[le.edge.From], le)
+	}
+	for _, n := range l.graph.Nodes {
+		writeNode(w, l.nodes[n])
+	}
+
+	w.WriteString(`</g></svg>`)
+	return w.String()
+}
+
+func writeNode(w *svgWriter, ln *layoutNode) {
+	s := ln.style
+	if s.invisible {
+		return
+	}
+
+	w.WriteString(`<g class="node">`)
+	rx, ry := s.width/2, s.height/2
+	x, y := formatFloat(ln.x), formatFloat(ln.y)
+	paint := append([]string{"fill", s.fillColor}, stroke(s.color, s.penWidth, s.dashArray)...)
+	switch s.shape {
+	case shapeEllipse, shapeCircle, shapePoint:
+		w.element("ellipse", append([]string{"cx", x, "cy", y, "rx", formatFloat(rx), "ry", formatFloat(ry)}, paint...)...)
+	case shapeDoubleCircle:
+		w.element("ellipse", append([]string{"cx", x, "cy", y, "rx", formatFloat(rx), "ry", formatFloat(ry)}, paint...)...)
+		w.element("ellipse", append([]string{"cx", x, "cy", y, "rx", formatFloat(rx - 4), "ry", formatFloat(ry - 4), "fill", "none"}, stroke(s.color, s.penWidth, s.dashArray)...)...)
+	case shapeDiamond:
+		points := fmt.Sprintf("%s,%s %s,%s %s,%s %s,%s",
+			x, formatFloat(ln.y-ry), formatFloat(ln.x+rx), y, x, formatFloat(ln.y+ry), formatFloat(ln.x-rx), y)
+		w.element("polygon", append([]string{"points", points}, paint...)...)
+	case shapeText:
+		if s.fillColor != "none" {
+			w.element("rect", "x", formatFloat(ln.x-rx), "y", formatFloat(ln.y-ry), "width", formatFloat(s.width), "height", formatFloat(s.height), "fill", s.fillColor)
+		}
+	default:
+		corner := ""
+		if s.rounded {
+			corner = "6"
+		}
+		w.element("rect", append([]string{"x", formatFloat(ln.x - rx), "y", formatFloat(ln.y - ry), "width", formatFloat(s.width), "height", formatFloat(s.height), "rx", corner}, paint...)...)
+	}
+	w.text(s.lines, ln.x, ln.y, s.fontSize, s.fontColor)
+	w.WriteString(`</g>`)
+}
+
+// clip returns the point where the line from the center of the node to the given point leaves the node shape
+func clip(ln *layoutNode, px, py float64) (float64, float64) {
+	dx, dy := px-ln.x, py-ln.y
+	if dx == 0 && dy == 0 {
+		return ln.x, ln.y
+	}
+	rx, ry := ln.style.width/2, ln.style.height/2
+	var t float64
+	switch ln.style.shape {
+	case shapeEllipse, shapeCircle, shapeDoubleCircle, shapePoint:
+		t = 1 / math.Sqrt((dx*dx)/(rx*rx)+(dy*dy)/(ry*ry))
+	case shapeDiamond:
+		t = 1 / (math.Abs(dx)/rx + math.Abs(dy)/ry)
+	default:
+		t = math.Min(rx/math.Abs(dx), ry/math.Abs(dy))
+	}
+	t = math.Min(t, 1)
+	return ln.x + dx*t, ln.y + dy*t
+}
+
+// arrow shortens the end of the line by the length of an arrowhead and returns the arrowhead
+func arrow(from [2]float64, to *[2]float64) string {
+	dx, dy := to[0]-from[0], to[1]-from[1]
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return ""
+	}
+	ux, uy := dx/length, dy/length
+	tipX, tipY := to[0], to[1]
+	to[0], to[1] = tipX-ux*math.Min(arrowLength, length), tipY-uy*math.Min(arrowLength, length)
+	return fmt.Sprintf("%s,%s %s,%s %s,%s",
+		formatFloat(tipX), formatFloat(tipY),
+		formatFloat(to[0]-uy*arrowWidth), formatFloat(to[1]+ux*arrowWidth),
+		formatFloat(to[0]+uy*arrowWidth), formatFloat(to[1]-ux*arrowWidth))
+}
+
+// smoothPath returns a path through the points using Catmull-Rom splines
+func smoothPath(points [][2]float64) string {
+	var sb strings.Builder
+	sb.WriteString("M" + formatFloat(points[0][0]) + "," + formatFloat(points[0][1]))
+	if len(points) == 2 {
+		sb.WriteString(" L" + formatFloat(points[1][0]) + "," + formatFloat(points[1][1]))
+		return sb.String()
+	}
+	at := func(i int) [2]float64 {
+		return points[max(0, min(i, len(points)-1))]
+	}
+	for i := 0; i+1 < len(points); i++ {
+		p0, p1, p2, p3 := at(i-1), at(i), at(i+1), at(i+2)
+		fmt.Fprintf(&sb, " C%s,%s %s,%s %s,%s",
+			formatFloat(p1[0]+(p2[0]-p0[0])/6), formatFloat(p1[1]+(p2[1]-p0[1])/6),
+			formatFloat(p2[0]-(p3[0]-p1[0])/6), formatFloat(p2[1]-(p3[1]-p1[1])/6),
+			formatFloat(p2[0]), formatFloat(p2[1]))
+	}
+	return sb.String()
+}
+
+func writeEdge(w *svgWriter, l *layout, le *layoutEdge) {
+	s := le.style
+	if s.invisible || len(le.points) < 2 {
+		return
+	}
+
+	from, to := l.nodes[le.edge.From], l.nodes[le.edge.To]
+	points := append([][2]float64(nil), le.points...)
+	last := len(points) - 1
+	points[0][0], points[0][1] = clip(from, points[1][0], points[1][1])
+	points[last][0], points[last][1] = clip(to, points[last-1][0], points[last-1][1])
+
+	var head, tail string
+	if s.arrowHead {
+		head = arrow(points[last-1], &points[last])
+	}
+	if s.arrowTail {
+		tail = arrow(points[1], &points[0])
+	}
+
+	w.WriteString(`<g class="edge">`)
+	w.element("path", append([]string{"d", smoothPath(points), "fill", "none"}, stroke(s.color, s.penWidth, s.dashArray)...)...)
+	for _, arrowhead := range []string{head, tail} {
+		if arrowhead != "" {
+			w.element("polygon", append([]string{"points", arrowhead, "fill", s.color}, stroke(s.color, s.penWidth, "")...)...)
+		}
+	}
+	if len(s.lines) > 0 {
+		labelW, labelH := textSize(s.lines, s.fontSize)
+		if l.horizontal() {
+			w.text(s.lines, le.labelX, le.labelY-labelH/2-4, s.fontSize, s.fontColor)
+		} else {
+			w.text(s.lines, le.labelX+labelW/2+8, le.labelY, s.fontSize, s.fontColor)
+		}
+	}
+	w.WriteString(`</g>`)
+}
+
+// writeLoop writes an edge from a node to itself on the right side of the node
+func writeLoop(w *svgWriter, ln *layoutNode, le *layoutEdge) {
+	s := le.style
+	if s.invisible {
+		return
+	}
+
+	startX, startY := clip(ln, ln.x+ln.style.width, ln.y-ln.style.height/4)
+	endX, endY := clip(ln, ln.x+ln.style.width, ln.y+ln.style.height/4)
+	outer := math.Max(startX, endX) + loopSize
+	controlStart := [2]float64{outer, startY - loopSize/2}
+	end := [2]float64{endX, endY}
+	var head string
+	if s.arrowHead || s.arrowTail {
+		head = arrow([2]float64{outer, endY + loopSize/2}, &end)
+	}
+
+	w.WriteString(`<g class="edge">`)
+	d := fmt.Sprintf("M%s,%s C%s,%s %s,%s %s,%s",
+		formatFloat(startX), formatFloat(startY),
+		formatFloat(controlStart[0]), formatFloat(controlStart[1]),
+		formatFloat(outer), formatFloat(endY+loopSize/2),
+		formatFloat(end[0]), formatFloat(end[1]))
+	w.element("path", append([]string{"d", d, "fill", "none"}, stroke(s.color, s.penWidth, s.dashArray)...)...)
+	if head != "" {
+		w.element("polygon", append([]string{"points", head, "fill", s.color}, stroke(s.color, s.penWidth, "")...)...)
+	}
+	if len(s.lines) > 0 {
+		labelW, _ := textSize(s.lines, s.fontSize)
+		w.text(s.lines, outer+labelW/2+4, ln.y, s.fontSize, s.fontColor)
+	}
+	w.WriteString(`</g>`)
+}
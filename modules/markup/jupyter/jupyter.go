@@ -0,0 +1,306 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package jupyter
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/highlight"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	trend "github.com/buildkite/terminal-to-html/v3"
+)
+
+// MarkupName describes markup's name
+var MarkupName = "jupyter"
+
+func init() {
+	markup.RegisterRenderer(Renderer{})
+}
+
+// Renderer implements markup.Renderer for Jupyter notebooks
+type Renderer struct{}
+
+// Name implements markup.Renderer
+func (Renderer) Name() string {
+	return MarkupName
+}
+
+// Extensions implements markup.Renderer
+func (Renderer) Extensions() []string {
+	return []string{".ipynb"}
+}
+
+// SanitizerRules implements markup.Renderer
+func (Renderer) SanitizerRules() []setting.MarkupSanitizerRule {
+	return []setting.MarkupSanitizerRule{
+		{Element: "div", AllowAttr: "class", Regexp: regexp.MustCompile(`^notebook(-[a-z]+)?( notebook-[a-z]+)?$`)},
+		{Element: "span", AllowAttr: "class", Regexp: regexp.MustCompile(`^term-((fg[ix]?|bg)\d+|container)$`)},
+		{AllowDataURIImages: true},
+	}
+}
+
+// MultilineString is a notebook string which is stored either as a string or as a list of lines
+type MultilineString string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *MultilineString) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*s = MultilineString(strings.Join(lines, ""))
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	*s = MultilineString(str)
+	return nil
+}
+
+// OutputData is the content of a rich output for a MIME type, it's kept as raw JSON
+// because the types like `application/json` or the widget views are stored as JSON objects
+type OutputData []byte
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *OutputData) UnmarshalJSON(data []byte) error {
+	*d = append((*d)[:0], data...)
+	return nil
+}
+
+// Text returns the content of a text or image type, which is stored as a string or as a list of lines
+func (d OutputData) Text() (string, bool) {
+	var s MultilineString
+	if err := json.Unmarshal(d, &s); err != nil {
+		return "", false
+	}
+	return string(s), true
+}
+
+// Notebook is a Jupyter notebook of the format version 4
+type Notebook struct {
+	Metadata struct {
+		KernelSpec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+	NbFormat int     `json:"nbformat"`
+	Cells    []*Cell `json:"cells"`
+}
+
+// Cell is a markdown, code or raw cell of a notebook
+type Cell struct {
+	CellType       string          `json:"cell_type"`
+	Source         MultilineString `json:"source"`
+	ExecutionCount *int            `json:"execution_count"`
+	Outputs        []*Output       `json:"outputs"`
+}
+
+// Output is an output of a code cell
+type Output struct {
+	OutputType     string                `json:"output_type"`
+	Name           string                `json:"name"`
+	Text           MultilineString       `json:"text"`
+	Data           map[string]OutputData `json:"data"`
+	ExecutionCount *int                  `json:"execution_count"`
+	EName          string                `json:"ename"`
+	EValue         string                `json:"evalue"`
+	Traceback      []string              `json:"traceback"`
+}
+
+// language returns the programming language of the code cells
+func (nb *Notebook) language() string {
+	if nb.Metadata.LanguageInfo.Name != "" {
+		return strings.ToLower(nb.Metadata.LanguageInfo.Name)
+	}
+	if nb.Metadata.KernelSpec.Language != "" {
+		return strings.ToLower(nb.Metadata.KernelSpec.Language)
+	}
+	return "python"
+}
+
+// Parse parses a Jupyter notebook, only the format version 4 is supported
+func Parse(input io.Reader) (*Notebook, error) {
+	var nb Notebook
+	if err := json.NewDecoder(input).Decode(&nb); err != nil {
+		return nil, fmt.Errorf("unable to parse notebook: %w", err)
+	}
+	if nb.NbFormat < 4 {
+		return nil, fmt.Errorf("unsupported notebook format version %d", nb.NbFormat)
+	}
+	return &nb, nil
+}
+
+// Render renders a Jupyter notebook to HTML
+func (Renderer) Render(ctx *markup.RenderContext, input io.Reader, output io.Writer) error {
+	nb, err := Parse(input)
+	if err != nil {
+		return err
+	}
+
+	lang := nb.language()
+
+	var w strings.Builder
+	w.WriteString(`<div class="notebook">`)
+	for _, c := range nb.Cells {
+		switch c.CellType {
+		case "markdown":
+			content, err := renderMarkdown(ctx, string(c.Source))
+			if err != nil {
+				return err
+			}
+			w.WriteString(`<div class="notebook-cell notebook-markdown">`)
+			w.WriteString(content)
+			w.WriteString(`</div>`)
+		case "code":
+			if err := writeCodeCell(ctx, &w, c, lang); err != nil {
+				return err
+			}
+		default:
+			// raw cells are shown as they are
+			w.WriteString(`<div class="notebook-cell notebook-raw"><pre>`)
+			w.WriteString(html.EscapeString(string(c.Source)))
+			w.WriteString(`</pre></div>`)
+		}
+	}
+	w.WriteString(`</div>`)
+
+	_, err = io.WriteString(output, w.String())
+	return err
+}
+
+func writeCodeCell(ctx *markup.RenderContext, w *strings.Builder, c *Cell, lang string) error {
+	w.WriteString(`<div class="notebook-cell notebook-code"><div class="notebook-input">`)
+	writePrompt(w, "In ", c.ExecutionCount)
+	w.WriteString(`<pre><code class="chroma language-` + html.EscapeString(lang) + `">`)
+	w.WriteString(highlightCode(lang, string(c.Source)))
+	w.WriteString(`</code></pre></div>`)
+
+	for _, o := range c.Outputs {
+		w.WriteString(`<div class="notebook-output">`)
+		if o.OutputType == "execute_result" {
+			writePrompt(w, "Out", o.ExecutionCount)
+		} else {
+			// keep the output aligned with the input
+			w.WriteString(`<div class="notebook-prompt"></div>`)
+		}
+		if err := writeOutput(ctx, w, o); err != nil {
+			return err
+		}
+		w.WriteString(`</div>`)
+	}
+	w.WriteString(`</div>`)
+	return nil
+}
+
+func writePrompt(w *strings.Builder, prompt string, count *int) {
+	w.WriteString(`<div class="notebook-prompt">`)
+	if count != nil {
+		fmt.Fprintf(w, "%s[%d]:", prompt, *count)
+	} else {
+		fmt.Fprintf(w, "%s[ ]:", prompt)
+	}
+	w.WriteString(`</div>`)
+}
+
+func writeOutput(ctx *markup.RenderContext, w *strings.Builder, o *Output) error {
+	switch o.OutputType {
+	case "stream":
+		class := "notebook-stream"
+		if o.Name == "stderr" {
+			class += " notebook-stderr"
+		}
+		w.WriteString(`<div class="` + class + `"><pre>`)
+		w.Write(trend.Render([]byte(o.Text)))
+		w.WriteString(`</pre></div>`)
+	case "error":
+		w.WriteString(`<div class="notebook-error"><pre>`)
+		if len(o.Traceback) > 0 {
+			w.Write(trend.Render([]byte(strings.Join(o.Traceback, "\n"))))
+		} else {
+			w.WriteString(html.EscapeString(o.EName + ": " + o.EValue))
+		}
+		w.WriteString(`</pre></div>`)
+	case "execute_result", "display_data":
+		return writeOutputData(ctx, w, o.Data)
+	}
+	return nil
+}
+
+// imageMimeTypes are the image types which are allowed as data URIs by the sanitizer
+var imageMimeTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// writeOutputData writes the richest representation of a rich output which can be displayed
+func writeOutputData(ctx *markup.RenderContext, w *strings.Builder, data map[string]OutputData) error {
+	text := func(mimeType string) (string, bool) {
+		if content, ok := data[mimeType]; ok {
+			return content.Text()
+		}
+		return "", false
+	}
+
+	if content, ok := text("text/html"); ok {
+		w.WriteString(`<div class="notebook-html">`)
+		w.WriteString(content)
+		w.WriteString(`</div>`)
+		return nil
+	}
+	for _, mimeType := range imageMimeTypes {
+		if content, ok := text(mimeType); ok {
+			// base64 encoded images may be split into several lines
+			encoded := strings.Join(strings.Fields(content), "")
+			w.WriteString(`<div class="notebook-image"><img src="data:` + mimeType + `;base64,` + html.EscapeString(encoded) + `"></div>`)
+			return nil
+		}
+	}
+	if content, ok := text("text/markdown"); ok {
+		rendered, err := renderMarkdown(ctx, content)
+		if err != nil {
+			return err
+		}
+		w.WriteString(`<div class="notebook-markdown">`)
+		w.WriteString(rendered)
+		w.WriteString(`</div>`)
+		return nil
+	}
+	if content, ok := text("text/plain"); ok {
+		w.WriteString(`<pre>`)
+		w.WriteString(html.EscapeString(content))
+		w.WriteString(`</pre>`)
+	}
+	return nil
+}
+
+func renderMarkdown(ctx *markup.RenderContext, content string) (string, error) {
+	return markdown.RenderString(&markup.RenderContext{
+		Ctx:          ctx.Ctx,
+		RelativePath: ctx.RelativePath,
+		Type:         markdown.MarkupName,
+		IsWiki:       ctx.IsWiki,
+		URLPrefix:    ctx.URLPrefix,
+		Metas:        ctx.Metas,
+		DefaultLink:  ctx.DefaultLink,
+		GitRepo:      ctx.GitRepo,
+	}, content)
+}
+
+func highlightCode(lang, source string) string {
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		return html.EscapeString(source)
+	}
+	return highlight.CodeFromLexer(chroma.Coalesce(lexer), source)
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package jupyter
+
+import (
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/markup"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderNotebook(t *testing.T) {
+	markup.Init(&markup.ProcessorHelper{})
+
+	notebook := `{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Title\n", "Some *text*"]},
+  {
+   "cell_type": "code", "execution_count": 1, "metadata": {},
+   "source": "print(1 + 1)",
+   "outputs": [
+    {"output_type": "stream", "name": "stdout", "text": ["2\n"]},
+    {"output_type": "execute_result", "execution_count": 1, "metadata": {}, "data": {"text/plain": "<2>"}},
+    {"output_type": "display_data", "metadata": {}, "data": {"image/png": "iVBORw0KGgo=\n", "text/plain": "<Figure>"}},
+    {"output_type": "display_data", "metadata": {}, "data": {"text/html": "<b>bold</b><script>alert(1)</script>"}},
+    {"output_type": "display_data", "metadata": {}, "data": {"application/json": {"a": [1, 2]}, "text/plain": "{'a': [1, 2]}"}},
+    {"output_type": "error", "ename": "ValueError", "evalue": "bad", "traceback": ["\u001b[31mValueError\u001b[0m: bad"]}
+   ]
+  },
+  {"cell_type": "raw", "metadata": {}, "source": "<raw>"}
+ ],
+ "metadata": {"language_info": {"name": "python"}},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}`
+
+	var buf strings.Builder
+	err := markup.Render(&markup.RenderContext{
+		Ctx:          git.DefaultContext,
+		RelativePath: "test.ipynb",
+	}, strings.NewReader(notebook), &buf)
+	assert.NoError(t, err)
+
+	res := buf.String()
+	assert.Contains(t, res, `<div class="notebook-cell notebook-markdown"><h1 id="user-content-title">Title</h1>`)
+	assert.Contains(t, res, `<em>text</em>`)
+	assert.Contains(t, res, `<div class="notebook-prompt">In [1]:</div><pre><code class="chroma language-python"><span class="nb">print</span>`)
+	assert.Contains(t, res, `<div class="notebook-stream"><pre>2</pre></div>`)
+	assert.Contains(t, res, `<div class="notebook-prompt">Out[1]:</div><pre>&lt;2&gt;</pre>`)
+	assert.Contains(t, res, `<img src="data:image/png;base64,iVBORw0KGgo=">`)
+	assert.Contains(t, res, `<div class="notebook-html"><b>bold</b></div>`)
+	assert.Contains(t, res, `<pre>{&#39;a&#39;: [1, 2]}</pre>`)
+	assert.Contains(t, res, `<span class="term-fg31">ValueError</span>: bad`)
+	assert.Contains(t, res, `<div class="notebook-cell notebook-raw"><pre>&lt;raw&gt;</pre></div>`)
+	assert.NotContains(t, res, "<script>")
+	assert.NotContains(t, res, "&lt;Figure&gt;")
+}
+
+func TestRenderInvalidNotebook(t *testing.T) {
+	var buf strings.Builder
+	assert.Error(t, Renderer{}.Render(&markup.RenderContext{Ctx: git.DefaultContext}, strings.NewReader(`{"nbformat": 3}`), &buf))
+	assert.Error(t, Renderer{}.Render(&markup.RenderContext{Ctx: git.DefaultContext}, strings.NewReader(`not json`), &buf))
+}
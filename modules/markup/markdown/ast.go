@@ -215,3 +215,30 @@ func NewAttention(attentionType string) *Attention {
 		AttentionType: attentionType,
 	}
 }
+
+// Graphviz is a block for a graph which has been rendered to an SVG image
+type Graphviz struct {
+	ast.BaseBlock
+	SVG []byte
+}
+
+// Dump implements Node.Dump.
+func (n *Graphviz) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// KindGraphviz is the NodeKind for Graphviz
+var KindGraphviz = ast.NewNodeKind("Graphviz")
+
+// Kind implements Node.Kind.
+func (n *Graphviz) Kind() ast.NodeKind {
+	return KindGraphviz
+}
+
+// NewGraphviz returns a new Graphviz node.
+func NewGraphviz(svg []byte) *Graphviz {
+	return &Graphviz{
+		BaseBlock: ast.BaseBlock{},
+		SVG:       svg,
+	}
+}
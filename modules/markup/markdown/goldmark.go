@@ -10,8 +10,10 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/markup"
 	"code.gitea.io/gitea/modules/markup/common"
+	"code.gitea.io/gitea/modules/markup/graphviz"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/svg"
 	giteautil "code.gitea.io/gitea/modules/util"
@@ -196,6 +198,25 @@ func (g *ASTTransformer) Transform(node *ast.Document, reader text.Reader, pc pa
 					v.SetHardLineBreak(setting.Markdown.EnableHardLineBreakInDocuments)
 				}
 			}
+		case *ast.FencedCodeBlock:
+			if language := string(v.Language(reader.Source())); language != "dot" && language != "graphviz" {
+				break
+			}
+			var source []byte
+			for i := 0; i < v.Lines().Len(); i++ {
+				segment := v.Lines().At(i)
+				source = append(source, segment.Value(reader.Source())...)
+			}
+			svg, err := graphviz.RenderSVG(source)
+			if err != nil {
+				// invalid graphs are shown as code
+				log.Debug("Unable to render graph: %v", err)
+				break
+			}
+			next := v.NextSibling()
+			v.Parent().ReplaceChild(v.Parent(), v, NewGraphviz([]byte(svg)))
+			// ensure the walk continues with the next sibling of the replaced code block
+			v.SetNextSibling(next)
 		case *ast.CodeSpan:
 			colorContent := n.Text(reader.Source())
 			if css.ColorHandler(strings.ToLower(string(colorContent))) {
@@ -302,6 +323,7 @@ func (r *HTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
 	reg.Register(KindDetails, r.renderDetails)
 	reg.Register(KindSummary, r.renderSummary)
 	reg.Register(KindIcon, r.renderIcon)
+	reg.Register(KindGraphviz, r.renderGraphviz)
 	reg.Register(ast.KindCodeSpan, r.renderCodeSpan)
 	reg.Register(KindAttention, r.renderAttention)
 	reg.Register(KindTaskCheckBoxListItem, r.renderTaskCheckBoxListItem)
@@ -421,6 +443,18 @@ func (r *HTMLRenderer) renderSummary(w util.BufWriter, source []byte, node ast.N
 	return ast.WalkContinue, nil
 }
 
+func (r *HTMLRenderer) renderGraphviz(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		if _, err := w.Write(node.(*Graphviz).SVG); err != nil {
+			return ast.WalkStop, err
+		}
+		if err := w.WriteByte('\n'); err != nil {
+			return ast.WalkStop, err
+		}
+	}
+	return ast.WalkContinue, nil
+}
+
 var validNameRE = regexp.MustCompile("^[a-z ]+$")
 
 func (r *HTMLRenderer) renderIcon(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
@@ -556,3 +556,20 @@ foo: bar
 		assert.Equal(t, test.expected, res, "Unexpected result in testcase %q", test.testcase)
 	}
 }
+
+func TestGraphvizBlock(t *testing.T) {
+	res, err := markdown.RenderString(&markup.RenderContext{Ctx: git.DefaultContext}, "before\n```dot\ndigraph { a -> b [label=\"#1\"] }\n```\nafter")
+	assert.NoError(t, err)
+	assert.Contains(t, res, `<p>before</p>`+"\n"+`<div class="graphviz"><svg width="`)
+	assert.Contains(t, res, `<g class="node"><ellipse cx="`)
+	assert.Contains(t, res, `<g class="edge"><path d="M`)
+	// issue references aren't linked in graphs
+	assert.Contains(t, res, `fill="currentColor">#1</text>`)
+	assert.Contains(t, res, `</svg></div>`+"\n"+`<p>after</p>`)
+
+	// invalid graphs are shown as code
+	res, err = markdown.RenderString(&markup.RenderContext{Ctx: git.DefaultContext}, "```graphviz\ndigraph { a -> }\n```")
+	assert.NoError(t, err)
+	assert.Equal(t, `<pre class="code-block"><code class="chroma language-graphviz">digraph { a -&gt; }
+</code></pre>`, res)
+}
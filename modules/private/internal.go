@@ -34,55 +34,62 @@ func getClientIP() string {
 	return strings.Fields(sshConnEnv)[0]
 }
 
-func newInternalRequest(ctx context.Context, url, method string, body ...any) *httplib.Request {
-	if setting.InternalToken == "" {
-		log.Fatal(`The INTERNAL_TOKEN setting is missing from the configuration file: %q.
-Ensure you are running in the correct environment or set the correct configuration file with -c.`, setting.CustomConf)
-	}
-
-	req := httplib.NewRequest(url, method).
-		SetContext(ctx).
-		Header("X-Real-IP", getClientIP()).
-		Header("Authorization", fmt.Sprintf("Bearer %s", setting.InternalToken)).
-		SetTLSClientConfig(&tls.Config{
+// NewLocalTransport returns a transport for requests to the LOCAL_ROOT_URL of gitea,
+// it dials the unix socket and writes the proxy protocol header if configured.
+func NewLocalTransport() *http.Transport {
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: true,
 			ServerName:         setting.Domain,
-		})
+		},
+	}
 
 	if setting.Protocol == setting.HTTPUnix {
-		req.SetTransport(&http.Transport{
-			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
-				var d net.Dialer
-				conn, err := d.DialContext(ctx, "unix", setting.HTTPAddr)
-				if err != nil {
-					return conn, err
-				}
-				if setting.LocalUseProxyProtocol {
-					if err = proxyprotocol.WriteLocalHeader(conn); err != nil {
-						_ = conn.Close()
-						return nil, err
-					}
-				}
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "unix", setting.HTTPAddr)
+			if err != nil {
 				return conn, err
-			},
-		})
-	} else if setting.LocalUseProxyProtocol {
-		req.SetTransport(&http.Transport{
-			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
-				var d net.Dialer
-				conn, err := d.DialContext(ctx, network, address)
-				if err != nil {
-					return conn, err
-				}
+			}
+			if setting.LocalUseProxyProtocol {
 				if err = proxyprotocol.WriteLocalHeader(conn); err != nil {
 					_ = conn.Close()
 					return nil, err
 				}
+			}
+			return conn, err
+		}
+	} else if setting.LocalUseProxyProtocol {
+		transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, network, address)
+			if err != nil {
 				return conn, err
-			},
-		})
+			}
+			if err = proxyprotocol.WriteLocalHeader(conn); err != nil {
+				_ = conn.Close()
+				return nil, err
+			}
+			return conn, err
+		}
+	} else {
+		transport.Proxy = http.ProxyFromEnvironment
+	}
+	return transport
+}
+
+func newInternalRequest(ctx context.Context, url, method string, body ...any) *httplib.Request {
+	if setting.InternalToken == "" {
+		log.Fatal(`The INTERNAL_TOKEN setting is missing from the configuration file: %q.
+Ensure you are running in the correct environment or set the correct configuration file with -c.`, setting.CustomConf)
 	}
 
+	req := httplib.NewRequest(url, method).
+		SetContext(ctx).
+		Header("X-Real-IP", getClientIP()).
+		Header("Authorization", fmt.Sprintf("Bearer %s", setting.InternalToken)).
+		SetTransport(NewLocalTransport())
+
 	if len(body) == 1 {
 		req.Header("Content-Type", "application/json")
 		jsonBytes, _ := json.Marshal(body[0])
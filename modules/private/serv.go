@@ -43,6 +43,8 @@ type ServCommandResults struct {
 	OwnerName   string
 	RepoName    string
 	RepoID      int64
+
+	IsPartialCloneEnabled bool
 }
 
 // ServCommand preps for a serv call
@@ -223,7 +223,7 @@ func generateRepoCommit(ctx context.Context, repo, templateRepo, generateRepo *r
 		}
 	}
 
-	if err := git.InitRepository(ctx, tmpDir, false); err != nil {
+	if err := git.InitRepository(ctx, tmpDir, false, repo.ObjectFormatName); err != nil {
 		return err
 	}
 
@@ -325,18 +325,20 @@ func (gro GenerateRepoOptions) IsValid() bool {
 // GenerateRepository generates a repository from a template
 func GenerateRepository(ctx context.Context, doer, owner *user_model.User, templateRepo *repo_model.Repository, opts GenerateRepoOptions) (_ *repo_model.Repository, err error) {
 	generateRepo := &repo_model.Repository{
-		OwnerID:       owner.ID,
-		Owner:         owner,
-		OwnerName:     owner.Name,
-		Name:          opts.Name,
-		LowerName:     strings.ToLower(opts.Name),
-		Description:   opts.Description,
-		DefaultBranch: opts.DefaultBranch,
-		IsPrivate:     opts.Private,
-		IsEmpty:       !opts.GitContent || templateRepo.IsEmpty,
-		IsFsckEnabled: templateRepo.IsFsckEnabled,
-		TemplateID:    templateRepo.ID,
-		TrustModel:    templateRepo.TrustModel,
+		OwnerID:               owner.ID,
+		Owner:                 owner,
+		OwnerName:             owner.Name,
+		Name:                  opts.Name,
+		LowerName:             strings.ToLower(opts.Name),
+		Description:           opts.Description,
+		DefaultBranch:         opts.DefaultBranch,
+		IsPrivate:             opts.Private,
+		IsEmpty:               !opts.GitContent || templateRepo.IsEmpty,
+		IsFsckEnabled:         templateRepo.IsFsckEnabled,
+		IsPartialCloneEnabled: templateRepo.IsPartialCloneEnabled,
+		TemplateID:            templateRepo.ID,
+		TrustModel:            templateRepo.TrustModel,
+		ObjectFormatName:      templateRepo.ObjectFormatName,
 	}
 
 	if err = CreateRepositoryByExample(ctx, doer, owner, generateRepo, false, false); err != nil {
@@ -356,7 +358,7 @@ func GenerateRepository(ctx context.Context, doer, owner *user_model.User, templ
 		}
 	}
 
-	if err = CheckInitRepository(ctx, owner.Name, generateRepo.Name); err != nil {
+	if err = CheckInitRepository(ctx, owner.Name, generateRepo.Name, generateRepo.ObjectFormatName); err != nil {
 		return generateRepo, err
 	}
 
@@ -134,6 +134,12 @@ func MigrateRepositoryGitData(ctx context.Context, u *user_model.User,
 	}
 	defer gitRepo.Close()
 
+	objectFormat, err := gitRepo.GetObjectFormat()
+	if err != nil {
+		return repo, fmt.Errorf("GetObjectFormat: %w", err)
+	}
+	repo.ObjectFormatName = objectFormat.Name()
+
 	repo.IsEmpty, err = gitRepo.IsEmpty()
 	if err != nil {
 		return repo, fmt.Errorf("git.IsEmpty: %w", err)
@@ -0,0 +1,63 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package setting
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Names of the stores which can be deduplicated
+const (
+	DedupStoreLFS         = "lfs"
+	DedupStoreAttachments = "attachments"
+	DedupStorePackages    = "packages"
+)
+
+// Dedup represents the configuration of the content-addressed deduplication
+var Dedup = struct {
+	Enabled bool
+	// Stores are the names of the stores whose objects are deduplicated
+	Stores  []string
+	Storage *Storage
+}{}
+
+// IsDedupStore returns whether the objects of the store are deduplicated
+func IsDedupStore(name string) bool {
+	if !Dedup.Enabled {
+		return false
+	}
+	for _, store := range Dedup.Stores {
+		if store == name {
+			return true
+		}
+	}
+	return false
+}
+
+func loadDedupFrom(rootCfg ConfigProvider) (err error) {
+	sec, _ := rootCfg.GetSection("dedup")
+	if sec == nil {
+		Dedup.Enabled = false
+		return nil
+	}
+	Dedup.Enabled = sec.Key("ENABLED").MustBool(false)
+
+	Dedup.Stores = make([]string, 0, 3)
+	for _, store := range sec.Key("STORES").Strings(",") {
+		store = strings.ToLower(store)
+		switch store {
+		case DedupStoreLFS, DedupStoreAttachments, DedupStorePackages:
+			Dedup.Stores = append(Dedup.Stores, store)
+		default:
+			return fmt.Errorf("unsupported dedup store %q", store)
+		}
+	}
+	if len(Dedup.Stores) == 0 {
+		Dedup.Stores = append(Dedup.Stores, DedupStoreLFS, DedupStoreAttachments, DedupStorePackages)
+	}
+
+	Dedup.Storage, err = getStorage(rootCfg, "dedup", "", sec)
+	return err
+}
@@ -8,6 +8,9 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
 )
 
 // StorageType is a type of Storage
@@ -18,14 +21,17 @@ const (
 	LocalStorageType StorageType = "local"
 	// MinioStorageType is the type descriptor for minio storage
 	MinioStorageType StorageType = "minio"
-	// HWStorageType is the type descriptor for hw storage
+	// HWCloudStorageType is the type descriptor for huawei cloud OBS storage
 	HWCloudStorageType StorageType = "hwcloud"
+	// ReplicatedStorageType is the type descriptor for a storage which replicates a primary storage to secondary storages
+	ReplicatedStorageType StorageType = "replicated"
 )
 
 var storageTypes = []StorageType{
 	LocalStorageType,
 	MinioStorageType,
 	HWCloudStorageType,
+	ReplicatedStorageType,
 }
 
 // IsValidStorageType returns true if the given storage type is valid
@@ -40,17 +46,18 @@ func IsValidStorageType(storageType StorageType) bool {
 
 // MinioStorageConfig represents the configuration for a minio storage
 type MinioStorageConfig struct {
-	Endpoint           string `ini:"MINIO_ENDPOINT" json:",omitempty"`
-	AccessKeyID        string `ini:"MINIO_ACCESS_KEY_ID" json:",omitempty"`
-	SecretAccessKey    string `ini:"MINIO_SECRET_ACCESS_KEY" json:",omitempty"`
-	Bucket             string `ini:"MINIO_BUCKET" json:",omitempty"`
-	Location           string `ini:"MINIO_LOCATION" json:",omitempty"`
-	BasePath           string `ini:"MINIO_BASE_PATH" json:",omitempty"`
-	BucketDomain       string `ini:"MINIO_BUCKET_DOMAIN" json:",omitempty"`
-	UseSSL             bool   `ini:"MINIO_USE_SSL"`
-	InsecureSkipVerify bool   `ini:"MINIO_INSECURE_SKIP_VERIFY"`
-	ChecksumAlgorithm  string `ini:"MINIO_CHECKSUM_ALGORITHM" json:",omitempty"`
-	ServeDirect        bool   `ini:"SERVE_DIRECT"`
+	Endpoint           string        `ini:"MINIO_ENDPOINT" json:",omitempty"`
+	AccessKeyID        string        `ini:"MINIO_ACCESS_KEY_ID" json:",omitempty"`
+	SecretAccessKey    string        `ini:"MINIO_SECRET_ACCESS_KEY" json:",omitempty"`
+	Bucket             string        `ini:"MINIO_BUCKET" json:",omitempty"`
+	Location           string        `ini:"MINIO_LOCATION" json:",omitempty"`
+	BasePath           string        `ini:"MINIO_BASE_PATH" json:",omitempty"`
+	BucketDomain       string        `ini:"MINIO_BUCKET_DOMAIN" json:",omitempty"`
+	UseSSL             bool          `ini:"MINIO_USE_SSL"`
+	InsecureSkipVerify bool          `ini:"MINIO_INSECURE_SKIP_VERIFY"`
+	ChecksumAlgorithm  string        `ini:"MINIO_CHECKSUM_ALGORITHM" json:",omitempty"`
+	ServeDirect        bool          `ini:"SERVE_DIRECT"`
+	ServeDirectExpiry  time.Duration `ini:"SERVE_DIRECT_EXPIRY" json:",omitempty"` // zero means the default of the storage type
 }
 
 // Storage represents configuration of storages
@@ -59,6 +66,21 @@ type Storage struct {
 	Path          string             `json:",omitempty"` // for local type
 	TemporaryPath string             `json:",omitempty"`
 	MinioConfig   MinioStorageConfig // for minio type
+
+	// MultipartChunkSize is the size of the parts of multipart uploads, it is scaled up for objects which would
+	// exceed 10,000 parts. MultipartExpiry is how long the part endpoints are valid. Zero means the default.
+	MultipartChunkSize int64         `json:",omitempty"`
+	MultipartExpiry    time.Duration `json:",omitempty"`
+
+	Replication *ReplicationConfig `json:",omitempty"` // for replicated type
+}
+
+// ReplicationConfig represents the configuration for a replicated storage
+type ReplicationConfig struct {
+	Primary     *Storage
+	Secondaries []*Storage
+	// QueueLength is the number of pending replications, changes which don't fit are replicated synchronously after a timeout
+	QueueLength int
 }
 
 func (storage *Storage) ToShadowCopy() Storage {
@@ -69,6 +91,17 @@ func (storage *Storage) ToShadowCopy() Storage {
 	if shadowStorage.MinioConfig.SecretAccessKey != "" {
 		shadowStorage.MinioConfig.SecretAccessKey = "******"
 	}
+	if storage.Replication != nil {
+		replication := *storage.Replication
+		primary := replication.Primary.ToShadowCopy()
+		replication.Primary = &primary
+		replication.Secondaries = make([]*Storage, 0, len(storage.Replication.Secondaries))
+		for _, secondary := range storage.Replication.Secondaries {
+			shadowSecondary := secondary.ToShadowCopy()
+			replication.Secondaries = append(replication.Secondaries, &shadowSecondary)
+		}
+		shadowStorage.Replication = &replication
+	}
 	return shadowStorage
 }
 
@@ -103,15 +136,38 @@ func getStorage(rootCfg ConfigProvider, name, typ string, sec ConfigSection) (*S
 
 	overrideSec := getStorageOverrideSection(rootCfg, targetSec, sec, tp, name)
 
+	var storage *Storage
 	targetType := targetSec.Key("STORAGE_TYPE").String()
 	switch targetType {
 	case string(LocalStorageType):
-		return getStorageForLocal(targetSec, overrideSec, tp, name)
-	case string(MinioStorageType):
-		return getStorageForMinio(targetSec, overrideSec, tp, name)
+		storage, err = getStorageForLocal(targetSec, overrideSec, tp, name)
+	case string(MinioStorageType), string(HWCloudStorageType):
+		storage, err = getStorageForMinio(targetSec, overrideSec, tp, name)
+	case string(ReplicatedStorageType):
+		storage, err = getStorageForReplicated(rootCfg, targetSec, name)
 	default:
 		return nil, fmt.Errorf("unsupported storage type %q", targetType)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	loadMultipartSettings(storage, targetSec, overrideSec)
+	return storage, nil
+}
+
+// loadMultipartSettings reads the settings of multipart uploads, the override section takes precedence
+func loadMultipartSettings(storage *Storage, targetSec, overrideSec ConfigSection) {
+	storage.MultipartChunkSize = targetSec.Key("MULTIPART_CHUNK_SIZE").MustInt64(0)
+	storage.MultipartExpiry = targetSec.Key("MULTIPART_EXPIRY").MustDuration(0)
+	if overrideSec != nil {
+		storage.MultipartChunkSize = overrideSec.Key("MULTIPART_CHUNK_SIZE").MustInt64(storage.MultipartChunkSize)
+		storage.MultipartExpiry = overrideSec.Key("MULTIPART_EXPIRY").MustDuration(storage.MultipartExpiry)
+	}
+	if storage.MultipartChunkSize < 0 {
+		log.Warn("Invalid MULTIPART_CHUNK_SIZE %d for %s storage, the default is used", storage.MultipartChunkSize, storage.Type)
+		storage.MultipartChunkSize = 0
+	}
 }
 
 type targetSecType int
@@ -268,6 +324,8 @@ func getStorageForMinio(targetSec, overrideSec ConfigSection, tp targetSecType,
 
 	if overrideSec != nil {
 		storage.MinioConfig.ServeDirect = ConfigSectionKeyBool(overrideSec, "SERVE_DIRECT", storage.MinioConfig.ServeDirect)
+		storage.MinioConfig.ServeDirectExpiry = overrideSec.Key("SERVE_DIRECT_EXPIRY").MustDuration(storage.MinioConfig.ServeDirectExpiry)
+		storage.MinioConfig.BucketDomain = ConfigSectionKeyString(overrideSec, "MINIO_BUCKET_DOMAIN", storage.MinioConfig.BucketDomain)
 		storage.MinioConfig.BasePath = ConfigSectionKeyString(overrideSec, "MINIO_BASE_PATH", defaultPath)
 		storage.MinioConfig.Bucket = ConfigSectionKeyString(overrideSec, "MINIO_BUCKET", storage.MinioConfig.Bucket)
 	} else {
@@ -275,3 +333,50 @@ func getStorageForMinio(targetSec, overrideSec ConfigSection, tp targetSecType,
 	}
 	return &storage, nil
 }
+
+// getStorageForReplicated reads the storages referenced by REPLICATION_PRIMARY and REPLICATION_SECONDARIES,
+// which are the names of [storage.xxx] sections, e.g.
+//
+//	[storage.lfs-replicated]
+//	STORAGE_TYPE = replicated
+//	REPLICATION_PRIMARY = lfs-local
+//	REPLICATION_SECONDARIES = lfs-obs
+func getStorageForReplicated(rootCfg ConfigProvider, targetSec ConfigSection, name string) (*Storage, error) {
+	getReplica := func(ref string) (*Storage, error) {
+		sec, err := rootCfg.GetSection(storageSectionName + "." + ref)
+		if err != nil {
+			return nil, fmt.Errorf("storage section %q of the replicated %s storage not found", ref, name)
+		}
+		// a nested replicated storage could reference itself
+		if StorageType(sec.Key("STORAGE_TYPE").String()) == ReplicatedStorageType {
+			return nil, fmt.Errorf("storage %q of the replicated %s storage can't be replicated itself", ref, name)
+		}
+		return getStorage(rootCfg, name, ref, nil)
+	}
+
+	replication := ReplicationConfig{
+		QueueLength: targetSec.Key("REPLICATION_QUEUE_LENGTH").MustInt(1000),
+	}
+
+	primary := ConfigSectionKeyString(targetSec, "REPLICATION_PRIMARY")
+	if primary == "" {
+		return nil, fmt.Errorf("no REPLICATION_PRIMARY for the replicated %s storage", name)
+	}
+	var err error
+	if replication.Primary, err = getReplica(primary); err != nil {
+		return nil, err
+	}
+
+	for _, secondary := range targetSec.Key("REPLICATION_SECONDARIES").Strings(",") {
+		storage, err := getReplica(secondary)
+		if err != nil {
+			return nil, err
+		}
+		replication.Secondaries = append(replication.Secondaries, storage)
+	}
+	if len(replication.Secondaries) == 0 {
+		return nil, fmt.Errorf("no REPLICATION_SECONDARIES for the replicated %s storage", name)
+	}
+
+	return &Storage{Type: ReplicatedStorageType, Replication: &replication}, nil
+}
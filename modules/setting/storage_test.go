@@ -6,6 +6,7 @@ package setting
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -465,3 +466,101 @@ MINIO_BASE_PATH = /lfs
 	assert.EqualValues(t, true, LFS.Storage.MinioConfig.UseSSL)
 	assert.EqualValues(t, "/lfs", LFS.Storage.MinioConfig.BasePath)
 }
+
+func Test_getStorageConfigurationHWCloud(t *testing.T) {
+	cfg, err := NewConfigProviderFromData(`
+[storage]
+STORAGE_TYPE = hwcloud
+MINIO_ENDPOINT = obs.example.com
+MINIO_BUCKET = gitea
+
+[lfs]
+SERVE_DIRECT = true
+SERVE_DIRECT_EXPIRY = 30m
+MINIO_BUCKET_DOMAIN = cdn.example.com
+`)
+	assert.NoError(t, err)
+	assert.NoError(t, loadLFSFrom(cfg))
+	assert.EqualValues(t, "hwcloud", LFS.Storage.Type)
+	assert.True(t, LFS.Storage.MinioConfig.ServeDirect)
+	assert.EqualValues(t, 30*time.Minute, LFS.Storage.MinioConfig.ServeDirectExpiry)
+	assert.EqualValues(t, "cdn.example.com", LFS.Storage.MinioConfig.BucketDomain)
+	assert.EqualValues(t, "lfs/", LFS.Storage.MinioConfig.BasePath)
+}
+
+func Test_getStorageMultipartSettings(t *testing.T) {
+	cfg, err := NewConfigProviderFromData(`
+[storage]
+MULTIPART_CHUNK_SIZE = 10000000
+
+[lfs]
+MULTIPART_EXPIRY = 1h
+`)
+	assert.NoError(t, err)
+	assert.NoError(t, loadLFSFrom(cfg))
+	assert.EqualValues(t, 10000000, LFS.Storage.MultipartChunkSize)
+	assert.EqualValues(t, time.Hour, LFS.Storage.MultipartExpiry)
+
+	cfg, err = NewConfigProviderFromData(`
+[lfs]
+MULTIPART_CHUNK_SIZE = -1
+`)
+	assert.NoError(t, err)
+	assert.NoError(t, loadLFSFrom(cfg))
+	assert.EqualValues(t, 0, LFS.Storage.MultipartChunkSize)
+	assert.EqualValues(t, 0, LFS.Storage.MultipartExpiry)
+}
+
+func Test_getStorageReplicated(t *testing.T) {
+	iniStr := `
+[lfs]
+STORAGE_TYPE = lfs-replicated
+
+[storage.lfs-replicated]
+STORAGE_TYPE = replicated
+REPLICATION_PRIMARY = lfs-local
+REPLICATION_SECONDARIES = lfs-minio, lfs-obs
+
+[storage.lfs-local]
+STORAGE_TYPE = local
+PATH = /data/lfs
+
+[storage.lfs-minio]
+STORAGE_TYPE = minio
+MINIO_BUCKET = gitea-lfs
+MINIO_SECRET_ACCESS_KEY = secret
+
+[storage.lfs-obs]
+STORAGE_TYPE = hwcloud
+MINIO_BUCKET = gitea-lfs-backup
+`
+	cfg, err := NewConfigProviderFromData(iniStr)
+	assert.NoError(t, err)
+	assert.NoError(t, loadLFSFrom(cfg))
+
+	assert.EqualValues(t, ReplicatedStorageType, LFS.Storage.Type)
+	replication := LFS.Storage.Replication
+	assert.EqualValues(t, 1000, replication.QueueLength)
+	assert.EqualValues(t, LocalStorageType, replication.Primary.Type)
+	assert.EqualValues(t, "/data/lfs", replication.Primary.Path)
+	if assert.Len(t, replication.Secondaries, 2) {
+		assert.EqualValues(t, MinioStorageType, replication.Secondaries[0].Type)
+		assert.EqualValues(t, "gitea-lfs", replication.Secondaries[0].MinioConfig.Bucket)
+		assert.EqualValues(t, HWCloudStorageType, replication.Secondaries[1].Type)
+		assert.EqualValues(t, "gitea-lfs-backup", replication.Secondaries[1].MinioConfig.Bucket)
+	}
+
+	shadow := LFS.Storage.ToShadowCopy()
+	assert.EqualValues(t, "******", shadow.Replication.Secondaries[0].MinioConfig.SecretAccessKey)
+	assert.EqualValues(t, "secret", replication.Secondaries[0].MinioConfig.SecretAccessKey)
+
+	iniStr = `
+[storage.lfs]
+STORAGE_TYPE = replicated
+REPLICATION_PRIMARY = lfs
+REPLICATION_SECONDARIES = lfs
+`
+	cfg, err = NewConfigProviderFromData(iniStr)
+	assert.NoError(t, err)
+	assert.Error(t, loadLFSFrom(cfg))
+}
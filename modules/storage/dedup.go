@@ -0,0 +1,305 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/util/filebuffer"
+)
+
+// DedupIndex keeps the references of the paths of the deduplicated stores to their content
+type DedupIndex interface {
+	// Lookup returns the hash and size of the content referenced by the path of the store, os.ErrNotExist if there's no reference
+	Lookup(ctx context.Context, store, path string) (hash string, size int64, err error)
+	// AddReference references the content from the path of the store, replacing the previous reference of the path.
+	// It returns the hash of the content which isn't referenced anymore.
+	AddReference(ctx context.Context, store, path, hash string, size int64) (unreferenced string, err error)
+	// RemoveReference removes the reference of the path of the store, os.ErrNotExist if there's no reference.
+	// It returns the hash of the content which isn't referenced anymore.
+	RemoveReference(ctx context.Context, store, path string) (unreferenced string, err error)
+	// IsReferenced returns whether the content is referenced by any path
+	IsReferenced(ctx context.Context, hash string) (bool, error)
+	// IterateReferences iterates across the references of the store below the path prefix
+	IterateReferences(ctx context.Context, store, prefix string, fn func(path, hash string) error) error
+}
+
+var dedupIndex DedupIndex
+
+// RegisterDedupIndex registers the index of the deduplicated storages
+func RegisterDedupIndex(index DedupIndex) {
+	dedupIndex = index
+}
+
+// dedupMemoryBufferSize is the size of the content which is hashed in memory, larger contents are buffered in a temporary file
+const dedupMemoryBufferSize = 32 * 1024 * 1024
+
+var _ ObjectStorage = &DedupStorage{}
+
+// DedupStorage stores the objects of a store once per content in a shared storage, keyed by their SHA-256 hash.
+// The content is deleted when the last path referencing it is deleted.
+// Objects stored before the deduplication was enabled are read from the legacy storage until they are migrated.
+// Multipart uploads are committed to the legacy storage and moved into the deduplicated storage afterwards.
+type DedupStorage struct {
+	ctx    context.Context
+	store  string
+	blobs  ObjectStorage
+	legacy ObjectStorage
+}
+
+// NewDedupStorage returns a deduplicated storage of the store
+func NewDedupStorage(ctx context.Context, store string, blobs, legacy ObjectStorage) (*DedupStorage, error) {
+	if dedupIndex == nil {
+		return nil, errors.New("no dedup index registered")
+	}
+	return &DedupStorage{ctx: ctx, store: store, blobs: blobs, legacy: legacy}, nil
+}
+
+// dedupBlobPath converts the sha256 hash aabb000000... to aa/bb/aabb000000...
+func dedupBlobPath(hash string) string {
+	return path.Join(hash[0:2], hash[2:4], hash)
+}
+
+func dedupCleanPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(p, "\\", "/")), "/")
+}
+
+type dedupObject struct {
+	Object
+	name string
+}
+
+func (o *dedupObject) Stat() (os.FileInfo, error) {
+	info, err := o.Object.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &dedupFileInfo{info, o.name}, nil
+}
+
+// dedupFileInfo reports the name of the path instead of the hash
+type dedupFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (i *dedupFileInfo) Name() string {
+	return i.name
+}
+
+// Open opens the content referenced by the path
+func (d *DedupStorage) Open(p string) (Object, error) {
+	p = dedupCleanPath(p)
+	hash, _, err := dedupIndex.Lookup(d.ctx, d.store, p)
+	if errors.Is(err, os.ErrNotExist) {
+		return d.legacy.Open(p)
+	} else if err != nil {
+		return nil, err
+	}
+	obj, err := d.blobs.Open(dedupBlobPath(hash))
+	if err != nil {
+		return nil, err
+	}
+	return &dedupObject{obj, path.Base(p)}, nil
+}
+
+// Save hashes the content, stores it if it isn't stored yet and references it from the path
+func (d *DedupStorage) Save(p string, r io.Reader, size int64) (int64, error) {
+	p = dedupCleanPath(p)
+	written, _, err := d.save(p, r)
+	if err != nil {
+		return 0, err
+	}
+	d.deleteLegacy(p)
+	return written, nil
+}
+
+// save stores the content and returns whether it was stored already
+func (d *DedupStorage) save(p string, r io.Reader) (int64, bool, error) {
+	buf, err := filebuffer.New(dedupMemoryBufferSize)
+	if err != nil {
+		return 0, false, err
+	}
+	defer buf.Close()
+
+	h := sha256.New()
+	written, err := io.Copy(io.MultiWriter(buf, h), r)
+	if err != nil {
+		return 0, false, err
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	unreferenced, err := dedupIndex.AddReference(d.ctx, d.store, p, hash, written)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// the content may be stored already, but the blob could also have been unreferenced concurrently
+	exists := true
+	if _, err := d.blobs.Stat(dedupBlobPath(hash)); errors.Is(err, os.ErrNotExist) {
+		exists = false
+		if _, err := d.blobs.Save(dedupBlobPath(hash), buf, written); err != nil {
+			if _, err := dedupIndex.RemoveReference(d.ctx, d.store, p); err != nil {
+				log.Error("Unable to remove the reference of %s from %s: %v", p, d.store, err)
+			}
+			return 0, false, err
+		}
+	} else if err != nil {
+		return 0, false, err
+	}
+
+	if unreferenced != "" && unreferenced != hash {
+		d.deleteBlob(unreferenced)
+	}
+	return written, exists, nil
+}
+
+// deleteLegacy deletes the legacy object which has been replaced by the deduplicated one
+func (d *DedupStorage) deleteLegacy(p string) {
+	if err := d.legacy.Delete(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warn("Unable to delete the legacy object %s from %s: %v", p, d.store, err)
+	}
+}
+
+// deleteBlob deletes the content if it's still unreferenced, it could have been referenced again in the meantime
+func (d *DedupStorage) deleteBlob(hash string) {
+	if referenced, err := dedupIndex.IsReferenced(d.ctx, hash); err != nil || referenced {
+		return
+	}
+	if err := d.blobs.Delete(dedupBlobPath(hash)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Error("Unable to delete the unreferenced content %s: %v", hash, err)
+	}
+}
+
+// Stat returns the stat information of the content referenced by the path
+func (d *DedupStorage) Stat(p string) (os.FileInfo, error) {
+	p = dedupCleanPath(p)
+	hash, _, err := dedupIndex.Lookup(d.ctx, d.store, p)
+	if errors.Is(err, os.ErrNotExist) {
+		return d.legacy.Stat(p)
+	} else if err != nil {
+		return nil, err
+	}
+	info, err := d.blobs.Stat(dedupBlobPath(hash))
+	if err != nil {
+		return nil, err
+	}
+	return &dedupFileInfo{info, path.Base(p)}, nil
+}
+
+// Delete removes the reference of the path, the content is deleted when it isn't referenced anymore
+func (d *DedupStorage) Delete(p string) error {
+	p = dedupCleanPath(p)
+	unreferenced, err := dedupIndex.RemoveReference(d.ctx, d.store, p)
+	if errors.Is(err, os.ErrNotExist) {
+		return d.legacy.Delete(p)
+	} else if err != nil {
+		return err
+	}
+	if unreferenced != "" {
+		d.deleteBlob(unreferenced)
+	}
+	return nil
+}
+
+// URL gets the redirect URL to the content referenced by the path
+func (d *DedupStorage) URL(p, name string) (*url.URL, error) {
+	p = dedupCleanPath(p)
+	hash, _, err := dedupIndex.Lookup(d.ctx, d.store, p)
+	if errors.Is(err, os.ErrNotExist) {
+		return d.legacy.URL(p, name)
+	} else if err != nil {
+		return nil, err
+	}
+	return d.blobs.URL(dedupBlobPath(hash), name)
+}
+
+// IterateObjects iterates across the referenced objects and the legacy objects which haven't been migrated
+func (d *DedupStorage) IterateObjects(dirName string, fn func(path string, obj Object) error) error {
+	if err := dedupIndex.IterateReferences(d.ctx, d.store, dedupCleanPath(dirName), func(p, hash string) error {
+		obj, err := d.blobs.Open(dedupBlobPath(hash))
+		if err != nil {
+			return err
+		}
+		defer obj.Close()
+		return fn(p, &dedupObject{obj, path.Base(p)})
+	}); err != nil {
+		return err
+	}
+	// the directory may only exist in the deduplicated storage
+	if err := d.legacy.IterateObjects(dirName, fn); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// DedupMigrateResult holds the statistics of the migration of legacy objects
+type DedupMigrateResult struct {
+	Objects      int
+	Size         int64
+	Deduplicated int
+	SavedSize    int64
+}
+
+// MigrateLegacyObjects moves the objects of the legacy storage into the deduplicated storage
+func (d *DedupStorage) MigrateLegacyObjects(ctx context.Context, logger func(format string, args ...any)) (*DedupMigrateResult, error) {
+	result := &DedupMigrateResult{}
+	var paths []string
+	if err := d.legacy.IterateObjects("", func(p string, obj Object) error {
+		paths = append(paths, p)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		written, deduplicated, err := d.migrateLegacyObject(p)
+		if err != nil {
+			return result, fmt.Errorf("migrate %s: %w", p, err)
+		}
+		result.Objects++
+		result.Size += written
+		if deduplicated {
+			result.Deduplicated++
+			result.SavedSize += written
+			if logger != nil {
+				logger("Deduplicated %s of %s", p, d.store)
+			}
+		}
+	}
+	return result, nil
+}
+
+// takeOverUpload moves an object which has been uploaded in parts to the legacy storage into the deduplicated storage
+func (d *DedupStorage) takeOverUpload(p string) error {
+	_, _, err := d.migrateLegacyObject(dedupCleanPath(p))
+	return err
+}
+
+func (d *DedupStorage) migrateLegacyObject(p string) (int64, bool, error) {
+	obj, err := d.legacy.Open(p)
+	if err != nil {
+		return 0, false, err
+	}
+	written, deduplicated, err := d.save(p, obj)
+	_ = obj.Close()
+	if err != nil {
+		return 0, false, err
+	}
+	d.deleteLegacy(p)
+	return written, deduplicated, nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryDedupIndex struct {
+	refs  map[string]string // store + path -> hash
+	count map[string]int
+}
+
+func (m *memoryDedupIndex) Lookup(_ context.Context, store, path string) (string, int64, error) {
+	hash, ok := m.refs[store+":"+path]
+	if !ok {
+		return "", 0, os.ErrNotExist
+	}
+	return hash, 0, nil
+}
+
+func (m *memoryDedupIndex) release(hash string) string {
+	m.count[hash]--
+	if m.count[hash] > 0 {
+		return ""
+	}
+	delete(m.count, hash)
+	return hash
+}
+
+func (m *memoryDedupIndex) AddReference(_ context.Context, store, path, hash string, _ int64) (string, error) {
+	m.count[hash]++
+	old, ok := m.refs[store+":"+path]
+	m.refs[store+":"+path] = hash
+	if !ok {
+		return "", nil
+	}
+	return m.release(old), nil
+}
+
+func (m *memoryDedupIndex) RemoveReference(_ context.Context, store, path string) (string, error) {
+	hash, ok := m.refs[store+":"+path]
+	if !ok {
+		return "", os.ErrNotExist
+	}
+	delete(m.refs, store+":"+path)
+	return m.release(hash), nil
+}
+
+func (m *memoryDedupIndex) IsReferenced(_ context.Context, hash string) (bool, error) {
+	return m.count[hash] > 0, nil
+}
+
+func (m *memoryDedupIndex) IterateReferences(_ context.Context, store, prefix string, fn func(path, hash string) error) error {
+	keys := make([]string, 0, len(m.refs))
+	for key := range m.refs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s, p, _ := strings.Cut(key, ":")
+		if s != store || (prefix != "" && !strings.HasPrefix(p, prefix+"/")) {
+			continue
+		}
+		if err := fn(p, m.refs[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func countObjects(t *testing.T, s ObjectStorage) int {
+	count := 0
+	assert.NoError(t, s.IterateObjects("", func(path string, obj Object) error {
+		count++
+		return nil
+	}))
+	return count
+}
+
+func TestDedupStorage(t *testing.T) {
+	oldIndex := dedupIndex
+	defer func() { dedupIndex = oldIndex }()
+	RegisterDedupIndex(&memoryDedupIndex{refs: map[string]string{}, count: map[string]int{}})
+
+	blobs, err := NewLocalStorage(context.Background(), &setting.Storage{Path: t.TempDir()})
+	assert.NoError(t, err)
+	legacyLFS, err := NewLocalStorage(context.Background(), &setting.Storage{Path: t.TempDir()})
+	assert.NoError(t, err)
+	legacyAttachments, err := NewLocalStorage(context.Background(), &setting.Storage{Path: t.TempDir()})
+	assert.NoError(t, err)
+
+	_, err = legacyLFS.Save("legacy/model.bin", strings.NewReader("model"), -1)
+	assert.NoError(t, err)
+
+	lfs, err := NewDedupStorage(context.Background(), "lfs", blobs, legacyLFS)
+	assert.NoError(t, err)
+	attachments, err := NewDedupStorage(context.Background(), "attachments", blobs, legacyAttachments)
+	assert.NoError(t, err)
+
+	// legacy objects are readable before the migration
+	info, err := lfs.Stat("legacy/model.bin")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, info.Size())
+
+	_, err = attachments.Save("a/b/uuid", strings.NewReader("model"), -1)
+	assert.NoError(t, err)
+	_, err = attachments.Save("c/d/uuid2", strings.NewReader("other"), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, countObjects(t, blobs))
+
+	result, err := lfs.MigrateLegacyObjects(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &DedupMigrateResult{Objects: 1, Size: 5, Deduplicated: 1, SavedSize: 5}, result)
+	assert.Equal(t, 0, countObjects(t, legacyLFS))
+	assert.Equal(t, 2, countObjects(t, blobs))
+
+	obj, err := lfs.Open("legacy/model.bin")
+	assert.NoError(t, err)
+	content, err := io.ReadAll(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "model", string(content))
+	info, err = obj.Stat()
+	assert.NoError(t, err)
+	assert.Equal(t, "model.bin", info.Name())
+	assert.NoError(t, obj.Close())
+
+	var paths []string
+	assert.NoError(t, attachments.IterateObjects("a", func(path string, obj Object) error {
+		paths = append(paths, path)
+		return nil
+	}))
+	assert.Equal(t, []string{"a/b/uuid"}, paths)
+
+	// the content is deleted with the last reference
+	assert.NoError(t, attachments.Delete("a/b/uuid"))
+	assert.Equal(t, 2, countObjects(t, blobs))
+	assert.NoError(t, lfs.Delete("legacy/model.bin"))
+	assert.Equal(t, 1, countObjects(t, blobs))
+
+	// overwriting a path releases the previous content
+	_, err = attachments.Save("c/d/uuid2", strings.NewReader("changed"), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, countObjects(t, blobs))
+	_, err = attachments.Stat("a/b/uuid")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestDedupStorageMultipart(t *testing.T) {
+	oldIndex := dedupIndex
+	defer func() { dedupIndex = oldIndex }()
+	RegisterDedupIndex(&memoryDedupIndex{refs: map[string]string{}, count: map[string]int{}})
+	setting.LFS.JWTSecretBytes = []byte("secret")
+
+	blobs, err := NewLocalStorage(context.Background(), &setting.Storage{Path: t.TempDir()})
+	assert.NoError(t, err)
+	legacy, err := NewLocalStorage(context.Background(), &setting.Storage{Path: t.TempDir()})
+	assert.NoError(t, err)
+	dedup, err := NewDedupStorage(context.Background(), "lfs", blobs, legacy)
+	assert.NoError(t, err)
+
+	// the multipart capability of the legacy storage is kept
+	s := withMultipart(dedup, legacy, dedup.takeOverUpload)
+	multipart, ok := s.(MultipartStorage)
+	assert.True(t, ok)
+	receiver, ok := s.(MultipartPartReceiver)
+	assert.True(t, ok)
+	assert.Equal(t, dedup, Unwrap(s))
+
+	parts, _, verify, err := multipart.GenerateMultipartParts("ab/cd/object", 10)
+	assert.NoError(t, err)
+	etag, err := receiver.UploadPart("ab/cd/object", 1, mustParseQuery(t, parts[0].Href), strings.NewReader("0123456789"))
+	assert.NoError(t, err)
+	assert.NoError(t, multipart.CommitUpload("ab/cd/object", `{"upload_id":"`+(*verify.Params)["upload_id"]+`","part_ids":[{"index":1,"etag":"`+etag+`"}]}`))
+
+	// the committed object is moved into the deduplicated storage
+	assert.Equal(t, 0, countObjects(t, legacy))
+	assert.Equal(t, 1, countObjects(t, blobs))
+	obj, err := s.Open("ab/cd/object")
+	assert.NoError(t, err)
+	content, err := io.ReadAll(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789", string(content))
+	assert.NoError(t, obj.Close())
+}
@@ -4,7 +4,6 @@
 package storage
 
 import (
-	"code.gitea.io/gitea/modules/structs"
 	"fmt"
 	"io"
 	"net/url"
@@ -15,14 +14,6 @@ var uninitializedStorage = discardStorage("uninitialized storage")
 
 type discardStorage string
 
-func (s discardStorage) GenerateMultipartParts(path string, size int64) (parts []*structs.MultipartObjectPart, abort *structs.MultipartEndpoint, verify *structs.MultipartEndpoint, err error) {
-	return nil, nil, nil, fmt.Errorf("%s", s)
-}
-
-func (s discardStorage) CommitUpload(path, additionalParameter string) error {
-	return fmt.Errorf("%s", s)
-}
-
 func (s discardStorage) Open(_ string) (Object, error) {
 	return nil, fmt.Errorf("%s", s)
 }
@@ -1,32 +1,20 @@
 package storage
 
 import (
-	"code.gitea.io/gitea/modules/log"
-	"code.gitea.io/gitea/modules/setting"
-	"code.gitea.io/gitea/modules/structs"
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
-)
-
-const multipart_chunk_size int64 = 20000000
-const default_expire int = 7200
+	"time"
 
-type MultipartPartID struct {
-	Etag  string `json:"etag"`
-	Index int    `json:"index"`
-}
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
 
-type MultiPartCommitUpload struct {
-	UploadID string            `json:"upload_id"`
-	PartIDs  []MultipartPartID `json:"part_ids"`
-}
+	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+)
 
 // NewHWCloudStorage returns a hwcloud storage
 func NewHWCloudStorage(ctx context.Context, cfg *setting.Storage) (ObjectStorage, error) {
@@ -49,6 +37,8 @@ func NewHWCloudStorage(ctx context.Context, cfg *setting.Storage) (ObjectStorage
 	}, nil
 }
 
+var _ MultipartStorage = &HWCloudStorage{}
+
 type HWCloudStorage struct {
 	hwclient     *obs.ObsClient
 	bucketDomain string
@@ -114,91 +104,47 @@ func (hwc *HWCloudStorage) GenerateMultipartParts(path string, size int64) (part
 		}
 	}
 	//generate part
-	currentPart := int64(0)
-	for {
-		if currentPart*multipart_chunk_size >= size {
-			break
-		}
-		partSize := size - currentPart*multipart_chunk_size
-		if partSize > multipart_chunk_size {
-			partSize = multipart_chunk_size
-		}
+	parts = splitMultipartParts(size, hwc.multipart.partSize(size))
+	for _, part := range parts {
 		//check part exists and length matches
-		if value, existed := taskParts[currentPart+1]; existed {
-			if value.Size == partSize {
-				log.Trace("lfs[multipart] Found existing part %d for multipart task %s and %s, will add etag information", currentPart+1, hwc.bucket, objectKey)
-				var part = &structs.MultipartObjectPart{
-					Index:             int(currentPart) + 1,
-					Pos:               currentPart * multipart_chunk_size,
-					Size:              partSize,
-					Etag:              strings.Trim(value.ETag, "\""),
-					MultipartEndpoint: nil,
-				}
-				parts = append(parts, part)
-				currentPart += 1
+		if value, existed := taskParts[int64(part.Index)]; existed {
+			if value.Size == part.Size {
+				log.Trace("lfs[multipart] Found existing part %d for multipart task %s and %s, will add etag information", part.Index, hwc.bucket, objectKey)
+				part.Etag = strings.Trim(value.ETag, "\"")
 				continue
-			} else {
-				log.Trace("lfs[multipart] Found existing part %d while size not matched for multipart task %s and %s", currentPart+1, hwc.bucket, objectKey)
 			}
+			log.Trace("lfs[multipart] Found existing part %d while size not matched for multipart task %s and %s", part.Index, hwc.bucket, objectKey)
 		}
 		request := obs.CreateSignedUrlInput{
 			Method:  obs.HttpMethodPut,
 			Bucket:  hwc.bucket,
 			Key:     objectKey,
-			Expires: default_expire,
+			Expires: hwc.multipart.expiresIn(),
 			QueryParams: map[string]string{
-				"partNumber": strconv.FormatInt(currentPart+1, 10),
+				"partNumber": strconv.Itoa(part.Index),
 				"uploadId":   uploadID,
 			},
 		}
-		result, errorMessage := hwc.hwclient.CreateSignedUrl(&request)
-		if errorMessage != nil {
+		result, err := hwc.hwclient.CreateSignedUrl(&request)
+		if err != nil {
 			return nil, nil, nil, err
 		}
-		var part = &structs.MultipartObjectPart{
-			Index: int(currentPart) + 1,
-			Pos:   currentPart * multipart_chunk_size,
-			Size:  partSize,
-			MultipartEndpoint: &structs.MultipartEndpoint{
-				ExpiresIn:         default_expire,
-				Href:              result.SignedUrl,
-				Method:            http.MethodPut,
-				Headers:           nil,
-				Params:            nil,
-				AggregationParams: nil,
-			},
+		part.MultipartEndpoint = &structs.MultipartEndpoint{
+			ExpiresIn: hwc.multipart.expiresIn(),
+			Href:      result.SignedUrl,
+			Method:    http.MethodPut,
 		}
-		parts = append(parts, part)
-		currentPart += 1
 	}
 	//generate abort
 	//TODO
-	//generate verify
-	verify = &structs.MultipartEndpoint{
-		Params: &map[string]string{
-			"upload_id": uploadID,
-		},
-		AggregationParams: &map[string]string{
-			"key":  "part_ids",
-			"type": "array",
-			"item": "index,etag",
-		},
-	}
-	return parts, nil, verify, nil
+	return parts, nil, newMultipartVerifyEndpoint(uploadID), nil
 }
 
 func (hwc *HWCloudStorage) CommitUpload(path, additionalParameter string) error {
-	var param MultiPartCommitUpload
-	err := json.Unmarshal([]byte(additionalParameter), &param)
+	param, err := parseMultipartCommitUpload(additionalParameter)
 	if err != nil {
-		log.Error("lfs[multipart] unable to decode additional parameter", additionalParameter)
 		return err
 	}
-	if len(param.UploadID) == 0 || len(param.PartIDs) == 0 {
-		log.Error("lfs[multipart] failed to commit objects, parameter is empty %v", param)
-		return errors.New("parameter is empty")
-	}
-	log.Trace("lfs[multipart] start to commit upload object %v", param)
 	//merge multipart
 	parts := make([]obs.Part, 0, len(param.PartIDs))
 	for _, p := range param.PartIDs {
@@ -219,31 +165,95 @@ func (hwc *HWCloudStorage) CommitUpload(path, additionalParameter string) error
 
 }
 
-// URL gets the redirect URL to a file. The presigned link is valid for 5 minutes.
+// IterateMultipartUploads iterates across the unfinished multipart uploads in the bucket
+func (hwc *HWCloudStorage) IterateMultipartUploads(fn func(upload *MultipartUpload) error) error {
+	prefix := hwc.buildMinioDirPrefix("")
+	input := &obs.ListMultipartUploadsInput{
+		Bucket:     hwc.bucket,
+		Prefix:     prefix,
+		MaxUploads: 1000,
+	}
+	for {
+		result, err := hwc.hwclient.ListMultipartUploads(input)
+		if err != nil {
+			return err
+		}
+		for _, task := range result.Uploads {
+			upload := &MultipartUpload{
+				Path:      strings.TrimPrefix(task.Key, prefix),
+				UploadID:  task.UploadId,
+				Initiated: task.Initiated,
+			}
+			partInput := &obs.ListPartsInput{
+				Bucket:   hwc.bucket,
+				Key:      task.Key,
+				UploadId: task.UploadId,
+				MaxParts: 1000,
+			}
+			for {
+				parts, err := hwc.hwclient.ListParts(partInput)
+				if err != nil {
+					return err
+				}
+				for _, part := range parts.Parts {
+					upload.Size += part.Size
+				}
+				if !parts.IsTruncated {
+					break
+				}
+				partInput.PartNumberMarker = parts.NextPartNumberMarker
+			}
+			if err := fn(upload); err != nil {
+				return err
+			}
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		input.KeyMarker, input.UploadIdMarker = result.NextKeyMarker, result.NextUploadIdMarker
+	}
+}
+
+// AbortMultipartUpload aborts the unfinished multipart upload
+func (hwc *HWCloudStorage) AbortMultipartUpload(upload *MultipartUpload) error {
+	_, err := hwc.hwclient.AbortMultipartUpload(&obs.AbortMultipartUploadInput{
+		Bucket:   hwc.bucket,
+		Key:      hwc.buildMinioPath(upload.Path),
+		UploadId: upload.UploadID,
+	})
+	return err
+}
+
+// URL gets the redirect URL to a file. The link is signed by OBS and valid for SERVE_DIRECT_EXPIRY,
+// 5 minutes by default. If a bucket domain is configured, the link points to it instead of the OBS endpoint.
 func (hwc *HWCloudStorage) URL(path, name string) (*url.URL, error) {
-	queryParameter := map[string]string{"response-content-disposition": "attachment; filename=\"" + url.QueryEscape(quoteEscaper.Replace(name)) + "\""}
-	input := &obs.CreateSignedUrlInput{}
-
-	input.Method = obs.HttpMethodGet
-	input.Bucket = hwc.bucket
-	input.Key = hwc.buildMinioPath(path)
-	input.Expires = default_expire
-	input.QueryParams = queryParameter
-	output, err := hwc.hwclient.CreateSignedUrl(input)
+	expires := int(hwc.serveDirectExpiry() / time.Second)
+	output, err := hwc.hwclient.CreateSignedUrl(&obs.CreateSignedUrlInput{
+		Method:  obs.HttpMethodGet,
+		Bucket:  hwc.bucket,
+		Key:     hwc.buildMinioPath(path),
+		Expires: expires,
+		QueryParams: map[string]string{
+			// the query parameters are escaped by the sdk
+			"response-content-disposition": "attachment; filename=\"" + quoteEscaper.Replace(name) + "\"",
+		},
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	//NOTE: it will work since CDN will replace hostname back to obs domain and that will make signed url work.
 	v, err := url.Parse(output.SignedUrl)
-	if err == nil {
+	if err != nil {
+		return nil, err
+	}
+	//NOTE: it will work since CDN will replace hostname back to obs domain and that will make signed url work.
+	if hwc.bucketDomain != "" {
 		v.Host = hwc.bucketDomain
 		v.Scheme = "https"
 	}
-
-	return v, err
+	return v, nil
 }
 
 func init() {
-	RegisterStorageType(setting.HWCloudStorageType, NewMinioStorage)
+	RegisterStorageType(setting.HWCloudStorageType, NewHWCloudStorage)
 }
@@ -0,0 +1,48 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHWCloudStorageURL(t *testing.T) {
+	cli, err := obs.New("ak", "sk", "https://obs.example.com")
+	assert.NoError(t, err)
+
+	hwc := &HWCloudStorage{
+		hwclient:     cli,
+		bucketDomain: "cdn.example.com",
+		MinioStorage: &MinioStorage{
+			cfg:      &setting.MinioStorageConfig{ServeDirectExpiry: time.Hour},
+			bucket:   "gitea",
+			basePath: "lfs/",
+		},
+	}
+
+	u, err := hwc.URL("ab/cd/object", `model "v1".bin`)
+	assert.NoError(t, err)
+	assert.Equal(t, "https", u.Scheme)
+	assert.Equal(t, "cdn.example.com", u.Host)
+	assert.Equal(t, "/lfs/ab/cd/object", u.Path)
+	assert.Equal(t, `attachment; filename="model \"v1\".bin"`, u.Query().Get("response-content-disposition"))
+	assert.NotEmpty(t, u.Query().Get("Signature"))
+
+	expires, err := strconv.ParseInt(u.Query().Get("Expires"), 10, 64)
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Now().Add(time.Hour).Unix(), expires, 60)
+
+	// without bucket domain the link points to OBS directly
+	hwc.bucketDomain = ""
+	u, err = hwc.URL("ab/cd/object", "object.bin")
+	assert.NoError(t, err)
+	assert.Equal(t, "gitea.obs.example.com", u.Hostname())
+}
@@ -4,34 +4,46 @@
 package storage
 
 import (
-	"code.gitea.io/gitea/modules/structs"
 	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/util"
 )
 
-var _ ObjectStorage = &LocalStorage{}
+var (
+	_ MultipartStorage      = &LocalStorage{}
+	_ MultipartPartReceiver = &LocalStorage{}
+)
+
+const (
+	// localMultipartUploadIDFile is the file in the directory of a multipart upload which keeps the upload id
+	localMultipartUploadIDFile = "upload_id"
+	// localMultipartPathFile is the file in the directory of a multipart upload which keeps the path of the object
+	localMultipartPathFile = "path"
+)
 
 // LocalStorage represents a local files storage
 type LocalStorage struct {
-	ctx    context.Context
-	dir    string
-	tmpdir string
-}
-
-func (l *LocalStorage) GenerateMultipartParts(path string, size int64) (parts []*structs.MultipartObjectPart, abort *structs.MultipartEndpoint, verify *structs.MultipartEndpoint, err error) {
-	panic("not implemented")
-}
-
-func (l *LocalStorage) CommitUpload(path, additionalParameter string) error {
-	panic("not implemented")
+	ctx       context.Context
+	dir       string
+	tmpdir    string
+	multipart multipartConfig
 }
 
 // NewLocalStorage returns a local files
@@ -52,9 +64,10 @@ func NewLocalStorage(ctx context.Context, config *setting.Storage) (ObjectStorag
 	}
 
 	return &LocalStorage{
-		ctx:    ctx,
-		dir:    config.Path,
-		tmpdir: config.TemporaryPath,
+		ctx:       ctx,
+		dir:       config.Path,
+		tmpdir:    config.TemporaryPath,
+		multipart: newMultipartConfig(config),
 	}, nil
 }
 
@@ -158,6 +171,263 @@ func (l *LocalStorage) IterateObjects(dirName string, fn func(path string, obj O
 	})
 }
 
+// multipartDir returns the temporary directory keeping the uploaded parts of the object
+func (l *LocalStorage) multipartDir(path string) string {
+	h := sha256.Sum256([]byte(l.buildLocalPath(path)))
+	return filepath.Join(l.tmpdir, "multipart", hex.EncodeToString(h[:]))
+}
+
+type localMultipartPart struct {
+	name string
+	etag string
+	size int64
+}
+
+// readMultipartUpload returns the id and the uploaded parts of the unfinished multipart upload in dir.
+// An empty id is returned if there is no such upload.
+func (l *LocalStorage) readMultipartUpload(dir string) (string, map[int]localMultipartPart, error) {
+	uploadID, err := os.ReadFile(filepath.Join(dir, localMultipartUploadIDFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil, nil
+		}
+		return "", nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	// uploaded parts are named as "{index}.{etag}"
+	parts := make(map[int]localMultipartPart, len(entries))
+	for _, entry := range entries {
+		idx, etag, ok := strings.Cut(entry.Name(), ".")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		index, err := strconv.Atoi(idx)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return "", nil, err
+		}
+		parts[index] = localMultipartPart{name: entry.Name(), etag: etag, size: info.Size()}
+	}
+	return string(uploadID), parts, nil
+}
+
+// signMultipartPart signs the parameters of a part upload, so the upload endpoint can trust them
+func signMultipartPart(path, uploadID string, index int, size, expires int64) string {
+	mac := hmac.New(sha256.New, setting.LFS.JWTSecretBytes)
+	_, _ = fmt.Fprintf(mac, "%s\n%s\n%d\n%d\n%d", path, uploadID, index, size, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateMultipartParts creates a multipart upload for the object, or resumes the existing one.
+// The parts are uploaded through gitea itself, so the hrefs of the returned endpoints are relative
+// to the upload link of the object and signed to expire.
+func (l *LocalStorage) GenerateMultipartParts(path string, size int64) (parts []*structs.MultipartObjectPart, abort *structs.MultipartEndpoint, verify *structs.MultipartEndpoint, err error) {
+	dir := l.multipartDir(path)
+	uploadID, uploaded, err := l.readMultipartUpload(dir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if uploadID == "" {
+		log.Trace("lfs[multipart] Starting to create local multipart task for %s", path)
+		if uploadID, err = util.CryptoRandomString(32); err != nil {
+			return nil, nil, nil, err
+		}
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return nil, nil, nil, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, localMultipartPathFile), []byte(path), 0o600); err != nil {
+			return nil, nil, nil, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, localMultipartUploadIDFile), []byte(uploadID), 0o600); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	expires := time.Now().Add(l.multipart.expiry).Unix()
+	parts = splitMultipartParts(size, l.multipart.partSize(size))
+	for _, part := range parts {
+		if existing, ok := uploaded[part.Index]; ok && existing.size == part.Size {
+			log.Trace("lfs[multipart] Found existing part %d for local multipart task %s, will add etag information", part.Index, path)
+			part.Etag = existing.etag
+			continue
+		}
+		query := url.Values{}
+		query.Set("upload_id", uploadID)
+		query.Set("size", strconv.FormatInt(part.Size, 10))
+		query.Set("expires", strconv.FormatInt(expires, 10))
+		query.Set("signature", signMultipartPart(path, uploadID, part.Index, part.Size, expires))
+		part.MultipartEndpoint = &structs.MultipartEndpoint{
+			ExpiresIn: l.multipart.expiresIn(),
+			Href:      "parts/" + strconv.Itoa(part.Index) + "?" + query.Encode(),
+			Method:    http.MethodPut,
+		}
+	}
+
+	return parts, nil, newMultipartVerifyEndpoint(uploadID), nil
+}
+
+// UploadPart stores a part of the multipart upload after checking the signed query of its endpoint
+func (l *LocalStorage) UploadPart(path string, index int, query url.Values, r io.Reader) (string, error) {
+	uploadID := query.Get("upload_id")
+	size, err := strconv.ParseInt(query.Get("size"), 10, 64)
+	if err != nil {
+		return "", ErrInvalidMultipartPart
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return "", ErrInvalidMultipartPart
+	}
+	if !hmac.Equal([]byte(query.Get("signature")), []byte(signMultipartPart(path, uploadID, index, size, expires))) {
+		return "", ErrInvalidMultipartPart
+	}
+	if time.Now().Unix() > expires {
+		return "", ErrInvalidMultipartPart
+	}
+
+	dir := l.multipartDir(path)
+	currentID, uploaded, err := l.readMultipartUpload(dir)
+	if err != nil {
+		return "", err
+	}
+	if currentID != uploadID {
+		return "", os.ErrNotExist
+	}
+
+	tmp, err := os.CreateTemp(dir, "part-*")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = util.Remove(tmp.Name())
+	}()
+
+	hash := md5.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(r, size+1))
+	if err != nil {
+		return "", err
+	}
+	if written != size {
+		return "", ErrInvalidMultipartPart
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	etag := hex.EncodeToString(hash.Sum(nil))
+	if existing, ok := uploaded[index]; ok {
+		if err := util.Remove(filepath.Join(dir, existing.name)); err != nil {
+			return "", err
+		}
+	}
+	if err := util.Rename(tmp.Name(), filepath.Join(dir, strconv.Itoa(index)+"."+etag)); err != nil {
+		return "", err
+	}
+	return etag, nil
+}
+
+// CommitUpload concatenates the uploaded parts into the object and removes the parts
+func (l *LocalStorage) CommitUpload(path, additionalParameter string) error {
+	param, err := parseMultipartCommitUpload(additionalParameter)
+	if err != nil {
+		return err
+	}
+
+	dir := l.multipartDir(path)
+	uploadID, uploaded, err := l.readMultipartUpload(dir)
+	if err != nil {
+		return err
+	}
+	if uploadID != param.UploadID {
+		return os.ErrNotExist
+	}
+
+	sort.Slice(param.PartIDs, func(i, j int) bool { return param.PartIDs[i].Index < param.PartIDs[j].Index })
+	readers := make([]io.Reader, 0, len(param.PartIDs))
+	for i, p := range param.PartIDs {
+		part, ok := uploaded[p.Index]
+		if !ok || p.Index != i+1 || part.etag != strings.Trim(p.Etag, "\"") {
+			return fmt.Errorf("%w: part %d", ErrInvalidMultipartPart, p.Index)
+		}
+		f, err := os.Open(filepath.Join(dir, part.name))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		readers = append(readers, f)
+	}
+
+	log.Trace("lfs[multipart] Start to merge local multipart task for %s", path)
+	if _, err := l.Save(path, io.MultiReader(readers...), -1); err != nil {
+		return err
+	}
+	return util.RemoveAll(dir)
+}
+
+// IterateMultipartUploads iterates across the unfinished multipart uploads in the temporary directory
+func (l *LocalStorage) IterateMultipartUploads(fn func(upload *MultipartUpload) error) error {
+	entries, err := os.ReadDir(filepath.Join(l.tmpdir, "multipart"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(l.tmpdir, "multipart", entry.Name())
+		uploadID, parts, err := l.readMultipartUpload(dir)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(filepath.Join(dir, localMultipartUploadIDFile))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		path, err := os.ReadFile(filepath.Join(dir, localMultipartPathFile))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		upload := &MultipartUpload{
+			Path:      string(path),
+			UploadID:  uploadID,
+			Initiated: info.ModTime(),
+		}
+		for _, part := range parts {
+			upload.Size += part.size
+		}
+		if err := fn(upload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AbortMultipartUpload removes the uploaded parts of the unfinished multipart upload
+func (l *LocalStorage) AbortMultipartUpload(upload *MultipartUpload) error {
+	dir := l.multipartDir(upload.Path)
+	uploadID, _, err := l.readMultipartUpload(dir)
+	if err != nil {
+		return err
+	}
+	if uploadID != upload.UploadID {
+		return os.ErrNotExist
+	}
+	return util.RemoveAll(dir)
+}
+
 func init() {
 	RegisterStorageType(setting.LocalStorageType, NewLocalStorage)
 }
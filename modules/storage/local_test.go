@@ -4,11 +4,17 @@
 package storage
 
 import (
+	"bytes"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -59,3 +65,101 @@ func TestLocalStorageIterator(t *testing.T) {
 	dir := filepath.Join(os.TempDir(), "TestLocalStorageIteratorTestDir")
 	testStorageIterator(t, setting.LocalStorageType, &setting.Storage{Path: dir})
 }
+
+func TestLocalStorageMultipart(t *testing.T) {
+	setting.LFS.JWTSecretBytes = []byte("secret")
+	s, err := NewStorage(setting.LocalStorageType, &setting.Storage{Path: t.TempDir()})
+	assert.NoError(t, err)
+	l := s.(*LocalStorage)
+
+	content := bytes.Repeat([]byte("0123456789"), int(defaultMultipartChunkSize)/10+100)
+	size := int64(len(content))
+
+	uploadPart := func(part *structs.MultipartObjectPart, body []byte) (string, error) {
+		href, query, _ := strings.Cut(part.Href, "?")
+		assert.Equal(t, "parts/"+strconv.Itoa(part.Index), href)
+		values, err := url.ParseQuery(query)
+		assert.NoError(t, err)
+		return l.UploadPart("ab/cd/object", part.Index, values, bytes.NewReader(body))
+	}
+
+	parts, _, verify, err := l.GenerateMultipartParts("ab/cd/object", size)
+	assert.NoError(t, err)
+	assert.Len(t, parts, 2)
+	uploadID := (*verify.Params)["upload_id"]
+	assert.NotEmpty(t, uploadID)
+
+	// a part with wrong size or a tampered index is rejected
+	_, err = uploadPart(parts[0], content[:10])
+	assert.ErrorIs(t, err, ErrInvalidMultipartPart)
+	_, err = l.UploadPart("ab/cd/object", 2, mustParseQuery(t, parts[0].Href), bytes.NewReader(content[:parts[0].Size]))
+	assert.ErrorIs(t, err, ErrInvalidMultipartPart)
+
+	// upload the first part only and request the parts again, the upload should be resumed
+	etag, err := uploadPart(parts[0], content[:parts[0].Size])
+	assert.NoError(t, err)
+	parts, _, verify, err = l.GenerateMultipartParts("ab/cd/object", size)
+	assert.NoError(t, err)
+	assert.Equal(t, uploadID, (*verify.Params)["upload_id"])
+	assert.Nil(t, parts[0].MultipartEndpoint)
+	assert.Equal(t, etag, parts[0].Etag)
+	assert.NotNil(t, parts[1].MultipartEndpoint)
+
+	parts[1].Etag, err = uploadPart(parts[1], content[parts[1].Pos:])
+	assert.NoError(t, err)
+
+	assert.Error(t, l.CommitUpload("ab/cd/object", `{"upload_id":"`+uploadID+`","part_ids":[{"index":2,"etag":"`+parts[1].Etag+`"}]}`))
+	commit := `{"upload_id":"` + uploadID + `","part_ids":[{"index":2,"etag":"` + parts[1].Etag + `"},{"index":1,"etag":"` + parts[0].Etag + `"}]}`
+	assert.NoError(t, l.CommitUpload("ab/cd/object", commit))
+
+	f, err := l.Open("ab/cd/object")
+	assert.NoError(t, err)
+	defer f.Close()
+	stored, err := io.ReadAll(f)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(content, stored))
+
+	// the parts are removed after the upload has been committed
+	assert.NoDirExists(t, l.multipartDir("ab/cd/object"))
+	assert.Error(t, l.CommitUpload("ab/cd/object", commit))
+}
+
+func mustParseQuery(t *testing.T, href string) url.Values {
+	_, query, _ := strings.Cut(href, "?")
+	values, err := url.ParseQuery(query)
+	assert.NoError(t, err)
+	return values
+}
+
+func TestLocalStorageAbortMultipartUpload(t *testing.T) {
+	setting.LFS.JWTSecretBytes = []byte("secret")
+	s, err := NewStorage(setting.LocalStorageType, &setting.Storage{Path: t.TempDir()})
+	assert.NoError(t, err)
+	l := s.(*LocalStorage)
+
+	assert.NoError(t, l.IterateMultipartUploads(func(upload *MultipartUpload) error {
+		assert.Fail(t, "there should be no multipart upload")
+		return nil
+	}))
+
+	parts, _, verify, err := l.GenerateMultipartParts("ab/cd/object", 10)
+	assert.NoError(t, err)
+	_, err = l.UploadPart("ab/cd/object", 1, mustParseQuery(t, parts[0].Href), strings.NewReader("0123456789"))
+	assert.NoError(t, err)
+
+	var uploads []*MultipartUpload
+	assert.NoError(t, l.IterateMultipartUploads(func(upload *MultipartUpload) error {
+		uploads = append(uploads, upload)
+		return nil
+	}))
+	if assert.Len(t, uploads, 1) {
+		assert.Equal(t, "ab/cd/object", uploads[0].Path)
+		assert.Equal(t, (*verify.Params)["upload_id"], uploads[0].UploadID)
+		assert.EqualValues(t, 10, uploads[0].Size)
+		assert.False(t, uploads[0].Initiated.IsZero())
+	}
+
+	assert.NoError(t, l.AbortMultipartUpload(uploads[0]))
+	assert.NoDirExists(t, l.multipartDir("ab/cd/object"))
+	assert.Error(t, l.AbortMultipartUpload(uploads[0]))
+}
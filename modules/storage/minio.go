@@ -4,7 +4,6 @@
 package storage
 
 import (
-	"code.gitea.io/gitea/modules/structs"
 	"context"
 	"crypto/tls"
 	"fmt"
@@ -13,11 +12,14 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/util"
 
 	"github.com/minio/minio-go/v7"
@@ -25,7 +27,7 @@ import (
 )
 
 var (
-	_ ObjectStorage = &MinioStorage{}
+	_ MultipartStorage = &MinioStorage{}
 
 	quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
 )
@@ -45,11 +47,12 @@ func (m *minioObject) Stat() (os.FileInfo, error) {
 
 // MinioStorage returns a minio bucket storage
 type MinioStorage struct {
-	cfg      *setting.MinioStorageConfig
-	ctx      context.Context
-	client   *minio.Client
-	bucket   string
-	basePath string
+	cfg       *setting.MinioStorageConfig
+	ctx       context.Context
+	client    *minio.Client
+	bucket    string
+	basePath  string
+	multipart multipartConfig
 }
 
 func convertMinioErr(err error) error {
@@ -137,20 +140,180 @@ func NewMinioStorage(ctx context.Context, cfg *setting.Storage) (ObjectStorage,
 	}
 
 	return &MinioStorage{
-		cfg:      &config,
-		ctx:      ctx,
-		client:   minioClient,
-		bucket:   config.Bucket,
-		basePath: config.BasePath,
+		cfg:       &config,
+		ctx:       ctx,
+		client:    minioClient,
+		bucket:    config.Bucket,
+		basePath:  config.BasePath,
+		multipart: newMultipartConfig(cfg),
 	}, nil
 }
 
+// GenerateMultipartParts creates a multipart upload for the object, or resumes the existing one,
+// and returns presigned endpoints for every part which hasn't been uploaded yet
 func (m *MinioStorage) GenerateMultipartParts(path string, size int64) (parts []*structs.MultipartObjectPart, abort *structs.MultipartEndpoint, verify *structs.MultipartEndpoint, err error) {
-	panic("not implemented")
+	core := &minio.Core{Client: m.client}
+	objectKey := m.buildMinioPath(path)
+
+	uploadID, uploaded, err := m.findMultipartUpload(core, objectKey)
+	if err != nil {
+		return nil, nil, nil, convertMinioErr(err)
+	}
+	if uploadID == "" {
+		log.Trace("lfs[multipart] Starting to create multipart task %s and %s", m.bucket, objectKey)
+		uploadID, err = core.NewMultipartUpload(m.ctx, m.bucket, objectKey, minio.PutObjectOptions{ContentType: "application/octet-stream"})
+		if err != nil {
+			return nil, nil, nil, convertMinioErr(err)
+		}
+	}
+
+	parts = splitMultipartParts(size, m.multipart.partSize(size))
+	for _, part := range parts {
+		if existing, ok := uploaded[part.Index]; ok && existing.Size == part.Size {
+			log.Trace("lfs[multipart] Found existing part %d for multipart task %s and %s, will add etag information", part.Index, m.bucket, objectKey)
+			part.Etag = strings.Trim(existing.ETag, "\"")
+			continue
+		}
+		reqParams := make(url.Values)
+		reqParams.Set("partNumber", strconv.Itoa(part.Index))
+		reqParams.Set("uploadId", uploadID)
+		u, err := m.client.Presign(m.ctx, http.MethodPut, m.bucket, objectKey, m.multipart.expiry, reqParams)
+		if err != nil {
+			return nil, nil, nil, convertMinioErr(err)
+		}
+		part.MultipartEndpoint = &structs.MultipartEndpoint{
+			ExpiresIn: m.multipart.expiresIn(),
+			Href:      u.String(),
+			Method:    http.MethodPut,
+		}
+	}
+
+	return parts, nil, newMultipartVerifyEndpoint(uploadID), nil
+}
+
+// findMultipartUpload returns the id and the uploaded parts of the unfinished multipart upload for the key.
+// If there is more than one unfinished upload for the key, all of them are aborted and an empty id is returned.
+func (m *MinioStorage) findMultipartUpload(core *minio.Core, objectKey string) (string, map[int]minio.ObjectPart, error) {
+	var uploads []minio.ObjectMultipartInfo
+	keyMarker, uploadIDMarker := "", ""
+	for {
+		result, err := core.ListMultipartUploads(m.ctx, m.bucket, objectKey, keyMarker, uploadIDMarker, "", 1000)
+		if err != nil {
+			log.Error("lfs[multipart] Failed to list existing multipart task %s and %s", m.bucket, objectKey)
+			return "", nil, err
+		}
+		for _, upload := range result.Uploads {
+			// the listing is prefix based, so "oid" would also match "oid-foo"
+			if upload.Key == objectKey {
+				uploads = append(uploads, upload)
+			}
+		}
+		if !result.IsTruncated {
+			break
+		}
+		keyMarker, uploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+	}
+
+	if len(uploads) == 0 {
+		return "", nil, nil
+	}
+	if len(uploads) > 1 {
+		for _, upload := range uploads {
+			if err := core.AbortMultipartUpload(m.ctx, m.bucket, objectKey, upload.UploadID); err != nil {
+				log.Error("lfs[multipart] Failed to abort existing multipart task %s and %s %s", m.bucket, objectKey, upload.UploadID)
+				return "", nil, err
+			}
+		}
+		return "", nil, nil
+	}
+
+	uploadID := uploads[0].UploadID
+	uploaded, err := m.listMultipartParts(core, objectKey, uploadID)
+	if err != nil {
+		log.Error("lfs[multipart] Failed to get existing multipart task part %s and %s %s", m.bucket, objectKey, uploadID)
+		return "", nil, err
+	}
+	return uploadID, uploaded, nil
 }
 
+// listMultipartParts returns the uploaded parts of the multipart upload by their part numbers
+func (m *MinioStorage) listMultipartParts(core *minio.Core, objectKey, uploadID string) (map[int]minio.ObjectPart, error) {
+	uploaded := make(map[int]minio.ObjectPart)
+	partNumberMarker := 0
+	for {
+		result, err := core.ListObjectParts(m.ctx, m.bucket, objectKey, uploadID, partNumberMarker, 1000)
+		if err != nil {
+			return nil, err
+		}
+		for _, part := range result.ObjectParts {
+			uploaded[part.PartNumber] = part
+		}
+		if !result.IsTruncated {
+			return uploaded, nil
+		}
+		partNumberMarker = result.NextPartNumberMarker
+	}
+}
+
+// IterateMultipartUploads iterates across the unfinished multipart uploads in the minio storage
+func (m *MinioStorage) IterateMultipartUploads(fn func(upload *MultipartUpload) error) error {
+	core := &minio.Core{Client: m.client}
+	prefix := m.buildMinioDirPrefix("")
+	keyMarker, uploadIDMarker := "", ""
+	for {
+		result, err := core.ListMultipartUploads(m.ctx, m.bucket, prefix, keyMarker, uploadIDMarker, "", 1000)
+		if err != nil {
+			return convertMinioErr(err)
+		}
+		for _, info := range result.Uploads {
+			parts, err := m.listMultipartParts(core, info.Key, info.UploadID)
+			if err != nil {
+				return convertMinioErr(err)
+			}
+			upload := &MultipartUpload{
+				Path:      strings.TrimPrefix(info.Key, prefix),
+				UploadID:  info.UploadID,
+				Initiated: info.Initiated,
+			}
+			for _, part := range parts {
+				upload.Size += part.Size
+			}
+			if err := fn(upload); err != nil {
+				return err
+			}
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		keyMarker, uploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+	}
+}
+
+// AbortMultipartUpload aborts the unfinished multipart upload
+func (m *MinioStorage) AbortMultipartUpload(upload *MultipartUpload) error {
+	core := &minio.Core{Client: m.client}
+	return convertMinioErr(core.AbortMultipartUpload(m.ctx, m.bucket, m.buildMinioPath(upload.Path), upload.UploadID))
+}
+
+// CommitUpload completes the multipart upload with the parts reported by the client
 func (m *MinioStorage) CommitUpload(path, additionalParameter string) error {
-	panic("not implemented")
+	param, err := parseMultipartCommitUpload(additionalParameter)
+	if err != nil {
+		return err
+	}
+
+	parts := make([]minio.CompletePart, 0, len(param.PartIDs))
+	for _, p := range param.PartIDs {
+		parts = append(parts, minio.CompletePart{ETag: p.Etag, PartNumber: p.Index})
+	}
+	// S3 requires the parts to be in ascending order
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+
+	core := &minio.Core{Client: m.client}
+	objectKey := m.buildMinioPath(path)
+	log.Trace("lfs[multipart] Start to merge multipart task %s and %s", m.bucket, objectKey)
+	_, err = core.CompleteMultipartUpload(m.ctx, m.bucket, objectKey, param.UploadID, parts, minio.PutObjectOptions{})
+	return convertMinioErr(err)
 }
 
 func (m *MinioStorage) buildMinioPath(p string) string {
@@ -252,12 +415,20 @@ func (m *MinioStorage) Delete(path string) error {
 	return convertMinioErr(err)
 }
 
-// URL gets the redirect URL to a file. The presigned link is valid for 5 minutes.
+// serveDirectExpiry returns how long the presigned links are valid, 5 minutes by default
+func (m *MinioStorage) serveDirectExpiry() time.Duration {
+	if m.cfg != nil && m.cfg.ServeDirectExpiry > 0 {
+		return m.cfg.ServeDirectExpiry
+	}
+	return 5 * time.Minute
+}
+
+// URL gets the redirect URL to a file. The presigned link is valid for SERVE_DIRECT_EXPIRY, 5 minutes by default.
 func (m *MinioStorage) URL(path, name string) (*url.URL, error) {
 	reqParams := make(url.Values)
 	// TODO it may be good to embed images with 'inline' like ServeData does, but we don't want to have to read the file, do we?
 	reqParams.Set("response-content-disposition", "attachment; filename=\""+quoteEscaper.Replace(name)+"\"")
-	u, err := m.client.PresignedGetObject(m.ctx, m.bucket, m.buildMinioPath(path), 5*time.Minute, reqParams)
+	u, err := m.client.PresignedGetObject(m.ctx, m.bucket, m.buildMinioPath(path), m.serveDirectExpiry(), reqParams)
 	return u, convertMinioErr(err)
 }
 
@@ -4,9 +4,12 @@
 package storage
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
 
 	"code.gitea.io/gitea/modules/setting"
@@ -92,3 +95,67 @@ func TestS3StorageBadRequest(t *testing.T) {
 	_, err := NewStorage(setting.MinioStorageType, cfg)
 	assert.ErrorContains(t, err, message)
 }
+
+func TestMinioStorageMultipart(t *testing.T) {
+	if os.Getenv("CI") == "" {
+		t.Skip("minioStorage not present outside of CI")
+		return
+	}
+	storage, err := NewStorage(setting.MinioStorageType, &setting.Storage{
+		MinioConfig: setting.MinioStorageConfig{
+			Endpoint:        "127.0.0.1:9000",
+			AccessKeyID:     "123456",
+			SecretAccessKey: "12345678",
+			Bucket:          "gitea",
+			Location:        "us-east-1",
+		},
+	})
+	assert.NoError(t, err)
+	s := storage.(MultipartStorage)
+
+	// S3 requires all parts but the last one to be at least 5MiB
+	content := bytes.Repeat([]byte("0123456789"), int(defaultMultipartChunkSize)/10+100)
+	size := int64(len(content))
+
+	uploadPart := func(part string, body []byte) string {
+		req, err := http.NewRequest(http.MethodPut, part, bytes.NewReader(body))
+		assert.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		return strings.Trim(resp.Header.Get("ETag"), "\"")
+	}
+
+	parts, _, verify, err := s.GenerateMultipartParts("multipart/object", size)
+	assert.NoError(t, err)
+	assert.Len(t, parts, 2)
+	uploadID := (*verify.Params)["upload_id"]
+	assert.NotEmpty(t, uploadID)
+
+	// upload the first part only and request the parts again, the upload should be resumed
+	etag := uploadPart(parts[0].Href, content[:parts[0].Size])
+	parts, _, verify, err = s.GenerateMultipartParts("multipart/object", size)
+	assert.NoError(t, err)
+	assert.Equal(t, uploadID, (*verify.Params)["upload_id"])
+	assert.Nil(t, parts[0].MultipartEndpoint)
+	assert.Equal(t, etag, parts[0].Etag)
+	assert.NotNil(t, parts[1].MultipartEndpoint)
+
+	parts[1].Etag = uploadPart(parts[1].Href, content[parts[1].Pos:])
+
+	commit := `{"upload_id":"` + uploadID + `","part_ids":[{"index":2,"etag":"` + parts[1].Etag + `"},{"index":1,"etag":"` + parts[0].Etag + `"}]}`
+	assert.NoError(t, s.CommitUpload("multipart/object", commit))
+
+	fi, err := s.Stat("multipart/object")
+	assert.NoError(t, err)
+	assert.EqualValues(t, size, fi.Size())
+
+	f, err := s.Open("multipart/object")
+	assert.NoError(t, err)
+	defer f.Close()
+	stored, err := io.ReadAll(f)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(content, stored))
+	assert.NoError(t, s.Delete("multipart/object"))
+}
@@ -0,0 +1,216 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
+)
+
+const (
+	defaultMultipartChunkSize int64 = 20000000
+	defaultMultipartExpiry          = 2 * time.Hour
+	// maxMultipartParts is the maximum number of parts of an upload which is supported by S3 compatible storages
+	maxMultipartParts = 10000
+)
+
+// multipartConfig holds the MULTIPART_CHUNK_SIZE and MULTIPART_EXPIRY settings of a storage
+type multipartConfig struct {
+	chunkSize int64
+	expiry    time.Duration
+}
+
+func newMultipartConfig(cfg *setting.Storage) multipartConfig {
+	c := multipartConfig{
+		chunkSize: defaultMultipartChunkSize,
+		expiry:    defaultMultipartExpiry,
+	}
+	if cfg != nil && cfg.MultipartChunkSize > 0 {
+		c.chunkSize = cfg.MultipartChunkSize
+	}
+	if cfg != nil && cfg.MultipartExpiry > 0 {
+		c.expiry = cfg.MultipartExpiry
+	}
+	return c
+}
+
+// partSize returns the size of the parts for an object of the given size. The configured
+// chunk size is scaled up if the object would be split into more than maxMultipartParts.
+func (c multipartConfig) partSize(size int64) int64 {
+	return MultipartPartSize(size, c.chunkSize)
+}
+
+// expiresIn returns the validity of the part endpoints in seconds
+func (c multipartConfig) expiresIn() int {
+	return int(c.expiry / time.Second)
+}
+
+// MultipartPartSize returns the size of the parts an object of the given size is split into.
+// The chunk size is scaled up so that objects never consist of more than 10,000 parts,
+// a chunk size <= 0 means the default of 20MB.
+func MultipartPartSize(size, chunkSize int64) int64 {
+	if chunkSize <= 0 {
+		chunkSize = defaultMultipartChunkSize
+	}
+	if minSize := (size + maxMultipartParts - 1) / maxMultipartParts; chunkSize < minSize {
+		chunkSize = minSize
+	}
+	return chunkSize
+}
+
+// ErrInvalidMultipartPart represents a part of a multipart upload which doesn't match its signed endpoint or the upload
+var ErrInvalidMultipartPart = errors.New("invalid multipart part")
+
+// MultipartPartReceiver is implemented by storages which don't provide endpoints for multipart uploads on their own,
+// the parts are uploaded to gitea and passed to the storage
+type MultipartPartReceiver interface {
+	// UploadPart stores a part of the object, the query is the one of the part endpoint generated by GenerateMultipartParts
+	UploadPart(path string, index int, query url.Values, r io.Reader) (etag string, err error)
+}
+
+// MultipartUpload represents an unfinished multipart upload of an object
+type MultipartUpload struct {
+	Path      string
+	UploadID  string
+	Initiated time.Time
+	// Size is the total size of the parts which have been uploaded
+	Size int64
+}
+
+type MultipartPartID struct {
+	Etag  string `json:"etag"`
+	Index int    `json:"index"`
+}
+
+type MultiPartCommitUpload struct {
+	UploadID string            `json:"upload_id"`
+	PartIDs  []MultipartPartID `json:"part_ids"`
+}
+
+// splitMultipartParts splits an object of the given size into parts of chunkSize,
+// the endpoints of the parts are left empty
+func splitMultipartParts(size, chunkSize int64) []*structs.MultipartObjectPart {
+	parts := make([]*structs.MultipartObjectPart, 0, (size+chunkSize-1)/chunkSize)
+	for pos := int64(0); pos < size; pos += chunkSize {
+		partSize := size - pos
+		if partSize > chunkSize {
+			partSize = chunkSize
+		}
+		parts = append(parts, &structs.MultipartObjectPart{
+			Index: len(parts) + 1,
+			Pos:   pos,
+			Size:  partSize,
+		})
+	}
+	return parts
+}
+
+// SplitMultipartDownload splits an object of the given size into byte ranges which can be downloaded in parallel.
+// Objects which fit into a single part aren't split and nil is returned, the endpoints of the parts are left empty.
+func SplitMultipartDownload(size, chunkSize int64) []*structs.MultipartObjectPart {
+	chunkSize = MultipartPartSize(size, chunkSize)
+	if size <= chunkSize {
+		return nil
+	}
+	return splitMultipartParts(size, chunkSize)
+}
+
+// newMultipartVerifyEndpoint returns the verify endpoint which tells the client
+// to send back the upload id and the etags of all parts
+func newMultipartVerifyEndpoint(uploadID string) *structs.MultipartEndpoint {
+	return &structs.MultipartEndpoint{
+		Params: &map[string]string{
+			"upload_id": uploadID,
+		},
+		AggregationParams: &map[string]string{
+			"key":  "part_ids",
+			"type": "array",
+			"item": "index,etag",
+		},
+	}
+}
+
+// parseMultipartCommitUpload decodes the parameter sent to the verify endpoint
+func parseMultipartCommitUpload(additionalParameter string) (*MultiPartCommitUpload, error) {
+	var param MultiPartCommitUpload
+	if err := json.Unmarshal([]byte(additionalParameter), &param); err != nil {
+		log.Error("lfs[multipart] unable to decode additional parameter %s", additionalParameter)
+		return nil, err
+	}
+	if len(param.UploadID) == 0 || len(param.PartIDs) == 0 {
+		log.Error("lfs[multipart] failed to commit objects, parameter is empty %v", param)
+		return nil, errors.New("parameter is empty")
+	}
+	log.Trace("lfs[multipart] start to commit upload object %v", param)
+	return &param, nil
+}
+
+// multipartForwarder adds the multipart capability of a wrapped storage to the storage wrapping it.
+// The uploads are committed to the wrapped storage, then committed is called to take over the object.
+type multipartForwarder struct {
+	ObjectStorage
+	multipart MultipartStorage
+	committed func(path string) error
+}
+
+// multipartPartForwarder also forwards the parts received by gitea to the wrapped storage
+type multipartPartForwarder struct {
+	*multipartForwarder
+	receiver MultipartPartReceiver
+}
+
+// withMultipart returns the wrapping storage with the multipart capability of the wrapped storage, if it has one
+func withMultipart(wrapping, wrapped ObjectStorage, committed func(path string) error) ObjectStorage {
+	multipart, ok := wrapped.(MultipartStorage)
+	if !ok {
+		return wrapping
+	}
+	f := &multipartForwarder{ObjectStorage: wrapping, multipart: multipart, committed: committed}
+	if receiver, ok := wrapped.(MultipartPartReceiver); ok {
+		return &multipartPartForwarder{f, receiver}
+	}
+	return f
+}
+
+// Unwrap returns the storage which the multipart capability of its wrapped storage has been added to,
+// other storages are returned as they are
+func Unwrap(s ObjectStorage) ObjectStorage {
+	switch f := s.(type) {
+	case *multipartForwarder:
+		return f.ObjectStorage
+	case *multipartPartForwarder:
+		return f.ObjectStorage
+	}
+	return s
+}
+
+func (f *multipartForwarder) GenerateMultipartParts(path string, size int64) ([]*structs.MultipartObjectPart, *structs.MultipartEndpoint, *structs.MultipartEndpoint, error) {
+	return f.multipart.GenerateMultipartParts(path, size)
+}
+
+func (f *multipartForwarder) CommitUpload(path, additionalParameter string) error {
+	if err := f.multipart.CommitUpload(path, additionalParameter); err != nil {
+		return err
+	}
+	return f.committed(path)
+}
+
+func (f *multipartForwarder) IterateMultipartUploads(iterator func(upload *MultipartUpload) error) error {
+	return f.multipart.IterateMultipartUploads(iterator)
+}
+
+func (f *multipartForwarder) AbortMultipartUpload(upload *MultipartUpload) error {
+	return f.multipart.AbortMultipartUpload(upload)
+}
+
+func (f *multipartPartForwarder) UploadPart(path string, index int, query url.Values, r io.Reader) (string, error) {
+	return f.receiver.UploadPart(path, index, query, r)
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitMultipartParts(t *testing.T) {
+	parts := splitMultipartParts(25, 10)
+	if assert.Len(t, parts, 3) {
+		assert.EqualValues(t, 1, parts[0].Index)
+		assert.EqualValues(t, 0, parts[0].Pos)
+		assert.EqualValues(t, 10, parts[0].Size)
+		assert.EqualValues(t, 3, parts[2].Index)
+		assert.EqualValues(t, 20, parts[2].Pos)
+		assert.EqualValues(t, 5, parts[2].Size)
+	}
+
+	assert.Len(t, splitMultipartParts(20, 10), 2)
+	assert.Len(t, splitMultipartParts(1, 10), 1)
+	assert.Empty(t, splitMultipartParts(0, 10))
+}
+
+func TestSplitMultipartDownload(t *testing.T) {
+	assert.Nil(t, SplitMultipartDownload(0, 0))
+	assert.Nil(t, SplitMultipartDownload(defaultMultipartChunkSize, 0))
+	assert.Nil(t, SplitMultipartDownload(10, 10))
+
+	parts := SplitMultipartDownload(defaultMultipartChunkSize+1, 0)
+	if assert.Len(t, parts, 2) {
+		assert.EqualValues(t, defaultMultipartChunkSize, parts[1].Pos)
+		assert.EqualValues(t, 1, parts[1].Size)
+		assert.Nil(t, parts[1].MultipartEndpoint)
+	}
+
+	assert.Len(t, SplitMultipartDownload(25, 10), 3)
+}
+
+func TestMultipartPartSize(t *testing.T) {
+	assert.EqualValues(t, defaultMultipartChunkSize, MultipartPartSize(100, 0))
+	assert.EqualValues(t, 10, MultipartPartSize(100, 10))
+	assert.EqualValues(t, 10, MultipartPartSize(100000, 10))
+
+	// the chunk size is scaled up to stay within 10,000 parts
+	assert.EqualValues(t, 11, MultipartPartSize(100001, 10))
+	size := int64(50) << 30
+	assert.LessOrEqual(t, len(splitMultipartParts(size, MultipartPartSize(size, defaultMultipartChunkSize))), maxMultipartParts)
+}
+
+func TestNewMultipartConfig(t *testing.T) {
+	c := newMultipartConfig(nil)
+	assert.EqualValues(t, defaultMultipartChunkSize, c.chunkSize)
+	assert.EqualValues(t, 7200, c.expiresIn())
+
+	c = newMultipartConfig(&setting.Storage{MultipartChunkSize: 5 << 20, MultipartExpiry: time.Hour})
+	assert.EqualValues(t, 5<<20, c.partSize(1<<30))
+	assert.EqualValues(t, 3600, c.expiresIn())
+}
+
+func TestParseMultipartCommitUpload(t *testing.T) {
+	param, err := parseMultipartCommitUpload(`{"upload_id":"abc","part_ids":[{"index":1,"etag":"e1"},{"index":2,"etag":"e2"}]}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", param.UploadID)
+	assert.Equal(t, []MultipartPartID{{Etag: "e1", Index: 1}, {Etag: "e2", Index: 2}}, param.PartIDs)
+
+	_, err = parseMultipartCommitUpload(`{"upload_id":"abc"}`)
+	assert.Error(t, err)
+	_, err = parseMultipartCommitUpload(`not json`)
+	assert.Error(t, err)
+}
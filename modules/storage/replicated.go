@@ -0,0 +1,281 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+var _ ObjectStorage = &ReplicatedStorage{}
+
+// replicationQueueTimeout is how long a change waits for room in the full replication queue,
+// before it's replicated synchronously by the writer
+var replicationQueueTimeout = 10 * time.Second
+
+type replicationTask struct {
+	path   string
+	delete bool
+}
+
+// ReplicatedStorage writes to a primary storage and replicates the changes asynchronously to the secondary storages.
+// Objects missing in the primary storage are read from the secondary storages.
+// Multipart uploads are passed to the primary storage and replicated once they are committed.
+// Replications which fail or are lost on shutdown are reconciled by the doctor check.
+type ReplicatedStorage struct {
+	primary     ObjectStorage
+	secondaries []ObjectStorage
+	tasks       chan replicationTask
+}
+
+// NewReplicatedStorage returns a replicated storage
+func NewReplicatedStorage(ctx context.Context, cfg *setting.Storage) (ObjectStorage, error) {
+	replication := cfg.Replication
+	if replication == nil || replication.Primary == nil || len(replication.Secondaries) == 0 {
+		return nil, ErrInvalidConfiguration{cfg: cfg, err: errors.New("a primary and at least one secondary storage are required")}
+	}
+
+	log.Info("Creating replicated storage with primary type %s and %d secondary storages", replication.Primary.Type, len(replication.Secondaries))
+
+	primary, err := NewStorage(replication.Primary.Type, replication.Primary)
+	if err != nil {
+		return nil, err
+	}
+	secondaries := make([]ObjectStorage, 0, len(replication.Secondaries))
+	for _, secondaryCfg := range replication.Secondaries {
+		secondary, err := NewStorage(secondaryCfg.Type, secondaryCfg)
+		if err != nil {
+			return nil, err
+		}
+		secondaries = append(secondaries, secondary)
+	}
+
+	queueLength := replication.QueueLength
+	if queueLength <= 0 {
+		queueLength = 1
+	}
+	s := &ReplicatedStorage{
+		primary:     primary,
+		secondaries: secondaries,
+		tasks:       make(chan replicationTask, queueLength),
+	}
+	go s.replicate(ctx)
+	return withMultipart(s, primary, func(path string) error {
+		s.queue(replicationTask{path: path})
+		return nil
+	}), nil
+}
+
+// Primary returns the storage which receives the writes
+func (s *ReplicatedStorage) Primary() ObjectStorage {
+	return s.primary
+}
+
+// Secondaries returns the storages the primary storage is replicated to
+func (s *ReplicatedStorage) Secondaries() []ObjectStorage {
+	return s.secondaries
+}
+
+func (s *ReplicatedStorage) queue(task replicationTask) {
+	select {
+	case s.tasks <- task:
+		return
+	default:
+	}
+
+	timer := time.NewTimer(replicationQueueTimeout)
+	defer timer.Stop()
+	select {
+	case s.tasks <- task:
+	case <-timer.C:
+		log.Warn("Replication queue is full, %s is replicated synchronously", task.path)
+		s.apply(task)
+	}
+}
+
+func (s *ReplicatedStorage) replicate(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case task := <-s.tasks:
+			s.apply(task)
+		}
+	}
+}
+
+// apply replicates the change to the secondary storages
+func (s *ReplicatedStorage) apply(task replicationTask) {
+	for _, secondary := range s.secondaries {
+		var err error
+		if task.delete {
+			if err = secondary.Delete(task.path); errors.Is(err, os.ErrNotExist) {
+				err = nil
+			}
+		} else {
+			_, err = Copy(secondary, task.path, s.primary, task.path)
+		}
+		if err != nil {
+			log.Error("Unable to replicate %s: %v", task.path, err)
+		}
+	}
+}
+
+// Open opens the object of the primary storage, or of the first secondary storage having it
+func (s *ReplicatedStorage) Open(path string) (Object, error) {
+	obj, err := openExisting(s.primary, path)
+	if !errors.Is(err, os.ErrNotExist) {
+		return obj, err
+	}
+	for _, secondary := range s.secondaries {
+		if obj, err := openExisting(secondary, path); !errors.Is(err, os.ErrNotExist) {
+			log.Warn("Object %s is missing in the primary storage, it's read from a secondary storage", path)
+			return obj, err
+		}
+	}
+	return nil, err
+}
+
+// openExisting opens an object and checks its existence, as some storages only report missing objects when reading
+func openExisting(storage ObjectStorage, path string) (Object, error) {
+	obj, err := storage.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := obj.Stat(); err != nil {
+		_ = obj.Close()
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Save saves the object to the primary storage and queues its replication
+func (s *ReplicatedStorage) Save(path string, r io.Reader, size int64) (int64, error) {
+	written, err := s.primary.Save(path, r, size)
+	if err != nil {
+		return written, err
+	}
+	s.queue(replicationTask{path: path})
+	return written, nil
+}
+
+// Stat returns the stat information of the object of the primary storage, or of the first secondary storage having it
+func (s *ReplicatedStorage) Stat(path string) (os.FileInfo, error) {
+	info, err := s.primary.Stat(path)
+	if !errors.Is(err, os.ErrNotExist) {
+		return info, err
+	}
+	for _, secondary := range s.secondaries {
+		if info, err := secondary.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			return info, err
+		}
+	}
+	return nil, err
+}
+
+// Delete deletes the object from the primary storage and queues its deletion from the secondary storages
+func (s *ReplicatedStorage) Delete(path string) error {
+	if err := s.primary.Delete(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	s.queue(replicationTask{path: path, delete: true})
+	return nil
+}
+
+// URL returns the URL of the object of the primary storage, or of the first secondary storage having it
+func (s *ReplicatedStorage) URL(path, name string) (*url.URL, error) {
+	if _, err := s.primary.Stat(path); errors.Is(err, os.ErrNotExist) {
+		for _, secondary := range s.secondaries {
+			if _, err := secondary.Stat(path); err == nil {
+				return secondary.URL(path, name)
+			}
+		}
+	}
+	return s.primary.URL(path, name)
+}
+
+// IterateObjects iterates across the objects of the primary storage
+func (s *ReplicatedStorage) IterateObjects(path string, iterator func(path string, obj Object) error) error {
+	return s.primary.IterateObjects(path, iterator)
+}
+
+// ReconcileResult holds the number of objects which differ between a primary and a secondary storage
+type ReconcileResult struct {
+	MissingInPrimary   int
+	MissingInSecondary int
+	SizeMismatch       int
+}
+
+// Reconcile compares the secondary storage with the primary storage and makes the secondary storage a copy of the primary
+// storage if autofix is set. The primary storage wins for objects of different size. Objects which only exist in the
+// secondary storage are deleted from it, as they are mostly deleted objects whose deletion hasn't been replicated.
+func (s *ReplicatedStorage) Reconcile(ctx context.Context, secondary ObjectStorage, autofix bool) (*ReconcileResult, error) {
+	result := &ReconcileResult{}
+	primarySizes := make(map[string]int64)
+	if err := s.primary.IterateObjects("", func(path string, obj Object) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		stat, err := obj.Stat()
+		if err != nil {
+			return err
+		}
+		primarySizes[path] = stat.Size()
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var toDelete []string
+	if err := secondary.IterateObjects("", func(path string, obj Object) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		stat, err := obj.Stat()
+		if err != nil {
+			return err
+		}
+		size, ok := primarySizes[path]
+		switch {
+		case !ok:
+			result.MissingInPrimary++
+			toDelete = append(toDelete, path)
+		case size != stat.Size():
+			result.SizeMismatch++
+		default:
+			delete(primarySizes, path)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	// the objects left are missing in the secondary storage or differ
+	result.MissingInSecondary = len(primarySizes) - result.SizeMismatch
+
+	if !autofix {
+		return result, nil
+	}
+	for _, path := range toDelete {
+		if err := secondary.Delete(path); err != nil {
+			return result, err
+		}
+	}
+	for path := range primarySizes {
+		if _, err := Copy(secondary, path, s.primary, path); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func init() {
+	RegisterStorageType(setting.ReplicatedStorageType, NewReplicatedStorage)
+}
@@ -0,0 +1,158 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestReplicatedStorage(t *testing.T) (*ReplicatedStorage, ObjectStorage) {
+	cfg := &setting.Storage{
+		Type: setting.ReplicatedStorageType,
+		Replication: &setting.ReplicationConfig{
+			Primary:     &setting.Storage{Type: setting.LocalStorageType, Path: t.TempDir()},
+			Secondaries: []*setting.Storage{{Type: setting.LocalStorageType, Path: t.TempDir()}},
+			QueueLength: 10,
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	s, err := NewReplicatedStorage(ctx, cfg)
+	assert.NoError(t, err)
+	replicated := Unwrap(s).(*ReplicatedStorage)
+	return replicated, replicated.Secondaries()[0]
+}
+
+func readObject(t *testing.T, s ObjectStorage, path string) string {
+	obj, err := s.Open(path)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer obj.Close()
+	content, err := io.ReadAll(obj)
+	assert.NoError(t, err)
+	return string(content)
+}
+
+func TestReplicatedStorage(t *testing.T) {
+	s, secondary := newTestReplicatedStorage(t)
+
+	_, err := s.Save("a/1.txt", strings.NewReader("a1"), -1)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		_, err := secondary.Stat("a/1.txt")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "a1", readObject(t, secondary, "a/1.txt"))
+
+	// objects missing in the primary storage are read from the secondary storage
+	assert.NoError(t, s.Primary().Delete("a/1.txt"))
+	assert.Equal(t, "a1", readObject(t, s, "a/1.txt"))
+	info, err := s.Stat("a/1.txt")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, info.Size())
+
+	assert.NoError(t, s.Delete("a/1.txt"))
+	assert.Eventually(t, func() bool {
+		_, err := secondary.Stat("a/1.txt")
+		return os.IsNotExist(err)
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err = s.Open("a/1.txt")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestReplicatedStorageReconcile(t *testing.T) {
+	s, secondary := newTestReplicatedStorage(t)
+
+	_, err := s.Primary().Save("primary.txt", strings.NewReader("p"), -1)
+	assert.NoError(t, err)
+	_, err = secondary.Save("secondary.txt", strings.NewReader("s"), -1)
+	assert.NoError(t, err)
+	_, err = s.Primary().Save("mismatch.txt", strings.NewReader("primary"), -1)
+	assert.NoError(t, err)
+	_, err = secondary.Save("mismatch.txt", strings.NewReader("secondary"), -1)
+	assert.NoError(t, err)
+
+	result, err := s.Reconcile(context.Background(), secondary, false)
+	assert.NoError(t, err)
+	assert.Equal(t, &ReconcileResult{MissingInPrimary: 1, MissingInSecondary: 1, SizeMismatch: 1}, result)
+
+	_, err = s.Reconcile(context.Background(), secondary, true)
+	assert.NoError(t, err)
+	_, err = secondary.Stat("secondary.txt")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = s.Primary().Stat("secondary.txt")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.Equal(t, "p", readObject(t, secondary, "primary.txt"))
+	assert.Equal(t, "primary", readObject(t, secondary, "mismatch.txt"))
+
+	result, err = s.Reconcile(context.Background(), secondary, false)
+	assert.NoError(t, err)
+	assert.Equal(t, &ReconcileResult{}, result)
+}
+
+func TestReplicatedStorageQueueFull(t *testing.T) {
+	defer test.MockVariableValue(&replicationQueueTimeout, 10*time.Millisecond)()
+
+	primary, err := NewLocalStorage(context.Background(), &setting.Storage{Path: t.TempDir()})
+	assert.NoError(t, err)
+	secondary, err := NewLocalStorage(context.Background(), &setting.Storage{Path: t.TempDir()})
+	assert.NoError(t, err)
+	// nothing takes the changes from the queue
+	s := &ReplicatedStorage{primary: primary, secondaries: []ObjectStorage{secondary}, tasks: make(chan replicationTask, 1)}
+
+	_, err = s.Save("1.txt", strings.NewReader("1"), -1)
+	assert.NoError(t, err)
+	_, err = s.Save("2.txt", strings.NewReader("2"), -1)
+	assert.NoError(t, err)
+
+	// the change which doesn't fit into the queue is replicated synchronously
+	_, err = secondary.Stat("1.txt")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.Equal(t, "2", readObject(t, secondary, "2.txt"))
+}
+
+func TestReplicatedStorageMultipart(t *testing.T) {
+	setting.LFS.JWTSecretBytes = []byte("secret")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := NewReplicatedStorage(ctx, &setting.Storage{
+		Type: setting.ReplicatedStorageType,
+		Replication: &setting.ReplicationConfig{
+			Primary:     &setting.Storage{Type: setting.LocalStorageType, Path: t.TempDir()},
+			Secondaries: []*setting.Storage{{Type: setting.LocalStorageType, Path: t.TempDir()}},
+		},
+	})
+	assert.NoError(t, err)
+	secondary := Unwrap(s).(*ReplicatedStorage).Secondaries()[0]
+
+	// the multipart capability of the local primary storage is kept
+	multipart, ok := s.(MultipartStorage)
+	assert.True(t, ok)
+	receiver, ok := s.(MultipartPartReceiver)
+	assert.True(t, ok)
+
+	parts, _, verify, err := multipart.GenerateMultipartParts("ab/cd/object", 10)
+	assert.NoError(t, err)
+	etag, err := receiver.UploadPart("ab/cd/object", 1, mustParseQuery(t, parts[0].Href), strings.NewReader("0123456789"))
+	assert.NoError(t, err)
+	assert.NoError(t, multipart.CommitUpload("ab/cd/object", `{"upload_id":"`+(*verify.Params)["upload_id"]+`","part_ids":[{"index":1,"etag":"`+etag+`"}]}`))
+
+	// the committed object is replicated
+	assert.Eventually(t, func() bool {
+		_, err := secondary.Stat("ab/cd/object")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "0123456789", readObject(t, secondary, "ab/cd/object"))
+}
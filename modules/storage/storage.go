@@ -4,7 +4,6 @@
 package storage
 
 import (
-	"code.gitea.io/gitea/modules/structs"
 	"context"
 	"errors"
 	"fmt"
@@ -14,11 +13,15 @@ import (
 
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
 )
 
 // ErrURLNotSupported represents url is not supported
 var ErrURLNotSupported = errors.New("url method not supported")
 
+// ErrMultipartNotSupported represents multipart upload is not supported
+var ErrMultipartNotSupported = errors.New("multipart upload not supported")
+
 // ErrInvalidConfiguration is called when there is invalid configuration for a storage
 type ErrInvalidConfiguration struct {
 	cfg any
@@ -66,10 +69,26 @@ type ObjectStorage interface {
 	Delete(path string) error
 	URL(path, name string) (*url.URL, error)
 	IterateObjects(path string, iterator func(path string, obj Object) error) error
+}
+
+// MultipartStorage represents an object storage which supports uploading objects in parts.
+// An unfinished upload is resumed when the parts of the same path are generated again.
+type MultipartStorage interface {
+	ObjectStorage
 	// GenerateMultipartParts Generate object upload endpoints and pos/index information
 	GenerateMultipartParts(path string, size int64) (parts []*structs.MultipartObjectPart, abort *structs.MultipartEndpoint, verify *structs.MultipartEndpoint, err error)
 	// CommitUpload used for merged multipart upload actions, used for multipart cases
 	CommitUpload(path, additionalParameter string) error
+	// IterateMultipartUploads iterates across the unfinished multipart uploads
+	IterateMultipartUploads(iterator func(upload *MultipartUpload) error) error
+	// AbortMultipartUpload aborts an unfinished multipart upload and removes its uploaded parts
+	AbortMultipartUpload(upload *MultipartUpload) error
+}
+
+// SupportsMultipart returns whether the storage supports multipart uploads
+func SupportsMultipart(s ObjectStorage) bool {
+	_, ok := s.(MultipartStorage)
+	return ok
 }
 
 // Copy copies a file from source ObjectStorage to dest ObjectStorage
@@ -134,6 +153,9 @@ var (
 	Actions ObjectStorage = uninitializedStorage
 	// Actions Artifacts represents actions artifacts storage
 	ActionsArtifacts ObjectStorage = uninitializedStorage
+
+	// DedupBlobs represents the storage of the deduplicated contents
+	DedupBlobs ObjectStorage = uninitializedStorage
 )
 
 // Init init the stoarge
@@ -146,6 +168,7 @@ func Init() error {
 		initRepoArchives,
 		initPackages,
 		initActions,
+		initDedup, // it wraps the storages above, so it must be the last one
 	} {
 		if err := f(); err != nil {
 			return err
@@ -229,3 +252,37 @@ func initActions() (err error) {
 	ActionsArtifacts, err = NewStorage(setting.Actions.ArtifactStorage.Type, setting.Actions.ArtifactStorage)
 	return err
 }
+
+func initDedup() (err error) {
+	if !setting.Dedup.Enabled {
+		DedupBlobs = discardStorage("Dedup isn't enabled")
+		return nil
+	}
+	log.Info("Initialising Dedup storage with type: %s", setting.Dedup.Storage.Type)
+	if DedupBlobs, err = NewStorage(setting.Dedup.Storage.Type, setting.Dedup.Storage); err != nil {
+		return err
+	}
+
+	for _, store := range []struct {
+		name    string
+		storage *ObjectStorage
+	}{
+		{setting.DedupStoreLFS, &LFS},
+		{setting.DedupStoreAttachments, &Attachments},
+		{setting.DedupStorePackages, &Packages},
+	} {
+		if !setting.IsDedupStore(store.name) {
+			continue
+		}
+		if _, ok := (*store.storage).(discardStorage); ok {
+			continue
+		}
+		log.Info("Deduplicating %s storage", store.name)
+		dedup, err := NewDedupStorage(context.Background(), store.name, DedupBlobs, *store.storage)
+		if err != nil {
+			return err
+		}
+		*store.storage = withMultipart(dedup, *store.storage, dedup.takeOverUpload)
+	}
+	return nil
+}
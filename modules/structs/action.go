@@ -0,0 +1,62 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package structs
+
+import "time"
+
+// ActionRun represents a run of an Actions workflow
+// swagger:model
+type ActionRun struct {
+	ID int64 `json:"id"`
+	// the number of the run in the repository, it's used in the web links of the run
+	RunNumber  int64  `json:"run_number"`
+	Title      string `json:"title"`
+	WorkflowID string `json:"workflow_id"`
+	Event      string `json:"event"`
+	Ref        string `json:"ref"`
+	HeadSHA    string `json:"head_sha"`
+	// one of unknown, waiting, running, success, failure, cancelled, skipped or blocked
+	Status       string `json:"status"`
+	NeedApproval bool   `json:"need_approval"`
+	TriggerUser  *User  `json:"trigger_user"`
+	// the evaluated `concurrency` group of the workflow, only one run of a group is in progress at a time
+	ConcurrencyGroup string `json:"concurrency_group"`
+	// whether the run cancels the runs of its group which are in progress
+	ConcurrencyCancelInProgress bool   `json:"concurrency_cancel_in_progress"`
+	HTMLURL                     string `json:"html_url"`
+	// the jobs of the run, they are only listed when a single run is requested
+	Jobs []*ActionRunJob `json:"jobs,omitempty"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Started *time.Time `json:"started_at"`
+	// swagger:strfmt date-time
+	Stopped *time.Time `json:"stopped_at"`
+}
+
+// ActionRunJob represents a job of an Actions run
+// swagger:model
+type ActionRunJob struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// the id of the job in the workflow
+	JobID  string   `json:"job_id"`
+	Needs  []string `json:"needs"`
+	RunsOn []string `json:"runs_on"`
+	// one of unknown, waiting, running, success, failure, cancelled, skipped or blocked
+	Status string `json:"status"`
+	// the evaluated `concurrency` group of the job
+	ConcurrencyGroup string `json:"concurrency_group"`
+	// whether the job cancels the jobs of its group which are in progress
+	ConcurrencyCancelInProgress bool `json:"concurrency_cancel_in_progress"`
+	// the concurrency group the blocked job waits for, it's empty if the job doesn't wait for a group
+	HeldByConcurrencyGroup string `json:"held_by_concurrency_group"`
+	// the deployment environment of the job
+	Environment    string `json:"environment"`
+	EnvironmentURL string `json:"environment_url"`
+	// swagger:strfmt date-time
+	Started *time.Time `json:"started_at"`
+	// swagger:strfmt date-time
+	Stopped *time.Time `json:"stopped_at"`
+}
@@ -109,4 +109,57 @@ type ChangedFile struct {
 	HTMLURL          string `json:"html_url,omitempty"`
 	ContentsURL      string `json:"contents_url,omitempty"`
 	RawURL           string `json:"raw_url,omitempty"`
+	// only set for notebooks, images and JSON or YAML documents if requested
+	RichDiff *RichDiff `json:"rich_diff,omitempty"`
+}
+
+// RichDiff represents a format aware diff of a changed file
+type RichDiff struct {
+	// enum: notebook,image,structured
+	Type string `json:"type"`
+	// Error is set if the content of the file can't be compared
+	Error      string                 `json:"error,omitempty"`
+	Notebook   []*NotebookCellDiff    `json:"notebook,omitempty"`
+	Image      *ImageDiff             `json:"image,omitempty"`
+	Structured []*StructuredDiffEntry `json:"structured,omitempty"`
+}
+
+// NotebookCellDiff represents the difference of a cell of a Jupyter notebook
+type NotebookCellDiff struct {
+	// enum: unchanged,changed,added,deleted
+	Status   string `json:"status"`
+	CellType string `json:"cell_type"`
+	// 1-based position of the cell in the base notebook, 0 if the cell was added
+	BaseIndex int `json:"base_index"`
+	// 1-based position of the cell in the head notebook, 0 if the cell was deleted
+	HeadIndex int `json:"head_index"`
+	// lines of the source prefixed by "+", "-" or " "
+	Lines          []string `json:"lines"`
+	OutputsChanged bool     `json:"outputs_changed"`
+}
+
+// ImageDiff represents the difference of the metadata of an image
+type ImageDiff struct {
+	Before     *ImageInfo `json:"before,omitempty"`
+	After      *ImageInfo `json:"after,omitempty"`
+	SizeChange int64      `json:"size_change"`
+}
+
+// ImageInfo represents the metadata of an image
+type ImageInfo struct {
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+	// 0 if the dimensions of the image format can't be read
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// StructuredDiffEntry represents a changed value of a JSON or YAML document
+type StructuredDiffEntry struct {
+	// JSONPath of the value
+	Path string `json:"path"`
+	// enum: changed,added,deleted
+	Status string `json:"status"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
 }
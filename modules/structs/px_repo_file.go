@@ -22,6 +22,9 @@ type CommitContentsResponse struct {
 	Size  int64  `json:"size"`
 	IsLFS bool   `json:"is_lfs"`
 
+	// `lfs` is populated when the entry is a LFS pointer, otherwise null
+	LFS *CommitContentsLFS `json:"lfs"`
+
 	// `encoding` is populated when `type` is `file`, otherwise null
 	Encoding *string `json:"encoding"`
 
@@ -35,3 +38,13 @@ type CommitContentsResponse struct {
 	SubmoduleGitURL *string            `json:"submodule_git_url"`
 	Links           *FileLinksResponse `json:"_links"`
 }
+
+// CommitContentsLFS contains the metadata of the LFS object an entry points to
+type CommitContentsLFS struct {
+	OID  string `json:"oid"`
+	Size int64  `json:"size"`
+	// `exists` is true if the object is stored on the server
+	Exists bool `json:"exists"`
+	// `download_url` is a pre-signed URL of the object if the storage serves it directly, otherwise null
+	DownloadURL *string `json:"download_url"`
+}
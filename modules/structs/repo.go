@@ -105,6 +105,11 @@ type Repository struct {
 	AvatarURL                     string           `json:"avatar_url"`
 	Internal                      bool             `json:"internal"`
 	MirrorInterval                string           `json:"mirror_interval"`
+	// ObjectFormatName of the underlying git repository
+	// enum: sha1,sha256
+	ObjectFormatName string `json:"object_format_name"`
+	// whether clients may clone the repository with a blob filter and fetch the missing objects on demand
+	AllowPartialClone bool `json:"allow_partial_clone"`
 	// swagger:strfmt date-time
 	MirrorUpdated time.Time     `json:"mirror_updated,omitempty"`
 	RepoTransfer  *RepoTransfer `json:"repo_transfer"`
@@ -139,6 +144,9 @@ type CreateRepoOption struct {
 	// TrustModel of the repository
 	// enum: default,collaborator,committer,collaboratorcommitter
 	TrustModel string `json:"trust_model"`
+	// ObjectFormatName of the underlying git repository, sha256 requires git 2.29 or later
+	// enum: sha1,sha256
+	ObjectFormatName string `json:"object_format_name" binding:"MaxSize(6)"`
 }
 
 // EditRepoOption options when editing a repository's properties
@@ -207,6 +215,8 @@ type EditRepoOption struct {
 	MirrorInterval *string `json:"mirror_interval,omitempty"`
 	// enable prune - remove obsolete remote-tracking references
 	EnablePrune *bool `json:"enable_prune,omitempty"`
+	// either `true` to allow partial clones (e.g. `git clone --filter=blob:none`), or `false` to prevent them.
+	AllowPartialClone *bool `json:"allow_partial_clone,omitempty"`
 }
 
 // GenerateRepoOption options when creating repository using a template
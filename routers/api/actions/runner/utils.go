@@ -6,6 +6,7 @@ package runner
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	actions_model "code.gitea.io/gitea/models/actions"
 	secret_model "code.gitea.io/gitea/models/secret"
@@ -33,9 +34,16 @@ func pickTask(ctx context.Context, runner *actions_model.ActionRunner) (*runnerv
 
 	actions.CreateCommitStatus(ctx, t.Job)
 
+	payload := t.Job.WorkflowPayload
+	if actions.CanJobRequestIDToken(t.Job) {
+		if payload, err = actions.AddIDTokenRequestEnv(payload, t.Token); err != nil {
+			return nil, false, fmt.Errorf("AddIDTokenRequestEnv: %w", err)
+		}
+	}
+
 	task := &runnerv1.Task{
 		Id:              t.ID,
-		WorkflowPayload: t.Job.WorkflowPayload,
+		WorkflowPayload: payload,
 		Context:         generateTaskContext(t),
 		Secrets:         getSecretsOfTask(ctx, t),
 		Vars:            getVariablesOfTask(ctx, t),
@@ -78,7 +86,36 @@ func getSecretsOfTask(ctx context.Context, task *actions_model.ActionTask) map[s
 		// go on
 	}
 
-	for _, secret := range append(ownerSecrets, repoSecrets...) {
+	var environmentSecrets []*secret_model.Secret
+	if environmentID := getEnvironmentIDOfTask(ctx, task); environmentID != 0 {
+		environmentSecrets, err = secret_model.FindSecrets(ctx, secret_model.FindSecretsOptions{RepoID: task.Job.Run.RepoID, EnvironmentID: environmentID})
+		if err != nil {
+			log.Error("find secrets of environment %v: %v", environmentID, err)
+			// go on
+		}
+	}
+
+	// Level precedence: Environment > Repo > Org / User
+	decryptSecrets(secrets, append(ownerSecrets, repoSecrets...))
+	if task.Job.CallerJobID != "" {
+		// the jobs of a called reusable workflow only get the secrets passed by their callers
+		jobs, err := actions_model.GetRunJobsByRunID(ctx, task.Job.RunID)
+		if err == nil {
+			secrets, err = actions.GetReusableWorkflowSecrets(task.Job, jobs, secrets)
+		}
+		if err != nil {
+			log.Error("get secrets passed to job %d: %v", task.Job.ID, err)
+			secrets = map[string]string{"GITHUB_TOKEN": task.Token, "GITEA_TOKEN": task.Token}
+		}
+	}
+	decryptSecrets(secrets, environmentSecrets)
+
+	return secrets
+}
+
+// decryptSecrets decrypts the encrypted secrets into secrets, the later ones override the earlier ones with the same names
+func decryptSecrets(secrets map[string]string, encrypted []*secret_model.Secret) {
+	for _, secret := range encrypted {
 		if v, err := secret_module.DecryptSecret(setting.SecretKey, secret.Data); err != nil {
 			log.Error("decrypt secret %v %q: %v", secret.ID, secret.Name, err)
 			// go on
@@ -86,8 +123,6 @@ func getSecretsOfTask(ctx context.Context, task *actions_model.ActionTask) map[s
 			secrets[secret.Name] = v
 		}
 	}
-
-	return secrets
 }
 
 func getVariablesOfTask(ctx context.Context, task *actions_model.ActionTask) map[string]string {
@@ -105,14 +140,36 @@ func getVariablesOfTask(ctx context.Context, task *actions_model.ActionTask) map
 		log.Error("find variables of repo: %d, error: %v", task.Job.Run.RepoID, err)
 	}
 
-	// Level precedence: Repo > Org / User
-	for _, v := range append(ownerVariables, repoVariables...) {
+	// Environment level
+	var environmentVariables []*actions_model.ActionVariable
+	if environmentID := getEnvironmentIDOfTask(ctx, task); environmentID != 0 {
+		environmentVariables, err = actions_model.FindVariables(ctx, actions_model.FindVariablesOpts{RepoID: task.Job.Run.RepoID, EnvironmentID: environmentID})
+		if err != nil {
+			log.Error("find variables of environment: %d, error: %v", environmentID, err)
+		}
+	}
+
+	// Level precedence: Environment > Repo > Org / User
+	for _, v := range append(append(ownerVariables, repoVariables...), environmentVariables...) {
 		variables[v.Name] = v.Data
 	}
 
 	return variables
 }
 
+// getEnvironmentIDOfTask returns the id of the environment which the job of a task deploys to, it's 0 if there isn't one
+func getEnvironmentIDOfTask(ctx context.Context, task *actions_model.ActionTask) int64 {
+	env, err := actions.GetJobEnvironment(ctx, task.Job)
+	if err != nil {
+		log.Error("find environment %q of job %d: %v", task.Job.Environment, task.Job.ID, err)
+		return 0
+	}
+	if env == nil {
+		return 0
+	}
+	return env.ID
+}
+
 func generateTaskContext(t *actions_model.ActionTask) *structpb.Struct {
 	event := map[string]any{}
 	_ = json.Unmarshal([]byte(t.Job.Run.EventPayload), &event)
@@ -210,19 +267,20 @@ func findTaskNeeds(ctx context.Context, task *actions_model.ActionTask) (map[str
 		if !needs.Contains(job.JobID) {
 			continue
 		}
-		if job.TaskID == 0 || !job.Status.IsDone() {
+		if (job.TaskID == 0 && !job.IsReusableWorkflowCaller()) || !job.Status.IsDone() {
 			// it shouldn't happen, or the job has been rerun
 			continue
 		}
-		outputs := make(map[string]string)
-		got, err := actions_model.FindTaskOutputByTaskID(ctx, job.TaskID)
+		outputs, err := actions.GetJobOutputs(ctx, job, jobs)
 		if err != nil {
-			return nil, fmt.Errorf("FindTaskOutputByTaskID: %w", err)
+			return nil, fmt.Errorf("GetJobOutputs: %w", err)
 		}
-		for _, v := range got {
-			outputs[v.OutputKey] = v.OutputValue
+		// the jobs of a called reusable workflow refer to each other without the prefix of their caller
+		needID := job.JobID
+		if task.Job.CallerJobID != "" {
+			needID = strings.TrimPrefix(needID, task.Job.CallerJobID+"/")
 		}
-		ret[job.JobID] = &runnerv1.TaskNeed{
+		ret[needID] = &runnerv1.TaskNeed{
 			Outputs: outputs,
 			Result:  runnerv1.Result(job.Status),
 		}
@@ -7,6 +7,8 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/routers/common"
 	files_service "code.gitea.io/gitea/services/repository/files"
 )
 
@@ -53,3 +55,51 @@ func GetBlob(ctx *context.APIContext) {
 		ctx.JSON(http.StatusOK, blob)
 	}
 }
+
+// GetRawBlob downloads the raw content of a blob of a repository by its sha
+func GetRawBlob(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/git/blobs/{sha}/raw repository GetRawBlob
+	// ---
+	// summary: Gets the raw content of a blob of a repository.
+	// produces:
+	// - application/octet-stream
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: sha
+	//   in: path
+	//   description: sha of the blob
+	//   type: string
+	//   required: true
+	// responses:
+	//   200:
+	//     description: Returns raw blob content.
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	blob, err := ctx.Repo.GitRepo.GetBlob(ctx.Params("sha"))
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetBlob", err)
+		}
+		return
+	}
+
+	if err := common.ServeBlob(ctx.Base, "", blob, nil); err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "ServeBlob", err)
+		}
+	}
+}
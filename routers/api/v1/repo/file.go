@@ -270,9 +270,14 @@ func GetArchive(ctx *context.APIContext) {
 	//   required: true
 	// - name: archive
 	//   in: path
-	//   description: the git reference for download with attached archive format (e.g. master.zip)
+	//   description: the git reference for download with attached archive format (e.g. master.zip), optionally followed by the path of a subdirectory (e.g. master/docs.tar.zst)
 	//   type: string
 	//   required: true
+	// - name: lfs
+	//   in: query
+	//   description: include the content of the LFS objects instead of their pointers
+	//   type: boolean
+	//   required: false
 	// responses:
 	//   200:
 	//     description: success
@@ -306,6 +311,7 @@ func archiveDownload(ctx *context.APIContext) {
 		}
 		return
 	}
+	aReq.IncludeLFS = setting.LFS.StartServer && ctx.FormBool("lfs")
 
 	archiver, err := aReq.Await(ctx)
 	if err != nil {
@@ -66,12 +66,12 @@ func getNote(ctx *context.APIContext, identifier string) {
 		return
 	}
 
-	commitSHA, err := ctx.Repo.GitRepo.ConvertToSHA1(identifier)
+	commitSHA, err := ctx.Repo.GitRepo.ConvertToGitID(identifier)
 	if err != nil {
 		if git.IsErrNotExist(err) {
 			ctx.NotFound(err)
 		} else {
-			ctx.Error(http.StatusInternalServerError, "ConvertToSHA1", err)
+			ctx.Error(http.StatusInternalServerError, "ConvertToGitID", err)
 		}
 		return
 	}
@@ -1398,6 +1398,10 @@ func GetPullRequestFiles(ctx *context.APIContext) {
 	//   description: whitespace behavior
 	//   type: string
 	//   enum: [ignore-all, ignore-change, ignore-eol, show-all]
+	// - name: rich_diff
+	//   in: query
+	//   description: include the format aware diffs of notebooks, images and JSON or YAML documents
+	//   type: boolean
 	// - name: page
 	//   in: query
 	//   description: page number of results to return (1-based)
@@ -1488,9 +1492,26 @@ func GetPullRequestFiles(ctx *context.APIContext) {
 		lenFiles = 0
 	}
 
+	var startCommit, endCommit *git.Commit
+	richDiff := ctx.FormBool("rich_diff")
+	if richDiff {
+		if startCommit, err = baseGitRepo.GetCommit(startCommitID); err != nil {
+			ctx.ServerError("GetCommit", err)
+			return
+		}
+		if endCommit, err = baseGitRepo.GetCommit(endCommitID); err != nil {
+			ctx.ServerError("GetCommit", err)
+			return
+		}
+	}
+
 	apiFiles := make([]*api.ChangedFile, 0, lenFiles)
 	for i := start; i < end; i++ {
-		apiFiles = append(apiFiles, convert.ToChangedFile(diff.Files[i], pr.HeadRepo, endCommitID))
+		apiFile := convert.ToChangedFile(diff.Files[i], pr.HeadRepo, endCommitID)
+		if richDiff {
+			apiFile.RichDiff = getRichDiff(diff.Files[i], startCommit, endCommit)
+		}
+		apiFiles = append(apiFiles, apiFile)
 	}
 
 	ctx.SetLinkHeader(totalNumberOfFiles, listOptions.PageSize)
@@ -1504,3 +1525,23 @@ func GetPullRequestFiles(ctx *context.APIContext) {
 
 	ctx.JSON(http.StatusOK, &apiFiles)
 }
+
+// getRichDiff returns the rich diff of a changed file or nil if the file has no rich diff type
+func getRichDiff(diffFile *gitdiff.DiffFile, startCommit, endCommit *git.Commit) *api.RichDiff {
+	var baseBlob, headBlob *git.Blob
+	if !diffFile.IsCreated {
+		baseBlob, _ = startCommit.GetBlobByPath(diffFile.OldName)
+	}
+	if !diffFile.IsDeleted {
+		headBlob, _ = endCommit.GetBlobByPath(diffFile.Name)
+	}
+	typ := gitdiff.GetRichDiffType(diffFile, baseBlob, headBlob)
+	if typ == gitdiff.RichDiffNone {
+		return nil
+	}
+	diff, err := gitdiff.CreateRichDiff(typ, baseBlob, headBlob)
+	if err != nil {
+		return &api.RichDiff{Type: string(typ), Error: err.Error()}
+	}
+	return convert.ToRichDiff(diff)
+}
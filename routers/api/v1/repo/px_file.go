@@ -6,6 +6,7 @@ import (
 	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/routers/api/v1/utils"
 	files_service "code.gitea.io/gitea/services/repository/files"
 )
 
@@ -32,6 +33,24 @@ func GetCommitsContents(ctx *context.APIContext) {
 	//   description: "The name of the commit/branch/tag. Default the repository’s default branch (usually master)"
 	//   type: string
 	//   required: false
+	// - name: recursive
+	//   in: query
+	//   description: list the entries of the subdirectories too
+	//   type: boolean
+	//   required: false
+	// - name: depth
+	//   in: query
+	//   description: number of directory levels listed recursively, up to 10 (the default)
+	//   type: integer
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based), all entries of a non-recursive listing are returned if neither page nor limit is given
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results, all entries of a non-recursive listing are returned if neither page nor limit is given
+	//   type: integer
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/ContentsListResponse"
@@ -49,14 +68,26 @@ func GetCommitsContents(ctx *context.APIContext) {
 
 	treePath := ctx.Params("*")
 	ref := ctx.FormTrim("ref")
+	opts := &files_service.CommitContentsListOptions{
+		ListOptions: utils.GetListOptions(ctx),
+		Recursive:   ctx.FormBool("recursive"),
+		Depth:       ctx.FormInt("depth"),
+	}
+	// the non-recursive listing has been complete before it could be paginated, so it's only paginated on request,
+	// the recursive listing is always paginated as it can be large
+	opts.ListAll = !opts.Recursive && ctx.FormString("page") == "" && ctx.FormString("limit") == ""
 
-	if fileList, err := files_service.GetCommitContentsOrList(ctx, ctx.Repo.Repository, treePath, ref); err != nil {
+	if fileList, total, err := files_service.GetCommitContentsOrList(ctx, ctx.Repo.Repository, treePath, ref, opts); err != nil {
 		if git.IsErrNotExist(err) {
 			ctx.NotFound("GetContentsOrList", err)
 			return
 		}
 		ctx.Error(http.StatusInternalServerError, "GetContentsOrList", err)
 	} else {
+		if !opts.IsListAll() {
+			ctx.SetLinkHeader(total, opts.PageSize)
+		}
+		ctx.SetTotalCountHeader(int64(total))
 		ctx.JSON(http.StatusOK, fileList)
 	}
 
@@ -85,6 +116,24 @@ func GetCommitsContentsList(ctx *context.APIContext) {
 	//   description: "The name of the commit/branch/tag. Default the repository’s default branch (usually master)"
 	//   type: string
 	//   required: false
+	// - name: recursive
+	//   in: query
+	//   description: list the entries of the subdirectories too
+	//   type: boolean
+	//   required: false
+	// - name: depth
+	//   in: query
+	//   description: number of directory levels listed recursively, up to 10 (the default)
+	//   type: integer
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based), all entries of a non-recursive listing are returned if neither page nor limit is given
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results, all entries of a non-recursive listing are returned if neither page nor limit is given
+	//   type: integer
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/ContentsListResponse"
@@ -18,3 +18,17 @@ type swaggerResponseSecret struct {
 	// in:body
 	Body api.Secret `json:"body"`
 }
+
+// ActionRunList
+// swagger:response ActionRunList
+type swaggerResponseActionRunList struct {
+	// in:body
+	Body []api.ActionRun `json:"body"`
+}
+
+// ActionRun
+// swagger:response ActionRun
+type swaggerResponseActionRun struct {
+	// in:body
+	Body api.ActionRun `json:"body"`
+}
@@ -195,6 +195,8 @@ func NormalRoutes() *web.Route {
 		// TODO: this prefix should be generated with a token string with runner ?
 		prefix = "/api/actions_pipeline"
 		r.Mount(prefix, actions_router.ArtifactsRoutes(prefix))
+
+		r.Mount(actions_service.IDTokenIssuerPath, actions_router.IDTokenRoutes())
 	}
 
 	return r
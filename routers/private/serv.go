@@ -159,6 +159,7 @@ func ServCommand(ctx *context.PrivateContext) {
 		repo.Owner = owner
 		repo.OwnerName = ownerName
 		results.RepoID = repo.ID
+		results.IsPartialCloneEnabled = repo.IsPartialCloneEnabled
 
 		if repo.IsBeingCreated() {
 			ctx.JSON(http.StatusInternalServerError, private.Response{
@@ -354,6 +355,7 @@ func ServCommand(ctx *context.PrivateContext) {
 			return
 		}
 		results.RepoID = repo.ID
+		results.IsPartialCloneEnabled = repo.IsPartialCloneEnabled
 	}
 
 	if results.IsWiki {
@@ -321,6 +321,7 @@ func editUserCommon(ctx *context.Context) {
 	ctx.Data["DisableMigrations"] = setting.Repository.DisableMigrations
 	ctx.Data["AllowedUserVisibilityModes"] = setting.Service.AllowedUserVisibilityModesSlice.ToVisibleTypeSlice()
 	ctx.Data["DisableGravatar"] = setting.Config().Picture.DisableGravatar.Value(ctx)
+	ctx.Data["LFSStartServer"] = setting.LFS.StartServer
 }
 
 // EditUser show editing user page
@@ -443,6 +444,8 @@ func EditUserPost(ctx *context.Context) {
 	u.Website = form.Website
 	u.Location = form.Location
 	u.MaxRepoCreation = form.MaxRepoCreation
+	u.LFSMaxFileSize = form.LFSMaxFileSize
+	u.LFSQuota = form.LFSQuota
 	u.IsActive = form.Active
 	u.IsAdmin = form.Admin
 	u.IsRestricted = form.Restricted
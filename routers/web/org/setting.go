@@ -47,6 +47,7 @@ func Settings(ctx *context.Context) {
 	ctx.Data["CurrentVisibility"] = ctx.Org.Organization.Visibility
 	ctx.Data["RepoAdminChangeTeamAccess"] = ctx.Org.Organization.RepoAdminChangeTeamAccess
 	ctx.Data["ContextUser"] = ctx.ContextUser
+	ctx.Data["LFSStartServer"] = setting.LFS.StartServer
 
 	err := shared_user.LoadHeaderCount(ctx)
 	if err != nil {
@@ -64,6 +65,7 @@ func SettingsPost(ctx *context.Context) {
 	ctx.Data["PageIsOrgSettings"] = true
 	ctx.Data["PageIsSettingsOptions"] = true
 	ctx.Data["CurrentVisibility"] = ctx.Org.Organization.Visibility
+	ctx.Data["LFSStartServer"] = setting.LFS.StartServer
 
 	if ctx.HasError() {
 		ctx.HTML(http.StatusOK, tplSettingsOptions)
@@ -105,6 +107,8 @@ func SettingsPost(ctx *context.Context) {
 
 	if ctx.Doer.IsAdmin {
 		org.MaxRepoCreation = form.MaxRepoCreation
+		org.LFSMaxFileSize = form.LFSMaxFileSize
+		org.LFSQuota = form.LFSQuota
 	}
 
 	org.FullName = form.FullName
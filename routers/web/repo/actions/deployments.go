@@ -0,0 +1,73 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"net/http"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/services/convert"
+)
+
+const tplDeployments base.TplName = "repo/actions/deployments"
+
+// Deployments render the history of the jobs which deploy to the environments of a repository
+func Deployments(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("actions.deployments")
+	ctx.Data["PageIsActions"] = true
+
+	page := ctx.FormInt("page")
+	if page <= 0 {
+		page = 1
+	}
+	environment := ctx.FormString("environment")
+	ctx.Data["CurEnvironment"] = environment
+
+	environments, err := actions_model.GetDeploymentEnvironments(ctx, ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.ServerError("GetDeploymentEnvironments", err)
+		return
+	}
+	ctx.Data["Environments"] = environments
+
+	opts := actions_model.FindDeploymentOptions{
+		ListOptions: db.ListOptions{
+			Page:     page,
+			PageSize: convert.ToCorrectPageSize(ctx.FormInt("limit")),
+		},
+		RepoID:      ctx.Repo.Repository.ID,
+		Environment: environment,
+	}
+	jobs, total, err := actions_model.FindDeployments(ctx, opts)
+	if err != nil {
+		ctx.ServerError("FindDeployments", err)
+		return
+	}
+	if err := jobs.LoadRuns(ctx, false); err != nil {
+		ctx.ServerError("LoadRuns", err)
+		return
+	}
+	runs := make(actions_model.RunList, 0, len(jobs))
+	for _, job := range jobs {
+		if job.Run != nil {
+			job.Run.Repo = ctx.Repo.Repository
+			runs = append(runs, job.Run)
+		}
+	}
+	if err := runs.LoadTriggerUser(ctx); err != nil {
+		ctx.ServerError("LoadTriggerUser", err)
+		return
+	}
+	ctx.Data["Deployments"] = jobs
+
+	pager := context.NewPagination(int(total), opts.PageSize, opts.Page, 5)
+	pager.SetDefaultParams(ctx)
+	pager.AddParamString("environment", environment)
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(http.StatusOK, tplDeployments)
+}
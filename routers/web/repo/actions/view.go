@@ -22,8 +22,8 @@ import (
 	"code.gitea.io/gitea/modules/actions"
 	"code.gitea.io/gitea/modules/base"
 	context_module "code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/storage"
-	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
 	actions_service "code.gitea.io/gitea/services/actions"
@@ -66,11 +66,18 @@ type ViewResponse struct {
 			Done       bool       `json:"done"`
 			Jobs       []*ViewJob `json:"jobs"`
 			Commit     ViewCommit `json:"commit"`
+			// ConcurrencyGroup is the concurrency group of the workflow, only one run of a group is in progress at a time
+			ConcurrencyGroup string `json:"concurrencyGroup"`
 		} `json:"run"`
 		CurrentJob struct {
 			Title  string         `json:"title"`
 			Detail string         `json:"detail"`
 			Steps  []*ViewJobStep `json:"steps"`
+			// Environment is the deployment environment of the job
+			Environment    string `json:"environment"`
+			EnvironmentURL string `json:"environmentURL"`
+			// CanReviewDeployment is true if the job waits for the reviewers of its environment and the doer is one of them
+			CanReviewDeployment bool `json:"canReviewDeployment"`
 		} `json:"currentJob"`
 	} `json:"state"`
 	Logs struct {
@@ -79,11 +86,16 @@ type ViewResponse struct {
 }
 
 type ViewJob struct {
-	ID       int64  `json:"id"`
-	Name     string `json:"name"`
-	Status   string `json:"status"`
-	CanRerun bool   `json:"canRerun"`
-	Duration string `json:"duration"`
+	ID               int64  `json:"id"`
+	Name             string `json:"name"`
+	Status           string `json:"status"`
+	CanRerun         bool   `json:"canRerun"`
+	Duration         string `json:"duration"`
+	ConcurrencyGroup string `json:"concurrencyGroup"`
+	Environment      string `json:"environment"`
+	// Depth is the nesting level of the called reusable workflow which the job belongs to, it's 0 for the jobs of the workflow of the run
+	Depth            int    `json:"depth"`
+	ReusableWorkflow string `json:"reusableWorkflow"`
 }
 
 type ViewCommit struct {
@@ -149,13 +161,22 @@ func ViewPost(ctx *context_module.Context) {
 	resp.State.Run.Done = run.Status.IsDone()
 	resp.State.Run.Jobs = make([]*ViewJob, 0, len(jobs)) // marshal to '[]' instead fo 'null' in json
 	resp.State.Run.Status = run.Status.String()
+	resp.State.Run.ConcurrencyGroup = run.ConcurrencyGroup
 	for _, v := range jobs {
+		depth := 0
+		if v.CallerJobID != "" {
+			depth = strings.Count(v.CallerJobID, "/") + 1
+		}
 		resp.State.Run.Jobs = append(resp.State.Run.Jobs, &ViewJob{
-			ID:       v.ID,
-			Name:     v.Name,
-			Status:   v.Status.String(),
-			CanRerun: v.Status.IsDone() && ctx.Repo.CanWrite(unit.TypeActions),
-			Duration: v.Duration().String(),
+			ID:               v.ID,
+			Name:             v.Name,
+			Status:           v.Status.String(),
+			CanRerun:         v.Status.IsDone() && ctx.Repo.CanWrite(unit.TypeActions),
+			Duration:         v.Duration().String(),
+			ConcurrencyGroup: v.ConcurrencyGroup,
+			Environment:      v.Environment,
+			Depth:            depth,
+			ReusableWorkflow: v.ReusableWorkflow,
 		})
 	}
 
@@ -195,6 +216,25 @@ func ViewPost(ctx *context_module.Context) {
 	resp.State.CurrentJob.Detail = current.Status.LocaleString(ctx.Locale)
 	if run.NeedApproval {
 		resp.State.CurrentJob.Detail = ctx.Locale.Tr("actions.need_approval_desc")
+	} else if group := actions_model.GetHeldConcurrencyGroup(run, current, jobs); group != "" {
+		resp.State.CurrentJob.Detail = ctx.Locale.Tr("actions.concurrency_held_desc", group)
+	} else if current.IsReusableWorkflowCaller() {
+		resp.State.CurrentJob.Detail = ctx.Locale.Tr("actions.reusable_workflow_desc", current.ReusableWorkflow, current.Status.LocaleString(ctx.Locale))
+	}
+	resp.State.CurrentJob.Environment = current.Environment
+	resp.State.CurrentJob.EnvironmentURL = current.EnvironmentURL
+	if current.Environment != "" {
+		env, err := actions_service.GetJobEnvironment(ctx, current)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, err.Error())
+			return
+		}
+		if env != nil && !run.NeedApproval && current.DeploymentApprovedBy == 0 && env.NeedReview() && actions_model.IsJobPending(current, jobs) {
+			resp.State.CurrentJob.Detail = ctx.Locale.Tr("actions.deployment_review_desc", env.Name)
+			resp.State.CurrentJob.CanReviewDeployment = ctx.Doer != nil && env.IsReviewer(ctx.Doer.ID)
+		} else if env != nil && current.Status == actions_model.StatusFailure && current.TaskID == 0 && !env.IsRefAllowed(run.Ref) {
+			resp.State.CurrentJob.Detail = ctx.Locale.Tr("actions.deployment_ref_not_allowed_desc", env.Name)
+		}
 	}
 	resp.State.CurrentJob.Steps = make([]*ViewJobStep, 0) // marshal to '[]' instead fo 'null' in json
 	resp.Logs.StepsLog = make([]*ViewStepLog, 0)          // marshal to '[]' instead fo 'null' in json
@@ -285,20 +325,52 @@ func Rerun(ctx *context_module.Context) {
 	}
 
 	if jobIndex != 0 {
-		jobs = []*actions_model.ActionRunJob{job}
+		jobs = getRerunJobs(job, jobs)
 	}
 
+	// a rerun waits for the other runs of its concurrency group like a new run
+	runHeld, err := actions_model.ShouldHoldRun(ctx, run)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	deploys := false
 	for _, j := range jobs {
-		if err := rerunJob(ctx, j); err != nil {
+		if err := rerunJob(ctx, j, runHeld); err != nil {
 			ctx.Error(http.StatusInternalServerError, err.Error())
 			return
 		}
+		deploys = deploys || j.Environment != "" || j.IsReusableWorkflowCaller()
+	}
+	if deploys {
+		// the emitter checks the protection rules of the environments again, and aggregates the statuses of the callers of reusable workflows
+		if err := actions_service.EmitJobsIfReady(run.ID); err != nil {
+			log.Error("EmitJobsIfReady: %v", err)
+		}
 	}
 
 	ctx.JSON(http.StatusOK, struct{}{})
 }
 
-func rerunJob(ctx *context_module.Context, job *actions_model.ActionRunJob) error {
+// getRerunJobs returns the jobs to rerun with a job, the jobs are all jobs of the run.
+// The jobs of a called reusable workflow are rerun with their caller, and the callers of a rerun job wait for it again.
+func getRerunJobs(job *actions_model.ActionRunJob, jobs []*actions_model.ActionRunJob) []*actions_model.ActionRunJob {
+	ret := []*actions_model.ActionRunJob{job}
+	for _, v := range jobs {
+		if v.ID == job.ID {
+			continue
+		}
+		if job.IsReusableWorkflowCaller() && strings.HasPrefix(v.JobID, job.JobID+"/") {
+			ret = append(ret, v)
+		} else if v.IsReusableWorkflowCaller() && strings.HasPrefix(job.JobID, v.JobID+"/") {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+func rerunJob(ctx *context_module.Context, job *actions_model.ActionRunJob, runHeld bool) error {
 	status := job.Status
 	if !status.IsDone() {
 		return nil
@@ -308,9 +380,21 @@ func rerunJob(ctx *context_module.Context, job *actions_model.ActionRunJob) erro
 	job.Status = actions_model.StatusWaiting
 	job.Started = 0
 	job.Stopped = 0
+	job.DeploymentApprovedBy = 0
 
 	if err := db.WithTx(ctx, func(ctx context.Context) error {
-		_, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"status": status}, "task_id", "status", "started", "stopped")
+		held := runHeld
+		if !held {
+			var err error
+			if held, err = actions_model.ShouldHoldJob(ctx, job); err != nil {
+				return err
+			}
+		}
+		// a deployment has to be allowed and approved again, and the caller of a reusable workflow waits for the called jobs
+		if held || job.Environment != "" || job.IsReusableWorkflowCaller() {
+			job.Status = actions_model.StatusBlocked
+		}
+		_, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"status": status}, "task_id", "status", "started", "stopped", "deployment_approved_by")
 		return err
 	}); err != nil {
 		return err
@@ -372,40 +456,23 @@ func Logs(ctx *context_module.Context) {
 func Cancel(ctx *context_module.Context) {
 	runIndex := ctx.ParamsInt64("run")
 
-	_, jobs := getRunJobs(ctx, runIndex, -1)
+	current, jobs := getRunJobs(ctx, runIndex, -1)
 	if ctx.Written() {
 		return
 	}
 
 	if err := db.WithTx(ctx, func(ctx context.Context) error {
-		for _, job := range jobs {
-			status := job.Status
-			if status.IsDone() {
-				continue
-			}
-			if job.TaskID == 0 {
-				job.Status = actions_model.StatusCancelled
-				job.Stopped = timeutil.TimeStampNow()
-				n, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"task_id": 0}, "status", "stopped")
-				if err != nil {
-					return err
-				}
-				if n == 0 {
-					return fmt.Errorf("job has changed, try again")
-				}
-				continue
-			}
-			if err := actions_model.StopTask(ctx, job.TaskID, actions_model.StatusCancelled); err != nil {
-				return err
-			}
-		}
-		return nil
+		return actions_model.CancelRunJobs(ctx, jobs)
 	}); err != nil {
 		ctx.Error(http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	actions_service.CreateCommitStatus(ctx, jobs...)
+	// release the concurrency groups of the run
+	if err := actions_service.EmitJobsIfReady(current.RunID); err != nil {
+		log.Error("EmitJobsIfReady: %v", err)
+	}
 
 	ctx.JSON(http.StatusOK, struct{}{})
 }
@@ -420,6 +487,7 @@ func Approve(ctx *context_module.Context) {
 	run := current.Run
 	doer := ctx.Doer
 
+	deploys := false
 	if err := db.WithTx(ctx, func(ctx context.Context) error {
 		run.NeedApproval = false
 		run.ApprovedBy = doer.ID
@@ -427,7 +495,18 @@ func Approve(ctx *context_module.Context) {
 			return err
 		}
 		for _, job := range jobs {
-			if len(job.Needs) == 0 && job.Status.IsBlocked() {
+			if job.Environment != "" {
+				// the emitter checks the protection rules of the environment
+				deploys = true
+				continue
+			}
+			if len(job.Needs) == 0 && job.Status.IsBlocked() && !job.IsReusableWorkflowCaller() {
+				// the job may still wait for its concurrency groups
+				if held, err := actions_service.IsJobHeld(ctx, run, jobs, job); err != nil {
+					return err
+				} else if held {
+					continue
+				}
 				job.Status = actions_model.StatusWaiting
 				_, err := actions_model.UpdateRunJob(ctx, job, nil, "status")
 				if err != nil {
@@ -442,10 +521,86 @@ func Approve(ctx *context_module.Context) {
 	}
 
 	actions_service.CreateCommitStatus(ctx, jobs...)
+	if deploys {
+		if err := actions_service.EmitJobsIfReady(run.ID); err != nil {
+			log.Error("EmitJobsIfReady: %v", err)
+		}
+	}
 
 	ctx.JSON(http.StatusOK, struct{}{})
 }
 
+// ApproveDeployment approves a job which waits for the reviewers of its environment
+func ApproveDeployment(ctx *context_module.Context) {
+	job := getJobWaitingForReview(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	job.DeploymentApprovedBy = ctx.Doer.ID
+	if n, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"status": actions_model.StatusBlocked, "deployment_approved_by": 0}, "deployment_approved_by"); err != nil {
+		ctx.Error(http.StatusInternalServerError, err.Error())
+		return
+	} else if n != 1 {
+		ctx.JSONError(ctx.Tr("actions.deployment_review.not_waiting"))
+		return
+	}
+
+	// the job may still wait for its concurrency groups
+	if err := actions_service.EmitJobsIfReady(job.RunID); err != nil {
+		log.Error("EmitJobsIfReady: %v", err)
+	}
+
+	ctx.JSON(http.StatusOK, struct{}{})
+}
+
+// RejectDeployment cancels a job which waits for the reviewers of its environment
+func RejectDeployment(ctx *context_module.Context) {
+	job := getJobWaitingForReview(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		return actions_model.CancelRunJobs(ctx, []*actions_model.ActionRunJob{job})
+	}); err != nil {
+		ctx.Error(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	actions_service.CreateCommitStatus(ctx, job)
+	// skip the jobs which need the rejected job
+	if err := actions_service.EmitJobsIfReady(job.RunID); err != nil {
+		log.Error("EmitJobsIfReady: %v", err)
+	}
+
+	ctx.JSON(http.StatusOK, struct{}{})
+}
+
+// getJobWaitingForReview returns the job of the request if it waits for the reviewers of its environment and the doer is one of them.
+// Any error will be written to the ctx.
+func getJobWaitingForReview(ctx *context_module.Context) *actions_model.ActionRunJob {
+	job, jobs := getRunJobs(ctx, ctx.ParamsInt64("run"), ctx.ParamsInt64("job"))
+	if ctx.Written() {
+		return nil
+	}
+
+	env, err := actions_service.GetJobEnvironment(ctx, job)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, err.Error())
+		return nil
+	}
+	if env == nil || !env.NeedReview() || job.Run.NeedApproval || job.DeploymentApprovedBy != 0 || !actions_model.IsJobPending(job, jobs) {
+		ctx.JSONError(ctx.Tr("actions.deployment_review.not_waiting"))
+		return nil
+	}
+	if !env.IsReviewer(ctx.Doer.ID) {
+		ctx.Error(http.StatusForbidden, "only the reviewers of the environment can review the deployment")
+		return nil
+	}
+	return job
+}
+
 // getRunJobs gets the jobs of runIndex, and returns jobs[jobIndex], jobs.
 // Any error will be written to the ctx.
 // It never returns a nil job of an empty jobs, if the jobIndex is out of range, it will be treated as 0.
@@ -79,6 +79,7 @@ func setCompareContext(ctx *context.Context, before, head *git.Commit, headOwner
 	setPathsCompareContext(ctx, before, head, headOwner, headName)
 	setImageCompareContext(ctx)
 	setCsvCompareContext(ctx)
+	setRichDiffCompareContext(ctx)
 }
 
 // SourceCommitURL creates a relative URL for a commit in the given repository
@@ -183,6 +184,35 @@ func setCsvCompareContext(ctx *context.Context) {
 	}
 }
 
+// setRichDiffCompareContext sets context data that is required by the notebook, image and structured compare templates
+func setRichDiffCompareContext(ctx *context.Context) {
+	ctx.Data["GetRichDiffTypeByName"] = func(diffFile *gitdiff.DiffFile) gitdiff.RichDiffType {
+		if diffFile == nil {
+			return gitdiff.RichDiffNone
+		}
+		return gitdiff.GetRichDiffTypeByName(diffFile.Name)
+	}
+
+	type RichDiffResult struct {
+		Diff  *gitdiff.RichDiff
+		Error string
+	}
+
+	ctx.Data["CreateRichDiff"] = func(typ gitdiff.RichDiffType, diffFile *gitdiff.DiffFile, baseBlob, headBlob *git.Blob) RichDiffResult {
+		if diffFile == nil {
+			return RichDiffResult{nil, ""}
+		}
+		diff, err := gitdiff.CreateRichDiff(typ, baseBlob, headBlob)
+		if err == gitdiff.ErrRichDiffTooLarge {
+			return RichDiffResult{nil, ctx.Locale.Tr("repo.error.rich_diff.too_large")}
+		} else if err != nil {
+			log.Debug("CreateRichDiff for file %s in %s failed: %v", diffFile.Name, ctx.Repo.Repository.Name, err)
+			return RichDiffResult{nil, ctx.Locale.Tr("repo.error.rich_diff.invalid", err.Error())}
+		}
+		return RichDiffResult{diff, ""}
+	}
+}
+
 // CompareInfo represents the collected results from ParseCompareInfo
 type CompareInfo struct {
 	HeadUser         *user_model.User
@@ -316,7 +346,7 @@ func ParseCompareInfo(ctx *context.Context) *CompareInfo {
 			ci.BaseBranch = baseCommit.ID.String()
 			ctx.Data["BaseBranch"] = ci.BaseBranch
 			baseIsCommit = true
-		} else if ci.BaseBranch == git.EmptySHA {
+		} else if ci.BaseBranch == ctx.Repo.Repository.GetObjectFormat().EmptyObjectID().String() {
 			if isSameRepo {
 				ctx.Redirect(ctx.Repo.RepoLink + "/compare/" + util.PathEscapeSegments(ci.HeadBranch))
 			} else {
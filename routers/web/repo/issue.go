@@ -51,6 +51,7 @@ import (
 	"code.gitea.io/gitea/services/convert"
 	"code.gitea.io/gitea/services/forms"
 	issue_service "code.gitea.io/gitea/services/issue"
+	"code.gitea.io/gitea/services/mailer/incoming"
 	pull_service "code.gitea.io/gitea/services/pull"
 	repo_service "code.gitea.io/gitea/services/repository"
 )
@@ -491,6 +492,15 @@ func Issues(ctx *context.Context) {
 		ctx.Data["Title"] = ctx.Tr("repo.issues")
 		ctx.Data["PageIsIssueList"] = true
 		ctx.Data["NewIssueChooseTemplate"] = issue_service.HasTemplatesOrContactLinks(ctx.Repo.Repository, ctx.Repo.GitRepo)
+
+		if setting.IncomingEmail.Enabled && ctx.IsSigned && !ctx.Repo.Repository.IsArchived {
+			address, err := incoming.NewIssueAddress(ctx.Doer, ctx.Repo.Repository)
+			if err != nil {
+				ctx.ServerError("NewIssueAddress", err)
+				return
+			}
+			ctx.Data["NewIssueEmailAddress"] = address
+		}
 	}
 
 	issues(ctx, ctx.FormInt64("milestone"), ctx.FormInt64("project"), util.OptionalBoolOf(isPullList))
@@ -159,6 +159,10 @@ func Create(ctx *context.Context) {
 	ctx.Data["private"] = getRepoPrivate(ctx)
 	ctx.Data["IsForcedPrivate"] = setting.Repository.ForcePrivate
 	ctx.Data["default_branch"] = setting.Repository.DefaultBranch
+	if len(git.SupportedObjectFormats) > 1 {
+		ctx.Data["SupportedObjectFormats"] = git.SupportedObjectFormats
+		ctx.Data["DefaultObjectFormat"] = git.Sha1ObjectFormat
+	}
 
 	ctxUser := checkContextUser(ctx, ctx.FormInt64("org"))
 	if ctx.Written() {
@@ -223,6 +227,10 @@ func CreatePost(ctx *context.Context) {
 	ctx.Data["LabelTemplateFiles"] = repo_module.LabelTemplateFiles
 	ctx.Data["Licenses"] = repo_module.Licenses
 	ctx.Data["Readmes"] = repo_module.Readmes
+	if len(git.SupportedObjectFormats) > 1 {
+		ctx.Data["SupportedObjectFormats"] = git.SupportedObjectFormats
+		ctx.Data["DefaultObjectFormat"] = git.Sha1ObjectFormat
+	}
 
 	ctx.Data["CanCreateRepo"] = ctx.Doer.CanCreateRepo()
 	ctx.Data["MaxCreationLimit"] = ctx.Doer.MaxCreationLimit()
@@ -277,17 +285,18 @@ func CreatePost(ctx *context.Context) {
 		}
 	} else {
 		repo, err = repo_service.CreateRepository(ctx, ctx.Doer, ctxUser, repo_service.CreateRepoOptions{
-			Name:          form.RepoName,
-			Description:   form.Description,
-			Gitignores:    form.Gitignores,
-			IssueLabels:   form.IssueLabels,
-			License:       form.License,
-			Readme:        form.Readme,
-			IsPrivate:     form.Private || setting.Repository.ForcePrivate,
-			DefaultBranch: form.DefaultBranch,
-			AutoInit:      form.AutoInit,
-			IsTemplate:    form.Template,
-			TrustModel:    repo_model.ToTrustModel(form.TrustModel),
+			Name:             form.RepoName,
+			Description:      form.Description,
+			Gitignores:       form.Gitignores,
+			IssueLabels:      form.IssueLabels,
+			License:          form.License,
+			Readme:           form.Readme,
+			IsPrivate:        form.Private || setting.Repository.ForcePrivate,
+			DefaultBranch:    form.DefaultBranch,
+			AutoInit:         form.AutoInit,
+			IsTemplate:       form.Template,
+			TrustModel:       repo_model.ToTrustModel(form.TrustModel),
+			ObjectFormatName: form.ObjectFormatName,
 		})
 		if err == nil {
 			log.Trace("Repository created [%d]: %s/%s", repo.ID, ctxUser.Name, repo.Name)
@@ -428,6 +437,7 @@ func Download(ctx *context.Context) {
 		}
 		return
 	}
+	aReq.IncludeLFS = setting.LFS.StartServer && ctx.FormBool("lfs")
 
 	archiver, err := aReq.Await(ctx)
 	if err != nil {
@@ -479,8 +489,9 @@ func InitiateDownload(ctx *context.Context) {
 		ctx.Error(http.StatusNotFound)
 		return
 	}
+	aReq.IncludeLFS = setting.LFS.StartServer && ctx.FormBool("lfs")
 
-	archiver, err := repo_model.GetRepoArchiver(ctx, aReq.RepoID, aReq.Type, aReq.CommitID)
+	archiver, err := repo_model.GetRepoArchiver(ctx, aReq.RepoID, aReq.Type, aReq.CommitID, aReq.Path, aReq.IncludeLFS)
 	if err != nil {
 		ctx.ServerError("archiver_service.StartArchive", err)
 		return
@@ -0,0 +1,196 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package setting
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/forms"
+)
+
+const (
+	tplRepoEnvironments    base.TplName = "repo/settings/actions"
+	tplRepoEnvironmentEdit base.TplName = "repo/settings/environment_edit"
+)
+
+// MustLoadEnvironment loads the environment of the request, its secrets and variables are managed by the shared handlers
+func MustLoadEnvironment(ctx *context.Context) {
+	env, err := actions_model.GetEnvironmentByID(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":environment_id"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound("GetEnvironmentByID", err)
+		} else {
+			ctx.ServerError("GetEnvironmentByID", err)
+		}
+		return
+	}
+	ctx.Data["Environment"] = env
+	ctx.Data["EnvironmentLink"] = fmt.Sprintf("%s/settings/actions/environments/%d", ctx.Repo.RepoLink, env.ID)
+}
+
+// Environments render the deployment environments of a repository
+func Environments(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("actions.environments")
+	ctx.Data["PageType"] = "environments"
+	ctx.Data["PageIsSharedSettingsEnvironments"] = true
+
+	envs, err := actions_model.FindEnvironments(ctx, ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.ServerError("FindEnvironments", err)
+		return
+	}
+	ctx.Data["Environments"] = envs
+
+	ctx.HTML(http.StatusOK, tplRepoEnvironments)
+}
+
+// NewEnvironment render the page to create a deployment environment
+func NewEnvironment(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("actions.environments.creation")
+	ctx.Data["PageIsSharedSettingsEnvironments"] = true
+
+	prepareEnvironmentForm(ctx, &actions_model.ActionEnvironment{})
+	if ctx.Written() {
+		return
+	}
+
+	ctx.HTML(http.StatusOK, tplRepoEnvironmentEdit)
+}
+
+// NewEnvironmentPost creates a deployment environment
+func NewEnvironmentPost(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("actions.environments.creation")
+	ctx.Data["PageIsSharedSettingsEnvironments"] = true
+	form := web.GetForm(ctx).(*forms.EditEnvironmentForm)
+
+	env := &actions_model.ActionEnvironment{
+		RepoID: ctx.Repo.Repository.ID,
+		Name:   strings.TrimSpace(form.Name),
+	}
+	if !applyEnvironmentForm(ctx, env, form) {
+		return
+	}
+
+	if err := actions_model.CreateEnvironment(ctx, env); err != nil {
+		if errors.Is(err, util.ErrAlreadyExist) {
+			ctx.Data["Err_Name"] = true
+			ctx.RenderWithErr(ctx.Tr("actions.environments.name_been_taken", env.Name), tplRepoEnvironmentEdit, form)
+			return
+		}
+		ctx.ServerError("CreateEnvironment", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("actions.environments.creation.success", env.Name))
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/actions/environments")
+}
+
+// EditEnvironment render the page to edit the protection rules of a deployment environment
+func EditEnvironment(ctx *context.Context) {
+	env := ctx.Data["Environment"].(*actions_model.ActionEnvironment)
+	ctx.Data["Title"] = ctx.Tr("actions.environments.edit")
+	ctx.Data["PageIsSharedSettingsEnvironments"] = true
+
+	prepareEnvironmentForm(ctx, env)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.HTML(http.StatusOK, tplRepoEnvironmentEdit)
+}
+
+// EditEnvironmentPost updates the protection rules of a deployment environment
+func EditEnvironmentPost(ctx *context.Context) {
+	env := ctx.Data["Environment"].(*actions_model.ActionEnvironment)
+	ctx.Data["Title"] = ctx.Tr("actions.environments.edit")
+	ctx.Data["PageIsSharedSettingsEnvironments"] = true
+	form := web.GetForm(ctx).(*forms.EditEnvironmentForm)
+
+	if !applyEnvironmentForm(ctx, env, form) {
+		return
+	}
+
+	if err := actions_model.UpdateEnvironment(ctx, env); err != nil {
+		ctx.ServerError("UpdateEnvironment", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("actions.environments.update.success", env.Name))
+	ctx.Redirect(ctx.Data["EnvironmentLink"].(string))
+}
+
+// DeleteEnvironment deletes a deployment environment with its secrets and variables
+func DeleteEnvironment(ctx *context.Context) {
+	env := ctx.Data["Environment"].(*actions_model.ActionEnvironment)
+
+	if err := actions_model.DeleteEnvironment(ctx, env); err != nil {
+		log.Error("DeleteEnvironment(%d) failed: %v", env.ID, err)
+		ctx.Flash.Error(ctx.Tr("actions.environments.deletion.failed"))
+	} else {
+		ctx.Flash.Success(ctx.Tr("actions.environments.deletion.success"))
+	}
+	ctx.JSONRedirect(ctx.Repo.RepoLink + "/settings/actions/environments")
+}
+
+// prepareEnvironmentForm sets the values of the form and the users who may review the deployments
+func prepareEnvironmentForm(ctx *context.Context, env *actions_model.ActionEnvironment) {
+	users, err := access_model.GetRepoReaders(ctx, ctx.Repo.Repository)
+	if err != nil {
+		ctx.ServerError("GetRepoReaders", err)
+		return
+	}
+	ctx.Data["Users"] = users
+	ctx.Data["name"] = env.Name
+	ctx.Data["branch_patterns"] = strings.Join(env.BranchPatterns, "\n")
+	ctx.Data["reviewers"] = strings.Join(base.Int64sToStrings(env.ReviewerIDs), ",")
+}
+
+// applyEnvironmentForm applies the protection rules of the form to an environment,
+// it renders the form with the error and returns false if the rules are invalid
+func applyEnvironmentForm(ctx *context.Context, env *actions_model.ActionEnvironment, form *forms.EditEnvironmentForm) bool {
+	prepareEnvironmentForm(ctx, env)
+	if ctx.Written() {
+		return false
+	}
+	ctx.Data["branch_patterns"] = form.BranchPatterns
+	ctx.Data["reviewers"] = form.Reviewers
+
+	if ctx.HasError() {
+		ctx.HTML(http.StatusOK, tplRepoEnvironmentEdit)
+		return false
+	}
+
+	env.BranchPatterns = env.BranchPatterns[:0]
+	for _, pattern := range strings.Split(form.BranchPatterns, "\n") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			env.BranchPatterns = append(env.BranchPatterns, pattern)
+		}
+	}
+
+	env.ReviewerIDs = env.ReviewerIDs[:0]
+	if strings.TrimSpace(form.Reviewers) != "" {
+		reviewerIDs, _ := base.StringsToInt64s(strings.Split(form.Reviewers, ","))
+		// only the users who can read the repository may review the deployments
+		for _, id := range reviewerIDs {
+			for _, u := range ctx.Data["Users"].([]*user_model.User) {
+				if u.ID == id {
+					env.ReviewerIDs = append(env.ReviewerIDs, id)
+					break
+				}
+			}
+		}
+	}
+	return true
+}
@@ -388,10 +388,10 @@ func LFSFileFind(ctx *context.Context) {
 	sha := ctx.FormString("sha")
 	ctx.Data["Title"] = oid
 	ctx.Data["PageIsSettingsLFS"] = true
-	var hash git.SHA1
+	var hash git.ObjectID
 	if len(sha) == 0 {
 		pointer := lfs.Pointer{Oid: oid, Size: size}
-		hash = git.ComputeBlobHash([]byte(pointer.StringContent()))
+		hash = git.ComputeBlobHash(ctx.Repo.Repository.GetObjectFormat(), []byte(pointer.StringContent()))
 		sha = hash.String()
 	} else {
 		hash = git.MustIDFromString(sha)
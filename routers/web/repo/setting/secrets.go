@@ -5,8 +5,10 @@ package setting
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
+	actions_model "code.gitea.io/gitea/models/actions"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/setting"
@@ -24,6 +26,7 @@ const (
 type secretsCtx struct {
 	OwnerID         int64
 	RepoID          int64
+	EnvironmentID   int64
 	IsRepo          bool
 	IsOrg           bool
 	IsUser          bool
@@ -33,6 +36,15 @@ type secretsCtx struct {
 
 func getSecretsCtx(ctx *context.Context) (*secretsCtx, error) {
 	if ctx.Data["PageIsRepoSettings"] == true {
+		if env, ok := ctx.Data["Environment"].(*actions_model.ActionEnvironment); ok {
+			return &secretsCtx{
+				RepoID:          ctx.Repo.Repository.ID,
+				EnvironmentID:   env.ID,
+				IsRepo:          true,
+				SecretsTemplate: tplRepoSecrets,
+				RedirectLink:    fmt.Sprintf("%s/settings/actions/environments/%d/secrets", ctx.Repo.RepoLink, env.ID),
+			}, nil
+		}
 		return &secretsCtx{
 			OwnerID:         0,
 			RepoID:          ctx.Repo.Repository.ID,
@@ -73,7 +85,6 @@ func getSecretsCtx(ctx *context.Context) (*secretsCtx, error) {
 func Secrets(ctx *context.Context) {
 	ctx.Data["Title"] = ctx.Tr("actions.actions")
 	ctx.Data["PageType"] = "secrets"
-	ctx.Data["PageIsSharedSettingsSecrets"] = true
 
 	sCtx, err := getSecretsCtx(ctx)
 	if err != nil {
@@ -81,11 +92,17 @@ func Secrets(ctx *context.Context) {
 		return
 	}
 
+	if sCtx.EnvironmentID != 0 {
+		ctx.Data["PageIsSharedSettingsEnvironments"] = true
+	} else {
+		ctx.Data["PageIsSharedSettingsSecrets"] = true
+	}
+
 	if sCtx.IsRepo {
 		ctx.Data["DisableSSH"] = setting.SSH.Disabled
 	}
 
-	shared.SetSecretsContext(ctx, sCtx.OwnerID, sCtx.RepoID)
+	shared.SetSecretsContext(ctx, sCtx.OwnerID, sCtx.RepoID, sCtx.EnvironmentID)
 	if ctx.Written() {
 		return
 	}
@@ -108,6 +125,7 @@ func SecretsPost(ctx *context.Context) {
 		ctx,
 		sCtx.OwnerID,
 		sCtx.RepoID,
+		sCtx.EnvironmentID,
 		sCtx.RedirectLink,
 	)
 }
@@ -122,6 +140,7 @@ func SecretsDelete(ctx *context.Context) {
 		ctx,
 		sCtx.OwnerID,
 		sCtx.RepoID,
+		sCtx.EnvironmentID,
 		sCtx.RedirectLink,
 	)
 }
@@ -58,6 +58,7 @@ func SettingsCtxData(ctx *context.Context) {
 	ctx.Data["DisableNewPushMirrors"] = setting.Mirror.DisableNewPush
 	ctx.Data["DefaultMirrorInterval"] = setting.Mirror.DefaultInterval
 	ctx.Data["MinimumMirrorInterval"] = setting.Mirror.MinInterval
+	ctx.Data["DisablePartialClone"] = setting.Git.DisablePartialClone
 
 	signing, _ := asymkey_service.SigningKey(ctx, ctx.Repo.Repository.RepoPath())
 	ctx.Data["SigningKeyAvailable"] = len(signing) > 0
@@ -103,6 +104,7 @@ func SettingsPost(ctx *context.Context) {
 	ctx.Data["DisableNewPushMirrors"] = setting.Mirror.DisableNewPush
 	ctx.Data["DefaultMirrorInterval"] = setting.Mirror.DefaultInterval
 	ctx.Data["MinimumMirrorInterval"] = setting.Mirror.MinInterval
+	ctx.Data["DisablePartialClone"] = setting.Git.DisablePartialClone
 
 	signing, _ := asymkey_service.SigningKey(ctx, ctx.Repo.Repository.RepoPath())
 	ctx.Data["SigningKeyAvailable"] = len(signing) > 0
@@ -161,6 +163,9 @@ func SettingsPost(ctx *context.Context) {
 		repo.Description = form.Description
 		repo.Website = form.Website
 		repo.IsTemplate = form.Template
+		if !setting.Git.DisablePartialClone {
+			repo.IsPartialCloneEnabled = form.EnablePartialClone
+		}
 
 		// Visibility of forked repository is forced sync with base repository.
 		if repo.IsFork {
@@ -637,6 +642,8 @@ func SettingsPost(ctx *context.Context) {
 		if repo.IsFsckEnabled != form.EnableHealthCheck {
 			repo.IsFsckEnabled = form.EnableHealthCheck
 		}
+		repo.LFSMaxFileSize = max(form.LFSMaxFileSize, 0)
+		repo.LFSQuota = max(form.LFSQuota, 0)
 
 		if err := repo_service.UpdateRepository(ctx, repo, false); err != nil {
 			ctx.ServerError("UpdateRepository", err)
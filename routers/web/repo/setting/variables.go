@@ -5,8 +5,10 @@ package setting
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
+	actions_model "code.gitea.io/gitea/models/actions"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/setting"
@@ -23,6 +25,7 @@ const (
 type variablesCtx struct {
 	OwnerID           int64
 	RepoID            int64
+	EnvironmentID     int64
 	IsRepo            bool
 	IsOrg             bool
 	IsUser            bool
@@ -32,6 +35,15 @@ type variablesCtx struct {
 
 func getVariablesCtx(ctx *context.Context) (*variablesCtx, error) {
 	if ctx.Data["PageIsRepoSettings"] == true {
+		if env, ok := ctx.Data["Environment"].(*actions_model.ActionEnvironment); ok {
+			return &variablesCtx{
+				RepoID:            ctx.Repo.Repository.ID,
+				EnvironmentID:     env.ID,
+				IsRepo:            true,
+				VariablesTemplate: tplRepoVariables,
+				RedirectLink:      fmt.Sprintf("%s/settings/actions/environments/%d/variables", ctx.Repo.RepoLink, env.ID),
+			}, nil
+		}
 		return &variablesCtx{
 			RepoID:            ctx.Repo.Repository.ID,
 			IsRepo:            true,
@@ -69,7 +81,6 @@ func getVariablesCtx(ctx *context.Context) (*variablesCtx, error) {
 func Variables(ctx *context.Context) {
 	ctx.Data["Title"] = ctx.Tr("actions.variables")
 	ctx.Data["PageType"] = "variables"
-	ctx.Data["PageIsSharedSettingsVariables"] = true
 
 	vCtx, err := getVariablesCtx(ctx)
 	if err != nil {
@@ -77,7 +88,13 @@ func Variables(ctx *context.Context) {
 		return
 	}
 
-	shared.SetVariablesContext(ctx, vCtx.OwnerID, vCtx.RepoID)
+	if vCtx.EnvironmentID != 0 {
+		ctx.Data["PageIsSharedSettingsEnvironments"] = true
+	} else {
+		ctx.Data["PageIsSharedSettingsVariables"] = true
+	}
+
+	shared.SetVariablesContext(ctx, vCtx.OwnerID, vCtx.RepoID, vCtx.EnvironmentID)
 	if ctx.Written() {
 		return
 	}
@@ -97,7 +114,7 @@ func VariableCreate(ctx *context.Context) {
 		return
 	}
 
-	shared.CreateVariable(ctx, vCtx.OwnerID, vCtx.RepoID, vCtx.RedirectLink)
+	shared.CreateVariable(ctx, vCtx.OwnerID, vCtx.RepoID, vCtx.EnvironmentID, vCtx.RedirectLink)
 }
 
 func VariableUpdate(ctx *context.Context) {
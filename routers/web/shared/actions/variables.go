@@ -17,10 +17,11 @@ import (
 	secret_service "code.gitea.io/gitea/services/secrets"
 )
 
-func SetVariablesContext(ctx *context.Context, ownerID, repoID int64) {
+func SetVariablesContext(ctx *context.Context, ownerID, repoID, environmentID int64) {
 	variables, err := actions_model.FindVariables(ctx, actions_model.FindVariablesOpts{
-		OwnerID: ownerID,
-		RepoID:  repoID,
+		OwnerID:       ownerID,
+		RepoID:        repoID,
+		EnvironmentID: environmentID,
 	})
 	if err != nil {
 		ctx.ServerError("FindVariables", err)
@@ -45,7 +46,7 @@ func envNameCIRegexMatch(name string) error {
 	return nil
 }
 
-func CreateVariable(ctx *context.Context, ownerID, repoID int64, redirectURL string) {
+func CreateVariable(ctx *context.Context, ownerID, repoID, environmentID int64, redirectURL string) {
 	form := web.GetForm(ctx).(*forms.EditVariableForm)
 
 	if err := secret_service.ValidateName(form.Name); err != nil {
@@ -58,7 +59,7 @@ func CreateVariable(ctx *context.Context, ownerID, repoID int64, redirectURL str
 		return
 	}
 
-	v, err := actions_model.InsertVariable(ctx, ownerID, repoID, form.Name, ReserveLineBreakForTextarea(form.Data))
+	v, err := actions_model.InsertVariable(ctx, ownerID, repoID, environmentID, form.Name, ReserveLineBreakForTextarea(form.Data))
 	if err != nil {
 		log.Error("InsertVariable error: %v", err)
 		ctx.JSONError(ctx.Tr("actions.variables.creation.failed"))
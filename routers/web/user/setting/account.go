@@ -166,6 +166,27 @@ func EmailPost(ctx *context.Context) {
 			ctx.ServerError("SetEmailNotifications", err)
 			return
 		}
+		digest := ctx.FormString("digest")
+		if digest == "" {
+			digest = user_model.EmailDigestImmediate
+		}
+		if !(digest == user_model.EmailDigestImmediate ||
+			digest == user_model.EmailDigestHourly ||
+			digest == user_model.EmailDigestDaily) {
+			log.Error("Email digest frequency change returned unrecognized option %s: %s", digest, ctx.Doer.Name)
+			ctx.ServerError("SetEmailDigestFrequency", errors.New("option unrecognized"))
+			return
+		}
+		if err := user_model.SetEmailDigestFrequency(ctx, ctx.Doer, digest); err != nil {
+			ctx.ServerError("SetEmailDigestFrequency", err)
+			return
+		}
+		if !ctx.Doer.IsEmailDigestEnabled() {
+			if err := mailer.FlushMailDigest(ctx, ctx.Doer.ID); err != nil {
+				ctx.ServerError("FlushMailDigest", err)
+				return
+			}
+		}
 		log.Trace("Email notifications preference made %s: %s", preference, ctx.Doer.Name)
 		ctx.Flash.Success(ctx.Tr("settings.email_preference_set_success"))
 		ctx.Redirect(setting.AppSubURL + "/user/settings/account")
@@ -286,6 +307,7 @@ func loadAccountData(ctx *context.Context) {
 	}
 	ctx.Data["Emails"] = emails
 	ctx.Data["EmailNotificationsPreference"] = ctx.Doer.EmailNotifications()
+	ctx.Data["EmailDigestFrequency"] = ctx.Doer.EmailDigestFrequency
 	ctx.Data["ActivationsPending"] = pendingActivation
 	ctx.Data["CanAddEmails"] = !pendingActivation || !setting.Service.RegisterEmailConfirm
 
@@ -0,0 +1,157 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/nektos/act/pkg/jobparser"
+	"github.com/nektos/act/pkg/model"
+)
+
+// prepareRunConcurrency evaluates the concurrency settings of a new run and of its jobs, it returns the settings of the jobs.
+// The runs and jobs of their groups are only cancelled when the new run is inserted, see insertRun.
+func prepareRunConcurrency(ctx context.Context, run *actions_model.ActionRun, content []byte, jobs []*jobparser.SingleWorkflow) ([]*actions_model.RunJobOptions, error) {
+	wc, err := actions_module.GetConcurrencyFromContent(content)
+	if err != nil {
+		return nil, fmt.Errorf("GetConcurrencyFromContent: %w", err)
+	}
+	if err := run.LoadAttributes(ctx); err != nil {
+		return nil, fmt.Errorf("LoadAttributes: %w", err)
+	}
+	gitCtx := generateGithubContext(run)
+
+	run.ConcurrencyGroup, run.ConcurrencyCancel = actions_module.EvaluateConcurrency(wc.Workflow, "", nil, gitCtx)
+	return prepareJobsConcurrency(run, wc, jobs), nil
+}
+
+// prepareJobsConcurrency evaluates the concurrency settings of the jobs of a new run, the jobs may belong to a called reusable workflow
+func prepareJobsConcurrency(run *actions_model.ActionRun, wc *actions_module.WorkflowConcurrency, jobs []*jobparser.SingleWorkflow) []*actions_model.RunJobOptions {
+	gitCtx := generateGithubContext(run)
+
+	jobOptions := make([]*actions_model.RunJobOptions, len(jobs))
+	for i, v := range jobs {
+		id, job := v.Job()
+		opts := &actions_model.RunJobOptions{}
+		opts.ConcurrencyGroup, opts.ConcurrencyCancel = actions_module.EvaluateConcurrency(wc.Jobs[id], id, job, gitCtx)
+		jobOptions[i] = opts
+	}
+	return jobOptions
+}
+
+// insertRun cancels the runs and jobs of the concurrency groups which a new run replaces and inserts the run in one transaction,
+// so the groups aren't released if the run can't be inserted. It returns the IDs of the runs whose jobs have been cancelled.
+func insertRun(ctx context.Context, run *actions_model.ActionRun, jobs []*jobparser.SingleWorkflow, jobOptions []*actions_model.RunJobOptions) ([]int64, error) {
+	cancelledRunIDs := make(container.Set[int64])
+	err := db.WithTx(ctx, func(ctx context.Context) error {
+		runIDs, err := actions_model.CancelConcurrencyGroupRuns(ctx, run.RepoID, run.ConcurrencyGroup, run.ConcurrencyCancel)
+		if err != nil {
+			return fmt.Errorf("CancelConcurrencyGroupRuns: %w", err)
+		}
+		cancelledRunIDs.AddMultiple(runIDs...)
+		for _, opts := range jobOptions {
+			runIDs, err := actions_model.CancelConcurrencyGroupJobs(ctx, run.RepoID, opts.ConcurrencyGroup, opts.ConcurrencyCancel)
+			if err != nil {
+				return fmt.Errorf("CancelConcurrencyGroupJobs: %w", err)
+			}
+			cancelledRunIDs.AddMultiple(runIDs...)
+		}
+		if err := actions_model.InsertRun(ctx, run, jobs, jobOptions); err != nil {
+			return fmt.Errorf("InsertRun: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cancelledRunIDs.Values(), nil
+}
+
+// generateGithubContext returns the github context which is available to the expressions evaluated before a run starts,
+// the values match those sent to the runners, but the run id and number may be unknown yet
+func generateGithubContext(run *actions_model.ActionRun) *model.GithubContext {
+	event := map[string]any{}
+	_ = json.Unmarshal([]byte(run.EventPayload), &event)
+
+	eventName := run.TriggerEvent
+	if eventName == "" {
+		eventName = run.Event.Event()
+	}
+
+	baseRef := ""
+	headRef := ""
+	ref := run.Ref
+	sha := run.CommitSHA
+	if pullPayload, err := run.GetPullRequestEventPayload(); err == nil && pullPayload.PullRequest != nil && pullPayload.PullRequest.Base != nil && pullPayload.PullRequest.Head != nil {
+		baseRef = pullPayload.PullRequest.Base.Ref
+		headRef = pullPayload.PullRequest.Head.Ref
+		if run.TriggerEvent == actions_module.GithubEventPullRequestTarget {
+			ref = git.BranchPrefix + pullPayload.PullRequest.Base.Name
+			sha = pullPayload.PullRequest.Base.Sha
+		}
+	}
+	refName := git.RefName(ref)
+
+	gitCtx := &model.GithubContext{
+		Event:     event,
+		EventName: eventName,
+		Workflow:  run.WorkflowID,
+		Sha:       sha,
+		Ref:       ref,
+		RefName:   refName.ShortName(),
+		RefType:   refName.RefType(),
+		BaseRef:   baseRef,
+		HeadRef:   headRef,
+		ServerURL: setting.AppURL,
+		APIURL:    setting.AppURL + "api/v1",
+	}
+	if run.ID > 0 {
+		gitCtx.RunID = fmt.Sprint(run.ID)
+		gitCtx.RunNumber = fmt.Sprint(run.Index)
+	}
+	if run.TriggerUser != nil {
+		gitCtx.Actor = run.TriggerUser.Name
+	}
+	if run.Repo != nil {
+		gitCtx.Repository = run.Repo.OwnerName + "/" + run.Repo.Name
+		gitCtx.RepositoryOwner = run.Repo.OwnerName
+	}
+	return gitCtx
+}
+
+// emitRuns pushes runs to the job emitter, e.g. the runs whose jobs have been cancelled to release their concurrency groups
+func emitRuns(runIDs []int64) {
+	for _, id := range runIDs {
+		if err := EmitJobsIfReady(id); err != nil {
+			log.Error("EmitJobsIfReady: %v", err)
+		}
+	}
+}
+
+// IsJobHeld returns whether a blocked job whose needs are done still has to wait for the approval of its run,
+// for the concurrency groups of its run and of itself, or for the reviewers of its environment, the jobs are all jobs of the run
+func IsJobHeld(ctx context.Context, run *actions_model.ActionRun, jobs []*actions_model.ActionRunJob, job *actions_model.ActionRunJob) (bool, error) {
+	if run.NeedApproval {
+		return true, nil
+	}
+	if actions_model.IsRunPending(jobs) {
+		if held, err := actions_model.ShouldHoldRun(ctx, run); err != nil || held {
+			return held, err
+		}
+	}
+	if held, err := actions_model.ShouldHoldJob(ctx, job); err != nil || held {
+		return held, err
+	}
+	return isJobWaitingForReview(ctx, job)
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/nektos/act/pkg/jobparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsertRunConcurrency(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	newRun := func() (*actions_model.ActionRun, []*jobparser.SingleWorkflow) {
+		jobs, err := jobparser.Parse([]byte(`
+on: push
+jobs:
+  deploy:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo deploy
+`))
+		assert.NoError(t, err)
+		return &actions_model.ActionRun{
+			Title:            "deploy",
+			RepoID:           1,
+			OwnerID:          2,
+			WorkflowID:       "deploy.yml",
+			TriggerUserID:    2,
+			Ref:              "refs/heads/master",
+			Event:            "push",
+			ConcurrencyGroup: "production",
+			Status:           actions_model.StatusWaiting,
+		}, jobs
+	}
+	jobStatus := func(run *actions_model.ActionRun) actions_model.Status {
+		jobs, err := actions_model.GetRunJobsByRunID(db.DefaultContext, run.ID)
+		assert.NoError(t, err)
+		assert.Len(t, jobs, 1)
+		return jobs[0].Status
+	}
+
+	run1, jobs := newRun()
+	ids, err := insertRun(db.DefaultContext, run1, jobs, []*actions_model.RunJobOptions{{}})
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+	run2, jobs := newRun()
+	_, err = insertRun(db.DefaultContext, run2, jobs, []*actions_model.RunJobOptions{{}})
+	assert.NoError(t, err)
+	assert.Equal(t, actions_model.StatusBlocked, jobStatus(run2))
+
+	// the pending run isn't cancelled if the run which replaces it can't be inserted
+	run3, jobs := newRun()
+	run3.ID = run1.ID
+	_, err = insertRun(db.DefaultContext, run3, jobs, []*actions_model.RunJobOptions{{}})
+	assert.Error(t, err)
+	assert.Equal(t, actions_model.StatusBlocked, jobStatus(run2))
+
+	run4, jobs := newRun()
+	ids, err = insertRun(db.DefaultContext, run4, jobs, []*actions_model.RunJobOptions{{}})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{run2.ID}, ids)
+	assert.Equal(t, actions_model.StatusCancelled, jobStatus(run2))
+	assert.Equal(t, actions_model.StatusBlocked, jobStatus(run4))
+}
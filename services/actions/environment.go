@@ -0,0 +1,66 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/nektos/act/pkg/jobparser"
+)
+
+// prepareRunEnvironments evaluates the deployment environments of the jobs of a new run into their options,
+// it returns whether a job deploys to an environment, then the run has to be emitted after it's inserted.
+func prepareRunEnvironments(ctx context.Context, run *actions_model.ActionRun, content []byte, jobs []*jobparser.SingleWorkflow, jobOptions []*actions_model.RunJobOptions) (bool, error) {
+	environments, err := actions_module.GetEnvironmentsFromContent(content)
+	if err != nil {
+		return false, fmt.Errorf("GetEnvironmentsFromContent: %w", err)
+	}
+	if len(environments) == 0 {
+		return false, nil
+	}
+	if err := run.LoadAttributes(ctx); err != nil {
+		return false, fmt.Errorf("LoadAttributes: %w", err)
+	}
+	gitCtx := generateGithubContext(run)
+
+	deploys := false
+	for i, v := range jobs {
+		id, job := v.Job()
+		opts := jobOptions[i]
+		opts.Environment, opts.EnvironmentURL = actions_module.EvaluateEnvironment(environments[id], id, job, gitCtx)
+		deploys = deploys || opts.Environment != ""
+	}
+	return deploys, nil
+}
+
+// GetJobEnvironment returns the environment which a job deploys to,
+// it's nil if the job doesn't deploy or the environment hasn't been created, then the job isn't protected.
+func GetJobEnvironment(ctx context.Context, job *actions_model.ActionRunJob) (*actions_model.ActionEnvironment, error) {
+	if job.Environment == "" {
+		return nil, nil
+	}
+	env, err := actions_model.GetEnvironmentByName(ctx, job.RepoID, job.Environment)
+	if errors.Is(err, util.ErrNotExist) {
+		return nil, nil
+	}
+	return env, err
+}
+
+// isJobWaitingForReview returns whether a job deploys to an environment whose reviewers haven't approved the job yet
+func isJobWaitingForReview(ctx context.Context, job *actions_model.ActionRunJob) (bool, error) {
+	if job.DeploymentApprovedBy != 0 {
+		return false, nil
+	}
+	env, err := GetJobEnvironment(ctx, job)
+	if err != nil || env == nil {
+		return false, err
+	}
+	return env.NeedReview(), nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/services/auth/source/oauth2"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/nektos/act/pkg/jobparser"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// IDTokenIssuerPath is the path of the OIDC issuer of the jobs, the discovery document, the JWKS and the token endpoint are under it
+	IDTokenIssuerPath = "/api/actions_id_token"
+	// IDTokenSigningAlgorithm is RS256 because all the cloud providers accept it
+	IDTokenSigningAlgorithm = "RS256"
+	// idTokenPermissionScope is the scope of the `permissions` setting which allows a job to request ID tokens
+	idTokenPermissionScope = "id-token"
+)
+
+// IDTokenSigningKey signs the ID tokens of the jobs, it's published by the JWKS of the issuer
+var IDTokenSigningKey oauth2.JWTSigningKey
+
+func initIDTokenSigningKey() error {
+	key, err := oauth2.LoadOrCreateAsymmetricSigningKey(setting.Actions.IDTokenSigningPrivateKeyFile, IDTokenSigningAlgorithm)
+	if err != nil {
+		return err
+	}
+	IDTokenSigningKey = key
+	return nil
+}
+
+// IDTokenIssuer returns the `iss` claim of the ID tokens
+func IDTokenIssuer() string {
+	return strings.TrimSuffix(setting.AppURL, "/") + IDTokenIssuerPath
+}
+
+// IDTokenRequestURL returns the URL where the jobs request their ID tokens with the token of their task,
+// it already has a query so the clients can append the `audience` parameter like they do on GitHub
+func IDTokenRequestURL() string {
+	return IDTokenIssuer() + "/token?api-version=2.0"
+}
+
+// AddIDTokenRequestEnv adds the variables which the jobs use to request their ID tokens to the workflow payload of a task,
+// they're named like on GitHub so the actions requesting ID tokens work as they are. The runner passes the env of the workflow to the steps.
+func AddIDTokenRequestEnv(payload []byte, token string) ([]byte, error) {
+	var workflow jobparser.SingleWorkflow
+	if err := yaml.Unmarshal(payload, &workflow); err != nil {
+		return nil, err
+	}
+	if workflow.Env == nil {
+		workflow.Env = make(map[string]string, 2)
+	}
+	workflow.Env["ACTIONS_ID_TOKEN_REQUEST_URL"] = IDTokenRequestURL()
+	workflow.Env["ACTIONS_ID_TOKEN_REQUEST_TOKEN"] = token
+	return workflow.Marshal()
+}
+
+// IDTokenClaims are the claims of the ID token of a job,
+// they're named like the claims of GitHub so the trust policies written for GitHub can be reused
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	Ref               string `json:"ref"`
+	RefType           string `json:"ref_type"`
+	SHA               string `json:"sha"`
+	Repository        string `json:"repository"`
+	RepositoryID      string `json:"repository_id"`
+	RepositoryOwner   string `json:"repository_owner"`
+	RepositoryOwnerID string `json:"repository_owner_id"`
+	Workflow          string `json:"workflow"`
+	EventName         string `json:"event_name"`
+	Environment       string `json:"environment,omitempty"`
+	HeadRef           string `json:"head_ref,omitempty"`
+	BaseRef           string `json:"base_ref,omitempty"`
+	RunID             string `json:"run_id"`
+	RunNumber         string `json:"run_number"`
+	RunAttempt        string `json:"run_attempt"`
+	Actor             string `json:"actor"`
+	ActorID           string `json:"actor_id"`
+}
+
+// CanJobRequestIDToken returns whether the `permissions` setting of a job grants `id-token: write`,
+// the runs of the pull requests from forks never get ID tokens because they run untrusted code
+func CanJobRequestIDToken(job *actions_model.ActionRunJob) bool {
+	return !job.IsForkPullRequest && actions_module.Permissions(job.Permissions).Level(idTokenPermissionScope) == actions_module.PermissionWrite
+}
+
+// CreateIDToken signs an ID token which identifies the job of a running task to the audience,
+// the audience is the URL of the owner of the repository if it's empty
+func CreateIDToken(ctx context.Context, task *actions_model.ActionTask, audience string) (string, error) {
+	if err := task.LoadAttributes(ctx); err != nil {
+		return "", err
+	}
+	job := task.Job
+	if !CanJobRequestIDToken(job) {
+		return "", util.NewPermissionDeniedErrorf("the job %d isn't permitted to request ID tokens", job.ID)
+	}
+	run := job.Run
+	if err := run.LoadAttributes(ctx); err != nil {
+		return "", err
+	}
+
+	if audience == "" {
+		audience = setting.AppURL + url.PathEscape(run.Repo.OwnerName)
+	}
+	eventName := run.TriggerEvent
+	if eventName == "" {
+		eventName = run.Event.Event()
+	}
+	refName := git.RefName(run.Ref)
+	repository := run.Repo.FullName()
+
+	// the subject is scoped like on GitHub, by the environment, by the pull request event or by the ref
+	subject := "repo:" + repository
+	switch {
+	case job.Environment != "":
+		subject += ":environment:" + job.Environment
+	case eventName == actions_module.GithubEventPullRequest || eventName == actions_module.GithubEventPullRequestTarget:
+		subject += ":pull_request"
+	default:
+		subject += ":ref:" + run.Ref
+	}
+
+	now := time.Now()
+	claims := &IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    IDTokenIssuer(),
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{audience},
+			ID:        fmt.Sprintf("%d-%d", task.ID, now.UnixNano()),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(setting.Actions.IDTokenExpirationTime)),
+		},
+		Ref:               run.Ref,
+		RefType:           refName.RefType(),
+		SHA:               run.CommitSHA,
+		Repository:        repository,
+		RepositoryID:      strconv.FormatInt(run.RepoID, 10),
+		RepositoryOwner:   run.Repo.OwnerName,
+		RepositoryOwnerID: strconv.FormatInt(run.Repo.OwnerID, 10),
+		Workflow:          run.WorkflowID,
+		EventName:         eventName,
+		Environment:       job.Environment,
+		RunID:             strconv.FormatInt(run.ID, 10),
+		RunNumber:         strconv.FormatInt(run.Index, 10),
+		RunAttempt:        strconv.FormatInt(job.Attempt, 10),
+		Actor:             run.TriggerUser.Name,
+		ActorID:           strconv.FormatInt(run.TriggerUserID, 10),
+	}
+	if pullPayload, err := run.GetPullRequestEventPayload(); err == nil && pullPayload.PullRequest != nil && pullPayload.PullRequest.Base != nil && pullPayload.PullRequest.Head != nil {
+		claims.BaseRef = pullPayload.PullRequest.Base.Ref
+		claims.HeadRef = pullPayload.PullRequest.Head.Ref
+	}
+
+	token := jwt.NewWithClaims(IDTokenSigningKey.SigningMethod(), claims)
+	IDTokenSigningKey.PreProcessToken(token)
+	return token.SignedString(IDTokenSigningKey.SignKey())
+}
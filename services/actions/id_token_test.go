@@ -0,0 +1,49 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/nektos/act/pkg/jobparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddIDTokenRequestEnv(t *testing.T) {
+	defer test.MockVariableValue(&setting.AppURL, "https://gitea.example.com/")()
+
+	workflows, err := jobparser.Parse([]byte(`
+on: push
+env:
+  TARGET: production
+jobs:
+  deploy:
+    runs-on: ubuntu-latest
+    permissions:
+      id-token: write
+    steps:
+      - run: echo deploy
+`))
+	assert.NoError(t, err)
+	payload, err := workflows[0].Marshal()
+	assert.NoError(t, err)
+
+	payload, err = AddIDTokenRequestEnv(payload, "task-token")
+	assert.NoError(t, err)
+	workflows, err = jobparser.Parse(payload)
+	assert.NoError(t, err)
+	if assert.Len(t, workflows, 1) {
+		assert.Equal(t, map[string]string{
+			"TARGET":                         "production",
+			"ACTIONS_ID_TOKEN_REQUEST_URL":   "https://gitea.example.com/api/actions_id_token/token?api-version=2.0",
+			"ACTIONS_ID_TOKEN_REQUEST_TOKEN": "task-token",
+		}, workflows[0].Env)
+		id, job := workflows[0].Job()
+		assert.Equal(t, "deploy", id)
+		assert.Len(t, job.Steps, 1)
+	}
+}
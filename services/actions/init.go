@@ -22,5 +22,9 @@ func Init() {
 	}
 	go graceful.GetManager().RunWithCancel(jobEmitterQueue)
 
+	if err := initIDTokenSigningKey(); err != nil {
+		log.Fatal("Unable to init the signing key of the ID tokens: %v", err)
+	}
+
 	notify_service.RegisterNotifier(NewNotifier())
 }
@@ -10,8 +10,11 @@ import (
 
 	actions_model "code.gitea.io/gitea/models/actions"
 	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/container"
 	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/timeutil"
 
 	"xorm.io/builder"
 )
@@ -48,17 +51,22 @@ func checkJobsOfRun(ctx context.Context, runID int64) error {
 	if err != nil {
 		return err
 	}
+	run, err := actions_model.GetRunByID(ctx, runID)
+	if err != nil {
+		return err
+	}
 	if err := db.WithTx(ctx, func(ctx context.Context) error {
-		idToJobs := make(map[string][]*actions_model.ActionRunJob, len(jobs))
-		for _, job := range jobs {
-			idToJobs[job.JobID] = append(idToJobs[job.JobID], job)
+		updates, err := resolveJobStatuses(ctx, run, jobs)
+		if err != nil {
+			return err
 		}
-
-		updates := newJobStatusResolver(jobs).Resolve()
 		for _, job := range jobs {
 			if status, ok := updates[job.ID]; ok {
 				job.Status = status
-				if n, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"status": actions_model.StatusBlocked}, "status"); err != nil {
+				if status.IsDone() {
+					job.Stopped = timeutil.TimeStampNow()
+				}
+				if n, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"status": actions_model.StatusBlocked}, "status", "stopped"); err != nil {
 					return err
 				} else if n != 1 {
 					return fmt.Errorf("no affected for updating blocked job %v", job.ID)
@@ -70,12 +78,128 @@ func checkJobsOfRun(ctx context.Context, runID int64) error {
 		return err
 	}
 	CreateCommitStatus(ctx, jobs...)
+
+	if err := emitConcurrencyGroups(ctx, runID, jobs); err != nil {
+		log.Error("emitConcurrencyGroups for run %d: %v", runID, err)
+	}
+	return nil
+}
+
+// resolveJobStatuses returns the new statuses of the blocked jobs of a run,
+// the jobs which deploy to an environment that doesn't allow the ref of the run fail,
+// the jobs which could be released but are held by approval, concurrency or reviewers stay blocked
+func resolveJobStatuses(ctx context.Context, run *actions_model.ActionRun, jobs actions_model.ActionJobList) (map[int64]actions_model.Status, error) {
+	resolver := newJobStatusResolver(jobs)
+	updates := resolver.Resolve()
+	for {
+		rejected := false
+		for _, job := range jobs {
+			if updates[job.ID] != actions_model.StatusWaiting {
+				continue
+			}
+			env, err := GetJobEnvironment(ctx, job)
+			if err != nil {
+				return nil, err
+			}
+			if env != nil && !env.IsRefAllowed(run.Ref) {
+				updates[job.ID] = actions_model.StatusFailure
+				resolver.statuses[job.ID] = actions_model.StatusFailure
+				rejected = true
+			}
+		}
+		if !rejected {
+			break
+		}
+		// the jobs which need the rejected jobs are skipped
+		for id, status := range resolver.Resolve() {
+			updates[id] = status
+		}
+	}
+
+	for _, job := range jobs {
+		if updates[job.ID] != actions_model.StatusWaiting {
+			continue
+		}
+		held, err := IsJobHeld(ctx, run, jobs, job)
+		if err != nil {
+			return nil, err
+		}
+		if held {
+			delete(updates, job.ID)
+		}
+	}
+	return updates, nil
+}
+
+// emitConcurrencyGroups pushes the other runs which wait for the concurrency groups released by a run
+func emitConcurrencyGroups(ctx context.Context, runID int64, jobs actions_model.ActionJobList) error {
+	run, err := actions_model.GetRunByID(ctx, runID)
+	if err != nil {
+		return err
+	}
+
+	var candidates actions_model.RunList
+	if run.ConcurrencyGroup != "" && run.Status.IsDone() {
+		runs, _, err := actions_model.FindRuns(ctx, actions_model.FindRunOptions{
+			RepoID:           run.RepoID,
+			ConcurrencyGroup: run.ConcurrencyGroup,
+			Status:           []actions_model.Status{actions_model.StatusWaiting, actions_model.StatusRunning, actions_model.StatusBlocked},
+		})
+		if err != nil {
+			return err
+		}
+		candidates = append(candidates, runs...)
+	}
+	for _, job := range jobs {
+		if job.ConcurrencyGroup == "" || !job.Status.IsDone() {
+			continue
+		}
+		heldJobs, _, err := actions_model.FindRunJobs(ctx, actions_model.FindRunJobOptions{
+			RepoID:           job.RepoID,
+			ConcurrencyGroup: job.ConcurrencyGroup,
+			Statuses:         []actions_model.Status{actions_model.StatusBlocked},
+		})
+		if err != nil {
+			return err
+		}
+		if err := heldJobs.LoadRuns(ctx, false); err != nil {
+			return err
+		}
+		for _, heldJob := range heldJobs {
+			if heldJob.Run != nil {
+				candidates = append(candidates, heldJob.Run)
+			}
+		}
+	}
+
+	// only the runs with releasable jobs are pushed, so runs which are still held don't wake each other up endlessly
+	emitted := make(container.Set[int64])
+	for _, candidate := range candidates {
+		if candidate.ID == runID || !emitted.Add(candidate.ID) {
+			continue
+		}
+		candidateJobs, _, err := actions_model.FindRunJobs(ctx, actions_model.FindRunJobOptions{RunID: candidate.ID})
+		if err != nil {
+			return err
+		}
+		updates, err := resolveJobStatuses(ctx, candidate, candidateJobs)
+		if err != nil {
+			return err
+		}
+		if len(updates) > 0 {
+			if err := EmitJobsIfReady(candidate.ID); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
 type jobStatusResolver struct {
 	statuses map[int64]actions_model.Status
 	needs    map[int64][]int64
+	// called maps the jobs which call reusable workflows to the jobs of the called workflows
+	called map[int64][]int64
 }
 
 func newJobStatusResolver(jobs actions_model.ActionJobList) *jobStatusResolver {
@@ -86,6 +210,7 @@ func newJobStatusResolver(jobs actions_model.ActionJobList) *jobStatusResolver {
 
 	statuses := make(map[int64]actions_model.Status, len(jobs))
 	needs := make(map[int64][]int64, len(jobs))
+	called := make(map[int64][]int64)
 	for _, job := range jobs {
 		statuses[job.ID] = job.Status
 		for _, need := range job.Needs {
@@ -93,10 +218,21 @@ func newJobStatusResolver(jobs actions_model.ActionJobList) *jobStatusResolver {
 				needs[job.ID] = append(needs[job.ID], v.ID)
 			}
 		}
+		if job.IsReusableWorkflowCaller() {
+			called[job.ID] = []int64{}
+		}
+	}
+	for _, job := range jobs {
+		for _, v := range idToJobs[job.CallerJobID] {
+			if v.IsReusableWorkflowCaller() {
+				called[v.ID] = append(called[v.ID], job.ID)
+			}
+		}
 	}
 	return &jobStatusResolver{
 		statuses: statuses,
 		needs:    needs,
+		called:   called,
 	}
 }
 
@@ -121,6 +257,12 @@ func (r *jobStatusResolver) resolve() map[int64]actions_model.Status {
 		if status != actions_model.StatusBlocked {
 			continue
 		}
+		if calledJobs, ok := r.called[id]; ok {
+			if status, ok := r.resolveCaller(calledJobs); ok {
+				ret[id] = status
+			}
+			continue
+		}
 		allDone, allSucceed := true, true
 		for _, need := range r.needs[id] {
 			needStatus := r.statuses[need]
@@ -141,3 +283,29 @@ func (r *jobStatusResolver) resolve() map[int64]actions_model.Status {
 	}
 	return ret
 }
+
+// resolveCaller aggregates the status of a job which calls a reusable workflow from the statuses of the called jobs,
+// it's resolved only when the called jobs are all done
+func (r *jobStatusResolver) resolveCaller(calledJobs []int64) (actions_model.Status, bool) {
+	allSkipped, hasFailure := true, false
+	for _, id := range calledJobs {
+		status := r.statuses[id]
+		if !status.IsDone() {
+			return actions_model.StatusUnknown, false
+		}
+		if status != actions_model.StatusSkipped {
+			allSkipped = false
+		}
+		if status.In(actions_model.StatusFailure, actions_model.StatusCancelled) {
+			hasFailure = true
+		}
+	}
+	switch {
+	case hasFailure:
+		return actions_model.StatusFailure, true
+	case allSkipped:
+		return actions_model.StatusSkipped, true
+	default:
+		return actions_model.StatusSuccess, true
+	}
+}
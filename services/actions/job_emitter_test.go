@@ -70,6 +70,45 @@ func Test_jobStatusResolver_Resolve(t *testing.T) {
 			},
 			want: map[int64]actions_model.Status{},
 		},
+		{
+			name: "reusable workflow running",
+			jobs: actions_model.ActionJobList{
+				{ID: 1, JobID: "call", Status: actions_model.StatusBlocked, Needs: []string{}, ReusableWorkflow: "org/ci/.gitea/workflows/build.yml@main"},
+				{ID: 2, JobID: "call/build", Status: actions_model.StatusSuccess, Needs: []string{}, CallerJobID: "call"},
+				{ID: 3, JobID: "call/test", Status: actions_model.StatusBlocked, Needs: []string{"call/build"}, CallerJobID: "call"},
+				{ID: 4, JobID: "deploy", Status: actions_model.StatusBlocked, Needs: []string{"call"}},
+			},
+			want: map[int64]actions_model.Status{
+				3: actions_model.StatusWaiting,
+			},
+		},
+		{
+			name: "reusable workflow done",
+			jobs: actions_model.ActionJobList{
+				{ID: 1, JobID: "call", Status: actions_model.StatusBlocked, Needs: []string{}, ReusableWorkflow: "org/ci/.gitea/workflows/build.yml@main"},
+				{ID: 2, JobID: "call/build", Status: actions_model.StatusSuccess, Needs: []string{}, CallerJobID: "call"},
+				{ID: 3, JobID: "call/test", Status: actions_model.StatusSkipped, Needs: []string{"call/build"}, CallerJobID: "call"},
+				{ID: 4, JobID: "deploy", Status: actions_model.StatusBlocked, Needs: []string{"call"}},
+			},
+			want: map[int64]actions_model.Status{
+				1: actions_model.StatusSuccess,
+				4: actions_model.StatusWaiting,
+			},
+		},
+		{
+			name: "reusable workflow failed",
+			jobs: actions_model.ActionJobList{
+				{ID: 1, JobID: "lint", Status: actions_model.StatusFailure, Needs: []string{}},
+				{ID: 2, JobID: "call", Status: actions_model.StatusBlocked, Needs: []string{"lint"}, ReusableWorkflow: "./.gitea/workflows/build.yml"},
+				{ID: 3, JobID: "call/build", Status: actions_model.StatusBlocked, Needs: []string{"lint"}, CallerJobID: "call"},
+				{ID: 4, JobID: "deploy", Status: actions_model.StatusBlocked, Needs: []string{"call"}},
+			},
+			want: map[int64]actions_model.Status{
+				2: actions_model.StatusSkipped,
+				3: actions_model.StatusSkipped,
+				4: actions_model.StatusSkipped,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
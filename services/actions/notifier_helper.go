@@ -21,6 +21,7 @@ import (
 	"code.gitea.io/gitea/modules/json"
 	"code.gitea.io/gitea/modules/log"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
 	webhook_module "code.gitea.io/gitea/modules/webhook"
 	"code.gitea.io/gitea/services/convert"
 
@@ -268,10 +269,26 @@ func handleWorkflows(
 			}
 		}
 
-		if err := actions_model.InsertRun(ctx, run, jobs); err != nil {
-			log.Error("InsertRun: %v", err)
+		run.Repo = input.Repo
+		run.TriggerUser = input.Doer
+		preparedJobs, jobOptions, deploys, err := prepareRun(ctx, run, dwf.Content, jobs)
+		if err != nil {
+			log.Error("prepareRun for workflow %s of repo %d: %v", dwf.EntryName, input.Repo.ID, err)
+			failRun(run)
+		} else {
+			jobs = preparedJobs
+		}
+
+		cancelledRunIDs, err := insertRun(ctx, run, jobs, jobOptions)
+		if err != nil {
+			log.Error("insertRun: %v", err)
 			continue
 		}
+		emitRuns(cancelledRunIDs)
+		if deploys {
+			// the emitter checks the protection rules of the environments
+			emitRuns([]int64{run.ID})
+		}
 
 		alljobs, _, err := actions_model.FindRunJobs(ctx, actions_model.FindRunJobOptions{RunID: run.ID})
 		if err != nil {
@@ -283,6 +300,36 @@ func handleWorkflows(
 	return nil
 }
 
+// prepareRun evaluates the settings of the jobs of a new run and expands the reusable workflows called by them,
+// it returns the jobs, their options and whether a job deploys to an environment.
+func prepareRun(ctx context.Context, run *actions_model.ActionRun, content []byte, jobs []*jobparser.SingleWorkflow) ([]*jobparser.SingleWorkflow, []*actions_model.RunJobOptions, bool, error) {
+	jobOptions, err := prepareRunConcurrency(ctx, run, content, jobs)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("prepareRunConcurrency: %w", err)
+	}
+	deploys, err := prepareRunEnvironments(ctx, run, content, jobs, jobOptions)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("prepareRunEnvironments: %w", err)
+	}
+	if err := prepareRunPermissions(content, jobs, jobOptions); err != nil {
+		return nil, nil, false, fmt.Errorf("prepareRunPermissions: %w", err)
+	}
+	jobs, jobOptions, calledDeploys, err := expandReusableWorkflows(ctx, run, jobs, jobOptions)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("expandReusableWorkflows: %w", err)
+	}
+	return jobs, jobOptions, deploys || calledDeploys, nil
+}
+
+// failRun marks a new run which can't be prepared as failed, e.g. if a called workflow can't be read.
+// Its jobs fail when it's inserted, and it doesn't replace the runs of any concurrency group.
+func failRun(run *actions_model.ActionRun) {
+	run.Status = actions_model.StatusFailure
+	run.Stopped = timeutil.TimeStampNow()
+	run.NeedApproval = false
+	run.ConcurrencyGroup, run.ConcurrencyCancel = "", false
+}
+
 func newNotifyInputFromIssue(issue *issues_model.Issue, event webhook_module.HookEventType) *notifyInput {
 	return newNotifyInput(issue.Repo, issue.Poster, event)
 }
@@ -0,0 +1,61 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/perm"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
+	actions_module "code.gitea.io/gitea/modules/actions"
+
+	"github.com/nektos/act/pkg/jobparser"
+)
+
+// prepareRunPermissions reads the `permissions` settings of the jobs of a new run into their options
+func prepareRunPermissions(content []byte, jobs []*jobparser.SingleWorkflow, jobOptions []*actions_model.RunJobOptions) error {
+	permissions, err := actions_module.GetPermissionsFromContent(content)
+	if err != nil {
+		return fmt.Errorf("GetPermissionsFromContent: %w", err)
+	}
+	for i, v := range jobs {
+		id, _ := v.Job()
+		jobOptions[i].Permissions = permissions[id]
+	}
+	return nil
+}
+
+// GetTaskPermission returns the permission of the token of a running task on the repository of its run.
+// The units are limited by the `permissions` setting of the job, and the runs of pull requests from forks can only read.
+// The access mode of the repository is at most read, the write access is only granted by the units.
+func GetTaskPermission(ctx context.Context, task *actions_model.ActionTask, repo *repo_model.Repository) (access_model.Permission, error) {
+	if err := task.LoadJob(ctx); err != nil {
+		return access_model.Permission{}, err
+	}
+	if err := repo.LoadUnits(ctx); err != nil {
+		return access_model.Permission{}, err
+	}
+
+	maxMode := perm.AccessModeWrite
+	if task.IsForkPullRequest {
+		maxMode = perm.AccessModeRead
+	}
+	unitsMode := actions_module.Permissions(task.Job.Permissions).UnitsMode(maxMode)
+
+	p := access_model.Permission{
+		AccessMode: min(perm.AccessModeRead, maxMode),
+		Units:      repo.Units,
+		UnitsMode:  make(map[unit.Type]perm.AccessMode, len(repo.Units)),
+	}
+	for _, u := range repo.Units {
+		if mode := unitsMode[u.Type]; mode > perm.AccessModeNone {
+			p.UnitsMode[u.Type] = mode
+		}
+	}
+	return p, nil
+}
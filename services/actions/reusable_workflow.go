@@ -0,0 +1,282 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/nektos/act/pkg/jobparser"
+	"gopkg.in/yaml.v3"
+)
+
+// maxReusableWorkflowDepth limits the nesting of reusable workflows, the workflow of the run is the first level
+const maxReusableWorkflowDepth = 4
+
+// expandReusableWorkflows appends the jobs of the reusable workflows called by the jobs of a new run to the jobs and their options.
+// The IDs of the called jobs are prefixed with the ID of the caller, e.g. `call/build`, and the called jobs without needs inherit
+// the needs and the `if` setting of the caller. The caller stays in the run to show the nesting and to aggregate the results.
+// It returns whether a called job deploys to an environment.
+func expandReusableWorkflows(ctx context.Context, run *actions_model.ActionRun, jobs []*jobparser.SingleWorkflow, jobOptions []*actions_model.RunJobOptions) ([]*jobparser.SingleWorkflow, []*actions_model.RunJobOptions, bool, error) {
+	return expandReusableWorkflowsAt(ctx, run, run.Repo, run.CommitSHA, jobs, jobOptions, 1)
+}
+
+// expandReusableWorkflowsAt expands the reusable workflows called by jobs read from a commit of a repository,
+// the local workflows called by the jobs are read from the same commit.
+func expandReusableWorkflowsAt(ctx context.Context, run *actions_model.ActionRun, repo *repo_model.Repository, ref string, jobs []*jobparser.SingleWorkflow, jobOptions []*actions_model.RunJobOptions, depth int) ([]*jobparser.SingleWorkflow, []*actions_model.RunJobOptions, bool, error) {
+	retJobs := make([]*jobparser.SingleWorkflow, 0, len(jobs))
+	retOptions := make([]*actions_model.RunJobOptions, 0, len(jobs))
+	deploys := false
+	for i, v := range jobs {
+		retJobs = append(retJobs, v)
+		retOptions = append(retOptions, jobOptions[i])
+
+		id, job := v.Job()
+		if job.Uses == "" {
+			continue
+		}
+		if depth >= maxReusableWorkflowDepth {
+			return nil, nil, false, fmt.Errorf("job %q: reusable workflows can't be nested more than %d levels", id, maxReusableWorkflowDepth)
+		}
+		if job.Strategy.RawMatrix.Kind != 0 {
+			return nil, nil, false, fmt.Errorf("job %q: the matrix of a job calling a reusable workflow isn't supported", id)
+		}
+
+		content, calledRepo, calledRef, err := readReusableWorkflow(ctx, run, repo, ref, job.Uses)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("job %q: %w", id, err)
+		}
+		wc, err := actions_module.GetWorkflowCallFromContent(content)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("job %q: GetWorkflowCallFromContent: %w", id, err)
+		} else if wc == nil {
+			return nil, nil, false, fmt.Errorf("job %q: workflow %q isn't triggered by workflow_call", id, job.Uses)
+		}
+		inputs, err := actions_module.EvaluateWorkflowCallInputs(wc, id, job, generateGithubContext(run))
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("job %q: %w", id, err)
+		}
+		if content, err = actions_module.InterpolateWorkflowCallInputs(content, inputs); err != nil {
+			return nil, nil, false, fmt.Errorf("job %q: InterpolateWorkflowCallInputs: %w", id, err)
+		}
+
+		// the called jobs are prepared like the jobs of a run before their IDs are prefixed
+		calledJobs, err := jobparser.Parse(content)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("job %q: jobparser.Parse: %w", id, err)
+		}
+		wcc, err := actions_module.GetConcurrencyFromContent(content)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("job %q: GetConcurrencyFromContent: %w", id, err)
+		}
+		calledOptions := prepareJobsConcurrency(run, wcc, calledJobs)
+		calledDeploys, err := prepareRunEnvironments(ctx, run, content, calledJobs, calledOptions)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("job %q: %w", id, err)
+		}
+		if err := prepareRunPermissions(content, calledJobs, calledOptions); err != nil {
+			return nil, nil, false, fmt.Errorf("job %q: %w", id, err)
+		}
+		calledJobs, calledOptions, nestedDeploys, err := expandReusableWorkflowsAt(ctx, run, calledRepo, calledRef, calledJobs, calledOptions, depth+1)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		deploys = deploys || calledDeploys || nestedDeploys
+
+		callerOptions := jobOptions[i]
+		for j, cv := range calledJobs {
+			calledID, calledJob := cv.Job()
+			needs := calledJob.Needs()
+			for k, need := range needs {
+				needs[k] = id + "/" + need
+			}
+			if len(needs) == 0 {
+				needs = job.Needs()
+				if jobIf := actions_module.CombineIfs(job.If.Value, calledJob.If.Value); jobIf != "" {
+					calledJob.If = yaml.Node{Kind: yaml.ScalarNode, Value: jobIf}
+				}
+			}
+			calledJob.RawNeeds = yaml.Node{}
+			if len(needs) > 0 {
+				if err := calledJob.RawNeeds.Encode(needs); err != nil {
+					return nil, nil, false, err
+				}
+			}
+			calledJob.Name = job.Name + " / " + calledJob.Name
+			if err := cv.SetJob(id+"/"+calledID, calledJob); err != nil {
+				return nil, nil, false, err
+			}
+
+			opts := calledOptions[j]
+			if opts.CallerJobID == "" {
+				opts.CallerJobID = id
+			} else {
+				opts.CallerJobID = id + "/" + opts.CallerJobID
+			}
+			opts.Permissions = actions_module.Permissions(opts.Permissions).Limit(callerOptions.Permissions)
+		}
+		retJobs = append(retJobs, calledJobs...)
+		retOptions = append(retOptions, calledOptions...)
+
+		// the caller keeps the expressions of the outputs of the called workflow, they are evaluated when the jobs which need it start
+		job.Outputs = make(map[string]string, len(wc.Outputs))
+		for name, output := range wc.Outputs {
+			job.Outputs[name] = output.Value
+		}
+		if err := v.SetJob(id, job); err != nil {
+			return nil, nil, false, err
+		}
+		callerOptions.ReusableWorkflow = job.Uses
+		// the caller doesn't run, so it doesn't occupy a concurrency group or deploy
+		callerOptions.ConcurrencyGroup, callerOptions.ConcurrencyCancel = "", false
+		callerOptions.Environment, callerOptions.EnvironmentURL = "", ""
+	}
+	return retJobs, retOptions, deploys, nil
+}
+
+// readReusableWorkflow reads the content of a reusable workflow called by a workflow read from a commit of a repository,
+// a local workflow is read from the same commit. It returns the repository and the commit ID which the workflow is read from.
+func readReusableWorkflow(ctx context.Context, run *actions_model.ActionRun, repo *repo_model.Repository, ref, uses string) ([]byte, *repo_model.Repository, string, error) {
+	u, err := actions_module.ParseReusableWorkflowUses(uses)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if !u.IsLocal() {
+		repo, err = repo_model.GetRepositoryByOwnerAndName(ctx, u.OwnerName, u.RepoName)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("GetRepositoryByOwnerAndName %s/%s: %w", u.OwnerName, u.RepoName, err)
+		}
+		ref = u.Ref
+	}
+	// the workflows of the repository of the run can always be called, as the run is read from it
+	if repo.ID != run.RepoID {
+		if ok, err := canCallReusableWorkflows(ctx, run, repo); err != nil {
+			return nil, nil, "", err
+		} else if !ok {
+			return nil, nil, "", fmt.Errorf("the run can't call the workflows of repository %s", repo.FullName())
+		}
+	}
+
+	gitRepo, err := git.OpenRepository(ctx, repo.RepoPath())
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("git.OpenRepository: %w", err)
+	}
+	defer gitRepo.Close()
+	commit, err := gitRepo.GetCommit(ref)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("GetCommit %q of %s: %w", ref, repo.FullName(), err)
+	}
+	entry, err := commit.GetTreeEntryByPath(u.Path)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("GetTreeEntryByPath %q of %s: %w", u.Path, repo.FullName(), err)
+	}
+	content, err := actions_module.GetContentFromEntry(entry)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return content, repo, commit.ID.String(), nil
+}
+
+// canCallReusableWorkflows returns whether a run can call the workflows of another repository, the repository has to be readable
+// by the user who triggers the run. The runs of pull requests from forks can't call them, as their workflows may be untrusted.
+func canCallReusableWorkflows(ctx context.Context, run *actions_model.ActionRun, repo *repo_model.Repository) (bool, error) {
+	if run.IsForkPullRequest {
+		return false, nil
+	}
+	if err := run.LoadAttributes(ctx); err != nil {
+		return false, err
+	}
+	perm, err := access_model.GetUserRepoPermission(ctx, repo, run.TriggerUser)
+	if err != nil {
+		return false, err
+	}
+	return perm.CanRead(unit.TypeCode), nil
+}
+
+// getCallerJob returns the job of a run which calls the reusable workflow of a job, the jobs are all jobs of the run
+func getCallerJob(job *actions_model.ActionRunJob, jobs []*actions_model.ActionRunJob) *actions_model.ActionRunJob {
+	if job.CallerJobID == "" {
+		return nil
+	}
+	for _, v := range jobs {
+		if v.JobID == job.CallerJobID && v.IsReusableWorkflowCaller() {
+			return v
+		}
+	}
+	return nil
+}
+
+// parseJobPayload returns the job in the workflow payload of a job of a run
+func parseJobPayload(job *actions_model.ActionRunJob) (*jobparser.Job, error) {
+	workflows, err := jobparser.Parse(job.WorkflowPayload)
+	if err != nil {
+		return nil, err
+	} else if len(workflows) != 1 {
+		return nil, fmt.Errorf("workflow payload of job %d has %d jobs", job.ID, len(workflows))
+	}
+	_, parsed := workflows[0].Job()
+	return parsed, nil
+}
+
+// GetJobOutputs returns the outputs of a done job for the jobs which need it,
+// the outputs of a job which calls a reusable workflow are evaluated from the outputs of the jobs of the called workflow.
+// The jobs are all jobs of the run.
+func GetJobOutputs(ctx context.Context, job *actions_model.ActionRunJob, jobs []*actions_model.ActionRunJob) (map[string]string, error) {
+	outputs := make(map[string]string)
+	if !job.IsReusableWorkflowCaller() {
+		if job.TaskID == 0 {
+			return outputs, nil
+		}
+		got, err := actions_model.FindTaskOutputByTaskID(ctx, job.TaskID)
+		if err != nil {
+			return nil, fmt.Errorf("FindTaskOutputByTaskID: %w", err)
+		}
+		for _, v := range got {
+			outputs[v.OutputKey] = v.OutputValue
+		}
+		return outputs, nil
+	}
+
+	caller, err := parseJobPayload(job)
+	if err != nil {
+		return nil, err
+	}
+	jobOutputs := make(map[string]map[string]string)
+	for _, v := range jobs {
+		if v.CallerJobID != job.JobID {
+			continue
+		}
+		if jobOutputs[strings.TrimPrefix(v.JobID, job.JobID+"/")], err = GetJobOutputs(ctx, v, jobs); err != nil {
+			return nil, err
+		}
+	}
+	return actions_module.EvaluateWorkflowCallOutputs(caller.Outputs, jobOutputs), nil
+}
+
+// GetReusableWorkflowSecrets returns the secrets of a job of a called reusable workflow,
+// each caller from the outermost one passes the secrets given by the `secrets` setting to the called workflow.
+// The jobs are all jobs of the run.
+func GetReusableWorkflowSecrets(job *actions_model.ActionRunJob, jobs []*actions_model.ActionRunJob, secrets map[string]string) (map[string]string, error) {
+	var callers []*actions_model.ActionRunJob
+	for caller := getCallerJob(job, jobs); caller != nil; caller = getCallerJob(caller, jobs) {
+		callers = append(callers, caller)
+	}
+	for i := len(callers) - 1; i >= 0; i-- {
+		caller, err := parseJobPayload(callers[i])
+		if err != nil {
+			return nil, err
+		}
+		secrets = actions_module.PassWorkflowCallSecrets(&caller.RawSecrets, secrets)
+	}
+	return secrets, nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanCallReusableWorkflows(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	publicRepo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+	privateRepo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 2})
+	callerRepo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 4})
+	canCall := func(run *actions_model.ActionRun, repo *repo_model.Repository) bool {
+		ok, err := canCallReusableWorkflows(db.DefaultContext, run, repo)
+		assert.NoError(t, err)
+		return ok
+	}
+
+	// the repository has to be readable by the user who triggers the run
+	run := &actions_model.ActionRun{RepoID: callerRepo.ID, TriggerUserID: 2}
+	assert.True(t, canCall(run, publicRepo))
+	assert.True(t, canCall(run, privateRepo))
+	run = &actions_model.ActionRun{RepoID: callerRepo.ID, TriggerUser: unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 4})}
+	assert.True(t, canCall(run, publicRepo))
+	assert.False(t, canCall(run, privateRepo))
+
+	// the runs of pull requests from forks can't call the workflows of other repositories
+	run = &actions_model.ActionRun{RepoID: callerRepo.ID, TriggerUserID: 2, IsForkPullRequest: true}
+	assert.False(t, canCall(run, publicRepo))
+}
@@ -123,10 +123,25 @@ func CreateScheduleTask(ctx context.Context, cron *actions_model.ActionSchedule)
 		return err
 	}
 
-	// Insert the action run and its associated jobs into the database
-	if err := actions_model.InsertRun(ctx, run, workflows); err != nil {
+	// Evaluate the settings of the jobs and expand the called reusable workflows, the run fails if they can't be prepared
+	preparedWorkflows, jobOptions, deploys, err := prepareRun(ctx, run, cron.Content, workflows)
+	if err != nil {
+		log.Error("prepareRun for schedule %d: %v", cron.ID, err)
+		failRun(run)
+	} else {
+		workflows = preparedWorkflows
+	}
+
+	// Insert the action run and its associated jobs into the database, the runs and jobs it replaces are cancelled at the same time
+	cancelledRunIDs, err := insertRun(ctx, run, workflows, jobOptions)
+	if err != nil {
 		return err
 	}
+	emitRuns(cancelledRunIDs)
+	if deploys {
+		// the emitter checks the protection rules of the environments
+		emitRuns([]int64{run.ID})
+	}
 
 	// Return nil if no errors occurred
 	return nil
@@ -0,0 +1,62 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package convert
+
+import (
+	"context"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	user_model "code.gitea.io/gitea/models/user"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// ToActionRun converts an ActionRun to API format, the jobs are only listed if they are given.
+// The repository and the trigger user of the run have to be loaded.
+func ToActionRun(ctx context.Context, run *actions_model.ActionRun, jobs []*actions_model.ActionRunJob, doer *user_model.User) *api.ActionRun {
+	result := &api.ActionRun{
+		ID:                          run.ID,
+		RunNumber:                   run.Index,
+		Title:                       run.Title,
+		WorkflowID:                  run.WorkflowID,
+		Event:                       string(run.Event),
+		Ref:                         run.Ref,
+		HeadSHA:                     run.CommitSHA,
+		Status:                      run.Status.String(),
+		NeedApproval:                run.NeedApproval,
+		TriggerUser:                 ToUser(ctx, run.TriggerUser, doer),
+		ConcurrencyGroup:            run.ConcurrencyGroup,
+		ConcurrencyCancelInProgress: run.ConcurrencyCancel,
+		HTMLURL:                     run.HTMLURL(),
+		Created:                     run.Created.AsTime(),
+		Started:                     actionTimePtr(run.Started),
+		Stopped:                     actionTimePtr(run.Stopped),
+	}
+	for _, job := range jobs {
+		result.Jobs = append(result.Jobs, &api.ActionRunJob{
+			ID:                          job.ID,
+			Name:                        job.Name,
+			JobID:                       job.JobID,
+			Needs:                       job.Needs,
+			RunsOn:                      job.RunsOn,
+			Status:                      job.Status.String(),
+			ConcurrencyGroup:            job.ConcurrencyGroup,
+			ConcurrencyCancelInProgress: job.ConcurrencyCancel,
+			HeldByConcurrencyGroup:      actions_model.GetHeldConcurrencyGroup(run, job, jobs),
+			Environment:                 job.Environment,
+			EnvironmentURL:              job.EnvironmentURL,
+			Started:                     actionTimePtr(job.Started),
+			Stopped:                     actionTimePtr(job.Stopped),
+		})
+	}
+	return result
+}
+
+func actionTimePtr(ts timeutil.TimeStamp) *time.Time {
+	if ts.IsZero() {
+		return nil
+	}
+	return ts.AsTimePtr()
+}
@@ -440,3 +440,63 @@ func ToChangedFile(f *gitdiff.DiffFile, repo *repo_model.Repository, commit stri
 
 	return file
 }
+
+// ToRichDiff convert a gitdiff.RichDiff to api.RichDiff
+func ToRichDiff(d *gitdiff.RichDiff) *api.RichDiff {
+	richDiff := &api.RichDiff{Type: string(d.Type)}
+
+	for _, cell := range d.Notebook {
+		apiCell := &api.NotebookCellDiff{
+			CellType:       cell.CellType,
+			BaseIndex:      cell.BaseIdx,
+			HeadIndex:      cell.HeadIdx,
+			Lines:          make([]string, 0, len(cell.Lines)),
+			OutputsChanged: cell.OutputsChanged,
+		}
+		switch cell.Type {
+		case gitdiff.NotebookCellUnchanged:
+			apiCell.Status = "unchanged"
+		case gitdiff.NotebookCellChanged:
+			apiCell.Status = "changed"
+		case gitdiff.NotebookCellAdd:
+			apiCell.Status = "added"
+		case gitdiff.NotebookCellDel:
+			apiCell.Status = "deleted"
+		}
+		for _, line := range cell.Lines {
+			prefix := " "
+			if line.Type == gitdiff.DiffLineAdd {
+				prefix = "+"
+			} else if line.Type == gitdiff.DiffLineDel {
+				prefix = "-"
+			}
+			apiCell.Lines = append(apiCell.Lines, prefix+line.Content)
+		}
+		richDiff.Notebook = append(richDiff.Notebook, apiCell)
+	}
+
+	if d.Image != nil {
+		toImageInfo := func(info *gitdiff.ImageInfo) *api.ImageInfo {
+			if info == nil {
+				return nil
+			}
+			return &api.ImageInfo{MimeType: info.MimeType, Size: info.Size, Width: info.Width, Height: info.Height}
+		}
+		richDiff.Image = &api.ImageDiff{
+			Before:     toImageInfo(d.Image.Before),
+			After:      toImageInfo(d.Image.After),
+			SizeChange: d.Image.SizeChange(),
+		}
+	}
+
+	for _, entry := range d.Structured {
+		apiEntry := &api.StructuredDiffEntry{Path: entry.Path, Status: "changed", Before: entry.Before, After: entry.After}
+		if entry.Type == gitdiff.StructuredDiffEntryAdd {
+			apiEntry.Status = "added"
+		} else if entry.Type == gitdiff.StructuredDiffEntryDel {
+			apiEntry.Status = "deleted"
+		}
+		richDiff.Structured = append(richDiff.Structured, apiEntry)
+	}
+	return richDiff
+}
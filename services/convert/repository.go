@@ -13,6 +13,7 @@ import (
 	repo_model "code.gitea.io/gitea/models/repo"
 	unit_model "code.gitea.io/gitea/models/unit"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
 )
 
@@ -221,6 +222,8 @@ func innerToRepo(ctx context.Context, repo *repo_model.Repository, permissionInR
 		AvatarURL:                     repo.AvatarLink(ctx),
 		Internal:                      !repo.IsPrivate && repo.Owner.Visibility == api.VisibleTypePrivate,
 		MirrorInterval:                mirrorInterval,
+		ObjectFormatName:              repo.ObjectFormatName,
+		AllowPartialClone:             repo.IsPartialCloneEnabled && !setting.Git.DisablePartialClone,
 		MirrorUpdated:                 mirrorUpdated,
 		RepoTransfer:                  transfer,
 	}
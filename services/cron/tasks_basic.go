@@ -15,6 +15,7 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/services/actions"
 	"code.gitea.io/gitea/services/auth"
+	"code.gitea.io/gitea/services/mailer"
 	"code.gitea.io/gitea/services/migrations"
 	mirror_service "code.gitea.io/gitea/services/mirror"
 	packages_cleanup_service "code.gitea.io/gitea/services/packages/cleanup"
@@ -171,6 +172,24 @@ func registerActionsCleanup() {
 	})
 }
 
+func registerSendMailDigests() {
+	RegisterTaskFatal("send_hourly_mail_digests", &BaseConfig{
+		Enabled:    true,
+		RunAtStart: false,
+		Schedule:   "@every 1h",
+	}, func(ctx context.Context, _ *user_model.User, _ Config) error {
+		return mailer.SendMailDigests(ctx, user_model.EmailDigestHourly)
+	})
+
+	RegisterTaskFatal("send_daily_mail_digests", &BaseConfig{
+		Enabled:    true,
+		RunAtStart: false,
+		Schedule:   "@midnight",
+	}, func(ctx context.Context, _ *user_model.User, _ Config) error {
+		return mailer.SendMailDigests(ctx, user_model.EmailDigestDaily)
+	})
+}
+
 func initBasicTasks() {
 	if setting.Mirror.Enabled {
 		registerUpdateMirrorTask()
@@ -190,4 +209,7 @@ func initBasicTasks() {
 	if setting.Actions.Enabled {
 		registerActionsCleanup()
 	}
+	if setting.Service.EnableNotifyMail {
+		registerSendMailDigests()
+	}
 }
@@ -5,14 +5,17 @@ package cron
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	activities_model "code.gitea.io/gitea/models/activities"
 	asymkey_model "code.gitea.io/gitea/models/asymkey"
 	"code.gitea.io/gitea/models/system"
 	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/git"
 	issue_indexer "code.gitea.io/gitea/modules/indexer/issues"
+	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/updatechecker"
 	repo_service "code.gitea.io/gitea/services/repository"
@@ -213,6 +216,35 @@ func registerGCLFS() {
 	})
 }
 
+func registerCleanupLFSMultipartUploads() {
+	if !setting.LFS.StartServer {
+		return
+	}
+	RegisterTaskFatal("cleanup_lfs_multipart_uploads", &OlderThanConfig{
+		BaseConfig: BaseConfig{
+			Enabled:    false,
+			RunAtStart: false,
+			Schedule:   "@every 24h",
+		},
+		// The parts of an upload can be resumed by the client, so give it a few days before aborting it
+		OlderThan: 72 * time.Hour,
+	}, func(ctx context.Context, _ *user_model.User, config Config) error {
+		olderThanConfig := config.(*OlderThanConfig)
+		count, size, err := repo_service.CleanupLFSMultipartUploads(ctx, repo_service.CleanupLFSMultipartUploadsOptions{
+			AutoFix:   true,
+			OlderThan: time.Now().Add(-olderThanConfig.OlderThan),
+		})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			log.Info("Aborted %d abandoned LFS multipart uploads, reclaimed %s", count, base.FileSize(size))
+			return system.CreateNotice(ctx, system.NoticeTask, fmt.Sprintf("Aborted %d abandoned LFS multipart uploads, reclaimed %s", count, base.FileSize(size)))
+		}
+		return nil
+	})
+}
+
 func registerRebuildIssueIndexer() {
 	RegisterTaskFatal("rebuild_issue_indexer", &BaseConfig{
 		Enabled:    false,
@@ -237,5 +269,6 @@ func initExtendedTasks() {
 	registerUpdateGiteaChecker()
 	registerDeleteOldSystemNotices()
 	registerGCLFS()
+	registerCleanupLFSMultipartUploads()
 	registerRebuildIssueIndexer()
 }
@@ -42,6 +42,8 @@ type AdminEditUserForm struct {
 	Website                 string `binding:"ValidUrl;MaxSize(255)"`
 	Location                string `binding:"MaxSize(50)"`
 	MaxRepoCreation         int
+	LFSMaxFileSize          int64
+	LFSQuota                int64
 	Active                  bool
 	Admin                   bool
 	Restricted              bool
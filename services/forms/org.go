@@ -44,6 +44,8 @@ type UpdateOrgSettingForm struct {
 	Location                  string `binding:"MaxSize(50)"`
 	Visibility                structs.VisibleType
 	MaxRepoCreation           int
+	LFSMaxFileSize            int64
+	LFSQuota                  int64
 	RepoAdminChangeTeamAccess bool
 }
 
@@ -53,6 +53,7 @@ type CreateRepoForm struct {
 	TrustModel      string
 
 	ForkSingleBranch string
+	ObjectFormatName string
 }
 
 // Validate validates the fields
@@ -134,6 +135,7 @@ type RepoSettingForm struct {
 	PushMirrorInterval     string
 	Private                bool
 	Template               bool
+	EnablePartialClone     bool
 	EnablePrune            bool
 
 	// Advanced settings
@@ -174,6 +176,8 @@ type RepoSettingForm struct {
 
 	// Admin settings
 	EnableHealthCheck  bool
+	LFSMaxFileSize     int64
+	LFSQuota           int64
 	RequestReindexType string
 }
 
@@ -222,6 +226,19 @@ func (f *ProtectBranchForm) Validate(req *http.Request, errs binding.Errors) bin
 	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// EditEnvironmentForm form for creating or editing a deployment environment of actions
+type EditEnvironmentForm struct {
+	Name           string `binding:"Required;MaxSize(255)"`
+	BranchPatterns string
+	Reviewers      string
+}
+
+// Validate validates the fields
+func (f *EditEnvironmentForm) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	ctx := context.GetValidateContext(req)
+	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 //  __      __      ___.   .__                   __
 // /  \    /  \ ____\_ |__ |  |__   ____   ____ |  | __
 // \   \/\/   // __ \| __ \|  |  \ /  _ \ /  _ \|  |/ /
@@ -0,0 +1,88 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package gitdiff
+
+import (
+	"bufio"
+	"image"
+	"io"
+
+	"code.gitea.io/gitea/modules/typesniffer"
+
+	_ "image/gif"  // for processing gif images
+	_ "image/jpeg" // for processing jpeg images
+	_ "image/png"  // for processing png images
+
+	_ "golang.org/x/image/bmp"  // for processing bmp images
+	_ "golang.org/x/image/webp" // for processing webp images
+)
+
+// imageSniffSize is the number of bytes which are needed to detect the type of an image
+const imageSniffSize = 1024
+
+// ImageInfo represents the metadata of a version of an image
+type ImageInfo struct {
+	MimeType string
+	Size     int64
+	// Width and Height are 0 if the dimensions of the image format can't be read
+	Width  int
+	Height int
+}
+
+// ImageDiff represents the difference of the metadata of an image, Before or After are nil if the image was added or deleted
+type ImageDiff struct {
+	Before *ImageInfo
+	After  *ImageInfo
+}
+
+// SizeChange returns the change of the file size in bytes
+func (d *ImageDiff) SizeChange() int64 {
+	var change int64
+	if d.After != nil {
+		change += d.After.Size
+	}
+	if d.Before != nil {
+		change -= d.Before.Size
+	}
+	return change
+}
+
+// DimensionsChanged returns whether the width or height of the image changed
+func (d *ImageDiff) DimensionsChanged() bool {
+	if d.Before == nil || d.After == nil {
+		return false
+	}
+	return d.Before.Width != d.After.Width || d.Before.Height != d.After.Height
+}
+
+// GetImageInfo reads the type and dimensions of an image of the given size
+func GetImageInfo(reader io.Reader, size int64) (*ImageInfo, error) {
+	br := bufio.NewReader(reader)
+	buf, err := br.Peek(imageSniffSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	info := &ImageInfo{MimeType: typesniffer.DetectContentType(buf).GetMimeType(), Size: size}
+	if config, _, err := image.DecodeConfig(br); err == nil {
+		info.Width, info.Height = config.Width, config.Height
+	}
+	return info, nil
+}
+
+// CreateImageDiff compares the metadata of two versions of an image, a nil reader is an absent image
+func CreateImageDiff(baseReader io.Reader, baseSize int64, headReader io.Reader, headSize int64) (*ImageDiff, error) {
+	diff := &ImageDiff{}
+	var err error
+	if baseReader != nil {
+		if diff.Before, err = GetImageInfo(baseReader, baseSize); err != nil {
+			return nil, err
+		}
+	}
+	if headReader != nil {
+		if diff.After, err = GetImageInfo(headReader, headSize); err != nil {
+			return nil, err
+		}
+	}
+	return diff, nil
+}
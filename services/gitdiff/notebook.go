@@ -0,0 +1,258 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package gitdiff
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/markup/jupyter"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// maxNotebookCells limits the number of cells which are aligned, as the alignment is quadratic
+const maxNotebookCells = 1000
+
+// ErrNotebookTooLarge is returned when a notebook has too many cells to be compared
+var ErrNotebookTooLarge = errors.New("notebook has too many cells")
+
+// NotebookCellDiffType represents the type of a NotebookCellDiff.
+type NotebookCellDiffType uint8
+
+// NotebookCellDiffType possible values.
+const (
+	NotebookCellUnchanged NotebookCellDiffType = iota + 1
+	NotebookCellChanged
+	NotebookCellAdd
+	NotebookCellDel
+)
+
+// NotebookLine represents a line of the source of a cell
+type NotebookLine struct {
+	Type    DiffLineType
+	Content string
+}
+
+// NotebookCellDiff represents the difference of a cell between two versions of a notebook
+type NotebookCellDiff struct {
+	Type     NotebookCellDiffType
+	CellType string
+	// BaseIdx and HeadIdx are the 1-based positions of the cell in the notebooks, 0 if the cell doesn't exist
+	BaseIdx        int
+	HeadIdx        int
+	Lines          []*NotebookLine
+	OutputsChanged bool
+}
+
+// GetHTMLDiffCellType returns the diff cell type name for HTML
+func (d *NotebookCellDiff) GetHTMLDiffCellType() string {
+	switch d.Type {
+	case NotebookCellChanged:
+		return "changed"
+	case NotebookCellAdd:
+		return "add"
+	case NotebookCellDel:
+		return "del"
+	}
+	return "same"
+}
+
+// GetHTMLDiffLineType returns the diff line type name for HTML
+func (l *NotebookLine) GetHTMLDiffLineType() string {
+	return (&DiffLine{Type: l.Type}).GetHTMLDiffLineType()
+}
+
+// CreateNotebookDiff creates a cell by cell diff of two notebooks, a nil reader is an absent notebook
+func CreateNotebookDiff(baseReader, headReader io.Reader) ([]*NotebookCellDiff, error) {
+	baseCells, err := readNotebookCells(baseReader)
+	if err != nil {
+		return nil, err
+	}
+	headCells, err := readNotebookCells(headReader)
+	if err != nil {
+		return nil, err
+	}
+	if len(baseCells) > maxNotebookCells || len(headCells) > maxNotebookCells {
+		return nil, ErrNotebookTooLarge
+	}
+
+	var diffs []*NotebookCellDiff
+	// gapDiffs pairs the removed and added cells between two unchanged cells as changed cells
+	gapDiffs := func(baseStart, baseEnd, headStart, headEnd int) {
+		for baseStart < baseEnd && headStart < headEnd && baseCells[baseStart].CellType == headCells[headStart].CellType {
+			diffs = append(diffs, createNotebookCellDiff(baseCells[baseStart], headCells[headStart], baseStart+1, headStart+1))
+			baseStart++
+			headStart++
+		}
+		for ; baseStart < baseEnd; baseStart++ {
+			diffs = append(diffs, createNotebookCellDiff(baseCells[baseStart], nil, baseStart+1, 0))
+		}
+		for ; headStart < headEnd; headStart++ {
+			diffs = append(diffs, createNotebookCellDiff(nil, headCells[headStart], 0, headStart+1))
+		}
+	}
+
+	baseIdx, headIdx := 0, 0
+	for _, match := range alignNotebookCells(baseCells, headCells) {
+		gapDiffs(baseIdx, match[0], headIdx, match[1])
+		diffs = append(diffs, createNotebookCellDiff(baseCells[match[0]], headCells[match[1]], match[0]+1, match[1]+1))
+		baseIdx, headIdx = match[0]+1, match[1]+1
+	}
+	gapDiffs(baseIdx, len(baseCells), headIdx, len(headCells))
+	return diffs, nil
+}
+
+func readNotebookCells(reader io.Reader) ([]*jupyter.Cell, error) {
+	if reader == nil {
+		return nil, nil
+	}
+	nb, err := jupyter.Parse(reader)
+	if err != nil {
+		return nil, err
+	}
+	return nb.Cells, nil
+}
+
+// alignNotebookCells returns the index pairs of the longest common subsequence of cells with the same type and source
+func alignNotebookCells(baseCells, headCells []*jupyter.Cell) [][2]int {
+	equal := func(i, j int) bool {
+		return baseCells[i].CellType == headCells[j].CellType && baseCells[i].Source == headCells[j].Source
+	}
+	n, m := len(baseCells), len(headCells)
+	lengths := make([][]int, n+1)
+	for i := range lengths {
+		lengths[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if equal(i, j) {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	var matches [][2]int
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case equal(i, j):
+			matches = append(matches, [2]int{i, j})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return matches
+}
+
+func createNotebookCellDiff(base, head *jupyter.Cell, baseIdx, headIdx int) *NotebookCellDiff {
+	diff := &NotebookCellDiff{BaseIdx: baseIdx, HeadIdx: headIdx}
+	switch {
+	case head == nil:
+		diff.Type = NotebookCellDel
+		diff.CellType = base.CellType
+		diff.Lines = notebookLines(string(base.Source), DiffLineDel)
+	case base == nil:
+		diff.Type = NotebookCellAdd
+		diff.CellType = head.CellType
+		diff.Lines = notebookLines(string(head.Source), DiffLineAdd)
+	default:
+		diff.CellType = head.CellType
+		diff.OutputsChanged = !notebookOutputsEqual(base.Outputs, head.Outputs)
+		if base.Source == head.Source {
+			diff.Type = NotebookCellUnchanged
+			if diff.OutputsChanged {
+				diff.Type = NotebookCellChanged
+			}
+			diff.Lines = notebookLines(string(head.Source), DiffLinePlain)
+		} else {
+			diff.Type = NotebookCellChanged
+			diff.Lines = diffNotebookSource(string(base.Source), string(head.Source))
+		}
+	}
+	return diff
+}
+
+func notebookLines(source string, lineType DiffLineType) []*NotebookLine {
+	if source == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(source, "\n"), "\n")
+	result := make([]*NotebookLine, 0, len(lines))
+	for _, line := range lines {
+		result = append(result, &NotebookLine{Type: lineType, Content: line})
+	}
+	return result
+}
+
+// diffNotebookSource creates a line diff of the sources of a cell
+func diffNotebookSource(base, head string) []*NotebookLine {
+	// the last lines are terminated so that they compare equal to the same lines followed by others
+	if !strings.HasSuffix(base, "\n") {
+		base += "\n"
+	}
+	if !strings.HasSuffix(head, "\n") {
+		head += "\n"
+	}
+	dmp := diffmatchpatch.New()
+	baseChars, headChars, lineArray := dmp.DiffLinesToChars(base, head)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(baseChars, headChars, false), lineArray)
+
+	var lines []*NotebookLine
+	for _, diff := range diffs {
+		lineType := DiffLinePlain
+		switch diff.Type {
+		case diffmatchpatch.DiffInsert:
+			lineType = DiffLineAdd
+		case diffmatchpatch.DiffDelete:
+			lineType = DiffLineDel
+		}
+		lines = append(lines, notebookLines(diff.Text, lineType)...)
+	}
+	return lines
+}
+
+// notebookOutputsEqual compares the content of the outputs, the execution counts are ignored
+func notebookOutputsEqual(base, head []*jupyter.Output) bool {
+	if len(base) != len(head) {
+		return false
+	}
+	for i := range base {
+		a, b := base[i], head[i]
+		if a.OutputType != b.OutputType || a.Name != b.Name || a.Text != b.Text ||
+			a.EName != b.EName || a.EValue != b.EValue || strings.Join(a.Traceback, "\n") != strings.Join(b.Traceback, "\n") ||
+			len(a.Data) != len(b.Data) {
+			return false
+		}
+		for mimeType, content := range a.Data {
+			if other, ok := b.Data[mimeType]; !ok || !notebookOutputDataEqual(content, other) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// notebookOutputDataEqual compares the content of a rich output, a text may be split into lines differently
+func notebookOutputDataEqual(a, b jupyter.OutputData) bool {
+	textA, okA := a.Text()
+	textB, okB := b.Text()
+	if okA || okB {
+		return okA && okB && textA == textB
+	}
+	var valueA, valueB any
+	if json.Unmarshal(a, &valueA) != nil || json.Unmarshal(b, &valueB) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(valueA, valueB)
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package gitdiff
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// RichDiffType represents the kind of a format aware diff of a file
+type RichDiffType string
+
+// RichDiffType possible values.
+const (
+	RichDiffNone       RichDiffType = ""
+	RichDiffNotebook   RichDiffType = "notebook"
+	RichDiffImage      RichDiffType = "image"
+	RichDiffStructured RichDiffType = "structured"
+)
+
+// ErrRichDiffTooLarge is returned when a file is too large to be compared by its content
+var ErrRichDiffTooLarge = errors.New("file is too large for a rich diff")
+
+// RichDiff represents a format aware diff of a file, only the field of the type is set
+type RichDiff struct {
+	Type       RichDiffType
+	Notebook   []*NotebookCellDiff
+	Image      *ImageDiff
+	Structured []*StructuredDiffEntry
+}
+
+// GetRichDiffTypeByName returns the rich diff type of a file by its name, images are only detected by their content
+func GetRichDiffTypeByName(name string) RichDiffType {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ipynb":
+		return RichDiffNotebook
+	case ".json", ".yaml", ".yml":
+		return RichDiffStructured
+	}
+	return RichDiffNone
+}
+
+// GetRichDiffType returns the rich diff type of a file, the blobs are nil if the file was added or deleted
+func GetRichDiffType(diffFile *DiffFile, baseBlob, headBlob *git.Blob) RichDiffType {
+	if diffFile == nil {
+		return RichDiffNone
+	}
+	if typ := GetRichDiffTypeByName(diffFile.Name); typ != RichDiffNone {
+		return typ
+	}
+	for _, blob := range []*git.Blob{headBlob, baseBlob} {
+		if blob == nil {
+			continue
+		}
+		st, err := blob.GuessContentType()
+		if err != nil || !st.IsImage() || (st.IsSvgImage() && !setting.UI.SVG.Enabled) {
+			return RichDiffNone
+		}
+	}
+	if baseBlob == nil && headBlob == nil {
+		return RichDiffNone
+	}
+	return RichDiffImage
+}
+
+// CreateRichDiff creates the rich diff of the given type of a file, nil is returned for RichDiffNone
+func CreateRichDiff(typ RichDiffType, baseBlob, headBlob *git.Blob) (*RichDiff, error) {
+	if typ == RichDiffNone {
+		return nil, nil
+	}
+	for _, blob := range []*git.Blob{baseBlob, headBlob} {
+		if blob != nil && setting.UI.MaxDisplayFileSize != 0 && blob.Size() > setting.UI.MaxDisplayFileSize {
+			return nil, ErrRichDiffTooLarge
+		}
+	}
+
+	baseReader, err := openRichDiffBlob(baseBlob)
+	if err != nil {
+		return nil, err
+	}
+	if baseReader != nil {
+		defer baseReader.Close()
+	}
+	headReader, err := openRichDiffBlob(headBlob)
+	if err != nil {
+		return nil, err
+	}
+	if headReader != nil {
+		defer headReader.Close()
+	}
+
+	diff := &RichDiff{Type: typ}
+	switch typ {
+	case RichDiffNotebook:
+		diff.Notebook, err = CreateNotebookDiff(baseReader, headReader)
+	case RichDiffStructured:
+		diff.Structured, err = CreateStructuredDiff(baseReader, headReader)
+	case RichDiffImage:
+		var baseSize, headSize int64
+		if baseBlob != nil {
+			baseSize = baseBlob.Size()
+		}
+		if headBlob != nil {
+			headSize = headBlob.Size()
+		}
+		diff.Image, err = CreateImageDiff(baseReader, baseSize, headReader, headSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+func openRichDiffBlob(blob *git.Blob) (io.ReadCloser, error) {
+	if blob == nil {
+		// It's ok for blob to be nil (file added or deleted)
+		return nil, nil
+	}
+	return blob.DataAsync()
+}
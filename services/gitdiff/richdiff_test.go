@@ -0,0 +1,176 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package gitdiff
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRichDiffTypeByName(t *testing.T) {
+	assert.Equal(t, RichDiffNotebook, GetRichDiffTypeByName("analysis/Notebook.IPYNB"))
+	assert.Equal(t, RichDiffStructured, GetRichDiffTypeByName("package.json"))
+	assert.Equal(t, RichDiffStructured, GetRichDiffTypeByName(".gitea/workflows/ci.yml"))
+	assert.Equal(t, RichDiffNone, GetRichDiffTypeByName("main.go"))
+}
+
+func TestNotebookDiff(t *testing.T) {
+	notebook := func(cells ...string) string {
+		return `{"nbformat": 4, "nbformat_minor": 5, "metadata": {}, "cells": [` + strings.Join(cells, ",") + `]}`
+	}
+	markdownCell := func(source string) string {
+		return `{"cell_type": "markdown", "metadata": {}, "source": "` + source + `"}`
+	}
+	codeCell := func(source string, count int, output string) string {
+		return `{"cell_type": "code", "metadata": {}, "execution_count": ` + string(rune('0'+count)) + `, "source": ["` + source + `"],
+			"outputs": [{"output_type": "stream", "name": "stdout", "text": "` + output + `"}]}`
+	}
+
+	base := notebook(
+		markdownCell("# Title"),
+		codeCell(`import math\n", "print(math.pi)`, 1, `3.14`),
+		markdownCell("removed"),
+		codeCell(`print(1)`, 2, `1`),
+	)
+	head := notebook(
+		markdownCell("# Title"),
+		codeCell(`import math\n", "print(math.e)`, 3, `2.71`),
+		codeCell(`print(1)`, 4, `1`),
+		markdownCell("added"),
+	)
+
+	diffs, err := CreateNotebookDiff(strings.NewReader(base), strings.NewReader(head))
+	assert.NoError(t, err)
+	if assert.Len(t, diffs, 5) {
+		assert.Equal(t, &NotebookCellDiff{
+			Type: NotebookCellUnchanged, CellType: "markdown", BaseIdx: 1, HeadIdx: 1,
+			Lines: []*NotebookLine{{DiffLinePlain, "# Title"}},
+		}, diffs[0])
+		assert.Equal(t, &NotebookCellDiff{
+			Type: NotebookCellChanged, CellType: "code", BaseIdx: 2, HeadIdx: 2, OutputsChanged: true,
+			Lines: []*NotebookLine{{DiffLinePlain, "import math"}, {DiffLineDel, "print(math.pi)"}, {DiffLineAdd, "print(math.e)"}},
+		}, diffs[1])
+		assert.Equal(t, NotebookCellDel, diffs[2].Type)
+		assert.Equal(t, 3, diffs[2].BaseIdx)
+		// a new execution count doesn't change the outputs
+		assert.Equal(t, NotebookCellUnchanged, diffs[3].Type)
+		assert.False(t, diffs[3].OutputsChanged)
+		assert.Equal(t, &NotebookCellDiff{
+			Type: NotebookCellAdd, CellType: "markdown", HeadIdx: 4,
+			Lines: []*NotebookLine{{DiffLineAdd, "added"}},
+		}, diffs[4])
+	}
+
+	// a new notebook
+	diffs, err = CreateNotebookDiff(nil, strings.NewReader(head))
+	assert.NoError(t, err)
+	assert.Len(t, diffs, 4)
+	for _, diff := range diffs {
+		assert.Equal(t, NotebookCellAdd, diff.Type)
+	}
+
+	_, err = CreateNotebookDiff(strings.NewReader(`{"nbformat": 3}`), strings.NewReader(head))
+	assert.Error(t, err)
+
+	// the rich outputs may be JSON objects
+	dataCell := func(data string) string {
+		return `{"cell_type": "code", "metadata": {}, "execution_count": 1, "source": "data",
+			"outputs": [{"output_type": "execute_result", "execution_count": 1, "metadata": {}, "data": ` + data + `}]}`
+	}
+	diffs, err = CreateNotebookDiff(
+		strings.NewReader(notebook(dataCell(`{"application/json": {"a": 1, "b": [2]}, "text/plain": ["{'a': 1,\n", "'b': [2]}"]}`))),
+		strings.NewReader(notebook(dataCell(`{"application/json": {"b": [2], "a": 1}, "text/plain": "{'a': 1,\n'b': [2]}"}`))),
+	)
+	assert.NoError(t, err)
+	if assert.Len(t, diffs, 1) {
+		assert.False(t, diffs[0].OutputsChanged)
+	}
+	diffs, err = CreateNotebookDiff(
+		strings.NewReader(notebook(dataCell(`{"application/json": {"a": 1}}`))),
+		strings.NewReader(notebook(dataCell(`{"application/json": {"a": 2}}`))),
+	)
+	assert.NoError(t, err)
+	if assert.Len(t, diffs, 1) {
+		assert.True(t, diffs[0].OutputsChanged)
+	}
+}
+
+func TestStructuredDiff(t *testing.T) {
+	base := `{
+	"name": "app",
+	"version": "1.0.0",
+	"scripts": {"build": "make", "test": "make test"},
+	"files": ["a", "b"],
+	"private": true
+}`
+	head := `
+name: app
+version: 1.1.0
+scripts:
+  build: make
+files: [a, b, c]
+private: "true"
+"odd key": null
+`
+	entries, err := CreateStructuredDiff(strings.NewReader(base), strings.NewReader(head))
+	assert.NoError(t, err)
+	assert.Equal(t, []*StructuredDiffEntry{
+		{Path: "$.version", Type: StructuredDiffEntryChanged, Before: `"1.0.0"`, After: `"1.1.0"`},
+		{Path: "$.scripts.test", Type: StructuredDiffEntryDel, Before: `"make test"`},
+		{Path: "$.files[2]", Type: StructuredDiffEntryAdd, After: `"c"`},
+		{Path: "$.private", Type: StructuredDiffEntryChanged, Before: `true`, After: `"true"`},
+		{Path: `$["odd key"]`, Type: StructuredDiffEntryAdd, After: `null`},
+	}, entries)
+
+	entries, err = CreateStructuredDiff(strings.NewReader(`{"a": {"b": 1}}`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*StructuredDiffEntry{{Path: "$.a.b", Type: StructuredDiffEntryDel, Before: "1"}}, entries)
+
+	// aliases are expanded until the document is too large
+	var sb strings.Builder
+	sb.WriteString("a: &a [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]\n")
+	for i, prev := 0, "a"; i < 5; i++ {
+		name := string(rune('b' + i))
+		sb.WriteString(name + ": &" + name + " [*" + prev + ", *" + prev + ", *" + prev + ", *" + prev + ", *" + prev + ", *" + prev + ", *" + prev + ", *" + prev + ", *" + prev + ", *" + prev + "]\n")
+		prev = name
+	}
+	_, err = CreateStructuredDiff(nil, strings.NewReader(sb.String()))
+	assert.ErrorIs(t, err, ErrStructuredDocumentTooLarge)
+
+	_, err = CreateStructuredDiff(strings.NewReader("{"), nil)
+	assert.Error(t, err)
+}
+
+func TestImageDiff(t *testing.T) {
+	encode := func(width, height int) []byte {
+		var buf bytes.Buffer
+		assert.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))))
+		return buf.Bytes()
+	}
+	base, head := encode(10, 20), encode(30, 20)
+
+	diff, err := CreateImageDiff(bytes.NewReader(base), int64(len(base)), bytes.NewReader(head), int64(len(head)))
+	assert.NoError(t, err)
+	assert.Equal(t, &ImageInfo{MimeType: "image/png", Size: int64(len(base)), Width: 10, Height: 20}, diff.Before)
+	assert.Equal(t, &ImageInfo{MimeType: "image/png", Size: int64(len(head)), Width: 30, Height: 20}, diff.After)
+	assert.True(t, diff.DimensionsChanged())
+	assert.Equal(t, int64(len(head)-len(base)), diff.SizeChange())
+
+	diff, err = CreateImageDiff(bytes.NewReader(base), int64(len(base)), nil, 0)
+	assert.NoError(t, err)
+	assert.Nil(t, diff.After)
+	assert.False(t, diff.DimensionsChanged())
+	assert.Equal(t, -int64(len(base)), diff.SizeChange())
+
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"></svg>`)
+	info, err := GetImageInfo(bytes.NewReader(svg), int64(len(svg)))
+	assert.NoError(t, err)
+	assert.Equal(t, "image/svg+xml", info.MimeType)
+	assert.Zero(t, info.Width)
+}
@@ -0,0 +1,176 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package gitdiff
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// maxStructuredValues limits the number of values of a document, which also stops the expansion of aliases
+	maxStructuredValues = 10000
+	maxStructuredDepth  = 100
+)
+
+// ErrStructuredDocumentTooLarge is returned when a document has too many values to be compared
+var ErrStructuredDocumentTooLarge = errors.New("document has too many values")
+
+// StructuredDiffEntryType represents the type of a StructuredDiffEntry.
+type StructuredDiffEntryType uint8
+
+// StructuredDiffEntryType possible values.
+const (
+	StructuredDiffEntryChanged StructuredDiffEntryType = iota + 1
+	StructuredDiffEntryAdd
+	StructuredDiffEntryDel
+)
+
+// StructuredDiffEntry represents a changed value of a JSON or YAML document
+type StructuredDiffEntry struct {
+	// Path is the JSONPath of the value, like $.spec.containers[0].image
+	Path   string
+	Type   StructuredDiffEntryType
+	Before string
+	After  string
+}
+
+// GetHTMLDiffLineType returns the diff line type name for HTML
+func (e *StructuredDiffEntry) GetHTMLDiffLineType() string {
+	switch e.Type {
+	case StructuredDiffEntryAdd:
+		return "add"
+	case StructuredDiffEntryDel:
+		return "del"
+	}
+	return "same"
+}
+
+// structuredValue is a scalar value or an empty collection of a document
+type structuredValue struct {
+	path  string
+	value string
+}
+
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// CreateStructuredDiff compares the values of two JSON or YAML documents by their paths, a nil reader is an absent document.
+// Only the first document of a YAML stream is compared.
+func CreateStructuredDiff(baseReader, headReader io.Reader) ([]*StructuredDiffEntry, error) {
+	baseValues, err := readStructuredValues(baseReader)
+	if err != nil {
+		return nil, err
+	}
+	headValues, err := readStructuredValues(headReader)
+	if err != nil {
+		return nil, err
+	}
+
+	baseByPath := make(map[string]string, len(baseValues))
+	for _, v := range baseValues {
+		baseByPath[v.path] = v.value
+	}
+	headByPath := make(map[string]string, len(headValues))
+	for _, v := range headValues {
+		headByPath[v.path] = v.value
+	}
+
+	// the entries follow the order of the head document, removed values are inserted after the preceding base value
+	var entries []*StructuredDiffEntry
+	baseIdx := 0
+	flushRemoved := func(untilPath string) {
+		for ; baseIdx < len(baseValues) && baseValues[baseIdx].path != untilPath; baseIdx++ {
+			v := baseValues[baseIdx]
+			if _, ok := headByPath[v.path]; !ok {
+				entries = append(entries, &StructuredDiffEntry{Path: v.path, Type: StructuredDiffEntryDel, Before: v.value})
+			}
+		}
+	}
+	for _, v := range headValues {
+		before, ok := baseByPath[v.path]
+		if !ok {
+			entries = append(entries, &StructuredDiffEntry{Path: v.path, Type: StructuredDiffEntryAdd, After: v.value})
+			continue
+		}
+		flushRemoved(v.path)
+		if baseIdx < len(baseValues) {
+			baseIdx++
+		}
+		if before != v.value {
+			entries = append(entries, &StructuredDiffEntry{Path: v.path, Type: StructuredDiffEntryChanged, Before: before, After: v.value})
+		}
+	}
+	flushRemoved("")
+	return entries, nil
+}
+
+func readStructuredValues(reader io.Reader) ([]structuredValue, error) {
+	if reader == nil {
+		return nil, nil
+	}
+	var doc yaml.Node
+	if err := yaml.NewDecoder(reader).Decode(&doc); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var values []structuredValue
+	if err := flattenStructuredNode(&doc, "$", 0, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func flattenStructuredNode(node *yaml.Node, path string, depth int, values *[]structuredValue) error {
+	if len(*values) >= maxStructuredValues || depth > maxStructuredDepth {
+		return ErrStructuredDocumentTooLarge
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return flattenStructuredNode(node.Content[0], path, depth+1, values)
+	case yaml.AliasNode:
+		return flattenStructuredNode(node.Alias, path, depth+1, values)
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			*values = append(*values, structuredValue{path: path, value: "{}"})
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			keyPath := path + "[" + strconv.Quote(key) + "]"
+			if identifierRegexp.MatchString(key) {
+				keyPath = path + "." + key
+			}
+			if err := flattenStructuredNode(node.Content[i+1], keyPath, depth+1, values); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			*values = append(*values, structuredValue{path: path, value: "[]"})
+		}
+		for i, child := range node.Content {
+			if err := flattenStructuredNode(child, path+"["+strconv.Itoa(i)+"]", depth+1, values); err != nil {
+				return err
+			}
+		}
+	default:
+		value := node.Value
+		if node.Tag == "!!null" {
+			value = "null"
+		} else if node.Tag == "!!str" {
+			// strings are quoted to distinguish them from other scalars
+			value = strconv.Quote(value)
+		}
+		*values = append(*values, structuredValue{path: path, value: value})
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package lfs
+
+import (
+	stdCtx "context"
+	"fmt"
+	"net/http"
+
+	git_model "code.gitea.io/gitea/models/git"
+	repo_model "code.gitea.io/gitea/models/repo"
+	lfs_module "code.gitea.io/gitea/modules/lfs"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// uploadLimits holds the size limits for LFS objects uploaded to a repository.
+// The maximum object size of the repository takes precedence over the one of the owner,
+// which takes precedence over LFS_MAX_FILE_SIZE. The quotas of the repository and the owner both apply.
+type uploadLimits struct {
+	maxFileSize int64
+
+	repoQuota      int64
+	repoRemaining  int64
+	ownerQuota     int64
+	ownerRemaining int64
+}
+
+func getUploadLimits(ctx stdCtx.Context, repo *repo_model.Repository) (*uploadLimits, error) {
+	if err := repo.LoadOwner(ctx); err != nil {
+		return nil, err
+	}
+
+	limits := &uploadLimits{
+		maxFileSize: setting.LFS.MaxFileSize,
+		repoQuota:   repo.LFSQuota,
+		ownerQuota:  repo.Owner.LFSQuota,
+	}
+	if repo.Owner.LFSMaxFileSize > 0 {
+		limits.maxFileSize = repo.Owner.LFSMaxFileSize
+	}
+	if repo.LFSMaxFileSize > 0 {
+		limits.maxFileSize = repo.LFSMaxFileSize
+	}
+
+	if limits.repoQuota > 0 {
+		used, err := git_model.GetRepoLFSSize(ctx, repo.ID)
+		if err != nil {
+			return nil, err
+		}
+		limits.repoRemaining = limits.repoQuota - used
+	}
+	if limits.ownerQuota > 0 {
+		used, err := git_model.GetOwnerLFSSize(ctx, repo.OwnerID)
+		if err != nil {
+			return nil, err
+		}
+		limits.ownerRemaining = limits.ownerQuota - used
+	}
+	return limits, nil
+}
+
+// check returns an error if the object may not be uploaded to the repository. The maximum size only applies
+// to objects which don't exist in the content store yet, the quotas only to objects which aren't linked to the
+// repository yet. The size of accepted objects is reserved from the remaining quotas, so that all objects of a
+// batch request are taken into account.
+func (l *uploadLimits) check(p lfs_module.Pointer, exists, linked bool) *lfs_module.ObjectError {
+	if !exists && l.maxFileSize > 0 && p.Size > l.maxFileSize {
+		return &lfs_module.ObjectError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: fmt.Sprintf("Size must be less than or equal to %d", l.maxFileSize),
+		}
+	}
+	if linked {
+		return nil
+	}
+	if l.repoQuota > 0 && p.Size > l.repoRemaining {
+		return &lfs_module.ObjectError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: fmt.Sprintf("Repository LFS quota of %d bytes exceeded", l.repoQuota),
+		}
+	}
+	if l.ownerQuota > 0 && p.Size > l.ownerRemaining {
+		return &lfs_module.ObjectError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: fmt.Sprintf("Owner LFS quota of %d bytes exceeded", l.ownerQuota),
+		}
+	}
+	l.repoRemaining -= p.Size
+	l.ownerRemaining -= p.Size
+	return nil
+}
+
+// checkUploadLimits checks the limits of the repository for an object whose content is sent to the server.
+// The batch request checks them as well, but the content may also be sent without a batch request.
+func checkUploadLimits(ctx stdCtx.Context, repo *repo_model.Repository, p lfs_module.Pointer, exists bool) (*lfs_module.ObjectError, error) {
+	limits, err := getUploadLimits(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := git_model.GetLFSMetaObjectByOid(ctx, repo.ID, p.Oid)
+	if err != nil && err != git_model.ErrLFSObjectNotExist {
+		return nil, err
+	}
+	return limits.check(p, exists, meta != nil), nil
+}
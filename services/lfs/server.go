@@ -4,7 +4,6 @@
 package lfs
 
 import (
-	"code.gitea.io/gitea/modules/structs"
 	stdCtx "context"
 	"encoding/base64"
 	"encoding/hex"
@@ -13,6 +12,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
 	"regexp"
 	"strconv"
@@ -32,6 +32,8 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/structs"
+	actions_service "code.gitea.io/gitea/services/actions"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/minio/sha256-simd"
@@ -95,6 +97,22 @@ func DownloadHandler(ctx *context.Context) {
 		return
 	}
 
+	filename := meta.Oid
+	if encoded := ctx.Params("filename"); len(encoded) > 0 {
+		if decodedFilename, err := base64.RawURLEncoding.DecodeString(encoded); err == nil {
+			filename = string(decodedFilename)
+		}
+	}
+
+	if setting.LFS.Storage.MinioConfig.ServeDirect {
+		// If we have a signed url (S3, object storage), redirect to this directly.
+		u, err := storage.LFS.URL(meta.RelativePath(), filename)
+		if u != nil && err == nil {
+			ctx.Redirect(u.String())
+			return
+		}
+	}
+
 	// Support resume download using Range header
 	var fromByte, toByte int64
 	toByte = meta.Size - 1
@@ -103,7 +121,7 @@ func DownloadHandler(ctx *context.Context) {
 		match := rangeHeaderRegexp.FindStringSubmatch(rangeHdr)
 		if len(match) > 1 {
 			statusCode = http.StatusPartialContent
-			fromByte, _ = strconv.ParseInt(match[1], 10, 32)
+			fromByte, _ = strconv.ParseInt(match[1], 10, 64)
 
 			if fromByte >= meta.Size {
 				writeStatus(ctx, http.StatusRequestedRangeNotSatisfiable)
@@ -111,13 +129,13 @@ func DownloadHandler(ctx *context.Context) {
 			}
 
 			if match[2] != "" {
-				_toByte, _ := strconv.ParseInt(match[2], 10, 32)
+				_toByte, _ := strconv.ParseInt(match[2], 10, 64)
 				if _toByte >= fromByte && _toByte < toByte {
 					toByte = _toByte
 				}
 			}
 
-			ctx.Resp.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", fromByte, toByte, meta.Size-fromByte))
+			ctx.Resp.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", fromByte, toByte, meta.Size))
 			ctx.Resp.Header().Set("Access-Control-Expose-Headers", "Content-Range")
 		}
 	}
@@ -143,13 +161,9 @@ func DownloadHandler(ctx *context.Context) {
 	ctx.Resp.Header().Set("Content-Length", strconv.FormatInt(contentLength, 10))
 	ctx.Resp.Header().Set("Content-Type", "application/octet-stream")
 
-	filename := ctx.Params("filename")
-	if len(filename) > 0 {
-		decodedFilename, err := base64.RawURLEncoding.DecodeString(filename)
-		if err == nil {
-			ctx.Resp.Header().Set("Content-Disposition", "attachment; filename=\""+string(decodedFilename)+"\"")
-			ctx.Resp.Header().Set("Access-Control-Expose-Headers", "Content-Disposition")
-		}
+	if filename != meta.Oid {
+		ctx.Resp.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+		ctx.Resp.Header().Set("Access-Control-Expose-Headers", "Content-Disposition")
 	}
 
 	ctx.Resp.WriteHeader(statusCode)
@@ -165,7 +179,8 @@ func BatchHandlerAdapter(ctx *context.Context) {
 		writeStatus(ctx, http.StatusBadRequest)
 		return
 	}
-	if isMultipartTransfers(br.Transfers) {
+	// ranged downloads work with every storage, uploads need the storage to assemble the parts
+	if isMultipartTransfers(br.Transfers) && (br.Operation == "download" || lfs_module.NewContentStore().SupportsMultipart()) {
 		log.Trace("handle batch request with multipart transfer")
 		MultipartBatchHandler(ctx, &br)
 	} else {
@@ -190,12 +205,20 @@ func MultipartBatchHandler(ctx *context.Context, br *lfs_module.BatchRequest) {
 	rc := getRequestContext(ctx)
 	repository := getAuthenticatedRepository(ctx, rc, isUpload)
 	if repository == nil {
-		log.Trace("Unable to get auth repository")
-		writeStatus(ctx, http.StatusBadRequest)
 		return
 	}
 	contentStore := lfs_module.NewContentStore()
 
+	var limits *uploadLimits
+	if isUpload {
+		var err error
+		if limits, err = getUploadLimits(ctx, repository); err != nil {
+			log.Error("Unable to get the LFS limits of %s/%s. Error: %v", rc.User, rc.Repo, err)
+			writeStatus(ctx, http.StatusInternalServerError)
+			return
+		}
+	}
+
 	var responseObjects []*lfs_module.ObjectResponseWithMultipart
 
 	for _, p := range br.Objects {
@@ -231,15 +254,9 @@ func MultipartBatchHandler(ctx *context.Context, br *lfs_module.BatchRequest) {
 
 		var responseObject *lfs_module.ObjectResponseWithMultipart
 		if isUpload {
-			var err *lfs_module.ObjectError
-			if !exists && setting.LFS.MaxFileSize > 0 && p.Size > setting.LFS.MaxFileSize {
-				err = &lfs_module.ObjectError{
-					Code:    http.StatusUnprocessableEntity,
-					Message: fmt.Sprintf("Size must be less than or equal to %d", setting.LFS.MaxFileSize),
-				}
-			}
+			err := limits.check(p, exists, meta != nil)
 
-			if exists && meta == nil {
+			if exists && meta == nil && err == nil {
 				accessible, err := git_model.LFSObjectAccessible(ctx, ctx.Doer, p.Oid)
 				if err != nil {
 					log.Error("Unable to check if LFS MetaObject [%s] is accessible. Error: %v", p.Oid, err)
@@ -257,12 +274,17 @@ func MultipartBatchHandler(ctx *context.Context, br *lfs_module.BatchRequest) {
 					exists = false
 				}
 			}
-			//get multipart information
-			part, _, verify, errorMessage := contentStore.GenerateMultipartParts(p)
-			if errorMessage != nil {
-				log.Error("Unable to generate multipart information. Error: %v", p.Oid, errorMessage)
-				writeStatus(ctx, http.StatusInternalServerError)
-				return
+			//get multipart information, only needed if the object has to be uploaded
+			var part []*structs.MultipartObjectPart
+			var verify *structs.MultipartEndpoint
+			if !exists && err == nil {
+				var errorMessage error
+				part, _, verify, errorMessage = contentStore.GenerateMultipartParts(p)
+				if errorMessage != nil {
+					log.Error("Unable to generate multipart information for LFS OID[%s]. Error: %v", p.Oid, errorMessage)
+					writeStatus(ctx, http.StatusInternalServerError)
+					return
+				}
 			}
 
 			responseObject = buildMultiPartObjectResponse(rc, p, false, !exists, err, part, verify)
@@ -311,6 +333,16 @@ func BatchHandler(ctx *context.Context, br *lfs_module.BatchRequest) {
 
 	contentStore := lfs_module.NewContentStore()
 
+	var limits *uploadLimits
+	if isUpload {
+		var err error
+		if limits, err = getUploadLimits(ctx, repository); err != nil {
+			log.Error("Unable to get the LFS limits of %s/%s. Error: %v", rc.User, rc.Repo, err)
+			writeStatus(ctx, http.StatusInternalServerError)
+			return
+		}
+	}
+
 	var responseObjects []*lfs_module.ObjectResponse
 
 	for _, p := range br.Objects {
@@ -346,15 +378,9 @@ func BatchHandler(ctx *context.Context, br *lfs_module.BatchRequest) {
 
 		var responseObject *lfs_module.ObjectResponse
 		if isUpload {
-			var err *lfs_module.ObjectError
-			if !exists && setting.LFS.MaxFileSize > 0 && p.Size > setting.LFS.MaxFileSize {
-				err = &lfs_module.ObjectError{
-					Code:    http.StatusUnprocessableEntity,
-					Message: fmt.Sprintf("Size must be less than or equal to %d", setting.LFS.MaxFileSize),
-				}
-			}
+			err := limits.check(p, exists, meta != nil)
 
-			if exists && meta == nil {
+			if exists && meta == nil && err == nil {
 				accessible, err := git_model.LFSObjectAccessible(ctx, ctx.Doer, p.Oid)
 				if err != nil {
 					log.Error("Unable to check if LFS MetaObject [%s] is accessible. Error: %v", p.Oid, err)
@@ -388,7 +414,7 @@ func BatchHandler(ctx *context.Context, br *lfs_module.BatchRequest) {
 		responseObjects = append(responseObjects, responseObject)
 	}
 
-	respobj := &lfs_module.BatchResponse{Objects: responseObjects}
+	respobj := &lfs_module.BatchResponse{Objects: responseObjects, Transfer: "basic"}
 
 	ctx.Resp.Header().Set("Content-Type", lfs_module.MediaType)
 
@@ -427,6 +453,15 @@ func UploadHandler(ctx *context.Context) {
 		return
 	}
 
+	if limitErr, err := checkUploadLimits(ctx, repository, p, exists); err != nil {
+		log.Error("Unable to check the LFS limits of %s/%s. Error: %v", rc.User, rc.Repo, err)
+		writeStatus(ctx, http.StatusInternalServerError)
+		return
+	} else if limitErr != nil {
+		writeStatusMessage(ctx, limitErr.Code, limitErr.Message)
+		return
+	}
+
 	uploadOrVerify := func() error {
 		if exists {
 			accessible, err := git_model.LFSObjectAccessible(ctx, ctx.Doer, p.Oid)
@@ -552,6 +587,14 @@ func MultiPartVerifyHandler(ctx *context.Context) {
 		writeStatus(ctx, http.StatusOK)
 		return
 	}
+	if limitErr, err := checkUploadLimits(ctx, repository, p, exists); err != nil {
+		log.Error("lfs[multipart] unable to check the LFS limits of %s/%s. Error: %v", rc.User, rc.Repo, err)
+		writeStatus(ctx, http.StatusInternalServerError)
+		return
+	} else if limitErr != nil {
+		writeStatusMessage(ctx, limitErr.Code, limitErr.Message)
+		return
+	}
 	ok, err := contentStore.CommitAndVerify(p, string(parameter))
 	if err != nil {
 		log.Error("lfs[multipart] failed to commit and verify LFS object %v", err)
@@ -563,7 +606,10 @@ func MultiPartVerifyHandler(ctx *context.Context) {
 	}
 
 	status := http.StatusOK
-	if err != nil {
+	if errors.Is(err, lfs_module.ErrSizeMismatch) || errors.Is(err, lfs_module.ErrHashMismatch) || errors.Is(err, storage.ErrInvalidMultipartPart) {
+		writeStatusMessage(ctx, http.StatusUnprocessableEntity, err.Error())
+		return
+	} else if err != nil {
 		log.Error("lfs[multipart] error commit and verify LFS OID[%s]: %v", p.Oid, err)
 		status = http.StatusInternalServerError
 	} else if !ok {
@@ -572,6 +618,46 @@ func MultiPartVerifyHandler(ctx *context.Context) {
 	writeStatus(ctx, status)
 }
 
+// MultipartPartUploadHandler receives a part of a multipart upload for storages which don't provide part endpoints on their own.
+// The request is authorized by the signed query of the part endpoint generated in the batch request,
+// the upload limits of the repository were checked then and are checked again when the upload is committed.
+func MultipartPartUploadHandler(ctx *context.Context) {
+	p := lfs_module.Pointer{Oid: ctx.Params("oid")}
+	var err error
+	if p.Size, err = strconv.ParseInt(ctx.Params("size"), 10, 64); err != nil {
+		writeStatusMessage(ctx, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	index, err := strconv.Atoi(ctx.Params("index"))
+	if err != nil || index < 1 {
+		writeStatus(ctx, http.StatusUnprocessableEntity)
+		return
+	}
+	if !p.IsValid() {
+		writeStatus(ctx, http.StatusUnprocessableEntity)
+		return
+	}
+
+	defer ctx.Req.Body.Close()
+	contentStore := lfs_module.NewContentStore()
+	etag, err := contentStore.UploadPart(p, index, ctx.Req.URL.Query(), ctx.Req.Body)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidMultipartPart) {
+			writeStatusMessage(ctx, http.StatusUnprocessableEntity, err.Error())
+		} else if os.IsNotExist(err) {
+			writeStatus(ctx, http.StatusNotFound)
+		} else {
+			log.Error("lfs[multipart] Error whilst uploading part %d of LFS OID[%s]: %v", index, p.Oid, err)
+			writeStatus(ctx, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	ctx.Resp.Header().Set("ETag", `"`+etag+`"`)
+	ctx.Resp.Header().Set("Access-Control-Expose-Headers", "ETag")
+	ctx.Resp.WriteHeader(http.StatusOK)
+}
+
 func decodeJSON(req *http.Request, v any) error {
 	defer req.Body.Close()
 
@@ -664,9 +750,35 @@ func buildMultiPartObjectResponse(rc *requestContext, pointer lfs_module.Pointer
 				link = &structs.MultipartEndpoint{Href: rc.DownloadLink(pointer), Headers: &header}
 			}
 			rep.Actions.Download = link
+
+			// large objects can additionally be fetched in ranged parts, both the presigned urls
+			// and the content handler of gitea support the Range header
+			rep.Actions.Parts = storage.SplitMultipartDownload(pointer.Size, setting.LFS.Storage.MultipartChunkSize)
+			for _, part := range rep.Actions.Parts {
+				partHeader := make(map[string]string, len(*link.Headers)+1)
+				for key, value := range *link.Headers {
+					partHeader[key] = value
+				}
+				partHeader["Range"] = fmt.Sprintf("bytes=%d-%d", part.Pos, part.Pos+part.Size-1)
+				part.MultipartEndpoint = &structs.MultipartEndpoint{
+					Href:    link.Href,
+					Method:  http.MethodGet,
+					Headers: &partHeader,
+				}
+			}
 		}
 		if upload {
-			//add parts
+			//add parts, the hrefs of parts uploaded through gitea are relative to the upload link
+			base, _ := url.Parse(rc.UploadLink(pointer) + "/")
+			for _, part := range parts {
+				if part.MultipartEndpoint == nil {
+					continue
+				}
+				if u, err := url.Parse(part.Href); err == nil && !u.IsAbs() {
+					part.Href = base.ResolveReference(u).String()
+					part.Headers = &header
+				}
+			}
 			rep.Actions.Parts = parts
 			if verify.Headers == nil {
 				headers := make(map[string]string)
@@ -777,10 +889,12 @@ func authenticate(ctx *context.Context, repository *repo_model.Repository, autho
 			return false
 		}
 
-		if task.IsForkPullRequest {
-			return accessMode <= perm.AccessModeRead
+		p, err := actions_service.GetTaskPermission(ctx, task, repository)
+		if err != nil {
+			log.Error("Unable to GetTaskPermission for task[%d] Error: %v", taskID, err)
+			return false
 		}
-		return accessMode <= perm.AccessModeWrite
+		return p.CanAccess(accessMode, unit.TypeCode)
 	}
 
 	// ctx.IsSigned is unnecessary here, this will be checked in perm.CanAccess
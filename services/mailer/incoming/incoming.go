@@ -348,6 +348,7 @@ func searchTokenInAddresses(addresses []*net_mail.Address) string {
 }
 
 type MailContent struct {
+	Subject     string
 	Content     string
 	Attachments []*Attachment
 }
@@ -369,6 +370,7 @@ func getContentFromMailReader(env *enmime.Envelope) *MailContent {
 	}
 
 	return &MailContent{
+		Subject:     env.GetHeader("Subject"),
 		Content:     reply.FromText(env.Text),
 		Attachments: attachments,
 	}
@@ -7,11 +7,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"strings"
 
 	issues_model "code.gitea.io/gitea/models/issues"
 	access_model "code.gitea.io/gitea/models/perm/access"
 	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
 	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/upload"
@@ -30,6 +33,7 @@ type MailHandler interface {
 var handlers = map[token.HandlerType]MailHandler{
 	token.ReplyHandlerType:       &ReplyHandler{},
 	token.UnsubscribeHandlerType: &UnsubscribeHandler{},
+	token.NewIssueHandlerType:    &NewIssueHandler{},
 }
 
 // ReplyHandler handles incoming emails to create a reply from them
@@ -82,35 +86,15 @@ func (h *ReplyHandler) Handle(ctx context.Context, content *MailContent, doer *u
 		return nil
 	}
 
-	switch r := ref.(type) {
-	case *issues_model.Issue:
-		attachmentIDs := make([]string, 0, len(content.Attachments))
-		if setting.Attachment.Enabled {
-			for _, attachment := range content.Attachments {
-				a, err := attachment_service.UploadAttachment(ctx, bytes.NewReader(attachment.Content), setting.Attachment.AllowedTypes, int64(len(attachment.Content)), &repo_model.Attachment{
-					Name:       attachment.Name,
-					UploaderID: doer.ID,
-					RepoID:     issue.Repo.ID,
-				})
-				if err != nil {
-					if upload.IsErrFileTypeForbidden(err) {
-						log.Info("Skipping disallowed attachment type: %s", attachment.Name)
-						continue
-					}
-					return err
-				}
-				attachmentIDs = append(attachmentIDs, a.UUID)
-			}
-		}
-
-		if content.Content == "" && len(attachmentIDs) == 0 {
-			return nil
+	if issue.IsPull {
+		if reviewType, reviewContent := extractReviewCommand(content.Content); reviewType != issues_model.ReviewTypeUnknown {
+			return submitReview(ctx, doer, issue, reviewType, reviewContent, content.Attachments)
 		}
+	}
 
-		_, err = issue_service.CreateIssueComment(ctx, doer, issue.Repo, issue, content.Content, attachmentIDs)
-		if err != nil {
-			return fmt.Errorf("CreateIssueComment failed: %w", err)
-		}
+	switch r := ref.(type) {
+	case *issues_model.Issue:
+		return createIssueComment(ctx, doer, issue, content)
 	case *issues_model.Comment:
 		comment := r
 
@@ -139,6 +123,178 @@ func (h *ReplyHandler) Handle(ctx context.Context, content *MailContent, doer *u
 	return nil
 }
 
+// NewIssueHandler handles incoming emails to create a new issue from them
+type NewIssueHandler struct{}
+
+func (h *NewIssueHandler) Handle(ctx context.Context, content *MailContent, doer *user_model.User, payload []byte) error {
+	if doer == nil {
+		return util.NewInvalidArgumentErrorf("doer can't be nil")
+	}
+
+	ref, err := incoming_payload.GetReferenceFromPayload(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	repo, ok := ref.(*repo_model.Repository)
+	if !ok {
+		return util.NewInvalidArgumentErrorf("unsupported new issue reference: %v", ref)
+	}
+
+	if repo.IsArchived {
+		log.Debug("can't create issue in archived repository")
+		return nil
+	}
+
+	perm, err := access_model.GetUserRepoPermission(ctx, repo, doer)
+	if err != nil {
+		return err
+	}
+
+	if !perm.CanRead(unit.TypeIssues) {
+		log.Debug("can't read issues")
+		return nil
+	}
+
+	title := strings.TrimSpace(content.Subject)
+	if title == "" {
+		log.Debug("can't create issue without a subject")
+		return nil
+	}
+
+	attachmentIDs, err := uploadAttachments(ctx, doer, repo, content.Attachments)
+	if err != nil {
+		return err
+	}
+
+	issue := &issues_model.Issue{
+		RepoID:   repo.ID,
+		Repo:     repo,
+		Title:    title,
+		PosterID: doer.ID,
+		Poster:   doer,
+		Content:  content.Content,
+	}
+	if err := issue_service.NewIssue(ctx, repo, issue, nil, attachmentIDs, nil); err != nil {
+		return fmt.Errorf("NewIssue failed: %w", err)
+	}
+	return nil
+}
+
+// NewIssueAddress returns the personal address the user can send emails to for creating issues in the repository
+func NewIssueAddress(doer *user_model.User, repo *repo_model.Repository) (string, error) {
+	payload, err := incoming_payload.CreateReferencePayload(repo)
+	if err != nil {
+		return "", err
+	}
+
+	t, err := token.CreateToken(token.NewIssueHandlerType, doer, payload)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Replace(setting.IncomingEmail.ReplyToAddress, setting.IncomingEmail.TokenPlaceholder, t, 1), nil
+}
+
+// reviewCommands maps the command lines which submit a pull request review from a reply
+var reviewCommands = map[string]issues_model.ReviewType{
+	"/approve":         issues_model.ReviewTypeApprove,
+	"/request-changes": issues_model.ReviewTypeReject,
+}
+
+// extractReviewCommand searches the content for a review command line.
+// It returns the review type and the content without the command line.
+func extractReviewCommand(content string) (issues_model.ReviewType, string) {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if reviewType, ok := reviewCommands[strings.ToLower(strings.TrimSpace(line))]; ok {
+			remaining := append(lines[:i:i], lines[i+1:]...)
+			return reviewType, strings.TrimSpace(strings.Join(remaining, "\n"))
+		}
+	}
+	return issues_model.ReviewTypeUnknown, content
+}
+
+// submitReview submits a review of the pull request with the content of the mail
+func submitReview(ctx context.Context, doer *user_model.User, issue *issues_model.Issue, reviewType issues_model.ReviewType, content string, attachments []*Attachment) error {
+	// can not approve/reject your own PR
+	if issue.IsPoster(doer.ID) {
+		log.Debug("can't approve or reject own pull request")
+		return nil
+	}
+
+	if err := issue.LoadPullRequest(ctx); err != nil {
+		return err
+	}
+
+	gitRepo, closer, err := git.RepositoryFromContextOrOpen(ctx, issue.Repo.RepoPath())
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	headCommitID, err := gitRepo.GetRefCommitID(issue.PullRequest.GetGitRefName())
+	if err != nil {
+		return err
+	}
+
+	attachmentIDs, err := uploadAttachments(ctx, doer, issue.Repo, attachments)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := pull_service.SubmitReview(ctx, doer, gitRepo, issue, reviewType, content, headCommitID, attachmentIDs); err != nil {
+		if issues_model.IsContentEmptyErr(err) {
+			log.Debug("can't submit review without content")
+			return nil
+		}
+		return fmt.Errorf("SubmitReview failed: %w", err)
+	}
+	return nil
+}
+
+// createIssueComment creates a comment with the content and attachments of the mail
+func createIssueComment(ctx context.Context, doer *user_model.User, issue *issues_model.Issue, content *MailContent) error {
+	attachmentIDs, err := uploadAttachments(ctx, doer, issue.Repo, content.Attachments)
+	if err != nil {
+		return err
+	}
+
+	if content.Content == "" && len(attachmentIDs) == 0 {
+		return nil
+	}
+
+	if _, err := issue_service.CreateIssueComment(ctx, doer, issue.Repo, issue, content.Content, attachmentIDs); err != nil {
+		return fmt.Errorf("CreateIssueComment failed: %w", err)
+	}
+	return nil
+}
+
+// uploadAttachments stores the mail attachments and returns their UUIDs. Disallowed file types are skipped.
+func uploadAttachments(ctx context.Context, doer *user_model.User, repo *repo_model.Repository, attachments []*Attachment) ([]string, error) {
+	if !setting.Attachment.Enabled {
+		return nil, nil
+	}
+
+	attachmentIDs := make([]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		a, err := attachment_service.UploadAttachment(ctx, bytes.NewReader(attachment.Content), setting.Attachment.AllowedTypes, int64(len(attachment.Content)), &repo_model.Attachment{
+			Name:       attachment.Name,
+			UploaderID: doer.ID,
+			RepoID:     repo.ID,
+		})
+		if err != nil {
+			if upload.IsErrFileTypeForbidden(err) {
+				log.Info("Skipping disallowed attachment type: %s", attachment.Name)
+				continue
+			}
+			return nil, err
+		}
+		attachmentIDs = append(attachmentIDs, a.UUID)
+	}
+	return attachmentIDs, nil
+}
+
 // UnsubscribeHandler handles unwatching issues/pulls
 type UnsubscribeHandler struct{}
 
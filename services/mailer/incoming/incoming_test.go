@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 
+	issues_model "code.gitea.io/gitea/models/issues"
+
 	"github.com/jhillyerd/enmime"
 	"github.com/stretchr/testify/assert"
 )
@@ -69,7 +71,8 @@ func TestIsAutomaticReply(t *testing.T) {
 }
 
 func TestGetContentFromMailReader(t *testing.T) {
-	mailString := "Content-Type: multipart/mixed; boundary=message-boundary\r\n" +
+	mailString := "Subject: mail subject\r\n" +
+		"Content-Type: multipart/mixed; boundary=message-boundary\r\n" +
 		"\r\n" +
 		"--message-boundary\r\n" +
 		"Content-Type: multipart/alternative; boundary=text-boundary\r\n" +
@@ -90,6 +93,7 @@ func TestGetContentFromMailReader(t *testing.T) {
 	env, err := enmime.ReadEnvelope(strings.NewReader(mailString))
 	assert.NoError(t, err)
 	content := getContentFromMailReader(env)
+	assert.Equal(t, "mail subject", content.Subject)
 	assert.Equal(t, "mail content", content.Content)
 	assert.Len(t, content.Attachments, 1)
 	assert.Equal(t, "attachment.txt", content.Attachments[0].Name)
@@ -136,3 +140,43 @@ func TestGetContentFromMailReader(t *testing.T) {
 	assert.Equal(t, "mail content without signature", content.Content)
 	assert.Empty(t, content.Attachments)
 }
+
+func TestExtractReviewCommand(t *testing.T) {
+	cases := []struct {
+		Content         string
+		ExpectedType    issues_model.ReviewType
+		ExpectedContent string
+	}{
+		{
+			Content:         "just a reply",
+			ExpectedType:    issues_model.ReviewTypeUnknown,
+			ExpectedContent: "just a reply",
+		},
+		{
+			Content:         "/approve",
+			ExpectedType:    issues_model.ReviewTypeApprove,
+			ExpectedContent: "",
+		},
+		{
+			Content:         "/approve\nlooks good",
+			ExpectedType:    issues_model.ReviewTypeApprove,
+			ExpectedContent: "looks good",
+		},
+		{
+			Content:         "please fix the typo\n  /Request-Changes  \nthanks",
+			ExpectedType:    issues_model.ReviewTypeReject,
+			ExpectedContent: "please fix the typo\nthanks",
+		},
+		{
+			Content:         "use /approve to approve",
+			ExpectedType:    issues_model.ReviewTypeUnknown,
+			ExpectedContent: "use /approve to approve",
+		},
+	}
+
+	for _, c := range cases {
+		reviewType, content := extractReviewCommand(c.Content)
+		assert.Equal(t, c.ExpectedType, reviewType)
+		assert.Equal(t, c.ExpectedContent, content)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 
 	issues_model "code.gitea.io/gitea/models/issues"
+	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/modules/util"
 )
 
@@ -17,6 +18,7 @@ type payloadReferenceType byte
 const (
 	payloadReferenceIssue payloadReferenceType = iota
 	payloadReferenceComment
+	payloadReferenceRepository
 )
 
 // CreateReferencePayload creates data which GetReferenceFromPayload resolves to the reference again.
@@ -31,6 +33,9 @@ func CreateReferencePayload(reference any) ([]byte, error) {
 	case *issues_model.Comment:
 		refType = payloadReferenceComment
 		refID = r.ID
+	case *repo_model.Repository:
+		refType = payloadReferenceRepository
+		refID = r.ID
 	default:
 		return nil, util.NewInvalidArgumentErrorf("unsupported reference type: %T", r)
 	}
@@ -64,6 +69,8 @@ func GetReferenceFromPayload(ctx context.Context, payload []byte) (any, error) {
 		return issues_model.GetIssueByID(ctx, id)
 	case payloadReferenceComment:
 		return issues_model.GetCommentByID(ctx, id)
+	case payloadReferenceRepository:
+		return repo_model.GetRepositoryByID(ctx, id)
 	default:
 		return nil, util.NewInvalidArgumentErrorf("unsupported reference type: %T", ref)
 	}
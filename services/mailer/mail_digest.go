@@ -0,0 +1,285 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	activities_model "code.gitea.io/gitea/models/activities"
+	"code.gitea.io/gitea/models/db"
+	issues_model "code.gitea.io/gitea/models/issues"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/translation"
+)
+
+const (
+	tplMailDigest base.TplName = "notify/digest"
+)
+
+type mailDigestEvent struct {
+	Doer       *user_model.User
+	ActionName string
+	IsMention  bool
+	Body       string
+	Link       string
+}
+
+type mailDigestIssue struct {
+	Issue  *issues_model.Issue
+	Events []*mailDigestEvent
+}
+
+type mailDigestRepo struct {
+	Repo   *repo_model.Repository
+	Issues []*mailDigestIssue
+}
+
+// queueMailDigestItems stores the event of the mail context for the next digests of the users
+func queueMailDigestItems(ctx *mailCommentContext, users []*user_model.User, fromMention bool) error {
+	items := make([]*activities_model.MailDigestItem, 0, len(users))
+	for _, user := range users {
+		item := &activities_model.MailDigestItem{
+			UserID:     user.ID,
+			RepoID:     ctx.Issue.RepoID,
+			IssueID:    ctx.Issue.ID,
+			DoerID:     ctx.Doer.ID,
+			ActionType: ctx.ActionType,
+			IsMention:  fromMention,
+		}
+		if ctx.Comment != nil {
+			item.CommentID = ctx.Comment.ID
+		}
+		items = append(items, item)
+	}
+	return activities_model.CreateMailDigestItems(ctx, items)
+}
+
+// SendMailDigests sends the queued events as one digest email to every user receiving digests with the given frequency
+func SendMailDigests(ctx context.Context, frequency string) error {
+	if setting.MailService == nil {
+		// No mail service configured
+		return nil
+	}
+
+	userIDs, err := activities_model.GetMailDigestUserIDs(ctx, frequency)
+	if err != nil {
+		return fmt.Errorf("GetMailDigestUserIDs: %w", err)
+	}
+
+	for _, userID := range userIDs {
+		select {
+		case <-ctx.Done():
+			return db.ErrCancelledf("before sending the mail digest of user %d", userID)
+		default:
+		}
+
+		if err := sendMailDigest(ctx, userID); err != nil {
+			log.Error("sendMailDigest(%d): %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// FlushMailDigest sends the queued events of the user at once. It's called when the user stops receiving digests,
+// the events wouldn't be sent with the digests of any frequency anymore.
+func FlushMailDigest(ctx context.Context, userID int64) error {
+	return sendMailDigest(ctx, userID)
+}
+
+func sendMailDigest(ctx context.Context, userID int64) error {
+	items, err := activities_model.GetMailDigestItemsByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	maxID := items[len(items)-1].ID
+
+	user, err := user_model.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// The preference may have changed since the events were queued
+	if setting.MailService != nil && user.IsMailable() && user.EmailNotificationsPreference != user_model.EmailNotificationsDisabled {
+		msg, err := composeMailDigestMessage(ctx, user, items)
+		if err != nil {
+			return err
+		}
+		if msg != nil {
+			SendAsync(msg)
+		}
+	}
+
+	return activities_model.DeleteMailDigestItemsByUserID(ctx, userID, maxID)
+}
+
+// composeMailDigestMessage groups the events by repository and issue. It returns nil if the user can't see any of them.
+func composeMailDigestMessage(ctx context.Context, user *user_model.User, items []*activities_model.MailDigestItem) (*Message, error) {
+	issueIDs := make([]int64, 0, len(items))
+	for _, item := range items {
+		issueIDs = append(issueIDs, item.IssueID)
+	}
+	issues, err := issues_model.GetIssuesByIDs(ctx, issueIDs)
+	if err != nil {
+		return nil, fmt.Errorf("GetIssuesByIDs: %w", err)
+	}
+	if _, err := issues.LoadRepositories(ctx); err != nil {
+		return nil, fmt.Errorf("LoadRepositories: %w", err)
+	}
+	issueMap := make(map[int64]*issues_model.Issue, len(issues))
+	for _, issue := range issues {
+		issueMap[issue.ID] = issue
+	}
+
+	repos := make([]*mailDigestRepo, 0, 5)
+	repoMap := make(map[int64]*mailDigestRepo)
+	digestIssueMap := make(map[int64]*mailDigestIssue)
+	visible := make(map[int64]bool)
+	doers := make(map[int64]*user_model.User)
+	count := 0
+
+	for _, item := range items {
+		issue, ok := issueMap[item.IssueID]
+		if !ok {
+			// the issue has been deleted in the meantime
+			continue
+		}
+
+		canSee, ok := visible[issue.ID]
+		if !ok {
+			// test if this user is still allowed to see the issue/pull
+			checkUnit := unit.TypeIssues
+			if issue.IsPull {
+				checkUnit = unit.TypePullRequests
+			}
+			canSee = access_model.CheckRepoUnitUser(ctx, issue.Repo, user, checkUnit)
+			visible[issue.ID] = canSee
+		}
+		if !canSee {
+			continue
+		}
+
+		doer, ok := doers[item.DoerID]
+		if !ok {
+			if doer, err = user_model.GetPossibleUserByID(ctx, item.DoerID); err != nil {
+				if !user_model.IsErrUserNotExist(err) {
+					return nil, err
+				}
+				doer = user_model.NewGhostUser()
+			}
+			doers[item.DoerID] = doer
+		}
+
+		var comment *issues_model.Comment
+		if item.CommentID != 0 {
+			if comment, err = issues_model.GetCommentByID(ctx, item.CommentID); err != nil {
+				if !issues_model.IsErrCommentNotExist(err) {
+					return nil, err
+				}
+				// the comment has been deleted in the meantime
+				continue
+			}
+			if comment.ReviewID != 0 {
+				if err := comment.LoadReview(ctx); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		event, err := newMailDigestEvent(ctx, issue, comment, doer, item)
+		if err != nil {
+			return nil, err
+		}
+
+		digestRepo, ok := repoMap[issue.RepoID]
+		if !ok {
+			digestRepo = &mailDigestRepo{Repo: issue.Repo}
+			repoMap[issue.RepoID] = digestRepo
+			repos = append(repos, digestRepo)
+		}
+		digestIssue, ok := digestIssueMap[issue.ID]
+		if !ok {
+			digestIssue = &mailDigestIssue{Issue: issue}
+			digestIssueMap[issue.ID] = digestIssue
+			digestRepo.Issues = append(digestRepo.Issues, digestIssue)
+		}
+		digestIssue.Events = append(digestIssue.Events, event)
+		count++
+	}
+
+	if count == 0 {
+		return nil, nil
+	}
+
+	locale := translation.NewLocale(user.Language)
+	subject := locale.TrN(count, "mail.digest.subject_1", "mail.digest.subject_n", count, setting.AppName)
+
+	mailMeta := map[string]any{
+		"locale":   locale,
+		"Subject":  subject,
+		"Repos":    repos,
+		"Link":     setting.AppURL + "notifications",
+		"Language": locale.Language(),
+	}
+
+	var mailBody bytes.Buffer
+	if err := bodyTemplates.ExecuteTemplate(&mailBody, string(tplMailDigest), mailMeta); err != nil {
+		return nil, fmt.Errorf("ExecuteTemplate [%s]: %w", string(tplMailDigest)+"/body", err)
+	}
+
+	msg := NewMessage(user.Email, subject, mailBody.String())
+	msg.Info = fmt.Sprintf("UID: %d, notification digest", user.ID)
+	return msg, nil
+}
+
+func newMailDigestEvent(ctx context.Context, issue *issues_model.Issue, comment *issues_model.Comment, doer *user_model.User, item *activities_model.MailDigestItem) (*mailDigestEvent, error) {
+	commentType := issues_model.CommentTypeComment
+	reviewType := issues_model.ReviewTypeComment
+	link := issue.HTMLURL()
+	if comment != nil {
+		commentType = comment.Type
+		if comment.Review != nil {
+			reviewType = comment.Review.Type
+		}
+		link += "#" + comment.HashTag()
+	}
+
+	_, actName, _ := actionToTemplate(issue, item.ActionType, commentType, reviewType)
+
+	var content string
+	if comment != nil {
+		content = comment.Content
+	} else if actName == "new" {
+		content = issue.Content
+	}
+
+	body, err := markdown.RenderString(&markup.RenderContext{
+		Ctx:       ctx,
+		URLPrefix: issue.Repo.HTMLURL(),
+		Metas:     issue.Repo.ComposeMetas(ctx),
+	}, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mailDigestEvent{
+		Doer:       doer,
+		ActionName: actName,
+		IsMention:  item.IsMention,
+		Body:       body,
+		Link:       link,
+	}, nil
+}
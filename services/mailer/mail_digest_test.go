@@ -0,0 +1,90 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mailer
+
+import (
+	"html/template"
+	"testing"
+
+	activities_model "code.gitea.io/gitea/models/activities"
+	"code.gitea.io/gitea/models/db"
+	issues_model "code.gitea.io/gitea/models/issues"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const digestTpl = `{{range .Repos}}[{{.Repo.FullName}}]{{range .Issues}} #{{.Issue.Index}}:{{range .Events}} {{.ActionName}} by {{.Doer.Name}}{{if .IsMention}} (mention){{end}}{{end}}{{end}}{{end}}`
+
+func TestComposeMailDigestMessage(t *testing.T) {
+	doer, _, issue, comment := prepareMailerTest(t)
+
+	bodyTemplates = template.Must(template.New(string(tplMailDigest)).Parse(digestTpl))
+
+	recipient := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 4})
+
+	assert.NoError(t, queueMailDigestItems(&mailCommentContext{
+		Context: db.DefaultContext,
+		Issue:   issue, Doer: doer, ActionType: activities_model.ActionCommentIssue,
+		Comment: comment,
+	}, []*user_model.User{recipient}, false))
+	assert.NoError(t, queueMailDigestItems(&mailCommentContext{
+		Context: db.DefaultContext,
+		Issue:   issue, Doer: doer, ActionType: activities_model.ActionCloseIssue,
+	}, []*user_model.User{recipient}, true))
+
+	// user 4 can't see the issues of the private repository user2/repo2
+	privateIssue := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 4})
+	assert.NoError(t, privateIssue.LoadRepo(db.DefaultContext))
+	assert.NoError(t, queueMailDigestItems(&mailCommentContext{
+		Context: db.DefaultContext,
+		Issue:   privateIssue, Doer: doer, ActionType: activities_model.ActionCommentIssue,
+	}, []*user_model.User{recipient}, false))
+
+	items, err := activities_model.GetMailDigestItemsByUserID(db.DefaultContext, recipient.ID)
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+
+	msg, err := composeMailDigestMessage(db.DefaultContext, recipient, items)
+	assert.NoError(t, err)
+	if assert.NotNil(t, msg) {
+		assert.Equal(t, recipient.Email, msg.To)
+		assert.Equal(t, "[user2/repo1] #1: comment by user2 close by user2 (mention)", msg.Body)
+	}
+
+	msg, err = composeMailDigestMessage(db.DefaultContext, recipient, items[2:])
+	assert.NoError(t, err)
+	assert.Nil(t, msg)
+}
+
+func TestFlushMailDigest(t *testing.T) {
+	doer, _, issue, _ := prepareMailerTest(t)
+
+	bodyTemplates = template.Must(template.New(string(tplMailDigest)).Parse(digestTpl))
+
+	recipient := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 4})
+	assert.NoError(t, user_model.SetEmailDigestFrequency(db.DefaultContext, recipient, user_model.EmailDigestDaily))
+	assert.NoError(t, queueMailDigestItems(&mailCommentContext{
+		Context: db.DefaultContext,
+		Issue:   issue, Doer: doer, ActionType: activities_model.ActionCloseIssue,
+	}, []*user_model.User{recipient}, false))
+
+	var sent []*Message
+	defer test.MockVariableValue(&SendAsync, func(msgs ...*Message) {
+		sent = append(sent, msgs...)
+	})()
+
+	// the queued events aren't part of any digest anymore when the user switches to immediate emails
+	assert.NoError(t, user_model.SetEmailDigestFrequency(db.DefaultContext, recipient, user_model.EmailDigestImmediate))
+	assert.NoError(t, FlushMailDigest(db.DefaultContext, recipient.ID))
+	if assert.Len(t, sent, 1) {
+		assert.Equal(t, "[user2/repo1] #1: close by user2", sent[0].Body)
+	}
+
+	items, err := activities_model.GetMailDigestItemsByUserID(db.DefaultContext, recipient.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
@@ -127,6 +127,7 @@ func mailIssueCommentBatch(ctx *mailCommentContext, users []*user_model.User, vi
 	}
 
 	langMap := make(map[string][]*user_model.User)
+	digestUsers := make([]*user_model.User, 0, 10)
 	for _, user := range users {
 		if !user.IsActive {
 			// Exclude deactivated users
@@ -150,9 +151,19 @@ func mailIssueCommentBatch(ctx *mailCommentContext, users []*user_model.User, vi
 			continue
 		}
 
+		// users receiving digests get the event with their next digest instead of an email now
+		if user.IsEmailDigestEnabled() {
+			digestUsers = append(digestUsers, user)
+			continue
+		}
+
 		langMap[user.Language] = append(langMap[user.Language], user)
 	}
 
+	if err := queueMailDigestItems(ctx, digestUsers, fromMention); err != nil {
+		return fmt.Errorf("queueMailDigestItems: %w", err)
+	}
+
 	for lang, receivers := range langMap {
 		// because we know that the len(receivers) > 0 and we don't care about the order particularly
 		// working backwards from the last (possibly) incomplete batch. If len(receivers) can be 0 this
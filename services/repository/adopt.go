@@ -49,6 +49,7 @@ func AdoptRepository(ctx context.Context, doer, u *user_model.User, opts CreateR
 		OriginalServiceType:             opts.GitServiceType,
 		IsPrivate:                       opts.IsPrivate,
 		IsFsckEnabled:                   !opts.IsMirror,
+		IsPartialCloneEnabled:           true,
 		CloseIssuesViaCommitInAnyBranch: setting.Repository.DefaultCloseIssuesViaCommitsInAnyBranch,
 		Status:                          opts.Status,
 		IsEmpty:                         !opts.AutoInit,
@@ -68,6 +69,12 @@ func AdoptRepository(ctx context.Context, doer, u *user_model.User, opts CreateR
 			}
 		}
 
+		objectFormat, err := git.GetObjectFormatOfRepo(ctx, repoPath)
+		if err != nil {
+			return fmt.Errorf("GetObjectFormatOfRepo: %w", err)
+		}
+		repo.ObjectFormatName = objectFormat.Name()
+
 		if err := repo_module.CreateRepositoryByExample(ctx, doer, u, repo, true, false); err != nil {
 			return err
 		}
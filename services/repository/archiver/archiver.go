@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"regexp"
 	"strings"
@@ -30,10 +31,12 @@ import (
 // This is entirely opaque to external entities, though, and mostly used as a
 // handle elsewhere.
 type ArchiveRequest struct {
-	RepoID   int64
-	refName  string
-	Type     git.ArchiveType
-	CommitID string
+	RepoID     int64
+	refName    string
+	Type       git.ArchiveType
+	CommitID   string
+	Path       string // the subdirectory to archive, empty for the whole tree
+	IncludeLFS bool   // whether the LFS pointers are replaced by the content of the LFS objects
 }
 
 // SHA1 hashes will only go up to 40 characters, but SHA256 hashes will go all
@@ -87,6 +90,12 @@ func NewRequest(repoID int64, repo *git.Repository, uri string) (*ArchiveRequest
 	case strings.HasSuffix(uri, ".tar.gz"):
 		ext = ".tar.gz"
 		r.Type = git.TARGZ
+	case strings.HasSuffix(uri, ".tar.zst"):
+		ext = ".tar.zst"
+		r.Type = git.TARZST
+	case strings.HasSuffix(uri, ".tar.xz"):
+		ext = ".tar.xz"
+		r.Type = git.TARXZ
 	case strings.HasSuffix(uri, ".bundle"):
 		ext = ".bundle"
 		r.Type = git.BUNDLE
@@ -98,35 +107,69 @@ func NewRequest(repoID int64, repo *git.Repository, uri string) (*ArchiveRequest
 
 	var err error
 	// Get corresponding commit.
-	if repo.IsBranchExist(r.refName) {
-		r.CommitID, err = repo.GetBranchCommitID(r.refName)
-		if err != nil {
-			return nil, err
+	r.CommitID, err = getRefCommitID(repo, r.refName)
+	if err == nil || r.Type == git.BUNDLE {
+		return r, err
+	}
+
+	// The reference may be followed by the path of a subdirectory, try the longest reference first
+	name := r.refName
+	for i := strings.LastIndex(name, "/"); i > 0; i = strings.LastIndex(name[:i], "/") {
+		commitID, refErr := getRefCommitID(repo, name[:i])
+		if refErr != nil {
+			if git.IsErrNotExist(refErr) || errors.Is(refErr, RepoRefNotFoundError{}) {
+				continue
+			}
+			return nil, refErr
 		}
-	} else if repo.IsTagExist(r.refName) {
-		r.CommitID, err = repo.GetTagCommitID(r.refName)
-		if err != nil {
-			return nil, err
+		if !isArchivableDir(repo, commitID, name[i+1:]) {
+			continue
 		}
-	} else if shaRegex.MatchString(r.refName) {
-		if repo.IsCommitExist(r.refName) {
-			r.CommitID = r.refName
-		} else {
-			return nil, git.ErrNotExist{
-				ID: r.refName,
-			}
+		r.refName, r.Path, r.CommitID = name[:i], name[i+1:], commitID
+		return r, nil
+	}
+
+	return nil, err
+}
+
+// getRefCommitID returns the commit ID of a branch, tag or commit
+func getRefCommitID(repo *git.Repository, refName string) (string, error) {
+	if repo.IsBranchExist(refName) {
+		return repo.GetBranchCommitID(refName)
+	} else if repo.IsTagExist(refName) {
+		return repo.GetTagCommitID(refName)
+	} else if shaRegex.MatchString(refName) {
+		if repo.IsCommitExist(refName) {
+			return refName, nil
+		}
+		return "", git.ErrNotExist{
+			ID: refName,
 		}
-	} else {
-		return nil, RepoRefNotFoundError{RefName: r.refName}
 	}
+	return "", RepoRefNotFoundError{RefName: refName}
+}
 
-	return r, nil
+// isArchivableDir checks whether the tree path is a directory of the commit which could be archived
+func isArchivableDir(repo *git.Repository, commitID, treePath string) bool {
+	if treePath == "." || len(treePath) > 255 || !fs.ValidPath(treePath) {
+		return false
+	}
+	commit, err := repo.GetCommit(commitID)
+	if err != nil {
+		return false
+	}
+	entry, err := commit.GetTreeEntryByPath(treePath)
+	return err == nil && entry.IsDir()
 }
 
 // GetArchiveName returns the name of the caller, based on the ref used by the
 // caller to create this request.
 func (aReq *ArchiveRequest) GetArchiveName() string {
-	return strings.ReplaceAll(aReq.refName, "/", "-") + "." + aReq.Type.String()
+	name := aReq.refName
+	if aReq.Path != "" {
+		name += "-" + aReq.Path
+	}
+	return strings.ReplaceAll(name, "/", "-") + "." + aReq.Type.String()
 }
 
 // Await awaits the completion of an ArchiveRequest. If the archive has
@@ -136,7 +179,7 @@ func (aReq *ArchiveRequest) GetArchiveName() string {
 // context is cancelled/times out a started archiver will still continue to run
 // in the background.
 func (aReq *ArchiveRequest) Await(ctx context.Context) (*repo_model.RepoArchiver, error) {
-	archiver, err := repo_model.GetRepoArchiver(ctx, aReq.RepoID, aReq.Type, aReq.CommitID)
+	archiver, err := repo_model.GetRepoArchiver(ctx, aReq.RepoID, aReq.Type, aReq.CommitID, aReq.Path, aReq.IncludeLFS)
 	if err != nil {
 		return nil, fmt.Errorf("models.GetRepoArchiver: %w", err)
 	}
@@ -161,7 +204,7 @@ func (aReq *ArchiveRequest) Await(ctx context.Context) (*repo_model.RepoArchiver
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		case <-poll.C:
-			archiver, err = repo_model.GetRepoArchiver(ctx, aReq.RepoID, aReq.Type, aReq.CommitID)
+			archiver, err = repo_model.GetRepoArchiver(ctx, aReq.RepoID, aReq.Type, aReq.CommitID, aReq.Path, aReq.IncludeLFS)
 			if err != nil {
 				return nil, fmt.Errorf("repo_model.GetRepoArchiver: %w", err)
 			}
@@ -181,7 +224,7 @@ func doArchive(ctx context.Context, r *ArchiveRequest) (*repo_model.RepoArchiver
 	ctx, _, finished := process.GetManager().AddContext(txCtx, fmt.Sprintf("ArchiveRequest[%d]: %s", r.RepoID, r.GetArchiveName()))
 	defer finished()
 
-	archiver, err := repo_model.GetRepoArchiver(ctx, r.RepoID, r.Type, r.CommitID)
+	archiver, err := repo_model.GetRepoArchiver(ctx, r.RepoID, r.Type, r.CommitID, r.Path, r.IncludeLFS)
 	if err != nil {
 		return nil, err
 	}
@@ -194,10 +237,12 @@ func doArchive(ctx context.Context, r *ArchiveRequest) (*repo_model.RepoArchiver
 		}
 	} else {
 		archiver = &repo_model.RepoArchiver{
-			RepoID:   r.RepoID,
-			Type:     r.Type,
-			CommitID: r.CommitID,
-			Status:   repo_model.ArchiverGenerating,
+			RepoID:     r.RepoID,
+			Type:       r.Type,
+			CommitID:   r.CommitID,
+			Path:       r.Path,
+			IncludeLFS: r.IncludeLFS,
+			Status:     repo_model.ArchiverGenerating,
 		}
 		if err := repo_model.AddRepoArchiver(ctx, archiver); err != nil {
 			return nil, err
@@ -244,26 +289,34 @@ func doArchive(ctx context.Context, r *ArchiveRequest) (*repo_model.RepoArchiver
 			}
 		}()
 
-		if archiver.Type == git.BUNDLE {
+		var paths []string
+		if archiver.Path != "" {
+			paths = []string{archiver.Path}
+		}
+
+		switch {
+		case archiver.Type == git.BUNDLE:
 			err = gitRepo.CreateBundle(
 				ctx,
 				archiver.CommitID,
 				w,
 			)
-		} else {
+		case archiver.IncludeLFS:
+			err = createArchiveWithLFS(ctx, repo, gitRepo, archiver, paths, w)
+		default:
 			err = gitRepo.CreateArchive(
 				ctx,
 				archiver.Type,
 				w,
 				setting.Repository.PrefixArchiveFiles,
 				archiver.CommitID,
+				paths...,
 			)
 		}
 		_ = w.CloseWithError(err)
 		done <- err
 	}(done, w, archiver, gitRepo)
 
-	// TODO: add lfs data to zip
 	// TODO: add submodule data to zip
 
 	if _, err := storage.RepoArchives.Save(rPath, rd, -1); err != nil {
@@ -4,16 +4,26 @@
 package archiver
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
 	"errors"
+	"io"
 	"testing"
 	"time"
 
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/unittest"
 	"code.gitea.io/gitea/modules/contexttest"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/lfs"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/test"
 
 	_ "code.gitea.io/gitea/models/actions"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -128,6 +138,111 @@ func TestArchive_Basic(t *testing.T) {
 	assert.NotEqual(t, zipReq.GetArchiveName(), secondReq.GetArchiveName())
 }
 
+func TestArchive_Subdirectory(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	ctx, _ := contexttest.MockContext(t, "user27/repo49")
+	contexttest.LoadRepo(t, ctx, 49)
+	contexttest.LoadGitRepo(t, ctx)
+	defer ctx.Repo.GitRepo.Close()
+
+	req, err := NewRequest(ctx.Repo.Repository.ID, ctx.Repo.GitRepo, "master/test.tar.zst")
+	assert.NoError(t, err)
+	assert.EqualValues(t, git.TARZST, req.Type)
+	assert.EqualValues(t, "test", req.Path)
+	assert.EqualValues(t, "master-test.tar.zst", req.GetArchiveName())
+
+	// the longest matching reference wins
+	req, err = NewRequest(ctx.Repo.Repository.ID, ctx.Repo.GitRepo, "test/archive/test.tar.xz")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "test", req.Path)
+	assert.EqualValues(t, "test-archive-test.tar.xz", req.GetArchiveName())
+
+	for _, uri := range []string{"master/README.md.zip", "master/nonexist.zip", "master/../test.zip", "master//test.zip", "master/test.bundle"} {
+		_, err = NewRequest(ctx.Repo.Repository.ID, ctx.Repo.GitRepo, uri)
+		assert.Error(t, err, uri)
+	}
+
+	req, err = NewRequest(ctx.Repo.Repository.ID, ctx.Repo.GitRepo, "master/test.tar.zst")
+	assert.NoError(t, err)
+	archiver, err := ArchiveRepository(db.DefaultContext, req)
+	assert.NoError(t, err)
+
+	f, err := storage.RepoArchives.Open(archiver.RelativePath())
+	assert.NoError(t, err)
+	defer f.Close()
+	zr, err := zstd.NewReader(f)
+	assert.NoError(t, err)
+	defer zr.Close()
+	assert.Equal(t, []string{"repo49/", "repo49/test/", "repo49/test/test.txt"}, readTarNames(t, zr))
+}
+
+func TestArchive_LFS(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	defer test.MockVariableValue(&setting.LFS.StartServer, true)()
+	assert.NoError(t, storage.Init())
+
+	ctx, _ := contexttest.MockContext(t, "user2/lfs")
+	contexttest.LoadRepo(t, ctx, 54)
+	contexttest.LoadGitRepo(t, ctx)
+	defer ctx.Repo.GitRepo.Close()
+
+	content := []byte("# Testing READMEs in LFS\n")
+	pointer, err := lfs.GeneratePointer(bytes.NewReader(content))
+	assert.NoError(t, err)
+	assert.NoError(t, lfs.NewContentStore().Put(pointer, bytes.NewReader(content)))
+
+	readZip := func(req *ArchiveRequest) map[string]string {
+		archiver, err := ArchiveRepository(db.DefaultContext, req)
+		assert.NoError(t, err)
+		f, err := storage.RepoArchives.Open(archiver.RelativePath())
+		assert.NoError(t, err)
+		defer f.Close()
+		bs, err := io.ReadAll(f)
+		assert.NoError(t, err)
+		zr, err := zip.NewReader(bytes.NewReader(bs), int64(len(bs)))
+		assert.NoError(t, err)
+		files := make(map[string]string)
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			assert.NoError(t, err)
+			fileContent, _ := io.ReadAll(rc)
+			rc.Close()
+			files[f.Name] = string(fileContent)
+		}
+		return files
+	}
+
+	req, err := NewRequest(ctx.Repo.Repository.ID, ctx.Repo.GitRepo, "master/subdir.zip")
+	assert.NoError(t, err)
+	assert.Equal(t, pointer.StringContent(), readZip(req)["lfs/subdir/README.md"])
+
+	req, err = NewRequest(ctx.Repo.Repository.ID, ctx.Repo.GitRepo, "master/subdir.zip")
+	assert.NoError(t, err)
+	req.IncludeLFS = true
+	files := readZip(req)
+	assert.Equal(t, string(content), files["lfs/subdir/README.md"])
+	assert.Contains(t, files, "lfs/subdir/.gitattributes")
+}
+
+func readTarNames(t *testing.T, r io.Reader) []string {
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			break
+		}
+		if hdr.Typeflag != tar.TypeXGlobalHeader {
+			names = append(names, hdr.Name)
+		}
+	}
+	return names
+}
+
 func TestErrUnknownArchiveFormat(t *testing.T) {
 	err := ErrUnknownArchiveFormat{RequestFormat: "master"}
 	assert.True(t, errors.Is(err, ErrUnknownArchiveFormat{}))
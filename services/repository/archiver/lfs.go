@@ -0,0 +1,179 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package archiver
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	git_model "code.gitea.io/gitea/models/git"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/lfs"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// lfsPointerMaxSize is the maximum size of a file which is checked for being an LFS pointer
+const lfsPointerMaxSize = 1024
+
+// archiveEntryWriter writes the entries of a tar stream into an archive
+type archiveEntryWriter interface {
+	WriteEntry(hdr *tar.Header, content io.Reader) error
+	Close() error
+}
+
+type tarEntryWriter struct {
+	compressor io.WriteCloser
+	tw         *tar.Writer
+}
+
+func newTarEntryWriter(format git.ArchiveType, w io.Writer) (*tarEntryWriter, error) {
+	compressor, err := git.NewTarCompressor(format, w)
+	if err != nil {
+		return nil, err
+	}
+	return &tarEntryWriter{compressor: compressor, tw: tar.NewWriter(compressor)}, nil
+}
+
+func (w *tarEntryWriter) WriteEntry(hdr *tar.Header, content io.Reader) error {
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if hdr.Typeflag == tar.TypeReg {
+		_, err := io.Copy(w.tw, content)
+		return err
+	}
+	return nil
+}
+
+func (w *tarEntryWriter) Close() error {
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return w.compressor.Close()
+}
+
+type zipEntryWriter struct {
+	zw *zip.Writer
+}
+
+func (w *zipEntryWriter) WriteEntry(hdr *tar.Header, content io.Reader) error {
+	fh := &zip.FileHeader{
+		Name:     hdr.Name,
+		Method:   zip.Deflate,
+		Modified: hdr.ModTime,
+	}
+	fh.SetMode(hdr.FileInfo().Mode())
+
+	switch hdr.Typeflag {
+	case tar.TypeXGlobalHeader:
+		// git stores the commit ID as comment of the archive
+		if comment, ok := hdr.PAXRecords["comment"]; ok {
+			return w.zw.SetComment(comment)
+		}
+		return nil
+	case tar.TypeDir:
+		fh.Method = zip.Store
+		_, err := w.zw.CreateHeader(fh)
+		return err
+	case tar.TypeSymlink:
+		content = strings.NewReader(hdr.Linkname)
+	case tar.TypeReg:
+	default:
+		return nil
+	}
+
+	fw, err := w.zw.CreateHeader(fh)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, content)
+	return err
+}
+
+func (w *zipEntryWriter) Close() error {
+	return w.zw.Close()
+}
+
+// createArchiveWithLFS creates the archive from the tar stream of the commit and replaces the LFS pointers
+// of the repository by the content of the LFS objects
+func createArchiveWithLFS(ctx context.Context, repo *repo_model.Repository, gitRepo *git.Repository, archiver *repo_model.RepoArchiver, paths []string, w io.Writer) error {
+	var aw archiveEntryWriter
+	switch {
+	case archiver.Type == git.ZIP:
+		aw = &zipEntryWriter{zw: zip.NewWriter(w)}
+	case archiver.Type.IsTar():
+		tw, err := newTarEntryWriter(archiver.Type, w)
+		if err != nil {
+			return err
+		}
+		aw = tw
+	default:
+		return fmt.Errorf("unsupported archive format for LFS content: %v", archiver.Type)
+	}
+
+	rd, pw := io.Pipe()
+	defer rd.Close()
+	go func() {
+		err := gitRepo.CreateArchive(ctx, git.TAR, pw, setting.Repository.PrefixArchiveFiles, archiver.CommitID, paths...)
+		_ = pw.CloseWithError(err)
+	}()
+
+	tr := tar.NewReader(rd)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		if err := writeArchiveEntry(ctx, repo, aw, hdr, tr); err != nil {
+			return err
+		}
+	}
+	return aw.Close()
+}
+
+func writeArchiveEntry(ctx context.Context, repo *repo_model.Repository, aw archiveEntryWriter, hdr *tar.Header, content io.Reader) error {
+	if hdr.Typeflag != tar.TypeReg || hdr.Size > lfsPointerMaxSize {
+		return aw.WriteEntry(hdr, content)
+	}
+
+	buf, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	pointer, _ := lfs.ReadPointerFromBuffer(buf)
+	if !pointer.IsValid() {
+		return aw.WriteEntry(hdr, bytes.NewReader(buf))
+	}
+
+	// only the objects which belong to the repository may be added to the archive
+	meta, err := git_model.GetLFSMetaObjectByOid(ctx, repo.ID, pointer.Oid)
+	if err != nil {
+		if errors.Is(err, git_model.ErrLFSObjectNotExist) {
+			return aw.WriteEntry(hdr, bytes.NewReader(buf))
+		}
+		return err
+	}
+	lfsContent, err := lfs.ReadMetaObject(meta.Pointer)
+	if err != nil {
+		// keep the pointer if the object is missing in the store
+		log.Warn("Unable to read LFS object %s of %-v for the archive: %v", pointer.Oid, repo, err)
+		return aw.WriteEntry(hdr, bytes.NewReader(buf))
+	}
+	defer lfsContent.Close()
+
+	hdr.Size = meta.Size
+	hdr.Format = tar.FormatUnknown
+	return aw.WriteEntry(hdr, lfsContent)
+}
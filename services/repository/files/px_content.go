@@ -8,9 +8,13 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/db"
+	git_model "code.gitea.io/gitea/models/git"
 	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/lfs"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/typesniffer"
 	"code.gitea.io/gitea/modules/util"
@@ -18,67 +22,258 @@ import (
 
 type checkOption func(*api.CommitContentsResponse, *git.TreeEntry) error
 
-// GetCommitContentsOrList gets the meta data of a file's contents (*ContentsResponse) if treePath not a tree
-// directory, otherwise a listing of file contents ([]*ContentsResponse). Ref can be a branch, commit or tag
-func GetCommitContentsOrList(ctx context.Context, repo *repo_model.Repository, treePath, ref string) (any, error) {
-	if repo.IsEmpty {
-		return make([]any, 0), nil
-	}
+// MaxCommitContentsDepth is the maximum number of directory levels listed by a recursive listing
+const MaxCommitContentsDepth = 10
+
+// CommitContentsListOptions holds the options to list the entries of a directory with their last commits
+type CommitContentsListOptions struct {
+	db.ListOptions
+	// Recursive lists the entries of the subdirectories too, the recursive listing is always paginated
+	Recursive bool
+	// Depth limits the number of directory levels listed recursively, 0 lists up to MaxCommitContentsDepth levels
+	Depth int
+}
+
+// commitContentsRef holds the resolved ref the entries are listed for
+type commitContentsRef struct {
+	gitRepo *git.Repository
+	commit  *git.Commit
+	origRef string
+	ref     string
+	refType git.ObjectType
+}
+
+// resolveCommitContentsRef resolves the ref and prepares the last commit cache of the repository
+func resolveCommitContentsRef(repo *repo_model.Repository, gitRepo *git.Repository, ref string) (*commitContentsRef, error) {
 	if ref == "" {
 		ref = repo.DefaultBranch
 	}
 	origRef := ref
 
-	// Check that the path given in opts.treePath is valid (not a git path)
+	// Get the commit object for the ref
+	commit, err := gitRepo.GetCommit(ref)
+	if err != nil {
+		return nil, err
+	}
+	commitID := commit.ID.String()
+	if len(ref) >= 4 && strings.HasPrefix(commitID, ref) {
+		ref = commitID
+	}
+
+	refType := gitRepo.GetRefType(ref)
+	if refType == "invalid" {
+		return nil, fmt.Errorf("no commit found for the ref [ref: %s]", ref)
+	}
+
+	if err := gitRepo.AddLastCommitCache(repo.GetCommitsCountCacheKey(ref, refType != git.ObjectCommit), repo.FullName(), commitID); err != nil {
+		return nil, err
+	}
+
+	return &commitContentsRef{
+		gitRepo: gitRepo,
+		commit:  commit,
+		origRef: origRef,
+		ref:     ref,
+		refType: refType,
+	}, nil
+}
+
+// cleanCommitContentsTreePath checks that the tree path is valid (not a git path)
+func cleanCommitContentsTreePath(treePath string) (string, error) {
 	cleanTreePath := CleanUploadFileName(treePath)
 	if cleanTreePath == "" && treePath != "" {
-		return nil, models.ErrFilenameInvalid{
+		return "", models.ErrFilenameInvalid{
 			Path: treePath,
 		}
 	}
-	treePath = cleanTreePath
+	return cleanTreePath, nil
+}
+
+// GetCommitContentsOrList gets the meta data of a file's contents (*ContentsResponse) if treePath not a tree
+// directory, otherwise a page of the listing of file contents ([]*ContentsResponse). Ref can be a branch, commit or tag.
+// It also returns the total number of entries of the listing.
+func GetCommitContentsOrList(ctx context.Context, repo *repo_model.Repository, treePath, ref string, opts *CommitContentsListOptions) (any, int, error) {
+	if repo.IsEmpty {
+		return make([]any, 0), 0, nil
+	}
+
+	treePath, err := cleanCommitContentsTreePath(treePath)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	gitRepo, closer, err := git.RepositoryFromContextOrOpen(ctx, repo.RepoPath())
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer closer.Close()
 
-	// Get the commit object for the ref
-	commit, err := gitRepo.GetCommit(ref)
+	r, err := resolveCommitContentsRef(repo, gitRepo, ref)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	entry, err := commit.GetTreeEntryByPath(treePath)
+	entry, err := r.commit.GetTreeEntryByPath(treePath)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	if entry.Type() != "tree" {
-		return GetCommitContents(ctx, repo, treePath, origRef, false, checkIsNonText)
+		lastCommit, err := r.commit.GetCommitByPath(treePath)
+		if err != nil {
+			return nil, 0, err
+		}
+		contentsResponse, err := r.toCommitContentsResponse(ctx, repo, treePath, entry, lastCommit, false, checkIsNonText)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := loadCommitContentsLFS(ctx, repo, []*api.CommitContentsResponse{contentsResponse}); err != nil {
+			return nil, 0, err
+		}
+		return contentsResponse, 1, nil
 	}
 
 	// We are in a directory, so we return a list of FileContentResponse objects
-	var fileList []*api.CommitContentsResponse
+	gitTree, err := r.commit.SubTree(treePath)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	gitTree, err := commit.SubTree(treePath)
+	depth := 1
+	if opts.Recursive {
+		opts.ListAll = false
+		depth = opts.Depth
+		if depth <= 0 || depth > MaxCommitContentsDepth {
+			depth = MaxCommitContentsDepth
+		}
+	}
+	entries, err := listCommitContentsEntries(gitTree, treePath, depth, nil)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	total := len(entries)
+	if !opts.IsListAll() {
+		start, end := opts.GetStartEnd()
+		if start > total {
+			start = total
+		}
+		if end > total {
+			end = total
+		}
+		entries = entries[start:end]
+	}
+
+	// Look up the last commits of the entries of each directory at once, the commit graph is only walked for the
+	// entries missing in the last commit cache and the found commits are cached for the next listings
+	lastCommits := make(map[string]*git.Commit, len(entries))
+	dirEntries := make(map[string]git.Entries)
+	var dirs []string
+	for _, e := range entries {
+		if _, ok := dirEntries[e.dir]; !ok {
+			dirs = append(dirs, e.dir)
+		}
+		dirEntries[e.dir] = append(dirEntries[e.dir], e.entry)
+	}
+	for _, dir := range dirs {
+		commitsInfo, _, err := dirEntries[dir].GetCommitsInfo(ctx, r.commit, dir)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, info := range commitsInfo {
+			lastCommits[path.Join(dir, info.Entry.Name())] = info.Commit
+		}
+	}
+
+	fileList := make([]*api.CommitContentsResponse, 0, len(entries))
+	for _, e := range entries {
+		entryPath := path.Join(e.dir, e.entry.Name())
+		lastCommit := lastCommits[entryPath]
+		if lastCommit == nil {
+			if lastCommit, err = r.commit.GetCommitByPath(entryPath); err != nil {
+				return nil, 0, err
+			}
+		}
+		fileContentResponse, err := r.toCommitContentsResponse(ctx, repo, entryPath, e.entry, lastCommit, true)
+		if err != nil {
+			return nil, 0, err
+		}
+		fileList = append(fileList, fileContentResponse)
+	}
+	if err := loadCommitContentsLFS(ctx, repo, fileList); err != nil {
+		return nil, 0, err
+	}
+	return fileList, total, nil
+}
+
+// loadCommitContentsLFS looks up the LFS objects of the LFS pointers at once and adds their download URLs
+func loadCommitContentsLFS(ctx context.Context, repo *repo_model.Repository, responses []*api.CommitContentsResponse) error {
+	oids := make([]string, 0, len(responses))
+	for _, response := range responses {
+		if response.LFS != nil {
+			oids = append(oids, response.LFS.OID)
+		}
+	}
+	if len(oids) == 0 {
+		return nil
+	}
+
+	metas, err := git_model.GetLFSMetaObjectsByOids(ctx, repo.ID, oids)
+	if err != nil {
+		return err
+	}
+	metaByOid := make(map[string]*git_model.LFSMetaObject, len(metas))
+	for _, meta := range metas {
+		metaByOid[meta.Oid] = meta
 	}
-	entries, err := gitTree.ListEntries()
+
+	for _, response := range responses {
+		if response.LFS == nil {
+			continue
+		}
+		meta, ok := metaByOid[response.LFS.OID]
+		if !ok {
+			continue
+		}
+		response.LFS.Exists = true
+		response.LFS.Size = meta.Size
+		if setting.LFS.Storage.MinioConfig.ServeDirect {
+			// If we have a signed url (S3, object storage), the object can be downloaded directly
+			u, err := storage.LFS.URL(meta.RelativePath(), response.Name)
+			if u != nil && err == nil {
+				downloadURL := u.String()
+				response.LFS.DownloadURL = &downloadURL
+			}
+		}
+	}
+	return nil
+}
+
+type commitContentsEntry struct {
+	dir   string
+	entry *git.TreeEntry
+}
+
+// listCommitContentsEntries lists the entries of the tree, followed by the entries of each subdirectory up to the depth
+func listCommitContentsEntries(tree *git.Tree, treePath string, depth int, list []commitContentsEntry) ([]commitContentsEntry, error) {
+	entries, err := tree.ListEntries()
 	if err != nil {
 		return nil, err
 	}
 	for _, e := range entries {
-		subTreePath := path.Join(treePath, e.Name())
-		fileContentResponse, err := GetCommitContents(ctx, repo, subTreePath, origRef, true)
+		list = append(list, commitContentsEntry{dir: treePath, entry: e})
+		if depth <= 1 || !e.IsDir() {
+			continue
+		}
+		subTree, err := tree.SubTree(e.Name())
 		if err != nil {
 			return nil, err
 		}
-		fileList = append(fileList, fileContentResponse)
+		if list, err = listCommitContentsEntries(subTree, path.Join(treePath, e.Name()), depth-1, list); err != nil {
+			return nil, err
+		}
 	}
-	return fileList, nil
+	return list, nil
 }
 
 // GetCommitContents gets the meta data on a directory's or a file's contents. Ref can be a branch, commit or tag
@@ -90,19 +285,10 @@ func GetCommitContents(
 	forList bool,
 	options ...checkOption,
 ) (*api.CommitContentsResponse, error) {
-	if ref == "" {
-		ref = repo.DefaultBranch
-	}
-	origRef := ref
-
-	// Check that the path given in opts.treePath is valid (not a git path)
-	cleanTreePath := CleanUploadFileName(treePath)
-	if cleanTreePath == "" && treePath != "" {
-		return nil, models.ErrFilenameInvalid{
-			Path: treePath,
-		}
+	treePath, err := cleanCommitContentsTreePath(treePath)
+	if err != nil {
+		return nil, err
 	}
-	treePath = cleanTreePath
 
 	gitRepo, closer, err := git.RepositoryFromContextOrOpen(ctx, repo.RepoPath())
 	if err != nil {
@@ -110,41 +296,46 @@ func GetCommitContents(
 	}
 	defer closer.Close()
 
-	// Get the commit object for the ref
-	commit, err := gitRepo.GetCommit(ref)
+	r, err := resolveCommitContentsRef(repo, gitRepo, ref)
 	if err != nil {
 		return nil, err
 	}
-	commitID := commit.ID.String()
-	if len(ref) >= 4 && strings.HasPrefix(commitID, ref) {
-		ref = commit.ID.String()
-	}
 
-	entry, err := commit.GetTreeEntryByPath(treePath)
+	entry, err := r.commit.GetTreeEntryByPath(treePath)
 	if err != nil {
 		return nil, err
 	}
 
-	refType := gitRepo.GetRefType(ref)
-	if refType == "invalid" {
-		return nil, fmt.Errorf("no commit found for the ref [ref: %s]", ref)
-	}
-
-	selfURL, err := url.Parse(repo.APIURL() + "/contents/" + util.PathEscapeSegments(treePath) + "?ref=" + url.QueryEscape(origRef))
+	lastCommit, err := r.commit.GetCommitByPath(treePath)
 	if err != nil {
 		return nil, err
 	}
-	selfURLString := selfURL.String()
 
-	err = gitRepo.AddLastCommitCache(repo.GetCommitsCountCacheKey(ref, refType != git.ObjectCommit), repo.FullName(), commitID)
+	contentsResponse, err := r.toCommitContentsResponse(ctx, repo, treePath, entry, lastCommit, forList, options...)
 	if err != nil {
 		return nil, err
 	}
+	if err := loadCommitContentsLFS(ctx, repo, []*api.CommitContentsResponse{contentsResponse}); err != nil {
+		return nil, err
+	}
+	return contentsResponse, nil
+}
 
-	lastCommit, err := commit.GetCommitByPath(treePath)
+// toCommitContentsResponse converts the entry of the tree path and its last commit to a CommitContentsResponse
+func (r *commitContentsRef) toCommitContentsResponse(
+	ctx context.Context,
+	repo *repo_model.Repository,
+	treePath string,
+	entry *git.TreeEntry,
+	lastCommit *git.Commit,
+	forList bool,
+	options ...checkOption,
+) (*api.CommitContentsResponse, error) {
+	selfURL, err := url.Parse(repo.APIURL() + "/contents/" + util.PathEscapeSegments(treePath) + "?ref=" + url.QueryEscape(r.origRef))
 	if err != nil {
 		return nil, err
 	}
+	selfURLString := selfURL.String()
 
 	// All content types have these fields in populated
 	contentsResponse := &api.CommitContentsResponse{
@@ -170,15 +361,21 @@ func GetCommitContents(
 	if p, b := isLFS(entry); b {
 		contentsResponse.Size = p.Size
 		contentsResponse.IsLFS = true
+		contentsResponse.LFS = &api.CommitContentsLFS{
+			OID:  p.Oid,
+			Size: p.Size,
+		}
 	}
 
 	// Now populate the rest of the ContentsResponse based on entry type
 	if entry.IsRegular() || entry.IsExecutable() {
 		contentsResponse.Type = string(ContentTypeRegular)
-		if blobResponse, err := GetBlobBySHA(ctx, repo, gitRepo, entry.ID.String()); err != nil {
-			return nil, err
-		} else if !forList {
-			// We don't show the content if we are getting a list of FileContentResponses
+		// We don't show the content if we are getting a list of FileContentResponses
+		if !forList {
+			blobResponse, err := GetBlobBySHA(ctx, repo, r.gitRepo, entry.ID.String())
+			if err != nil {
+				return nil, err
+			}
 			contentsResponse.Encoding = &blobResponse.Encoding
 			contentsResponse.Content = &blobResponse.Content
 		}
@@ -194,7 +391,7 @@ func GetCommitContents(
 		contentsResponse.Target = &targetFromContent
 	} else if entry.IsSubModule() {
 		contentsResponse.Type = string(ContentTypeSubmodule)
-		submodule, err := commit.GetSubModule(treePath)
+		submodule, err := r.commit.GetSubModule(treePath)
 		if err != nil {
 			return nil, err
 		}
@@ -204,7 +401,7 @@ func GetCommitContents(
 	}
 	// Handle links
 	if entry.IsRegular() || entry.IsLink() {
-		downloadURL, err := url.Parse(repo.HTMLURL() + "/raw/" + url.PathEscape(string(refType)) + "/" + util.PathEscapeSegments(ref) + "/" + util.PathEscapeSegments(treePath))
+		downloadURL, err := url.Parse(repo.HTMLURL() + "/raw/" + url.PathEscape(string(r.refType)) + "/" + util.PathEscapeSegments(r.ref) + "/" + util.PathEscapeSegments(treePath))
 		if err != nil {
 			return nil, err
 		}
@@ -212,7 +409,7 @@ func GetCommitContents(
 		contentsResponse.DownloadURL = &downloadURLString
 	}
 	if !entry.IsSubModule() {
-		htmlURL, err := url.Parse(repo.HTMLURL() + "/src/" + url.PathEscape(string(refType)) + "/" + util.PathEscapeSegments(ref) + "/" + util.PathEscapeSegments(treePath))
+		htmlURL, err := url.Parse(repo.HTMLURL() + "/src/" + url.PathEscape(string(r.refType)) + "/" + util.PathEscapeSegments(r.ref) + "/" + util.PathEscapeSegments(treePath))
 		if err != nil {
 			return nil, err
 		}
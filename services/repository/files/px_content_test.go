@@ -0,0 +1,118 @@
+package files
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/contexttest"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCommitContentsOrList(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+	ctx, _ := contexttest.MockContext(t, "user2/glob")
+	contexttest.LoadRepo(t, ctx, 42)
+	contexttest.LoadRepoCommit(t, ctx)
+	contexttest.LoadUser(t, ctx, 2)
+	contexttest.LoadGitRepo(t, ctx)
+	defer ctx.Repo.GitRepo.Close()
+
+	listPaths := func(t *testing.T, treePath string, opts *CommitContentsListOptions) ([]string, int) {
+		list, total, err := GetCommitContentsOrList(ctx, ctx.Repo.Repository, treePath, "", opts)
+		assert.NoError(t, err)
+		var paths []string
+		for _, e := range list.([]*api.CommitContentsResponse) {
+			assert.NotEmpty(t, e.LastCommitSHA)
+			assert.Nil(t, e.Content)
+			paths = append(paths, e.Path)
+		}
+		return paths, total
+	}
+
+	t.Run("List", func(t *testing.T) {
+		paths, total := listPaths(t, "", &CommitContentsListOptions{})
+		assert.Equal(t, []string{"a.txt", "aaa.doc", "x"}, paths)
+		assert.Equal(t, 3, total)
+
+		paths, total = listPaths(t, "x", &CommitContentsListOptions{})
+		assert.Equal(t, []string{"x/b.txt", "x/y"}, paths)
+		assert.Equal(t, 2, total)
+	})
+
+	t.Run("Paginated", func(t *testing.T) {
+		paths, total := listPaths(t, "", &CommitContentsListOptions{ListOptions: db.ListOptions{Page: 2, PageSize: 2}})
+		assert.Equal(t, []string{"x"}, paths)
+		assert.Equal(t, 3, total)
+
+		paths, total = listPaths(t, "", &CommitContentsListOptions{ListOptions: db.ListOptions{Page: 3, PageSize: 2}})
+		assert.Empty(t, paths)
+		assert.Equal(t, 3, total)
+
+		paths, total = listPaths(t, "", &CommitContentsListOptions{ListOptions: db.ListOptions{ListAll: true, PageSize: 2}})
+		assert.Equal(t, []string{"a.txt", "aaa.doc", "x"}, paths)
+		assert.Equal(t, 3, total)
+	})
+
+	t.Run("Recursive", func(t *testing.T) {
+		paths, total := listPaths(t, "", &CommitContentsListOptions{Recursive: true})
+		assert.Equal(t, []string{"a.txt", "aaa.doc", "x", "x/b.txt", "x/y", "x/y/a.txt", "x/y/z", "x/y/z/a.txt"}, paths)
+		assert.Equal(t, 8, total)
+
+		paths, total = listPaths(t, "", &CommitContentsListOptions{Recursive: true, Depth: 2})
+		assert.Equal(t, []string{"a.txt", "aaa.doc", "x", "x/b.txt", "x/y"}, paths)
+		assert.Equal(t, 5, total)
+
+		paths, total = listPaths(t, "", &CommitContentsListOptions{Recursive: true, ListOptions: db.ListOptions{Page: 2, PageSize: 3}})
+		assert.Equal(t, []string{"x/b.txt", "x/y", "x/y/a.txt"}, paths)
+		assert.Equal(t, 8, total)
+
+		paths, total = listPaths(t, "", &CommitContentsListOptions{Recursive: true, ListOptions: db.ListOptions{ListAll: true, PageSize: 3}})
+		assert.Equal(t, []string{"a.txt", "aaa.doc", "x"}, paths)
+		assert.Equal(t, 8, total)
+	})
+
+	t.Run("File", func(t *testing.T) {
+		file, total, err := GetCommitContentsOrList(ctx, ctx.Repo.Repository, "x/y/a.txt", "", &CommitContentsListOptions{Recursive: true})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+		if assert.IsType(t, &api.CommitContentsResponse{}, file) {
+			assert.Equal(t, "x/y/a.txt", file.(*api.CommitContentsResponse).Path)
+			assert.NotNil(t, file.(*api.CommitContentsResponse).Content)
+		}
+	})
+}
+
+func TestGetCommitContentsOrListLFS(t *testing.T) {
+	unittest.PrepareTestEnv(t)
+	ctx, _ := contexttest.MockContext(t, "user2/lfs")
+	contexttest.LoadRepo(t, ctx, 54)
+	contexttest.LoadRepoCommit(t, ctx)
+	contexttest.LoadUser(t, ctx, 2)
+	contexttest.LoadGitRepo(t, ctx)
+	defer ctx.Repo.GitRepo.Close()
+
+	list, _, err := GetCommitContentsOrList(ctx, ctx.Repo.Repository, "", "", &CommitContentsListOptions{})
+	assert.NoError(t, err)
+	lfsByPath := make(map[string]*api.CommitContentsLFS)
+	for _, e := range list.([]*api.CommitContentsResponse) {
+		assert.Equal(t, e.IsLFS, e.LFS != nil)
+		lfsByPath[e.Path] = e.LFS
+	}
+	assert.Nil(t, lfsByPath["README.md"])
+	assert.Equal(t, &api.CommitContentsLFS{
+		OID:    "2eccdb43825d2a49d99d542daa20075cff1d97d9d2349a8977efe9c03661737c",
+		Size:   107,
+		Exists: true,
+	}, lfsByPath["crypt.bin"])
+
+	file, _, err := GetCommitContentsOrList(ctx, ctx.Repo.Repository, "subdir/README.md", "", &CommitContentsListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, &api.CommitContentsLFS{
+		OID:    "9d172e5c64b4f0024b9901ec6afe9ea052f3c9b6ff9f4b07956d8c48c86fca82",
+		Size:   25,
+		Exists: true,
+	}, file.(*api.CommitContentsResponse).LFS)
+}
@@ -37,19 +37,20 @@ func GetTreeBySHA(ctx context.Context, repo *repo_model.Repository, gitRepo *git
 	}
 	apiURL := repo.APIURL()
 	apiURLLen := len(apiURL)
+	hashLen := repo.GetObjectFormat().FullLength()
 
-	// 51 is len(sha1) + len("/git/blobs/"). 40 + 11.
-	blobURL := make([]byte, apiURLLen+51)
+	// 11 is len("/git/blobs/")
+	blobURL := make([]byte, apiURLLen+11+hashLen)
 	copy(blobURL, apiURL)
 	copy(blobURL[apiURLLen:], "/git/blobs/")
 
-	// 51 is len(sha1) + len("/git/trees/"). 40 + 11.
-	treeURL := make([]byte, apiURLLen+51)
+	// 11 is len("/git/trees/")
+	treeURL := make([]byte, apiURLLen+11+hashLen)
 	copy(treeURL, apiURL)
 	copy(treeURL[apiURLLen:], "/git/trees/")
 
-	// 40 is the size of the sha1 hash in hexadecimal format.
-	copyPos := len(treeURL) - git.SHAFullLength
+	// the hash is copied to the end of the URLs
+	copyPos := len(treeURL) - hashLen
 
 	if perPage <= 0 || perPage > setting.API.DefaultGitTreesPerPage {
 		perPage = setting.API.DefaultGitTreesPerPage
@@ -202,9 +202,9 @@ func ChangeRepoFiles(ctx context.Context, repo *repo_model.Repository, doer *use
 		if opts.LastCommitID == "" {
 			opts.LastCommitID = commit.ID.String()
 		} else {
-			lastCommitID, err := t.gitRepo.ConvertToSHA1(opts.LastCommitID)
+			lastCommitID, err := t.gitRepo.ConvertToGitID(opts.LastCommitID)
 			if err != nil {
-				return nil, fmt.Errorf("ConvertToSHA1: Invalid last commit ID: %w", err)
+				return nil, fmt.Errorf("ConvertToGitID: Invalid last commit ID: %w", err)
 			}
 			opts.LastCommitID = lastCommitID.String()
 
@@ -9,12 +9,15 @@ import (
 	"fmt"
 	"time"
 
+	"code.gitea.io/gitea/models/db"
 	git_model "code.gitea.io/gitea/models/git"
 	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/lfs"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/timeutil"
 )
 
@@ -84,7 +87,7 @@ func GarbageCollectLFSMetaObjectsForRepo(ctx context.Context, repo *repo_model.R
 			return errStop
 		}
 		total++
-		pointerSha := git.ComputeBlobHash([]byte(metaObject.Pointer.StringContent()))
+		pointerSha := git.ComputeBlobHash(repo.GetObjectFormat(), []byte(metaObject.Pointer.StringContent()))
 
 		if gitRepo.IsObjectExist(pointerSha.String()) {
 			return git_model.MarkLFSMetaObject(ctx, metaObject.ID)
@@ -135,3 +138,60 @@ func GarbageCollectLFSMetaObjectsForRepo(ctx context.Context, repo *repo_model.R
 	}
 	return nil
 }
+
+// CleanupLFSMultipartUploadsOptions provides options for CleanupLFSMultipartUploads function
+type CleanupLFSMultipartUploadsOptions struct {
+	LogDetail func(format string, v ...any)
+	AutoFix   bool
+	OlderThan time.Time
+}
+
+// CleanupLFSMultipartUploads aborts the unfinished LFS multipart uploads which have been initiated before OlderThan.
+// It returns the number and the total size of the abandoned uploads, which are only aborted if AutoFix is set.
+func CleanupLFSMultipartUploads(ctx context.Context, opts CleanupLFSMultipartUploadsOptions) (count int, size int64, err error) {
+	log.Trace("Doing: CleanupLFSMultipartUploads")
+	defer log.Trace("Finished: CleanupLFSMultipartUploads")
+
+	if opts.LogDetail == nil {
+		opts.LogDetail = log.Debug
+	}
+
+	if !setting.LFS.StartServer {
+		opts.LogDetail("LFS support is disabled")
+		return 0, 0, nil
+	}
+
+	multipart, ok := storage.LFS.(storage.MultipartStorage)
+	if !ok {
+		opts.LogDetail("LFS storage doesn't support multipart uploads")
+		return 0, 0, nil
+	}
+
+	var abandoned []*storage.MultipartUpload
+	if err := multipart.IterateMultipartUploads(func(upload *storage.MultipartUpload) error {
+		select {
+		case <-ctx.Done():
+			return db.ErrCancelledf("before checking multipart upload %s of %s", upload.UploadID, upload.Path)
+		default:
+		}
+		if upload.Initiated.Before(opts.OlderThan) {
+			opts.LogDetail("Found abandoned LFS multipart upload %s of %s initiated at %v with %s uploaded", upload.UploadID, upload.Path, upload.Initiated, base.FileSize(upload.Size))
+			abandoned = append(abandoned, upload)
+		}
+		return nil
+	}); err != nil {
+		return 0, 0, err
+	}
+
+	for _, upload := range abandoned {
+		if opts.AutoFix {
+			if err := multipart.AbortMultipartUpload(upload); err != nil {
+				log.Error("Unable to abort LFS multipart upload %s of %s: %v", upload.UploadID, upload.Path, err)
+				continue
+			}
+		}
+		count++
+		size += upload.Size
+	}
+	return count, size, nil
+}
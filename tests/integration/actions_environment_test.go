@@ -0,0 +1,145 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	secret_model "code.gitea.io/gitea/models/secret"
+	unit_model "code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	repo_service "code.gitea.io/gitea/services/repository"
+	files_service "code.gitea.io/gitea/services/repository/files"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/builder"
+)
+
+func TestActionsDeploymentEnvironment(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user2 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+		session := loginUser(t, user2.Name)
+
+		repo, err := repo_service.CreateRepository(db.DefaultContext, user2, user2, repo_service.CreateRepoOptions{
+			Name:          "repo-deployment-environment",
+			AutoInit:      true,
+			Readme:        "Default",
+			DefaultBranch: "main",
+		})
+		assert.NoError(t, err)
+		err = repo_model.UpdateRepositoryUnits(db.DefaultContext, repo, []repo_model.RepoUnit{{
+			RepoID: repo.ID,
+			Type:   unit_model.TypeActions,
+		}}, nil)
+		assert.NoError(t, err)
+
+		// only main can deploy to production, and user2 has to approve the deployments
+		environmentsLink := fmt.Sprintf("/%s/settings/actions/environments", repo.FullName())
+		req := NewRequestWithValues(t, "POST", environmentsLink+"/new", map[string]string{
+			"_csrf":           GetCSRF(t, session, environmentsLink+"/new"),
+			"name":            "Production",
+			"branch_patterns": "main\n",
+			"reviewers":       "2",
+		})
+		session.MakeRequest(t, req, http.StatusSeeOther)
+		env := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionEnvironment{RepoID: repo.ID, LowerName: "production"})
+		assert.Equal(t, []string{"main"}, env.BranchPatterns)
+		assert.Equal(t, []int64{2}, env.ReviewerIDs)
+
+		envLink := fmt.Sprintf("%s/%d", environmentsLink, env.ID)
+		for _, link := range []string{environmentsLink, envLink, envLink + "/secrets", envLink + "/variables"} {
+			session.MakeRequest(t, NewRequest(t, "GET", link), http.StatusOK)
+		}
+
+		// the secrets of the environment don't conflict with those of the repository
+		req = NewRequestWithValues(t, "POST", envLink+"/secrets", map[string]string{
+			"_csrf": GetCSRF(t, session, envLink+"/secrets"),
+			"name":  "DEPLOY_TOKEN",
+			"data":  "token",
+		})
+		session.MakeRequest(t, req, http.StatusOK)
+		unittest.AssertExistsAndLoadBean(t, &secret_model.Secret{RepoID: repo.ID, EnvironmentID: env.ID, Name: "DEPLOY_TOKEN"})
+		unittest.AssertNotExistsBean(t, &secret_model.Secret{RepoID: repo.ID, Name: "DEPLOY_TOKEN"}, builder.Eq{"environment_id": 0})
+
+		pushWorkflow := func(branch string) *actions_model.ActionRunJob {
+			_, err := files_service.ChangeRepoFiles(git.DefaultContext, repo, user2, &files_service.ChangeRepoFilesOptions{
+				Files: []*files_service.ChangeRepoFile{
+					{
+						Operation:     "create",
+						TreePath:      ".gitea/workflows/deploy-" + branch + ".yml",
+						ContentReader: strings.NewReader("on: push\njobs:\n  deploy:\n    runs-on: ubuntu-latest\n    environment:\n      name: production\n      url: https://${{ github.ref_name }}.example.com\n    steps:\n      - run: echo deploy\n"),
+					},
+				},
+				Message:   "add workflow",
+				OldBranch: "main",
+				NewBranch: branch,
+				Author: &files_service.IdentityOptions{
+					Name:  user2.Name,
+					Email: user2.Email,
+				},
+				Committer: &files_service.IdentityOptions{
+					Name:  user2.Name,
+					Email: user2.Email,
+				},
+				Dates: &files_service.CommitDateOptions{
+					Author:    time.Now(),
+					Committer: time.Now(),
+				},
+			})
+			assert.NoError(t, err)
+
+			var job *actions_model.ActionRunJob
+			assert.Eventually(t, func() bool {
+				jobs, _, err := actions_model.FindDeployments(db.DefaultContext, actions_model.FindDeploymentOptions{RepoID: repo.ID})
+				assert.NoError(t, err)
+				for _, j := range jobs {
+					if j.EnvironmentURL == "https://"+branch+".example.com" {
+						job = j
+						return true
+					}
+				}
+				return false
+			}, 10*time.Second, 100*time.Millisecond)
+			return job
+		}
+
+		// the deployment of main waits for the reviewer
+		job := pushWorkflow("main")
+		assert.Equal(t, "production", job.Environment)
+		time.Sleep(time.Second)
+		job = unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{ID: job.ID})
+		assert.Equal(t, actions_model.StatusBlocked, job.Status)
+		run := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRun{ID: job.RunID})
+
+		runLink := fmt.Sprintf("/%s/actions/runs/%d", repo.FullName(), run.Index)
+		req = NewRequestWithValues(t, "POST", runLink+"/jobs/0/approve-deployment", map[string]string{
+			"_csrf": GetCSRF(t, session, runLink),
+		})
+		session.MakeRequest(t, req, http.StatusOK)
+		assert.Eventually(t, func() bool {
+			job = unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{ID: job.ID})
+			return job.Status == actions_model.StatusWaiting
+		}, 10*time.Second, 100*time.Millisecond)
+		assert.Equal(t, user2.ID, job.DeploymentApprovedBy)
+
+		// the other branches can't deploy to production
+		job = pushWorkflow("feature")
+		assert.Eventually(t, func() bool {
+			job = unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{ID: job.ID})
+			return job.Status == actions_model.StatusFailure
+		}, 10*time.Second, 100*time.Millisecond)
+
+		session.MakeRequest(t, NewRequest(t, "GET", fmt.Sprintf("/%s/actions/deployments?environment=production", repo.FullName())), http.StatusOK)
+	})
+}
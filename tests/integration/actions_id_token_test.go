@@ -0,0 +1,83 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/setting"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionsIDToken(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	issuer := setting.AppURL + "api/actions_id_token"
+
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	resp := MakeRequest(t, NewRequest(t, "GET", "/api/actions_id_token/.well-known/openid-configuration"), http.StatusOK)
+	DecodeJSON(t, resp, &discovery)
+	assert.Equal(t, issuer, discovery.Issuer)
+	assert.Equal(t, issuer+"/jwks", discovery.JWKSURI)
+
+	var jwks struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	resp = MakeRequest(t, NewRequest(t, "GET", "/api/actions_id_token/jwks"), http.StatusOK)
+	DecodeJSON(t, resp, &jwks)
+	assert.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "RSA", jwks.Keys[0]["kty"])
+	n, err := base64.RawURLEncoding.DecodeString(jwks.Keys[0]["n"])
+	assert.NoError(t, err)
+	e, err := base64.RawURLEncoding.DecodeString(jwks.Keys[0]["e"])
+	assert.NoError(t, err)
+	publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+
+	// the task of the fixtures runs a job without `permissions`
+	requestToken := func(expectedStatus int) *httptest.ResponseRecorder {
+		req := NewRequest(t, "GET", "/api/actions_id_token/token?api-version=2.0&audience=sts.example.com")
+		req = addTokenAuthHeader(req, "Bearer 8061e833a55f6fc0157c98b883e91fcfeeb1a71a")
+		return MakeRequest(t, req, expectedStatus)
+	}
+	requestToken(http.StatusForbidden)
+
+	_, err = db.GetEngine(db.DefaultContext).ID(192).Cols("permissions").Update(&actions_model.ActionRunJob{
+		Permissions: map[string]string{"contents": "read", "id-token": "write"},
+	})
+	assert.NoError(t, err)
+
+	var tokenResp struct {
+		Value string `json:"value"`
+	}
+	DecodeJSON(t, requestToken(http.StatusOK), &tokenResp)
+
+	claims := &actions_service.IDTokenClaims{}
+	token, err := jwt.ParseWithClaims(tokenResp.Value, claims, func(token *jwt.Token) (any, error) {
+		assert.Equal(t, jwks.Keys[0]["kid"], token.Header["kid"])
+		return publicKey, nil
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithIssuer(issuer), jwt.WithAudience("sts.example.com"))
+	assert.NoError(t, err)
+	assert.True(t, token.Valid)
+	assert.Equal(t, "repo:user5/repo4:ref:refs/heads/master", claims.Subject)
+	assert.Equal(t, "user5/repo4", claims.Repository)
+	assert.Equal(t, "refs/heads/master", claims.Ref)
+	assert.Equal(t, "branch", claims.RefType)
+	assert.Equal(t, "artifact.yaml", claims.Workflow)
+	assert.Equal(t, "791", claims.RunID)
+	assert.Equal(t, "187", claims.RunNumber)
+}
@@ -0,0 +1,250 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	unit_model "code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	actions_web "code.gitea.io/gitea/routers/web/repo/actions"
+	actions_service "code.gitea.io/gitea/services/actions"
+	repo_service "code.gitea.io/gitea/services/repository"
+	files_service "code.gitea.io/gitea/services/repository/files"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionsReusableWorkflow(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user2 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+		session := loginUser(t, user2.Name)
+
+		addFile := func(owner *user_model.User, repo *repo_model.Repository, treePath, content string) {
+			_, err := files_service.ChangeRepoFiles(git.DefaultContext, repo, owner, &files_service.ChangeRepoFilesOptions{
+				Files: []*files_service.ChangeRepoFile{
+					{
+						Operation:     "create",
+						TreePath:      treePath,
+						ContentReader: strings.NewReader(content),
+					},
+				},
+				Message:   "add workflow",
+				OldBranch: "main",
+				NewBranch: "main",
+				Author: &files_service.IdentityOptions{
+					Name:  owner.Name,
+					Email: owner.Email,
+				},
+				Committer: &files_service.IdentityOptions{
+					Name:  owner.Name,
+					Email: owner.Email,
+				},
+				Dates: &files_service.CommitDateOptions{
+					Author:    time.Now(),
+					Committer: time.Now(),
+				},
+			})
+			assert.NoError(t, err)
+		}
+		createRepoWithFile := func(owner *user_model.User, name string, private bool, treePath, content string) *repo_model.Repository {
+			repo, err := repo_service.CreateRepository(db.DefaultContext, owner, owner, repo_service.CreateRepoOptions{
+				Name:          name,
+				AutoInit:      true,
+				Readme:        "Default",
+				DefaultBranch: "main",
+				IsPrivate:     private,
+			})
+			assert.NoError(t, err)
+			err = repo_model.UpdateRepositoryUnits(db.DefaultContext, repo, []repo_model.RepoUnit{{
+				RepoID: repo.ID,
+				Type:   unit_model.TypeActions,
+			}}, nil)
+			assert.NoError(t, err)
+			addFile(owner, repo, treePath, content)
+			return repo
+		}
+
+		// the private workflows can be called by the runs which are triggered by the users who can read them
+		createRepoWithFile(user2, "actions-central", true, ".gitea/workflows/build.yml", `on:
+  workflow_call:
+    inputs:
+      target:
+        type: string
+        required: true
+    outputs:
+      version:
+        value: ${{ jobs.build.outputs.version }}
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    permissions:
+      contents: write
+      issues: write
+    outputs:
+      version: ${{ steps.version.outputs.version }}
+    steps:
+      - id: version
+        run: echo "version=${{ inputs.target }}" >> "$GITHUB_OUTPUT"
+  test:
+    needs: build
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo test
+`)
+		repo := createRepoWithFile(user2, "actions-caller", false, ".gitea/workflows/ci.yml", `on: push
+jobs:
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo lint
+  call:
+    needs: lint
+    uses: user2/actions-central/.gitea/workflows/build.yml@main
+    with:
+      target: ${{ github.ref_name }}
+    secrets: inherit
+    permissions:
+      contents: read
+  deploy:
+    needs: call
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ needs.call.outputs.version }}
+`)
+
+		findRun := func(repo *repo_model.Repository) (run *actions_model.ActionRun) {
+			assert.Eventually(t, func() bool {
+				runs, _, err := actions_model.FindRuns(db.DefaultContext, actions_model.FindRunOptions{RepoID: repo.ID})
+				assert.NoError(t, err)
+				if len(runs) == 1 {
+					run = runs[0]
+				}
+				return run != nil
+			}, 10*time.Second, 100*time.Millisecond)
+			return run
+		}
+		run := findRun(repo)
+
+		jobs, err := actions_model.GetRunJobsByRunID(db.DefaultContext, run.ID)
+		assert.NoError(t, err)
+		jobsByID := make(map[string]*actions_model.ActionRunJob, len(jobs))
+		for _, job := range jobs {
+			jobsByID[job.JobID] = job
+		}
+		assert.Len(t, jobsByID, 5)
+
+		caller := jobsByID["call"]
+		assert.Equal(t, "user2/actions-central/.gitea/workflows/build.yml@main", caller.ReusableWorkflow)
+		assert.Equal(t, actions_model.StatusBlocked, caller.Status)
+
+		build := jobsByID["call/build"]
+		assert.Equal(t, "call / build", build.Name)
+		assert.Equal(t, "call", build.CallerJobID)
+		assert.Equal(t, []string{"lint"}, build.Needs)
+		assert.Equal(t, actions_model.StatusBlocked, build.Status)
+		assert.Contains(t, string(build.WorkflowPayload), "version=${{ 'main' }}")
+		// the permissions of the called jobs are limited by the caller
+		assert.Equal(t, "read", build.Permissions["contents"])
+		assert.Equal(t, "none", build.Permissions["issues"])
+
+		assert.Equal(t, []string{"call/build"}, jobsByID["call/test"].Needs)
+		assert.Equal(t, []string{"call"}, jobsByID["deploy"].Needs)
+
+		// the run view nests the called jobs below their caller
+		runLink := fmt.Sprintf("/%s/actions/runs/%d", repo.FullName(), run.Index)
+		req := NewRequestWithJSON(t, "POST", runLink+"/jobs/0", &actions_web.ViewRequest{})
+		req.Header.Add("X-Csrf-Token", GetCSRF(t, session, runLink))
+		var view actions_web.ViewResponse
+		DecodeJSON(t, session.MakeRequest(t, req, http.StatusOK), &view)
+		depths := make(map[string]int, len(view.State.Run.Jobs))
+		for _, job := range view.State.Run.Jobs {
+			depths[job.Name] = job.Depth
+		}
+		assert.Equal(t, map[string]int{"lint": 0, "call": 0, "call / build": 1, "call / test": 1, "deploy": 0}, depths)
+
+		// the caller succeeds when the called jobs are done, then the jobs which need it are released
+		setStatus := func(jobID string, status actions_model.Status) {
+			job := jobsByID[jobID]
+			job.Status = status
+			_, err := actions_model.UpdateRunJob(db.DefaultContext, job, nil, "status")
+			assert.NoError(t, err)
+		}
+		setStatus("lint", actions_model.StatusSuccess)
+		setStatus("call/build", actions_model.StatusSuccess)
+		setStatus("call/test", actions_model.StatusSuccess)
+		assert.NoError(t, actions_service.EmitJobsIfReady(run.ID))
+		assert.Eventually(t, func() bool {
+			deploy := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{ID: jobsByID["deploy"].ID})
+			return deploy.Status == actions_model.StatusWaiting
+		}, 10*time.Second, 100*time.Millisecond)
+		caller = unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{ID: caller.ID})
+		assert.Equal(t, actions_model.StatusSuccess, caller.Status)
+
+		// the run fails if the user who triggers it can't read the called workflow
+		user5 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 5})
+		createRepoWithFile(user5, "actions-private", true, ".gitea/workflows/build.yml", `on: workflow_call
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo build
+`)
+		repo = createRepoWithFile(user2, "actions-denied", false, ".gitea/workflows/ci.yml", `on: push
+jobs:
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo lint
+  call:
+    uses: user5/actions-private/.gitea/workflows/build.yml@main
+`)
+		run = findRun(repo)
+		assert.Equal(t, actions_model.StatusFailure, run.Status)
+		jobs, err = actions_model.GetRunJobsByRunID(db.DefaultContext, run.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, jobs, 2) {
+			assert.Equal(t, actions_model.StatusFailure, jobs[0].Status)
+			assert.Equal(t, actions_model.StatusFailure, jobs[1].Status)
+		}
+
+		// the local workflows called by a workflow of another repository are read from that repository
+		central := createRepoWithFile(user2, "actions-nested", true, ".gitea/workflows/build.yml", `on: workflow_call
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo build
+`)
+		addFile(user2, central, ".gitea/workflows/release.yml", `on: workflow_call
+jobs:
+  release:
+    uses: ./.gitea/workflows/build.yml
+`)
+		repo = createRepoWithFile(user2, "actions-nested-caller", false, ".gitea/workflows/ci.yml", `on: push
+jobs:
+  call:
+    uses: user2/actions-nested/.gitea/workflows/release.yml@main
+`)
+		run = findRun(repo)
+		assert.NotEqual(t, actions_model.StatusFailure, run.Status)
+		jobs, err = actions_model.GetRunJobsByRunID(db.DefaultContext, run.ID)
+		assert.NoError(t, err)
+		jobIDs := make([]string, 0, len(jobs))
+		for _, job := range jobs {
+			jobIDs = append(jobIDs, job.JobID)
+		}
+		assert.ElementsMatch(t, []string{"call", "call/release", "call/release/build"}, jobIDs)
+	})
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionsTokenPermissions(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	setPermissions := func(permissions map[string]string) {
+		_, err := db.GetEngine(db.DefaultContext).ID(192).Cols("permissions").Update(&actions_model.ActionRunJob{
+			Permissions: permissions,
+		})
+		assert.NoError(t, err)
+	}
+	request := func(method, url string, body any, expectedStatus int) {
+		req := NewRequestWithJSON(t, method, url, body)
+		req = addTokenAuthHeader(req, "Bearer 8061e833a55f6fc0157c98b883e91fcfeeb1a71a")
+		MakeRequest(t, req, expectedStatus)
+	}
+
+	// the job of the task in the fixtures has no `permissions`, so its token can write
+	request("POST", "/api/v1/repos/user5/repo4/labels", &api.CreateLabelOption{Name: "label1", Color: "#123456"}, http.StatusCreated)
+
+	setPermissions(map[string]string{"issues": "read"})
+	request("GET", "/api/v1/repos/user5/repo4/issues", nil, http.StatusOK)
+	request("POST", "/api/v1/repos/user5/repo4/labels", &api.CreateLabelOption{Name: "label2", Color: "#123456"}, http.StatusForbidden)
+
+	setPermissions(map[string]string{"contents": "read"})
+	request("GET", "/api/v1/repos/user5/repo4", nil, http.StatusOK)
+	request("GET", "/api/v1/repos/user5/repo4/issues", nil, http.StatusNotFound)
+
+	setPermissions(map[string]string{"issues": "write"})
+	request("POST", "/api/v1/repos/user5/repo4/labels", &api.CreateLabelOption{Name: "label3", Color: "#123456"}, http.StatusCreated)
+
+	// the write access to a unit doesn't grant the write access to the other units
+	setPermissions(map[string]string{"contents": "write"})
+	request("POST", "/api/v1/repos/user5/repo4/releases", &api.CreateReleaseOption{TagName: "v-draft", Title: "draft", IsDraft: true}, http.StatusCreated)
+	listDrafts := func() (drafts int) {
+		req := NewRequest(t, "GET", "/api/v1/repos/user5/repo4/releases")
+		req = addTokenAuthHeader(req, "Bearer 8061e833a55f6fc0157c98b883e91fcfeeb1a71a")
+		var releases []*api.Release
+		DecodeJSON(t, MakeRequest(t, req, http.StatusOK), &releases)
+		for _, release := range releases {
+			if release.IsDraft {
+				drafts++
+			}
+		}
+		return drafts
+	}
+	assert.Equal(t, 1, listDrafts())
+	setPermissions(map[string]string{"contents": "read", "issues": "write"})
+	assert.Equal(t, 0, listDrafts())
+}
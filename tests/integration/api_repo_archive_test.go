@@ -4,6 +4,8 @@
 package integration
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,7 +18,9 @@ import (
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/tests"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
+	"github.com/ulikunitz/xz"
 )
 
 func TestAPIDownloadArchive(t *testing.T) {
@@ -48,7 +52,66 @@ func TestAPIDownloadArchive(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, bs, 382)
 
+	link, _ = url.Parse(fmt.Sprintf("/api/v1/repos/%s/%s/archive/master.tar.zst", user2.Name, repo.Name))
+	link.RawQuery = url.Values{"token": {token}}.Encode()
+	resp = MakeRequest(t, NewRequest(t, "GET", link.String()), http.StatusOK)
+	zr, err := zstd.NewReader(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"repo1/", "repo1/README.md"}, listTarEntries(t, zr))
+	zr.Close()
+
+	link, _ = url.Parse(fmt.Sprintf("/api/v1/repos/%s/%s/archive/master.tar.xz", user2.Name, repo.Name))
+	link.RawQuery = url.Values{"token": {token}}.Encode()
+	resp = MakeRequest(t, NewRequest(t, "GET", link.String()), http.StatusOK)
+	xr, err := xz.NewReader(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"repo1/", "repo1/README.md"}, listTarEntries(t, xr))
+
 	link, _ = url.Parse(fmt.Sprintf("/api/v1/repos/%s/%s/archive/master", user2.Name, repo.Name))
 	link.RawQuery = url.Values{"token": {token}}.Encode()
 	MakeRequest(t, NewRequest(t, "GET", link.String()), http.StatusBadRequest)
 }
+
+func TestAPIDownloadArchiveOfSubdirectory(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 42})
+	user2 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	session := loginUser(t, user2.LowerName)
+	token := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeReadRepository)
+
+	link, _ := url.Parse(fmt.Sprintf("/api/v1/repos/%s/%s/archive/master/x/y.tar.gz", user2.Name, repo.Name))
+	link.RawQuery = url.Values{"token": {token}}.Encode()
+	resp := MakeRequest(t, NewRequest(t, "GET", link.String()), http.StatusOK)
+	assert.Contains(t, resp.Header().Get("Content-Disposition"), "glob-master-x-y.tar.gz")
+	gr, err := gzip.NewReader(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"glob/", "glob/x/", "glob/x/y/", "glob/x/y/a.txt", "glob/x/y/z/", "glob/x/y/z/a.txt"}, listTarEntries(t, gr))
+
+	// a file or a missing directory can't be archived
+	link, _ = url.Parse(fmt.Sprintf("/api/v1/repos/%s/%s/archive/master/x/b.txt.zip", user2.Name, repo.Name))
+	link.RawQuery = url.Values{"token": {token}}.Encode()
+	MakeRequest(t, NewRequest(t, "GET", link.String()), http.StatusNotFound)
+
+	link, _ = url.Parse(fmt.Sprintf("/api/v1/repos/%s/%s/archive/master/nonexist.zip", user2.Name, repo.Name))
+	link.RawQuery = url.Values{"token": {token}}.Encode()
+	MakeRequest(t, NewRequest(t, "GET", link.String()), http.StatusNotFound)
+}
+
+func listTarEntries(t *testing.T, r io.Reader) []string {
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			break
+		}
+		if hdr.Typeflag != tar.TypeXGlobalHeader {
+			names = append(names, hdr.Name)
+		}
+	}
+	return names
+}
@@ -4,6 +4,7 @@
 package integration
 
 import (
+	"encoding/base64"
 	"net/http"
 	"testing"
 
@@ -43,6 +44,15 @@ func TestAPIReposGitBlobs(t *testing.T) {
 	expectedContent := "dHJlZSAyYTJmMWQ0NjcwNzI4YTJlMTAwNDllMzQ1YmQ3YTI3NjQ2OGJlYWI2CmF1dGhvciB1c2VyMSA8YWRkcmVzczFAZXhhbXBsZS5jb20+IDE0ODk5NTY0NzkgLTA0MDAKY29tbWl0dGVyIEV0aGFuIEtvZW5pZyA8ZXRoYW50a29lbmlnQGdtYWlsLmNvbT4gMTQ4OTk1NjQ3OSAtMDQwMAoKSW5pdGlhbCBjb21taXQK"
 	assert.Equal(t, expectedContent, gitBlobResponse.Content)
 
+	// Test downloading the raw content of the blob
+	req = NewRequestf(t, "GET", "/api/v1/repos/%s/%s/git/blobs/%s/raw", user2.Name, repo1.Name, repo1ReadmeSHA)
+	resp = MakeRequest(t, req, http.StatusOK)
+	expectedRawContent, _ := base64.StdEncoding.DecodeString(expectedContent)
+	assert.Equal(t, string(expectedRawContent), resp.Body.String())
+
+	req = NewRequestf(t, "GET", "/api/v1/repos/%s/%s/git/blobs/%s/raw", user2.Name, repo1.Name, "ffffffffffffffffffffffffffffffffffffffff")
+	MakeRequest(t, req, http.StatusNotFound)
+
 	// Tests a private repo with no token so will fail
 	req = NewRequestf(t, "GET", "/api/v1/repos/%s/%s/git/blobs/%s", user2.Name, repo16.Name, repo16ReadmeSHA)
 	MakeRequest(t, req, http.StatusNotFound)
@@ -5,6 +5,7 @@ package integration
 
 import (
 	"bytes"
+	"io"
 	"net/http"
 	"path"
 	"strconv"
@@ -20,6 +21,8 @@ import (
 	"code.gitea.io/gitea/modules/json"
 	"code.gitea.io/gitea/modules/lfs"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/test"
 	"code.gitea.io/gitea/tests"
 
 	"github.com/stretchr/testify/assert"
@@ -326,6 +329,65 @@ func TestAPILFSBatch(t *testing.T) {
 			assert.NotNil(t, vl)
 			assert.NotEmpty(t, vl.Href)
 		})
+
+		t.Run("Limits", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			owner := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: repo.OwnerID})
+			used, err := git_model.GetRepoLFSSize(db.DefaultContext, repo.ID)
+			assert.NoError(t, err)
+
+			setLimits := func(t *testing.T, repoMaxFileSize, repoQuota, ownerMaxFileSize, ownerQuota int64) {
+				repo.LFSMaxFileSize, repo.LFSQuota = repoMaxFileSize, repoQuota
+				assert.NoError(t, repo_model.UpdateRepositoryCols(db.DefaultContext, repo, "lfs_max_file_size", "lfs_quota"))
+				owner.LFSMaxFileSize, owner.LFSQuota = ownerMaxFileSize, ownerQuota
+				assert.NoError(t, user_model.UpdateUserCols(db.DefaultContext, owner, "lfs_max_file_size", "lfs_quota"))
+			}
+			defer setLimits(t, 0, 0, 0, 0)
+
+			upload := func(t *testing.T, objects ...lfs.Pointer) *lfs.BatchResponse {
+				req := newRequest(t, &lfs.BatchRequest{Operation: "upload", Objects: objects})
+				resp := session.MakeRequest(t, req, http.StatusOK)
+				br := decodeResponse(t, resp.Body)
+				assert.Len(t, br.Objects, len(objects))
+				return br
+			}
+			p1 := lfs.Pointer{Oid: "d6f175817f886ec6fbbc1515326465fa96c3bfd54a4ea06cfd6dbbd8340e0153", Size: 4}
+			p2 := lfs.Pointer{Oid: "fb8f7d8435968c4f82a726a92395be4d16f2f63116caf36c8ad35c60831ab042", Size: 6}
+
+			// the limit of the repository overrides the one of the owner
+			setLimits(t, 5, 0, 3, 0)
+			br := upload(t, p1, p2)
+			assert.Nil(t, br.Objects[0].Error)
+			if assert.NotNil(t, br.Objects[1].Error) {
+				assert.Equal(t, "Size must be less than or equal to 5", br.Objects[1].Error.Message)
+			}
+
+			setLimits(t, 0, 0, 3, 0)
+			br = upload(t, p1)
+			if assert.NotNil(t, br.Objects[0].Error) {
+				assert.Equal(t, "Size must be less than or equal to 3", br.Objects[0].Error.Message)
+			}
+
+			// all objects of a batch count towards the quota
+			setLimits(t, 0, used+8, 0, 0)
+			br = upload(t, p1, p2)
+			assert.Nil(t, br.Objects[0].Error)
+			if assert.NotNil(t, br.Objects[1].Error) {
+				assert.Equal(t, http.StatusUnprocessableEntity, br.Objects[1].Error.Code)
+				assert.Contains(t, br.Objects[1].Error.Message, "Repository LFS quota")
+			}
+
+			ownerUsed, err := git_model.GetOwnerLFSSize(db.DefaultContext, repo.OwnerID)
+			assert.NoError(t, err)
+			assert.GreaterOrEqual(t, ownerUsed, used)
+			setLimits(t, 0, 0, 0, ownerUsed+5)
+			br = upload(t, p1, p2)
+			assert.Nil(t, br.Objects[0].Error)
+			if assert.NotNil(t, br.Objects[1].Error) {
+				assert.Contains(t, br.Objects[1].Error.Message, "Owner LFS quota")
+			}
+		})
 	})
 }
 
@@ -414,6 +476,31 @@ func TestAPILFSUpload(t *testing.T) {
 		session.MakeRequest(t, req, http.StatusUnprocessableEntity)
 	})
 
+	t.Run("Limits", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		// the limits also apply to uploads without a batch request
+		p, err := lfs.GeneratePointer(strings.NewReader("limits"))
+		assert.NoError(t, err)
+
+		repo.LFSMaxFileSize = 5
+		assert.NoError(t, repo_model.UpdateRepositoryCols(db.DefaultContext, repo, "lfs_max_file_size"))
+		resp := session.MakeRequest(t, newRequest(t, p, "limits"), http.StatusUnprocessableEntity)
+		assert.Contains(t, resp.Body.String(), "Size must be less than or equal to 5")
+
+		repo.LFSMaxFileSize, repo.LFSQuota = 0, 1
+		assert.NoError(t, repo_model.UpdateRepositoryCols(db.DefaultContext, repo, "lfs_max_file_size", "lfs_quota"))
+		resp = session.MakeRequest(t, newRequest(t, p, "limits"), http.StatusUnprocessableEntity)
+		assert.Contains(t, resp.Body.String(), "Repository LFS quota")
+
+		repo.LFSQuota = 0
+		assert.NoError(t, repo_model.UpdateRepositoryCols(db.DefaultContext, repo, "lfs_quota"))
+
+		exist, err := lfs.NewContentStore().Exists(p)
+		assert.NoError(t, err)
+		assert.False(t, exist)
+	})
+
 	t.Run("Success", func(t *testing.T) {
 		defer tests.PrintCurrentTest(t)()
 
@@ -486,3 +573,201 @@ func TestAPILFSVerify(t *testing.T) {
 		session.MakeRequest(t, req, http.StatusOK)
 	})
 }
+
+func TestAPILFSBatchMultipart(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	setting.LFS.StartServer = true
+
+	repo := createLFSTestRepository(t, "multipart")
+
+	session := loginUser(t, "user2")
+
+	content := []byte("multipart content")
+	p, err := lfs.GeneratePointer(bytes.NewReader(content))
+	assert.NoError(t, err)
+
+	newRequest := func(t testing.TB, br *lfs.BatchRequest) *http.Request {
+		req := NewRequestWithJSON(t, "POST", "/user2/lfs-multipart-repo.git/info/lfs/objects/batch", br)
+		req.Header.Set("Accept", lfs.MediaType)
+		req.Header.Set("Content-Type", lfs.MediaType)
+		return req
+	}
+
+	t.Run("FallbackToBasic", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		// hide the multipart methods of the configured storage
+		defer test.MockVariableValue(&storage.LFS, storage.ObjectStorage(struct{ storage.ObjectStorage }{storage.LFS}))()
+
+		req := newRequest(t, &lfs.BatchRequest{
+			Operation: "upload",
+			Transfers: []string{"multipart", "basic"},
+			Objects:   []lfs.Pointer{p},
+		})
+		resp := session.MakeRequest(t, req, http.StatusOK)
+
+		var br lfs.BatchResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &br))
+		assert.Equal(t, "basic", br.Transfer)
+		assert.Len(t, br.Objects, 1)
+		assert.Contains(t, br.Objects[0].Actions, "upload")
+		assert.Contains(t, br.Objects[0].Actions, "verify")
+	})
+
+	// testUpload uploads the parts of the content to the storage, either to gitea or to the object storage, and commits them
+	testUpload := func(t *testing.T, s storage.ObjectStorage, content []byte) {
+		defer test.MockVariableValue(&storage.LFS, s)()
+
+		p, err := lfs.GeneratePointer(bytes.NewReader(content))
+		assert.NoError(t, err)
+
+		req := newRequest(t, &lfs.BatchRequest{
+			Operation: "upload",
+			Transfers: []string{"multipart", "basic"},
+			Objects:   []lfs.Pointer{p},
+		})
+		resp := session.MakeRequest(t, req, http.StatusOK)
+
+		var br lfs.BatchResponseWithMultiPart
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &br))
+		assert.Equal(t, "multipart", br.Transfer)
+		assert.Len(t, br.Objects, 1)
+		assert.Nil(t, br.Objects[0].Error)
+		parts := br.Objects[0].Actions.Parts
+		assert.Greater(t, len(parts), 1)
+		verify := br.Objects[0].Actions.Verify
+		assert.NotNil(t, verify)
+
+		partIDs := make([]map[string]any, 0, len(parts))
+		for _, part := range parts {
+			assert.NotNil(t, part.MultipartEndpoint)
+			body := bytes.NewReader(content[part.Pos : part.Pos+part.Size])
+
+			var etag string
+			if strings.HasPrefix(part.Href, setting.AppURL) {
+				req := NewRequestWithBody(t, part.Method, part.Href, body)
+				resp := MakeRequest(t, req, http.StatusOK)
+				etag = resp.Header().Get("ETag")
+			} else {
+				req, err := http.NewRequest(part.Method, part.Href, body)
+				assert.NoError(t, err)
+				resp, err := http.DefaultClient.Do(req)
+				assert.NoError(t, err)
+				resp.Body.Close()
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				etag = resp.Header.Get("ETag")
+			}
+			assert.NotEmpty(t, etag)
+			partIDs = append(partIDs, map[string]any{"index": part.Index, "etag": strings.Trim(etag, `"`)})
+		}
+
+		commit := map[string]any{
+			"upload_id": (*verify.Params)["upload_id"],
+			"part_ids":  partIDs,
+		}
+		req = NewRequestWithJSON(t, verify.Method, verify.Href, commit)
+		req.Header.Set("Accept", lfs.MediaType)
+		req.Header.Set("Content-Type", lfs.MediaType)
+		session.MakeRequest(t, req, http.StatusOK)
+
+		f, err := lfs.NewContentStore().Get(p)
+		assert.NoError(t, err)
+		uploaded, err := io.ReadAll(f)
+		f.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, content, uploaded)
+
+		// the object exists now, so no parts are generated anymore
+		req = newRequest(t, &lfs.BatchRequest{
+			Operation: "upload",
+			Transfers: []string{"multipart", "basic"},
+			Objects:   []lfs.Pointer{p},
+		})
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		br = lfs.BatchResponseWithMultiPart{}
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &br))
+		assert.Len(t, br.Objects, 1)
+		assert.Empty(t, br.Objects[0].Actions.Parts)
+		assert.Nil(t, br.Objects[0].Actions.Verify)
+	}
+
+	t.Run("UploadLocal", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		s, err := storage.NewStorage(setting.LocalStorageType, &setting.Storage{
+			Path:               t.TempDir(),
+			MultipartChunkSize: 10,
+		})
+		assert.NoError(t, err)
+
+		testUpload(t, s, []byte("local multipart content"))
+	})
+
+	t.Run("UploadMinio", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		// only the pgsql tests have a minio server
+		if setting.LFS.Storage.Type != setting.MinioStorageType {
+			t.Skip("minio storage isn't configured")
+		}
+		cfg := *setting.LFS.Storage
+		cfg.MinioConfig.BasePath = "lfs-multipart/"
+		// minio requires all parts but the last one to be at least 5 MiB
+		cfg.MultipartChunkSize = 5 * 1024 * 1024
+		s, err := storage.NewStorage(setting.MinioStorageType, &cfg)
+		assert.NoError(t, err)
+
+		testUpload(t, s, bytes.Repeat([]byte("minio multipart content"), 250000))
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		small := []byte("small content")
+		smallOid := storeObjectInRepo(t, repo.ID, &small)
+		defer git_model.RemoveLFSMetaObjectByOid(db.DefaultContext, repo.ID, smallOid)
+
+		large := bytes.Repeat([]byte("0123456789"), 2000001)
+		largeOid := storeObjectInRepo(t, repo.ID, &large)
+		defer git_model.RemoveLFSMetaObjectByOid(db.DefaultContext, repo.ID, largeOid)
+
+		req := newRequest(t, &lfs.BatchRequest{
+			Operation: "download",
+			Transfers: []string{"multipart", "basic"},
+			Objects: []lfs.Pointer{
+				{Oid: smallOid, Size: int64(len(small))},
+				{Oid: largeOid, Size: int64(len(large))},
+			},
+		})
+		resp := session.MakeRequest(t, req, http.StatusOK)
+
+		var br lfs.BatchResponseWithMultiPart
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &br))
+		assert.Equal(t, "multipart", br.Transfer)
+		assert.Len(t, br.Objects, 2)
+
+		// small objects are downloaded at once
+		assert.Nil(t, br.Objects[0].Error)
+		assert.NotNil(t, br.Objects[0].Actions.Download)
+		assert.Empty(t, br.Objects[0].Actions.Parts)
+
+		// large objects can also be downloaded in ranged parts
+		assert.Nil(t, br.Objects[1].Error)
+		assert.NotNil(t, br.Objects[1].Actions.Download)
+		parts := br.Objects[1].Actions.Parts
+		assert.Len(t, parts, 2)
+
+		part := parts[1]
+		assert.Equal(t, http.MethodGet, part.Method)
+		assert.Equal(t, "bytes=20000000-20000009", (*part.Headers)["Range"])
+
+		req = NewRequest(t, part.Method, part.Href)
+		for key, value := range *part.Headers {
+			req.Header.Set(key, value)
+		}
+		resp = session.MakeRequest(t, req, http.StatusPartialContent)
+		assert.Equal(t, large[part.Pos:part.Pos+part.Size], resp.Body.Bytes())
+		assert.Equal(t, "bytes 20000000-20000009/20000010", resp.Header().Get("Content-Range"))
+	})
+}
@@ -6,9 +6,15 @@ package integration
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"testing"
 
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	files_service "code.gitea.io/gitea/services/repository/files"
 	"code.gitea.io/gitea/tests"
 
 	"github.com/stretchr/testify/assert"
@@ -118,3 +124,32 @@ func TestCompareBranches(t *testing.T) {
 
 	inspectCompare(t, htmlDoc, diffCount, diffChanges)
 }
+
+func TestCompareStructuredFile(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, _ *url.URL) {
+		user2 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+		repo1 := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+		_, err := files_service.ChangeRepoFiles(git.DefaultContext, repo1, user2, &files_service.ChangeRepoFilesOptions{
+			Files: []*files_service.ChangeRepoFile{
+				{
+					Operation:     "create",
+					TreePath:      "config.json",
+					ContentReader: strings.NewReader(`{"name": "repo1"}`),
+				},
+			},
+			OldBranch: "master",
+			NewBranch: "structured-file",
+		})
+		assert.NoError(t, err)
+
+		session := loginUser(t, "user2")
+		req := NewRequest(t, "GET", "/user2/repo1/compare/master...structured-file")
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		htmlDoc := NewHTMLParser(t, resp.Body)
+
+		// the source diff is shown by default, the rendered view is only shown on demand
+		assert.False(t, htmlDoc.doc.Find("[id^=diff-source-]").HasClass("gt-hidden"))
+		assert.True(t, htmlDoc.doc.Find("[id^=diff-rendered-]").HasClass("gt-hidden"))
+		assert.True(t, htmlDoc.doc.Find(".file-view-toggle[data-toggle-selector^='#diff-source-']").HasClass("active"))
+	})
+}
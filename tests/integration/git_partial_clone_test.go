@@ -0,0 +1,102 @@
+// Copyright 2023 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/modules/git"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitPartialClone(t *testing.T) {
+	onGiteaRun(t, testGitPartialClone)
+}
+
+func testGitPartialClone(t *testing.T, u *url.URL) {
+	if git.CheckGitVersionAtLeast("2.22") != nil {
+		t.Skip("partial clones require git 2.22 or later")
+	}
+
+	ctx := NewAPITestContext(t, "user2", "repo-partial-clone", auth_model.AccessTokenScopeWriteRepository, auth_model.AccessTokenScopeWriteUser)
+	t.Run("CreateRepo", doAPICreateRepository(ctx, false))
+
+	u.Path = ctx.GitPath()
+	u.User = url.UserPassword(ctx.Username, userPassword)
+
+	t.Run("Push", func(t *testing.T) {
+		dstPath := t.TempDir()
+		t.Run("Clone", doGitClone(dstPath, u))
+		doCommitAndPush(t, littleSize, dstPath, "data-file-")
+		doCommitAndPush(t, 64*1024, dstPath, "data-file-")
+	})
+
+	t.Run("HTTP", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+		testPartialCloneFilter(t, ctx, u)
+	})
+
+	t.Run("SSH", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+		withKeyFile(t, "my-testing-key", func(keyFile string) {
+			t.Run("CreateUserKey", doAPICreateUserKey(ctx, "test-key", keyFile))
+			testPartialCloneFilter(t, ctx, createSSHUrl(ctx.GitPath(), u))
+		})
+	})
+}
+
+func testPartialCloneFilter(t *testing.T, ctx APITestContext, u *url.URL) {
+	t.Run("BlobNone", func(t *testing.T) {
+		dstPath := t.TempDir()
+		assert.NoError(t, git.Clone(git.DefaultContext, u.String(), dstPath, git.CloneRepoOptions{NoCheckout: true, Filter: "blob:none"}))
+		assert.NotZero(t, countMissingObjects(t, dstPath))
+
+		// the checkout has to fetch the missing blobs on demand from the server
+		_, _, runErr := git.NewCommand(git.DefaultContext, "checkout", "-f", "HEAD").RunStdString(&git.RunOpts{Dir: dstPath})
+		assert.NoError(t, runErr)
+		exist, err := util.IsExist(filepath.Join(dstPath, "README.md"))
+		assert.NoError(t, err)
+		assert.True(t, exist)
+	})
+
+	t.Run("BlobLimit", func(t *testing.T) {
+		dstPath := t.TempDir()
+		assert.NoError(t, git.Clone(git.DefaultContext, u.String(), dstPath, git.CloneRepoOptions{NoCheckout: true, Filter: "blob:limit=16k"}))
+		// only the bigger data file is filtered out, the small files are still sent
+		assert.EqualValues(t, 1, countMissingObjects(t, dstPath))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Run("DisablePartialClone", doAPIEditRepository(ctx, &api.EditRepoOption{AllowPartialClone: util.ToPointer(false)}, func(t *testing.T, repo api.Repository) {
+			assert.False(t, repo.AllowPartialClone)
+		}))
+		defer t.Run("EnablePartialClone", doAPIEditRepository(ctx, &api.EditRepoOption{AllowPartialClone: util.ToPointer(true)}))
+
+		// the server doesn't advertise the filter capability, so the client falls back to a full clone
+		dstPath := t.TempDir()
+		assert.NoError(t, git.Clone(git.DefaultContext, u.String(), dstPath, git.CloneRepoOptions{NoCheckout: true, Filter: "blob:none"}))
+		assert.Zero(t, countMissingObjects(t, dstPath))
+	})
+}
+
+// countMissingObjects returns the number of objects reachable from HEAD which have not been fetched yet
+func countMissingObjects(t *testing.T, repoPath string) int {
+	stdout, _, err := git.NewCommand(git.DefaultContext, "rev-list", "--objects", "--missing=print", "HEAD").RunStdString(&git.RunOpts{Dir: repoPath})
+	assert.NoError(t, err)
+	count := 0
+	for _, line := range strings.Split(stdout, "\n") {
+		if strings.HasPrefix(line, "?") {
+			count++
+		}
+	}
+	return count
+}
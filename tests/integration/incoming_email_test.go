@@ -4,6 +4,8 @@
 package integration
 
 import (
+	"bytes"
+	"context"
 	"io"
 	"net"
 	"net/smtp"
@@ -13,14 +15,19 @@ import (
 
 	"code.gitea.io/gitea/models/db"
 	issues_model "code.gitea.io/gitea/models/issues"
+	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/models/unittest"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
 	"code.gitea.io/gitea/services/mailer/incoming"
 	incoming_payload "code.gitea.io/gitea/services/mailer/incoming/payload"
 	token_service "code.gitea.io/gitea/services/mailer/token"
 	"code.gitea.io/gitea/tests"
 
+	imap_memory "github.com/emersion/go-imap/backend/memory"
+	imap_client "github.com/emersion/go-imap/client"
+	imap_server "github.com/emersion/go-imap/server"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/gomail.v2"
 )
@@ -151,6 +158,61 @@ func TestIncomingEmail(t *testing.T) {
 			})
 		})
 
+		t.Run("Review", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			pull := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 2})
+
+			handler := &incoming.ReplyHandler{}
+
+			payload, err := incoming_payload.CreateReferencePayload(pull)
+			assert.NoError(t, err)
+
+			content := &incoming.MailContent{
+				Content: "/approve\nlooks good",
+			}
+
+			assert.NoError(t, handler.Handle(db.DefaultContext, content, user, payload))
+
+			review, err := issues_model.GetReviewByIssueIDAndUserID(db.DefaultContext, pull.ID, user.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, issues_model.ReviewTypeApprove, review.Type)
+			assert.Equal(t, "looks good", review.Content)
+		})
+
+		t.Run("NewIssue", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+
+			handler := &incoming.NewIssueHandler{}
+
+			payload, err := incoming_payload.CreateReferencePayload(repo)
+			assert.NoError(t, err)
+
+			assert.Error(t, handler.Handle(db.DefaultContext, &incoming.MailContent{}, nil, payload))
+
+			content := &incoming.MailContent{
+				Subject: "issue by mail",
+				Content: "new issue by mail",
+				Attachments: []*incoming.Attachment{
+					{
+						Name:    "attachment.txt",
+						Content: []byte("test"),
+					},
+				},
+			}
+
+			assert.NoError(t, handler.Handle(db.DefaultContext, content, user, payload))
+
+			issue := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{RepoID: repo.ID, Title: content.Subject})
+			assert.Equal(t, user.ID, issue.PosterID)
+			assert.Equal(t, content.Content, issue.Content)
+			attachments, err := repo_model.GetAttachmentsByIssueID(db.DefaultContext, issue.ID)
+			assert.NoError(t, err)
+			assert.Len(t, attachments, 1)
+		})
+
 		t.Run("Unsubscribe", func(t *testing.T) {
 			defer tests.PrintCurrentTest(t)()
 
@@ -175,6 +237,59 @@ func TestIncomingEmail(t *testing.T) {
 		})
 	})
 
+	// This test runs the incoming email processing against a local in-memory IMAP server.
+	t.Run("IMAPStandIn", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+
+		imapServer := imap_server.New(imap_memory.New())
+		imapServer.AllowInsecureAuth = true
+		go func() {
+			_ = imapServer.Serve(l)
+		}()
+		defer imapServer.Close()
+
+		defer test.MockVariableValue(&setting.IncomingEmail, setting.IncomingEmail)()
+		setting.IncomingEmail.Enabled = true
+		setting.IncomingEmail.ReplyToAddress = "incoming+%{token}@localhost"
+		setting.IncomingEmail.Host = "127.0.0.1"
+		setting.IncomingEmail.Port = l.Addr().(*net.TCPAddr).Port
+		setting.IncomingEmail.UseTLS = false
+		setting.IncomingEmail.Username = "username"
+		setting.IncomingEmail.Password = "password"
+		setting.IncomingEmail.Mailbox = "INBOX"
+
+		address, err := incoming.NewIssueAddress(user, repo)
+		assert.NoError(t, err)
+
+		msg := gomail.NewMessage()
+		msg.SetHeader("To", address)
+		msg.SetHeader("From", user.Email)
+		msg.SetHeader("Subject", "issue by imap")
+		msg.SetBody("text/plain", "new issue by imap")
+		var buf bytes.Buffer
+		_, err = msg.WriteTo(&buf)
+		assert.NoError(t, err)
+
+		c, err := imap_client.Dial(l.Addr().String())
+		assert.NoError(t, err)
+		assert.NoError(t, c.Login("username", "password"))
+		assert.NoError(t, c.Append("INBOX", nil, time.Now(), &buf))
+		assert.NoError(t, c.Logout())
+
+		ctx, cancel := context.WithCancel(db.DefaultContext)
+		defer cancel()
+		assert.NoError(t, incoming.Init(ctx))
+
+		assert.Eventually(t, func() bool {
+			return unittest.BeanExists(t, &issues_model.Issue{RepoID: repo.ID, Title: "issue by imap", PosterID: user.ID})
+		}, 10*time.Second, 100*time.Millisecond)
+	})
+
 	if setting.IncomingEmail.Enabled {
 		// This test connects to the configured email server and is currently only enabled for MySql integration tests.
 		// It sends a reply to create a comment. If the comment is not detected after 10 seconds the test fails.
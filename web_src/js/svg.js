@@ -67,6 +67,7 @@ import octiconSync from '../../public/assets/img/svg/octicon-sync.svg';
 import octiconTable from '../../public/assets/img/svg/octicon-table.svg';
 import octiconTag from '../../public/assets/img/svg/octicon-tag.svg';
 import octiconTriangleDown from '../../public/assets/img/svg/octicon-triangle-down.svg';
+import octiconWorkflow from '../../public/assets/img/svg/octicon-workflow.svg';
 import octiconX from '../../public/assets/img/svg/octicon-x.svg';
 import octiconXCircleFill from '../../public/assets/img/svg/octicon-x-circle-fill.svg';
 
@@ -138,6 +139,7 @@ const svgs = {
   'octicon-table': octiconTable,
   'octicon-tag': octiconTag,
   'octicon-triangle-down': octiconTriangleDown,
+  'octicon-workflow': octiconWorkflow,
   'octicon-x': octiconX,
   'octicon-x-circle-fill': octiconXCircleFill,
 };